@@ -41,6 +41,7 @@ describe('Ducky Connect class', () => {
               id: 'XN/2YQP/uAdTsa3946CvbicxbwZGFPqAdep7g47UyyQ=',
               exp: 1760591204,
               addrs: ['RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A'],
+              networks: ['TestNet'],
             }),
             {
               headers: {
@@ -65,6 +66,7 @@ describe('Ducky Connect class', () => {
       expect(session.id).toBe('XN/2YQP/uAdTsa3946CvbicxbwZGFPqAdep7g47UyyQ=')
       expect(Math.floor(session.exp.getTime() / 1000)).toBe(1760591204)
       expect(session.addrs).toStrictEqual(['RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A'])
+      expect(session.networks).toStrictEqual(['TestNet'])
       expect(await duckconn.retrieveSession()).not.toBeNull()
     })
 
@@ -502,4 +504,173 @@ describe('Ducky Connect class', () => {
       await expect(() => duckconn.signTransaction(testTxn)).rejects.toThrowError()
     })
   })
+
+  describe('signTransactionGroup()', () => {
+    const signedTxnB64 = 'gqNzaWfEQHOy8+zozpBTp3wOA1ZzANbN2LXeHTUTFre5xg0WpsPiKTm9Eto4Kq+XuutVHvaTMa9v7KxpWB+tZ79iOeCqDgyjdHhuiKNmZWXNA+iiZnbOAnvsNaNnZW6sdGVzdG5ldC12MS4womdoxCBIY7UYpLPITsgQ8i1PEIHLD3HwWaesIN7GL39w5Qk6IqJsds4Ce/Ado3JjdsQgiwGZNPVYGY6ClrTkNzeS0dFK/BjHmWsRisH9vCzgUvKjc25kxCCLAZk09VgZjoKWtOQ3N5LR0Ur8GMeZaxGKwf28LOBS8qR0eXBlo3BheQ=='
+
+    /** Creates a group of 2 payment transactions */
+    function makeTestGroup() {
+      const suggestedParams = {
+        fee: 1000, // 0.001 Algos
+        flatFee: true,
+        firstValid: 6000000,
+        lastValid: 6001000,
+        genesisHash: algosdk.base64ToBytes('SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI='),
+        genesisID: 'testnet-v1.0',
+        minFee: 1000,
+      }
+      return algosdk.assignGroupID([
+        algosdk.makePaymentTxnWithSuggestedParamsFromObject({
+          sender: 'RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A',
+          receiver: 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4',
+          amount: 0,
+          suggestedParams,
+        }),
+        algosdk.makePaymentTxnWithSuggestedParamsFromObject({
+          sender: 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4',
+          receiver: 'RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A',
+          amount: 0,
+          suggestedParams,
+        }),
+      ])
+    }
+
+    beforeEach(async () => {
+      // Put session data into storage
+      idbSet(dc.DEFAULT_SESSION_DATA_NAME, {
+        connectId: '7v/yMHo8iYIvnDvq5ObjgSjTX88/PIdpxkTA+zRM/Xo=',
+        session: {
+          id: 'XN/2YQP/uAdTsa3946CvbicxbwZGFPqAdep7g47UyyQ=',
+          exp: new Date(1760591204 * 1000),
+          addrs: ['RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A'],
+        },
+        dapp: { name: 'Test DApp' },
+        serverURL: dc.DEFAULT_SERVER_BASE_URL,
+      })
+      // Put connect ("dapp") key pair into storage
+      idbSet(dc.DEFAULT_CONNECT_KEY_PAIR_NAME,
+        await globalThis.crypto.subtle.generateKey(dc.KEY_ALGORITHM, false, ['deriveBits'])
+      )
+    })
+
+    it('sends the whole group with the transactions to sign', async () => {
+      // Mock the responses to connect server requests
+      fetchSpy.mockImplementation(async url => {
+        hawkClientAuthSpy.mockReturnValue({ headers: {'server-authorization': 'fake_header'}})
+        if (url === `${dc.DEFAULT_SERVER_BASE_URL}${dc.SIGN_TXN_GROUP_ENDPOINT}`) {
+          return new Response(
+            JSON.stringify({ signed_transactions: [signedTxnB64] }),
+            {
+              headers: {
+                'Content-Type': 'application/json',
+                'Server-Authorization': 'some_faked_auth_header',
+              }
+            }
+          )
+        }
+        return new Response
+      })
+
+      const group = makeTestGroup()
+      const duckconn = await (new dc.DuckyConnect({dapp: { name: 'Test DApp'}})).setup()
+      const promptUserFn = vi.fn()
+      const signedTxns = await duckconn.signTransactionGroup(group, [0], promptUserFn)
+      expect(promptUserFn).toHaveBeenCalledOnce()
+
+      // Check the request
+      const reqBody = JSON.parse(fetchSpy.mock.calls[0][1]?.body as string)
+      expect(reqBody.group).toEqual(group.map(txn => algosdk.bytesToBase64(txn.bytesToSign())))
+      expect(reqBody.transactions).toEqual([algosdk.bytesToBase64(group[0].bytesToSign())])
+
+      // Check the signed transactions
+      expect(signedTxns).toHaveLength(1)
+      expect(signedTxns[0].txn.sender.toString()).toBe('RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A')
+    })
+
+    it('fails when given an index that is not in the group', async () => {
+      const duckconn = await (new dc.DuckyConnect({dapp: { name: 'Test DApp'}})).setup()
+
+      await expect(() => duckconn.signTransactionGroup(makeTestGroup(), [2])).rejects.toThrowError()
+      expect(fetchSpy).not.toHaveBeenCalled()
+    })
+
+    it('fails when the server responds with an error', async () => {
+      fetchSpy.mockImplementation(async () => {
+        hawkClientAuthSpy.mockReturnValue({ headers: {'server-authorization': 'fake_header'}})
+        return new Response(
+          JSON.stringify({ name: 'invalid_group', message: 'the group ID does not match' }),
+          { status: 400, headers: { 'Content-Type': 'application/json' } },
+        )
+      })
+      const duckconn = await (new dc.DuckyConnect({dapp: { name: 'Test DApp'}})).setup()
+
+      await expect(() => duckconn.signTransactionGroup(makeTestGroup())).rejects.toThrowError(/invalid_group/)
+    })
+  })
+
+  describe('registerAppSpec()', () => {
+    const appSpec = {
+      name: 'Swapper',
+      methods: [{name: 'swap', args: [{type: 'uint64', name: 'amount'}], returns: {type: 'void'}}],
+    }
+
+    beforeEach(async () => {
+      // Put session data into storage
+      idbSet(dc.DEFAULT_SESSION_DATA_NAME, {
+        connectId: '7v/yMHo8iYIvnDvq5ObjgSjTX88/PIdpxkTA+zRM/Xo=',
+        session: {
+          id: 'XN/2YQP/uAdTsa3946CvbicxbwZGFPqAdep7g47UyyQ=',
+          exp: new Date(1760591204 * 1000),
+          addrs: ['RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A'],
+        },
+        dapp: { name: 'Test DApp' },
+        serverURL: dc.DEFAULT_SERVER_BASE_URL,
+      })
+      // Put connect ("dapp") key pair into storage
+      idbSet(dc.DEFAULT_CONNECT_KEY_PAIR_NAME,
+        await globalThis.crypto.subtle.generateKey(dc.KEY_ALGORITHM, false, ['deriveBits'])
+      )
+    })
+
+    it('sends the app spec of the application', async () => {
+      // Mock the responses to connect server requests
+      fetchSpy.mockImplementation(async url => {
+        hawkClientAuthSpy.mockReturnValue({ headers: {'server-authorization': 'fake_header'}})
+        if (url === `${dc.DEFAULT_SERVER_BASE_URL}${dc.APP_SPEC_ENDPOINT}`) {
+          return new Response(
+            JSON.stringify({ app_id: 1234, name: 'Swapper', format: 'arc56' }),
+            {
+              headers: {
+                'Content-Type': 'application/json',
+                'Server-Authorization': 'some_faked_auth_header',
+              }
+            }
+          )
+        }
+        return new Response
+      })
+
+      const duckconn = await (new dc.DuckyConnect({dapp: { name: 'Test DApp'}})).setup()
+      const registered = await duckconn.registerAppSpec(1234, appSpec)
+
+      // Check the request
+      const reqBody = JSON.parse(fetchSpy.mock.calls[0][1]?.body as string)
+      expect(reqBody).toEqual({ app_id: 1234, spec: appSpec })
+
+      expect(registered).toEqual({ app_id: 1234, name: 'Swapper', format: 'arc56' })
+    })
+
+    it('fails when the server responds with an error', async () => {
+      fetchSpy.mockImplementation(async () => {
+        hawkClientAuthSpy.mockReturnValue({ headers: {'server-authorization': 'fake_header'}})
+        return new Response(
+          JSON.stringify({ name: 'app_spec_exists', message: 'there is already an app spec' }),
+          { status: 409, headers: { 'Content-Type': 'application/json' } },
+        )
+      })
+      const duckconn = await (new dc.DuckyConnect({dapp: { name: 'Test DApp'}})).setup()
+
+      await expect(() => duckconn.registerAppSpec(1234, appSpec)).rejects.toThrowError(/app_spec_exists/)
+    })
+  })
 })
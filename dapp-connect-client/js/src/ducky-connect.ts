@@ -12,6 +12,10 @@ export const SESSION_CONFIRM_ENDPOINT = '/session/confirm'
 export const SESSION_END_ENDPOINT = '/session/end'
 /** The endpoint path for signing a transaction */
 export const SIGN_TXN_ENDPOINT = '/transaction/sign'
+/** The endpoint path for signing the transactions of a transaction group */
+export const SIGN_TXN_GROUP_ENDPOINT = '/transaction/group/sign'
+/** The endpoint path for registering the app spec of an application */
+export const APP_SPEC_ENDPOINT = '/app/spec'
 
 /** The default name in storage for the connect ID/key pair */
 export const DEFAULT_CONNECT_KEY_PAIR_NAME = 'dcDappKeyPair'
@@ -53,6 +57,10 @@ export interface SessionInfo {
    * that are allowed to sign things within this session.
    */
   addrs: string[]
+  /** Names of the wallet's network profiles (e.g. "TestNet") that the user bound this session to.
+   * The wallet rejects requests to sign transactions for any other network.
+   */
+  networks?: string[]
 }
 
 /** Information needed for an initialized session to be confirmed, and therefore established */
@@ -92,6 +100,10 @@ export interface ConnectOptions {
   dapp: DappInfo
   /** The base URL to the wallet's connect server */
   serverURL?: string
+  /** Genesis IDs of the networks (e.g. "testnet-v1.0") the dApp wants to request signatures for.
+   * The user is asked to choose from all the networks configured in the wallet if not given.
+   */
+  networks?: string[]
   /** Function for displaying the confirmation code. If this function throws an error, the request
    * to confirm the session will not be sent to the connect server.
    */
@@ -116,6 +128,7 @@ export class DuckyConnect {
   #connectId: string = ''
   #baseURL: string
   #dappInfo: DappInfo
+  #networks?: string[]
   #confirmCodeDisplayFn: (code: string) => void
   #connectKeyPairName: string
   #sessionDataKeyName: string
@@ -123,6 +136,7 @@ export class DuckyConnect {
   constructor(options: ConnectOptions) {
     this.#baseURL = options.serverURL ?? DEFAULT_SERVER_BASE_URL
     this.#dappInfo = options.dapp
+    this.#networks = options.networks
     this.#confirmCodeDisplayFn = options.confirmCodeDisplayFn
       ?? ((code: string) => alert(`Confirmation code: ${code}`))
     this.#connectKeyPairName = options.connectKeyPairName ?? DEFAULT_CONNECT_KEY_PAIR_NAME
@@ -209,7 +223,11 @@ export class DuckyConnect {
     const url = `${this.#baseURL}${SESSION_CONFIRM_ENDPOINT}`
     const reqMethod = 'POST'
     const reqContentType = 'application/json'
-    const reqBody = JSON.stringify({ token: sessionConfirm.token, dapp: this.#dappInfo })
+    const reqBody = JSON.stringify({
+      token: sessionConfirm.token,
+      dapp: this.#dappInfo,
+      networks: this.#networks,
+    })
 
     // Create Hawk header
     const credentials: hawk.client.Credentials = {
@@ -265,7 +283,8 @@ export class DuckyConnect {
     return {
       id: respJSON.id,
       exp: new Date(respJSON.exp * 1000),
-      addrs: respJSON.addrs
+      addrs: respJSON.addrs,
+      networks: respJSON.networks,
     }
   }
 
@@ -495,6 +514,181 @@ export class DuckyConnect {
 
     return algosdk.decodeSignedTransaction(algosdk.base64ToBytes(respJSON['signed_transaction']))
   }
+
+  /** Sign the transactions of the given transaction group. The whole group is sent so the user can
+   * see what the group does as a whole.
+   * @param group Transactions of the group, which must already have their group ID assigned
+   * @param indexesToSign Indexes (in the group) of the transactions to sign. All the transactions
+   *                      in the group are signed if not given.
+   * @param promptUserFn Function to run when starting to wait for the user to sign the
+   *                     transactions. Should be used to indicate to the user that the transactions
+   *                     are ready for them sign.
+   * @returns The signed transactions, in the order of the given indexes
+   */
+  async signTransactionGroup(
+    group: algosdk.Transaction[],
+    indexesToSign?: number[],
+    promptUserFn = () => alert('Please sign the transactions.'), // TODO: Move this into configuration
+  ): Promise<algosdk.SignedTransaction[]> {
+    if (!this.#setupComplete) {
+      throw new Error(NOT_INIT_ERR_MSG)
+    }
+
+    const sessionId = (await this.retrieveSession())?.session.id
+
+    if (!sessionId) {
+      throw new NoConnectSessionError(
+        'There is no valid connect session. Establish a connect session and try again.'
+      )
+    }
+
+    // Transform transactions into Base64 encoded bytes
+    const groupB64 = group.map(txn => algosdk.bytesToBase64(txn.bytesToSign()))
+    const txnsToBeSignedB64 = (indexesToSign ?? group.map((_, i) => i)).map(i => {
+      if (i < 0 || i >= groupB64.length) {
+        throw new RangeError(`There is no transaction at index ${i} in the group`)
+      }
+      return groupB64[i]
+    })
+
+    const url = `${this.#baseURL}${SIGN_TXN_GROUP_ENDPOINT}`
+    const reqMethod = 'POST'
+    const reqContentType = 'application/json'
+    const reqBody = JSON.stringify({ group: groupB64, transactions: txnsToBeSignedB64 })
+
+    // Create Hawk header
+    const credentials: hawk.client.Credentials = {
+      id: sessionId,
+      key: await deriveSharedKeyB64(
+        (await this.#retrieveConnectKeyPair())!.privateKey,
+        await base64ToKey(sessionId, true), // Convert confirmation ID to public key
+      ),
+      algorithm: 'sha256'
+    }
+    const hawkHeader = hawk.client.header(url, reqMethod, {
+      credentials,
+      contentType: reqContentType,
+      payload: reqBody,
+    })
+
+    promptUserFn()
+
+    // Make request to server
+    const response = await fetch(url, {
+      method: reqMethod,
+      body: reqBody,
+      headers: {
+        'Content-Type': reqContentType,
+        'Authorization': hawkHeader.header,
+      },
+    })
+    const respText = await response.text()
+    const respJSON = JSON.parse(respText)
+
+    // Verify server response
+    const authResult = hawk.client.authenticate(
+      response as any,
+      credentials,
+      hawkHeader.artifacts,
+      { payload: respText.trim() }
+    )
+
+    if (!response.ok) {
+      // NOTE: An error from the server will have a 'name' and a 'message'
+      throw Error(
+        `Transaction group signing failed. Error from server: ${respJSON.message} (${respJSON.name})`
+      )
+    }
+
+    // If the response is valid the authentication header is returned, otherwise no headers are
+    // returned or an error is thrown
+    if (Object.keys(authResult.headers).length === 0) {
+      throw new Error('Server response failed verification')
+    }
+
+    return (respJSON['signed_transactions'] as string[]).map(
+      stxn => algosdk.decodeSignedTransaction(algosdk.base64ToBytes(stxn))
+    )
+  }
+  /** Register the ARC-32 or ARC-56 app spec of the given application so the calls to the
+   * application's ABI methods can be shown to the user in a readable way when they are asked to
+   * sign them. Only app specs that were registered by this dApp can be replaced.
+   * @param appId ID of the application the app spec is for
+   * @param spec The ARC-32 or ARC-56 app spec, which is either the parsed JSON or the JSON string
+   * @returns The app ID, the contract name and the format ("arc32" or "arc56") of the registered
+   *          app spec
+   */
+  async registerAppSpec(
+    appId: number | bigint,
+    spec: object | string,
+  ): Promise<{app_id: number, name: string, format: string}> {
+    if (!this.#setupComplete) {
+      throw new Error(NOT_INIT_ERR_MSG)
+    }
+
+    const sessionId = (await this.retrieveSession())?.session.id
+
+    if (!sessionId) {
+      throw new NoConnectSessionError(
+        'There is no valid connect session. Establish a connect session and try again.'
+      )
+    }
+
+    const url = `${this.#baseURL}${APP_SPEC_ENDPOINT}`
+    const reqMethod = 'POST'
+    const reqContentType = 'application/json'
+    const reqBody = `{"app_id":${appId},"spec":${typeof spec === 'string' ? spec : JSON.stringify(spec)}}`
+
+    // Create Hawk header
+    const credentials: hawk.client.Credentials = {
+      id: sessionId,
+      key: await deriveSharedKeyB64(
+        (await this.#retrieveConnectKeyPair())!.privateKey,
+        await base64ToKey(sessionId, true), // Convert confirmation ID to public key
+      ),
+      algorithm: 'sha256'
+    }
+    const hawkHeader = hawk.client.header(url, reqMethod, {
+      credentials,
+      contentType: reqContentType,
+      payload: reqBody,
+    })
+
+    // Make request to server
+    const response = await fetch(url, {
+      method: reqMethod,
+      body: reqBody,
+      headers: {
+        'Content-Type': reqContentType,
+        'Authorization': hawkHeader.header,
+      },
+    })
+    const respText = await response.text()
+    const respJSON = JSON.parse(respText)
+
+    // Verify server response
+    const authResult = hawk.client.authenticate(
+      response as any,
+      credentials,
+      hawkHeader.artifacts,
+      { payload: respText.trim() }
+    )
+
+    if (!response.ok) {
+      // NOTE: An error from the server will have a 'name' and a 'message'
+      throw Error(
+        `App spec registration failed. Error from server: ${respJSON.message} (${respJSON.name})`
+      )
+    }
+
+    // If the response is valid the authentication header is returned, otherwise no headers are
+    // returned or an error is thrown
+    if (Object.keys(authResult.headers).length === 0) {
+      throw new Error('Server response failed verification')
+    }
+
+    return respJSON
+  }
 }
 
 class KeyPairExistsError extends Error {}
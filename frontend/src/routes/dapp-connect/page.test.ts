@@ -8,9 +8,10 @@ const eventEmitFunc = vi.fn();
 const windowCloseFunc = vi.fn();
 vi.mock('@wailsio/runtime', () => ({
   Events: {
-    Emit: () => eventEmitFunc(),
+    Emit: (...args: any[]) => eventEmitFunc(...args),
     On: vi.fn().mockImplementation((evtName: string, cb: Function) => {
-      cb({data: '{"dapp":{"name":"Foo DApp","uri":"http://example.com","desc":"Foobar","icon":""}}'});
+      if (evtName !== 'session_confirm_prompt_load') return
+      cb({data: '{"request_id":"abc123","dapp":{"name":"Foo DApp","uri":"http://example.com","desc":"Foobar","icon":""},"networks":["TestNet","LocalNet"]}'});
     }),
   },
   Window: { Close: () => windowCloseFunc() }
@@ -30,6 +31,13 @@ describe('DApp Connect Confirmation Page', () => {
     expect(await screen.findByText('No accounts')).toBeInTheDocument()
   });
 
+  it('has list of networks that are all chosen by default', async () => {
+		render(DappConnectPage);
+    expect(await screen.findByText('Choose networks to allow')).toBeInTheDocument()
+    expect(await screen.findByLabelText('TestNet')).toBeChecked()
+    expect(await screen.findByLabelText('LocalNet')).toBeChecked()
+  });
+
   it('responds to backend & closes window when confirmation code submitted', async () => {
 		render(DappConnectPage);
 
@@ -39,6 +47,8 @@ describe('DApp Connect Confirmation Page', () => {
     await userEvent.click(screen.getByText('Confirm connection'));
 
     expect(eventEmitFunc).toHaveBeenCalledOnce()
+    expect(eventEmitFunc.mock.calls[0][0]).toBe('session_confirm_response')
+    expect(JSON.parse(eventEmitFunc.mock.calls[0][1]).request_id).toBe('abc123')
     expect(windowCloseFunc).toHaveBeenCalledOnce()
   });
 });
@@ -0,0 +1,122 @@
+import {render, screen} from '@testing-library/svelte';
+import userEvent from '@testing-library/user-event';
+import { describe, it, expect, vi } from 'vitest';
+
+import TxnGroupSignApprovalPage from './+page.svelte';
+
+// Code (with modifications) from https://vitest.dev/api/vi.html#vi-hoisted
+const { eventEmitFunc, windowCloseFunc } = vi.hoisted(() => {
+  return {
+    eventEmitFunc: vi.fn(),
+    windowCloseFunc: vi.fn(),
+  }
+});
+vi.mock('@wailsio/runtime', () => ({
+  Events: {
+    Emit: eventEmitFunc,
+    On: vi.fn().mockImplementation((evtName: string, cb: Function) => {
+      if (evtName !== 'txn_group_sign_prompt_load') return
+      const walletAddr = 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4'
+      const dappAddr = 'GD64YIY3TWGDMCNPP553DZPPR6LDUSFQOIJVFDPPXWEG3FVOJCCDBBHU5A'
+      cb({data: JSON.stringify({
+        request_id: 'abc123',
+        group: ['', ''],
+        sign_indexes: [0],
+        summaries: [
+          {
+            id: 'TXID1',
+            type: 'pay',
+            type_name: 'Payment',
+            sender: walletAddr,
+            receiver: dappAddr,
+            fee: { microalgos: 2000, algos: '0.002000' },
+          },
+          {
+            id: 'TXID2',
+            type: 'axfer',
+            type_name: 'Asset Transfer',
+            sender: dappAddr,
+            receiver: walletAddr,
+            fee: { microalgos: 0, algos: '0.000000' },
+          },
+        ],
+        note_analyses: [
+          null,
+          {
+            format: 'text',
+            text: 'Claim at example.com',
+            urls: ['example.com'],
+            findings: [{code: 'url', message: 'Note has a link: example.com'}],
+          },
+        ],
+        net_flows: [
+          {account: walletAddr, asset_id: 0, sent: 5_002_000, received: 0},
+          {account: walletAddr, asset_id: 10, sent: 0, received: 25},
+        ],
+        risks: [{code: 'simulation_failed', message: 'The transaction group failed when it was simulated'}],
+      })});
+    }),
+  },
+  Window: { Close: windowCloseFunc }
+}));
+
+describe('Transaction Group Signing Approval Page', () => {
+
+  it('has heading', async () => {
+    render(TxnGroupSignApprovalPage);
+    expect(await screen.findByText('Approve Transaction Group')).toHaveRole('heading');
+  });
+
+  it('has the transactions in the group', async () => {
+    render(TxnGroupSignApprovalPage);
+    expect(await screen.findByText(/wants 1 of the 2 transactions/)).toBeInTheDocument()
+    expect(await screen.findByText(/Asset Transfer/)).toBeInTheDocument()
+    expect(await screen.findByText('To be signed')).toBeInTheDocument()
+  });
+
+  it('has what the accounts send and receive across the group', async () => {
+    render(TxnGroupSignApprovalPage);
+    expect(await screen.findByText('5.002000 Algos')).toBeInTheDocument()
+    expect(await screen.findByText('25 of asset 10')).toBeInTheDocument()
+  });
+
+  it('warns about what was found in the notes of the transactions', async () => {
+    render(TxnGroupSignApprovalPage);
+    expect(await screen.findByText('Warning: The note of transaction 1 may be a scam')).toBeInTheDocument()
+    expect(await screen.findByText('Note has a link: example.com')).toBeInTheDocument()
+    expect(screen.queryByText('Warning: The note of transaction 0 may be a scam')).not.toBeInTheDocument()
+  });
+
+  it('requires every risk to be acknowledged before approving', async() => {
+    eventEmitFunc.mockClear()
+    windowCloseFunc.mockClear()
+
+    render(TxnGroupSignApprovalPage);
+    const approveButton = await screen.findByText("Approve")
+    expect(approveButton).toBeDisabled()
+
+    await userEvent.click(await screen.findByLabelText('The transaction group failed when it was simulated'))
+    expect(approveButton).toBeEnabled()
+    await userEvent.click(approveButton)
+
+    expect(eventEmitFunc).toHaveBeenCalledOnce()
+    expect(eventEmitFunc).toHaveBeenCalledWith(
+      'txn_group_sign_response',
+      '{"request_id":"abc123","approved":true,"acknowledged_risks":["simulation_failed"]}',
+    )
+    expect(windowCloseFunc).toHaveBeenCalledOnce()
+  });
+
+  it('responds to backend & closes window when user rejects the group', async() => {
+    eventEmitFunc.mockClear()
+    windowCloseFunc.mockClear()
+
+    render(TxnGroupSignApprovalPage);
+    await userEvent.click(await screen.findByText("Reject"))
+
+    expect(eventEmitFunc).toHaveBeenCalledOnce()
+    expect(eventEmitFunc).toHaveBeenCalledWith('txn_group_sign_response', '{"request_id":"abc123","approved":false}')
+    expect(windowCloseFunc).toHaveBeenCalledOnce()
+  });
+
+});
@@ -6,16 +6,21 @@ import algosdk from 'algosdk';
 import TxnSignApprovalPage from './+page.svelte';
 
 // Code (with modifications) from https://vitest.dev/api/vi.html#vi-hoisted
-const { eventEmitFunc, windowCloseFunc } = vi.hoisted(() => {
+const { eventEmitFunc, windowCloseFunc, promptRisks, promptSummaryExtra } = vi.hoisted(() => {
   return {
     eventEmitFunc: vi.fn(),
     windowCloseFunc: vi.fn(),
+    // Risks sent with the prompt, which can be changed by each test
+    promptRisks: [] as {code: string, message: string}[],
+    // Extra summary fields sent with the prompt, which can be changed by each test
+    promptSummaryExtra: {} as {[key: string]: any},
   }
 });
 vi.mock('@wailsio/runtime', () => ({
   Events: {
     Emit: eventEmitFunc,
     On: vi.fn().mockImplementation((evtName: string, cb: Function) => {
+      if (evtName !== 'txn_sign_prompt_load') return
       const txnB64 = Buffer.from(algosdk.encodeUnsignedTransaction(
         algosdk.makePaymentTxnWithSuggestedParamsFromObject({
           sender: 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4',
@@ -24,7 +29,51 @@ vi.mock('@wailsio/runtime', () => ({
           suggestedParams: { fee: 1000, firstValid: 6000000, lastValid: 6001000, minFee: 1000 }
         })
       )).toString('base64');
-      cb({data: `{"data":{"transaction":"${txnB64}","signer":""}}`});
+      const summary = {
+        id: 'TXID',
+        type: 'pay',
+        type_name: 'Payment',
+        sender: 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4',
+        receiver: 'GD64YIY3TWGDMCNPP553DZPPR6LDUSFQOIJVFDPPXWEG3FVOJCCDBBHU5A',
+        amount: { microalgos: 5_000_000, algos: '5.000000' },
+        fee: { microalgos: 1000, algos: '0.001000' },
+        first_valid: 6000000,
+        last_valid: 6001000,
+        genesis_hash: '',
+        addresses: {
+          'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4': {
+            label: 'Savings', trusted: true, in_wallet: true, known: true,
+          },
+          'GD64YIY3TWGDMCNPP553DZPPR6LDUSFQOIJVFDPPXWEG3FVOJCCDBBHU5A': {
+            trusted: false, in_wallet: false, known: false,
+          },
+        },
+        ...promptSummaryExtra,
+      }
+      cb({data: JSON.stringify({
+        request_id: 'abc123',
+        data: { transaction: txnB64, signer: '' },
+        summary,
+        risks: promptRisks,
+        note_analysis: {
+          format: 'text',
+          text: 'Claim at example.com',
+          urls: ['example.com'],
+          findings: [
+            {code: 'url', message: 'Note has a link: example.com'},
+            {code: 'scam_wording', message: 'Note has wording that is common in scams: claim'},
+          ],
+        },
+        simulation: {
+          would_succeed: true,
+          balance_changes: [
+            {address: 'EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4', asset_id: 0, sent: 5_001_000, received: 0},
+            {address: 'GD64YIY3TWGDMCNPP553DZPPR6LDUSFQOIJVFDPPXWEG3FVOJCCDBBHU5A', asset_id: 0, sent: 0, received: 5_000_000},
+          ],
+          app_state_changes: [],
+          inner_txns: [],
+        },
+      })});
     }),
   },
   Window: { Close: windowCloseFunc }
@@ -39,8 +88,47 @@ describe('Transaction Signing Approval Page', () => {
 
   it('has transaction information', async () => {
 		render(TxnSignApprovalPage);
-    expect(await screen.findByText(/EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4/))
-      .toBeInTheDocument()
+    expect(await screen.findAllByText(/EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4/))
+      .not.toHaveLength(0)
+  })
+
+  it('has decoded transaction summary from backend', async () => {
+		render(TxnSignApprovalPage);
+    expect(await screen.findByText('Payment')).toBeInTheDocument()
+    expect(await screen.findByText('5.000000 Algos')).toBeInTheDocument()
+    expect(await screen.findByText('0.001000 Algos')).toBeInTheDocument()
+  })
+
+  it('has address labels and highlights unknown addresses', async () => {
+		render(TxnSignApprovalPage);
+    expect(await screen.findByText('Savings')).toBeInTheDocument()
+    expect(await screen.findByText('Unknown address')).toBeInTheDocument()
+  })
+
+  it('shows the ABI method being called', async () => {
+    promptSummaryExtra.app_call = {
+      app_id: 42,
+      on_completion: 'NoOp',
+      method: {name: 'swap', signature: 'swap(uint64,uint64)void', text: 'swap(asset_in=31566704, amount=1000000)'},
+    }
+
+		render(TxnSignApprovalPage);
+    expect(await screen.findByText('swap(asset_in=31566704, amount=1000000)')).toBeInTheDocument()
+
+    delete promptSummaryExtra.app_call
+  })
+
+  it('warns about what was found in the note', async () => {
+		render(TxnSignApprovalPage);
+    expect(await screen.findByText('Warning: The note of this transaction may be a scam')).toBeInTheDocument()
+    expect(await screen.findByText('Note has a link: example.com')).toBeInTheDocument()
+  })
+
+  it('shows the balance changes from the simulation', async () => {
+		render(TxnSignApprovalPage);
+    expect(await screen.findByText('Simulation')).toBeInTheDocument()
+    expect(await screen.findByText('-5.001000 Algos')).toBeInTheDocument()
+    expect(await screen.findByText('+5.000000 Algos')).toBeInTheDocument()
   })
 
   it('responds to backend & closes window when user approves transaction', async() => {
@@ -51,7 +139,7 @@ describe('Transaction Signing Approval Page', () => {
     await userEvent.click(await screen.findByText("Approve"))
 
     expect(eventEmitFunc).toHaveBeenCalledOnce()
-    expect(eventEmitFunc).toHaveBeenCalledWith('txn_sign_response', '[{"approved":true}]')
+    expect(eventEmitFunc).toHaveBeenCalledWith('txn_sign_response', '{"request_id":"abc123","approved":true}')
     expect(windowCloseFunc).toHaveBeenCalledOnce()
   });
 
@@ -63,8 +151,31 @@ describe('Transaction Signing Approval Page', () => {
     await userEvent.click(await screen.findByText("Reject"))
 
     expect(eventEmitFunc).toHaveBeenCalledOnce()
-    expect(eventEmitFunc).toHaveBeenCalledWith('txn_sign_response', '[{"approved":false}]')
+    expect(eventEmitFunc).toHaveBeenCalledWith('txn_sign_response', '{"request_id":"abc123","approved":false}')
+    expect(windowCloseFunc).toHaveBeenCalledOnce()
+  });
+
+  it('requires every risk to be acknowledged before approving', async() => {
+    eventEmitFunc.mockClear()
+    windowCloseFunc.mockClear()
+    promptRisks.push({code: 'rekey', message: 'The account will be rekeyed'})
+
+    render(TxnSignApprovalPage);
+    const approveButton = await screen.findByText("Approve")
+    expect(approveButton).toBeDisabled()
+
+    await userEvent.click(await screen.findByLabelText('The account will be rekeyed'))
+    expect(approveButton).toBeEnabled()
+    await userEvent.click(approveButton)
+
+    expect(eventEmitFunc).toHaveBeenCalledOnce()
+    expect(eventEmitFunc).toHaveBeenCalledWith(
+      'txn_sign_response',
+      '{"request_id":"abc123","approved":true,"acknowledged_risks":["rekey"]}',
+    )
     expect(windowCloseFunc).toHaveBeenCalledOnce()
+
+    promptRisks.length = 0
   });
 
 });
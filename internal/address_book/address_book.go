@@ -0,0 +1,262 @@
+// Package address_book is a wallet's book of contacts, which gives addresses
+// labels that are easier for a person to recognize. The contacts are stored
+// within an encrypted database file in the wallet's directory.
+package address_book
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/encdb"
+)
+
+// DefaultDataFile is the default name of the encrypted database file that
+// contains the address book. It is stored in the wallet's directory, next to
+// the wallet's other encrypted database files.
+const DefaultDataFile = "address_book.duckdb"
+
+// ErrContactNotFound is the error returned when there is no contact with the
+// given address
+var ErrContactNotFound = errors.New("contact not found")
+
+// schema is the SQL statement for creating the contacts table
+const schema = `
+CREATE TABLE IF NOT EXISTS db.contacts (
+    address VARCHAR PRIMARY KEY,
+    label VARCHAR NOT NULL,
+    notes VARCHAR NOT NULL,
+    tags VARCHAR NOT NULL,
+    trusted BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+`
+
+// selectContactsSQL is the SQL statement for getting contacts. Conditions are
+// to be appended to it.
+const selectContactsSQL = `SELECT address, label, notes, tags, trusted,
+created_at, updated_at FROM db.contacts`
+
+// writeMutex prevents contacts from being saved at the same time
+var writeMutex sync.Mutex
+
+// Contact is an address with a label and other information about it
+type Contact struct {
+	// Address of the contact's account
+	Address string `json:"address"`
+	// Label for the user to recognize the address by
+	Label string `json:"label"`
+	// Any notes about the contact
+	Notes string `json:"notes,omitempty"`
+	// Tags for grouping contacts
+	Tags []string `json:"tags,omitempty"`
+	// If the user trusts the contact
+	Trusted bool `json:"trusted"`
+	// The date-time the contact was added
+	CreatedAt time.Time `json:"created_at"`
+	// The date-time the contact was last changed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks if the contact is valid
+func (contact *Contact) Validate() error {
+	if _, err := types.DecodeAddress(contact.Address); err != nil {
+		return fmt.Errorf("invalid contact address: '%s'", contact.Address)
+	}
+
+	if strings.TrimSpace(contact.Label) == "" {
+		return errors.New("contact label cannot be empty")
+	}
+
+	return nil
+}
+
+// Store is an address book stored within an encrypted DuckDB file
+type Store struct {
+	// Path of the encrypted database file that contains the address book
+	filePath string
+}
+
+// NewStore creates a new Store that is stored in the encrypted DuckDB file with
+// the given file path
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+// FilePath returns the path of the database file that contains the address
+// book
+func (s *Store) FilePath() string {
+	return s.filePath
+}
+
+// ListContacts retrieves all the contacts ordered by label using the given
+// file encryption key to access the database file
+func (s *Store) ListContacts(fileEncKey []byte) ([]Contact, error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(selectContactsSQL + " ORDER BY lower(label), address")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []Contact
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, *contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+// GetContact retrieves the contact with the given address using the given file
+// encryption key to access the database file. Returns ErrContactNotFound if
+// there is no contact with that address.
+func (s *Store) GetContact(address string, fileEncKey []byte) (*Contact, error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	contact, err := scanContact(db.QueryRow(selectContactsSQL+" WHERE address = ?", address))
+	if err == sql.ErrNoRows {
+		return nil, ErrContactNotFound
+	}
+
+	return contact, err
+}
+
+// SaveContact validates and adds the given contact, or replaces the contact
+// with the same address, using the given file encryption key to access the
+// database file. Returns the contact as it was stored.
+func (s *Store) SaveContact(contact Contact, fileEncKey []byte) (*Contact, error) {
+	if err := contact.Validate(); err != nil {
+		return nil, err
+	}
+
+	contact.Label = strings.TrimSpace(contact.Label)
+	tags, err := json.Marshal(contact.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Keep the date-time the contact was first added
+	// NOTE: DuckDB timestamps have a precision of microseconds
+	contact.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	err = tx.QueryRow("SELECT created_at FROM db.contacts WHERE address = ?", contact.Address).
+		Scan(&contact.CreatedAt)
+	if err == sql.ErrNoRows {
+		contact.CreatedAt = contact.UpdatedAt
+	} else if err != nil {
+		return nil, err
+	}
+	contact.CreatedAt = contact.CreatedAt.UTC()
+
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO db.contacts VALUES (?, ?, ?, ?, ?, ?, ?)",
+		contact.Address,
+		contact.Label,
+		contact.Notes,
+		string(tags),
+		contact.Trusted,
+		contact.CreatedAt,
+		contact.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// RemoveContact removes the contact with the given address using the given
+// file encryption key to access the database file. Returns ErrContactNotFound
+// if there is no contact with that address.
+func (s *Store) RemoveContact(address string, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM db.contacts WHERE address = ?", address)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrContactNotFound
+	}
+
+	return nil
+}
+
+// scanContact converts the given row of the contacts table into a contact
+func scanContact(row interface{ Scan(...any) error }) (*Contact, error) {
+	var (
+		contact Contact
+		tags    string
+	)
+
+	err := row.Scan(
+		&contact.Address,
+		&contact.Label,
+		&contact.Notes,
+		&tags,
+		&contact.Trusted,
+		&contact.CreatedAt,
+		&contact.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	contact.CreatedAt = contact.CreatedAt.UTC()
+	contact.UpdatedAt = contact.UpdatedAt.UTC()
+	if err = json.Unmarshal([]byte(tags), &contact.Tags); err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
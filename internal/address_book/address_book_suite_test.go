@@ -0,0 +1,13 @@
+package address_book_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAddressBook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Address Book Suite")
+}
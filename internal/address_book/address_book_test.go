@@ -0,0 +1,66 @@
+package address_book_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/address_book"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+var fileEncKey = []byte("01234567890123456789012345678901")
+
+var _ = Describe("Address Book", func() {
+	var store *address_book.Store
+
+	BeforeEach(func() {
+		store = address_book.NewStore(filepath.Join(GinkgoT().TempDir(), address_book.DefaultDataFile))
+	})
+
+	It("saves, lists, gets and removes contacts", func() {
+		By("Saving contacts")
+		bob, err := store.SaveContact(address_book.Contact{
+			Address: testAddrA,
+			Label:   " Bob ",
+			Notes:   "Met at conference",
+			Tags:    []string{"friends"},
+			Trusted: true,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bob.Label).To(Equal("Bob"))
+		Expect(bob.CreatedAt).To(Equal(bob.UpdatedAt))
+		alice, err := store.SaveContact(address_book.Contact{Address: testAddrB, Label: "alice"}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Listing contacts ordered by label")
+		contacts, err := store.ListContacts(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contacts).To(Equal([]address_book.Contact{*alice, *bob}))
+
+		By("Replacing a contact")
+		bob.Label = "Robert"
+		updatedBob, err := store.SaveContact(*bob, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updatedBob.CreatedAt).To(Equal(bob.CreatedAt), "Keeps when the contact was added")
+		gotBob, err := store.GetContact(testAddrA, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gotBob).To(Equal(updatedBob))
+
+		By("Removing a contact")
+		Expect(store.RemoveContact(testAddrA, fileEncKey)).To(Succeed())
+		_, err = store.GetContact(testAddrA, fileEncKey)
+		Expect(err).To(MatchError(address_book.ErrContactNotFound))
+		Expect(store.RemoveContact(testAddrA, fileEncKey)).To(MatchError(address_book.ErrContactNotFound))
+	})
+
+	It("does not save an invalid contact", func() {
+		_, err := store.SaveContact(address_book.Contact{Address: "foo", Label: "Foo"}, fileEncKey)
+		Expect(err).To(HaveOccurred())
+		_, err = store.SaveContact(address_book.Contact{Address: testAddrA, Label: "  "}, fileEncKey)
+		Expect(err).To(HaveOccurred())
+	})
+})
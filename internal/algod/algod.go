@@ -0,0 +1,50 @@
+// Package algod gives access to the algod API of an Algorand network, which is
+// used to get what the wallet needs to know about the chain (e.g. account
+// balances, asset names and whether a transaction was confirmed)
+package algod
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/kmd/config"
+)
+
+// DefaultTimeout is the default amount of time to wait for a response from
+// algod
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound is the error returned when the requested item (e.g. account,
+// asset or transaction) could not be found
+var ErrNotFound = errors.New("not found")
+
+// ErrNoAlgodURL is the error returned when trying to access the algod API of a
+// network whose profile does not have an algod URL
+var ErrNoAlgodURL = errors.New("network profile does not have an algod URL")
+
+// Client accesses the algod API of a network
+type Client interface {
+	// SuggestedParams gets the parameters suggested for new transactions
+	SuggestedParams(ctx context.Context) (types.SuggestedParams, error)
+	// AccountInfo gets the information of the account with the given address
+	AccountInfo(ctx context.Context, address string) (models.Account, error)
+	// AssetInfo gets the information of the asset with the given ID
+	AssetInfo(ctx context.Context, assetID uint64) (models.Asset, error)
+	// PendingTxnInfo gets the information of the transaction with the given
+	// ID, which is either in the transaction pool or was recently confirmed
+	PendingTxnInfo(ctx context.Context, txID string) (models.PendingTransactionInfoResponse, error)
+	// SubmitTxns submits the given encoded signed transaction or transaction
+	// group. Returns the ID of the first transaction.
+	SubmitTxns(ctx context.Context, signedTxns []byte) (txID string, err error)
+	// Simulate simulates the transaction groups in the given request without
+	// submitting them
+	Simulate(ctx context.Context, request models.SimulateRequest) (models.SimulateResponse, error)
+}
+
+// Provider gives the client for the algod API of the network with the given
+// profile
+type Provider func(network config.NetworkProfile) (Client, error)
@@ -0,0 +1,13 @@
+package algod_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAlgod(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Algod Suite")
+}
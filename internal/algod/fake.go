@@ -0,0 +1,160 @@
+package algod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/kmd/config"
+)
+
+// ErrSimulationUnavailable is the error returned by a Fake when it is asked to
+// simulate transactions without having a simulate function
+var ErrSimulationUnavailable = errors.New("simulation is not available")
+
+// Fake is an in-process Client that keeps its chain data in memory. It is meant
+// to be used in tests in place of an actual algod API.
+type Fake struct {
+	mu sync.Mutex
+	// Parameters to give as the suggested parameters
+	Params types.SuggestedParams
+	// Accounts to give information for, mapped by address
+	Accounts map[string]models.Account
+	// Assets to give information for, mapped by ID
+	Assets map[uint64]models.Asset
+	// Information of transactions that were submitted or are pending, mapped by
+	// transaction ID
+	PendingTxns map[string]models.PendingTransactionInfoResponse
+	// Function used to simulate transactions. Simulation fails if it is nil.
+	SimulateFunc func(request models.SimulateRequest) (models.SimulateResponse, error)
+	// Encoded signed transactions that have been submitted, in the order they
+	// were submitted
+	Submitted [][]byte
+}
+
+// NewFake creates a Fake with no chain data
+func NewFake() *Fake {
+	return &Fake{
+		Accounts:    make(map[string]models.Account),
+		Assets:      make(map[uint64]models.Asset),
+		PendingTxns: make(map[string]models.PendingTransactionInfoResponse),
+	}
+}
+
+// Provider returns a Provider that gives the fake for any network
+func (f *Fake) Provider() Provider {
+	return func(network config.NetworkProfile) (Client, error) {
+		return f, nil
+	}
+}
+
+// SuggestedParams gives the parameters in Params
+func (f *Fake) SuggestedParams(ctx context.Context) (types.SuggestedParams, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Params, nil
+}
+
+// AccountInfo gives the account in Accounts with the given address
+func (f *Fake) AccountInfo(ctx context.Context, address string) (models.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	acct, ok := f.Accounts[address]
+	if !ok {
+		return models.Account{}, fmt.Errorf("%w: account %s", ErrNotFound, address)
+	}
+	return acct, nil
+}
+
+// AssetInfo gives the asset in Assets with the given ID
+func (f *Fake) AssetInfo(ctx context.Context, assetID uint64) (models.Asset, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	asset, ok := f.Assets[assetID]
+	if !ok {
+		return models.Asset{}, fmt.Errorf("%w: asset %d", ErrNotFound, assetID)
+	}
+	return asset, nil
+}
+
+// PendingTxnInfo gives the transaction information in PendingTxns with the
+// given transaction ID
+func (f *Fake) PendingTxnInfo(ctx context.Context, txID string) (models.PendingTransactionInfoResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, ok := f.PendingTxns[txID]
+	if !ok {
+		return models.PendingTransactionInfoResponse{}, fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+	}
+	return info, nil
+}
+
+// SubmitTxns records the given encoded signed transactions in Submitted and adds
+// each of them to PendingTxns as an unconfirmed transaction
+func (f *Fake) SubmitTxns(ctx context.Context, signedTxns []byte) (string, error) {
+	var stxns []types.SignedTxn
+	dec := msgpack.NewDecoder(bytes.NewReader(signedTxns))
+	for {
+		var stxn types.SignedTxn
+		if err := dec.Decode(&stxn); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("could not decode signed transactions: %w", err)
+		}
+		stxns = append(stxns, stxn)
+	}
+	if len(stxns) == 0 {
+		return "", errors.New("no signed transactions were given")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Submitted = append(f.Submitted, signedTxns)
+	for _, stxn := range stxns {
+		f.PendingTxns[crypto.GetTxID(stxn.Txn)] = models.PendingTransactionInfoResponse{
+			Transaction: stxn,
+		}
+	}
+
+	return crypto.GetTxID(stxns[0].Txn), nil
+}
+
+// Confirm marks the transaction in PendingTxns with the given ID as confirmed
+// in the given round
+func (f *Fake) Confirm(txID string, round uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, ok := f.PendingTxns[txID]
+	if !ok {
+		return fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+	}
+	info.ConfirmedRound = round
+	f.PendingTxns[txID] = info
+
+	return nil
+}
+
+// Simulate simulates the transactions using SimulateFunc
+func (f *Fake) Simulate(ctx context.Context, request models.SimulateRequest) (models.SimulateResponse, error) {
+	f.mu.Lock()
+	simulate := f.SimulateFunc
+	f.mu.Unlock()
+
+	if simulate == nil {
+		return models.SimulateResponse{}, ErrSimulationUnavailable
+	}
+	return simulate(request)
+}
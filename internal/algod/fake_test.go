@@ -0,0 +1,124 @@
+package algod_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/algod"
+	"duckysigner/internal/kmd/config"
+)
+
+const testAddr = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+
+// signPayment creates and signs a payment transaction with a new account
+func signPayment(amount uint64) (string, []byte) {
+	acct := crypto.GenerateAccount()
+	txn, err := transaction.MakePaymentTxn(acct.Address.String(), testAddr, amount, nil, "", types.SuggestedParams{
+		Fee:             1000,
+		GenesisID:       "testnet-v1.0",
+		GenesisHash:     []byte("01234567890123456789012345678901"),
+		FirstRoundValid: 1,
+		LastRoundValid:  1000,
+		FlatFee:         true,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	txID, stxn, err := crypto.SignTransaction(acct.PrivateKey, txn)
+	Expect(err).NotTo(HaveOccurred())
+	return txID, stxn
+}
+
+var _ = Describe("Fake", func() {
+	var fake *algod.Fake
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		fake = algod.NewFake()
+	})
+
+	It("can be given by its provider for any network", func() {
+		client, err := fake.Provider()(config.NetworkProfile{Name: "TestNet"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client).To(BeIdenticalTo(fake))
+	})
+
+	It("gives the suggested parameters that were set", func() {
+		fake.Params = types.SuggestedParams{Fee: 1000, GenesisID: "testnet-v1.0"}
+		params, err := fake.SuggestedParams(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(params).To(Equal(fake.Params))
+	})
+
+	It("gives the information of accounts and assets that were set", func() {
+		fake.Accounts[testAddr] = models.Account{Address: testAddr, Amount: 5_000_000}
+		fake.Assets[10] = models.Asset{Index: 10, Params: models.AssetParams{Name: "Duck Coin"}}
+
+		acct, err := fake.AccountInfo(ctx, testAddr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acct.Amount).To(BeEquivalentTo(5_000_000))
+
+		asset, err := fake.AssetInfo(ctx, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(asset.Params.Name).To(Equal("Duck Coin"))
+	})
+
+	It("returns a not found error for unknown items", func() {
+		_, err := fake.AccountInfo(ctx, testAddr)
+		Expect(errors.Is(err, algod.ErrNotFound)).To(BeTrue())
+		_, err = fake.AssetInfo(ctx, 10)
+		Expect(errors.Is(err, algod.ErrNotFound)).To(BeTrue())
+		_, err = fake.PendingTxnInfo(ctx, "ABC")
+		Expect(errors.Is(err, algod.ErrNotFound)).To(BeTrue())
+		Expect(errors.Is(fake.Confirm("ABC", 1), algod.ErrNotFound)).To(BeTrue())
+	})
+
+	It("records submitted transactions as pending until they are confirmed", func() {
+		txID1, stxn1 := signPayment(1)
+		txID2, stxn2 := signPayment(2)
+		group := append(append([]byte{}, stxn1...), stxn2...)
+
+		By("submitting a transaction group")
+		txID, err := fake.SubmitTxns(ctx, group)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(txID).To(Equal(txID1))
+		Expect(fake.Submitted).To(Equal([][]byte{group}))
+
+		By("checking the submitted transactions are pending")
+		info, err := fake.PendingTxnInfo(ctx, txID2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ConfirmedRound).To(BeZero())
+		Expect(info.Transaction.Txn.Amount).To(BeEquivalentTo(2))
+
+		By("confirming one of the transactions")
+		Expect(fake.Confirm(txID2, 42)).To(Succeed())
+		info, err = fake.PendingTxnInfo(ctx, txID2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ConfirmedRound).To(BeEquivalentTo(42))
+	})
+
+	It("does not accept invalid signed transactions", func() {
+		_, err := fake.SubmitTxns(ctx, []byte{})
+		Expect(err).To(HaveOccurred())
+		_, err = fake.SubmitTxns(ctx, []byte("not a transaction"))
+		Expect(err).To(HaveOccurred())
+		Expect(fake.Submitted).To(BeEmpty())
+	})
+
+	It("simulates using the simulate function that was set", func() {
+		_, err := fake.Simulate(ctx, models.SimulateRequest{})
+		Expect(err).To(MatchError(algod.ErrSimulationUnavailable))
+
+		fake.SimulateFunc = func(request models.SimulateRequest) (models.SimulateResponse, error) {
+			return models.SimulateResponse{LastRound: 7}, nil
+		}
+		resp, err := fake.Simulate(ctx, models.SimulateRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.LastRound).To(BeEquivalentTo(7))
+	})
+})
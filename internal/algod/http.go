@@ -0,0 +1,102 @@
+package algod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sdkAlgod "github.com/algorand/go-algorand-sdk/v2/client/v2/algod"
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/kmd/config"
+)
+
+// HTTPClient is a Client that accesses the algod API of a network through HTTP
+type HTTPClient struct {
+	client *sdkAlgod.Client
+	// Amount of time to wait for each response
+	timeout time.Duration
+}
+
+// NewHTTPClient creates an HTTPClient for the algod API at the URL and with the
+// token in the given network profile. It can be used as a Provider.
+func NewHTTPClient(network config.NetworkProfile) (Client, error) {
+	if network.AlgodURL == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoAlgodURL, network.Name)
+	}
+
+	client, err := sdkAlgod.MakeClient(network.AlgodURL, network.AlgodToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPClient{client: client, timeout: DefaultTimeout}, nil
+}
+
+// SuggestedParams gets the parameters suggested for new transactions from algod
+func (c *HTTPClient) SuggestedParams(ctx context.Context) (types.SuggestedParams, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	params, err := c.client.SuggestedParams().Do(ctx)
+	return params, wrapError(err)
+}
+
+// AccountInfo gets the information of the account with the given address from
+// algod
+func (c *HTTPClient) AccountInfo(ctx context.Context, address string) (models.Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	acct, err := c.client.AccountInformation(address).Do(ctx)
+	return acct, wrapError(err)
+}
+
+// AssetInfo gets the information of the asset with the given ID from algod
+func (c *HTTPClient) AssetInfo(ctx context.Context, assetID uint64) (models.Asset, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	asset, err := c.client.GetAssetByID(assetID).Do(ctx)
+	return asset, wrapError(err)
+}
+
+// PendingTxnInfo gets the information of the transaction with the given ID
+// from algod
+func (c *HTTPClient) PendingTxnInfo(ctx context.Context, txID string) (models.PendingTransactionInfoResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	info, _, err := c.client.PendingTransactionInformation(txID).Do(ctx)
+	return info, wrapError(err)
+}
+
+// SubmitTxns submits the given encoded signed transactions to algod
+func (c *HTTPClient) SubmitTxns(ctx context.Context, signedTxns []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	txID, err := c.client.SendRawTransaction(signedTxns).Do(ctx)
+	return txID, wrapError(err)
+}
+
+// Simulate asks algod to simulate the transaction groups in the given request
+func (c *HTTPClient) Simulate(ctx context.Context, request models.SimulateRequest) (models.SimulateResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.SimulateTransaction(request).Do(ctx)
+	return resp, wrapError(err)
+}
+
+// wrapError wraps the given error from the algod SDK client with ErrNotFound if
+// it is for a "404 Not Found" response. The SDK client does not give the status
+// code in a way that can be checked other than by its message.
+func wrapError(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "HTTP 404") {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
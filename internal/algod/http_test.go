@@ -0,0 +1,73 @@
+package algod_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/algod"
+	"duckysigner/internal/kmd/config"
+)
+
+var _ = Describe("HTTPClient", func() {
+	const token = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	var server *httptest.Server
+	var requests []*http.Request
+
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v2/accounts/" + testAddr:
+				w.Write([]byte(`{"address":"` + testAddr + `","amount":5000000}`))
+			case "/v2/assets/10":
+				w.Write([]byte(`{"index":10,"params":{"creator":"` + testAddr + `","decimals":0,"total":1,"name":"Duck Coin"}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message":"not found"}`))
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("cannot be created for a network without an algod URL", func() {
+		_, err := algod.NewHTTPClient(config.NetworkProfile{Name: "TestNet"})
+		Expect(errors.Is(err, algod.ErrNoAlgodURL)).To(BeTrue())
+	})
+
+	It("gets information from the algod API using the network's token", func() {
+		client, err := algod.NewHTTPClient(config.NetworkProfile{
+			Name:       "LocalNet",
+			AlgodURL:   server.URL,
+			AlgodToken: token,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		acct, err := client.AccountInfo(context.Background(), testAddr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acct.Amount).To(BeEquivalentTo(5_000_000))
+
+		asset, err := client.AssetInfo(context.Background(), 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(asset.Params.Name).To(Equal("Duck Coin"))
+
+		Expect(requests).To(HaveLen(2))
+		for _, r := range requests {
+			Expect(r.Header.Get("X-Algo-API-Token")).To(Equal(token))
+		}
+	})
+
+	It("returns a not found error when algod responds with 404", func() {
+		client, err := algod.NewHTTPClient(config.NetworkProfile{Name: "LocalNet", AlgodURL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.PendingTxnInfo(context.Background(), "ABC")
+		Expect(errors.Is(err, algod.ErrNotFound)).To(BeTrue())
+	})
+})
@@ -0,0 +1,323 @@
+// Package app_spec is a wallet's collection of application specifications
+// (ARC-32 or ARC-56), which describe the ARC-4 ABI methods of applications so
+// the calls made to them can be decoded for the user. The specs are stored
+// within an encrypted database file in the wallet's directory.
+package app_spec
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/abi"
+
+	"duckysigner/internal/encdb"
+)
+
+// DefaultDataFile is the default name of the encrypted database file that
+// contains the app specs. It is stored in the wallet's directory, next to the
+// wallet's other encrypted database files.
+const DefaultDataFile = "app_specs.duckdb"
+
+// App spec formats
+const (
+	// FormatARC32 is for ARC-32 application specifications, which have the
+	// ARC-4 contract description under "contract"
+	FormatARC32 = "arc32"
+	// FormatARC56 is for ARC-56 application specifications, which have the
+	// methods at the top level. Plain ARC-4 contract descriptions also have
+	// this format.
+	FormatARC56 = "arc56"
+)
+
+// ErrSpecNotFound is the error returned when there is no app spec for the
+// given application
+var ErrSpecNotFound = errors.New("app spec not found")
+
+// ErrSpecExists is the error returned when a dApp tries to replace an app spec
+// that was not registered by the same dApp
+var ErrSpecExists = errors.New("there is already an app spec for the application that was added by someone else")
+
+// schema is the SQL statement for creating the app specs table
+const schema = `
+CREATE TABLE IF NOT EXISTS db.app_specs (
+    app_id UBIGINT PRIMARY KEY,
+    spec VARCHAR NOT NULL,
+    dapp_id VARCHAR NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+`
+
+// selectSpecsSQL is the SQL statement for getting app specs. Conditions are to
+// be appended to it.
+const selectSpecsSQL = `SELECT app_id, spec, dapp_id, created_at, updated_at
+FROM db.app_specs`
+
+// writeMutex prevents app specs from being saved at the same time
+var writeMutex sync.Mutex
+
+// Spec is the application specification of an application
+type Spec struct {
+	// ID of the application
+	AppID uint64 `json:"app_id"`
+	// Name of the application's contract
+	Name string `json:"name"`
+	// Format of the spec, which is either FormatARC32 or FormatARC56
+	Format string `json:"format"`
+	// The ARC-4 ABI methods of the application
+	Methods []abi.Method `json:"methods"`
+	// ID of the dApp that registered the spec. It is empty if the spec was
+	// added by the user.
+	DappID string `json:"dapp_id,omitempty"`
+	// The date-time the spec was added
+	CreatedAt time.Time `json:"created_at"`
+	// The date-time the spec was last changed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Parse parses the given ARC-32 or ARC-56 application specification JSON for
+// the application with the given ID. The methods in the spec are checked for
+// valid argument and return types.
+func Parse(appID uint64, specJSON string) (*Spec, error) {
+	if appID == 0 {
+		return nil, errors.New("app spec must be for an application ID")
+	}
+
+	var parsed struct {
+		// ARC-32 contract description
+		Contract *abi.Contract `json:"contract"`
+		// ARC-56 name and methods
+		Name    string       `json:"name"`
+		Methods []abi.Method `json:"methods"`
+	}
+	if err := json.Unmarshal([]byte(specJSON), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid app spec: %w", err)
+	}
+
+	spec := Spec{AppID: appID, Name: parsed.Name, Format: FormatARC56, Methods: parsed.Methods}
+	if parsed.Contract != nil {
+		spec.Name = parsed.Contract.Name
+		spec.Format = FormatARC32
+		spec.Methods = parsed.Contract.Methods
+	}
+
+	if len(spec.Methods) == 0 {
+		return nil, errors.New("invalid app spec: there are no methods")
+	}
+	for i := range spec.Methods {
+		if err := validateMethod(&spec.Methods[i]); err != nil {
+			return nil, fmt.Errorf("invalid app spec: method '%s': %w", spec.Methods[i].Name, err)
+		}
+	}
+
+	return &spec, nil
+}
+
+// validateMethod checks if the given method has a name and if its argument and
+// return types are valid ABI types
+func validateMethod(method *abi.Method) error {
+	if strings.TrimSpace(method.Name) == "" {
+		return errors.New("method name cannot be empty")
+	}
+
+	for i := range method.Args {
+		arg := &method.Args[i]
+		if arg.IsTransactionArg() || arg.IsReferenceArg() {
+			continue
+		}
+		if _, err := arg.GetTypeObject(); err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+
+	if !method.Returns.IsVoid() {
+		if _, err := method.Returns.GetTypeObject(); err != nil {
+			return fmt.Errorf("return: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Store is a collection of app specs stored within an encrypted DuckDB file
+type Store struct {
+	// Path of the encrypted database file that contains the app specs
+	filePath string
+}
+
+// NewStore creates a new Store that is stored in the encrypted DuckDB file with
+// the given file path
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+// FilePath returns the path of the database file that contains the app specs
+func (s *Store) FilePath() string {
+	return s.filePath
+}
+
+// ListSpecs retrieves all the app specs ordered by app ID using the given file
+// encryption key to access the database file
+func (s *Store) ListSpecs(fileEncKey []byte) ([]Spec, error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(selectSpecsSQL + " ORDER BY app_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var specs []Spec
+	for rows.Next() {
+		spec, err := scanSpec(rows)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, *spec)
+	}
+
+	return specs, rows.Err()
+}
+
+// GetSpec retrieves the app spec for the application with the given ID using
+// the given file encryption key to access the database file. Returns
+// ErrSpecNotFound if there is no app spec for that application.
+func (s *Store) GetSpec(appID uint64, fileEncKey []byte) (*Spec, error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	spec, err := scanSpec(db.QueryRow(selectSpecsSQL+" WHERE app_id = ?", appID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSpecNotFound
+	}
+
+	return spec, err
+}
+
+// SaveSpec parses and adds the given application specification JSON for the
+// application with the given ID, or replaces the app spec for that
+// application, using the given file encryption key to access the database
+// file. The dApp ID is the ID of the dApp registering the spec, which must be
+// empty if the user is adding the spec. A dApp can only replace the app specs
+// it registered. Returns the app spec as it was stored.
+func (s *Store) SaveSpec(appID uint64, specJSON string, dappID string, fileEncKey []byte) (*Spec, error) {
+	spec, err := Parse(appID, specJSON)
+	if err != nil {
+		return nil, err
+	}
+	spec.DappID = dappID
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Keep the date-time the spec was first added
+	// NOTE: DuckDB timestamps have a precision of microseconds
+	spec.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	var existingDappID string
+	err = tx.QueryRow("SELECT created_at, dapp_id FROM db.app_specs WHERE app_id = ?", appID).
+		Scan(&spec.CreatedAt, &existingDappID)
+	if err == sql.ErrNoRows {
+		spec.CreatedAt = spec.UpdatedAt
+	} else if err != nil {
+		return nil, err
+	} else if dappID != "" && existingDappID != dappID {
+		return nil, ErrSpecExists
+	}
+	spec.CreatedAt = spec.CreatedAt.UTC()
+
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO db.app_specs VALUES (?, ?, ?, ?, ?)",
+		spec.AppID,
+		specJSON,
+		spec.DappID,
+		spec.CreatedAt,
+		spec.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// RemoveSpec removes the app spec for the application with the given ID using
+// the given file encryption key to access the database file. Returns
+// ErrSpecNotFound if there is no app spec for that application.
+func (s *Store) RemoveSpec(appID uint64, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM db.app_specs WHERE app_id = ?", appID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSpecNotFound
+	}
+
+	return nil
+}
+
+// scanSpec converts the given row of the app specs table into an app spec
+func scanSpec(row interface{ Scan(...any) error }) (*Spec, error) {
+	var (
+		appID     uint64
+		specJSON  string
+		dappID    string
+		createdAt time.Time
+		updatedAt time.Time
+	)
+
+	if err := row.Scan(&appID, &specJSON, &dappID, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	spec, err := Parse(appID, specJSON)
+	if err != nil {
+		return nil, err
+	}
+	spec.DappID = dappID
+	spec.CreatedAt = createdAt.UTC()
+	spec.UpdatedAt = updatedAt.UTC()
+
+	return spec, nil
+}
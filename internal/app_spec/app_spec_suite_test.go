@@ -0,0 +1,13 @@
+package app_spec_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAppSpec(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "App Spec Suite")
+}
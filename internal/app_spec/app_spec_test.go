@@ -0,0 +1,124 @@
+package app_spec_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/app_spec"
+)
+
+var fileEncKey = []byte("01234567890123456789012345678901")
+
+// arc32Spec is an ARC-32 app spec with a single method
+const arc32Spec = `{
+	"hints": {},
+	"contract": {
+		"name": "Swapper",
+		"methods": [{
+			"name": "swap",
+			"args": [{"type": "asset", "name": "asset_in"}, {"type": "uint64", "name": "amount"}],
+			"returns": {"type": "void"}
+		}]
+	}
+}`
+
+// arc56Spec is an ARC-56 app spec with a single method
+const arc56Spec = `{
+	"arcs": [4, 56],
+	"name": "Counter",
+	"structs": {},
+	"methods": [{
+		"name": "increment",
+		"args": [{"type": "uint64", "name": "by", "desc": "Amount to increment by"}],
+		"returns": {"type": "uint64"},
+		"actions": {"create": [], "call": ["NoOp"]},
+		"readonly": false
+	}]
+}`
+
+var _ = Describe("Parse()", func() {
+	It("parses an ARC-32 app spec", func() {
+		spec, err := app_spec.Parse(123, arc32Spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spec.AppID).To(BeEquivalentTo(123))
+		Expect(spec.Name).To(Equal("Swapper"))
+		Expect(spec.Format).To(Equal(app_spec.FormatARC32))
+		Expect(spec.Methods).To(HaveLen(1))
+		Expect(spec.Methods[0].GetSignature()).To(Equal("swap(asset,uint64)void"))
+	})
+
+	It("parses an ARC-56 app spec", func() {
+		spec, err := app_spec.Parse(123, arc56Spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spec.Name).To(Equal("Counter"))
+		Expect(spec.Format).To(Equal(app_spec.FormatARC56))
+		Expect(spec.Methods).To(HaveLen(1))
+		Expect(spec.Methods[0].GetSignature()).To(Equal("increment(uint64)uint64"))
+	})
+
+	It("does not parse an invalid app spec", func() {
+		_, err := app_spec.Parse(0, arc56Spec)
+		Expect(err).To(HaveOccurred(), "No app ID")
+		_, err = app_spec.Parse(123, "not JSON")
+		Expect(err).To(HaveOccurred(), "Not JSON")
+		_, err = app_spec.Parse(123, `{"name": "Empty", "methods": []}`)
+		Expect(err).To(HaveOccurred(), "No methods")
+		_, err = app_spec.Parse(123, `{"methods": [{"name": "foo", "args": [{"type": "uint7"}], "returns": {"type": "void"}}]}`)
+		Expect(err).To(HaveOccurred(), "Invalid argument type")
+	})
+})
+
+var _ = Describe("Store", func() {
+	var store *app_spec.Store
+
+	BeforeEach(func() {
+		store = app_spec.NewStore(filepath.Join(GinkgoT().TempDir(), app_spec.DefaultDataFile))
+	})
+
+	It("saves, lists, gets and removes app specs", func() {
+		By("Saving app specs")
+		swapper, err := store.SaveSpec(200, arc32Spec, "", fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapper.CreatedAt).To(Equal(swapper.UpdatedAt))
+		counter, err := store.SaveSpec(100, arc56Spec, "dapp1", fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(counter.DappID).To(Equal("dapp1"))
+
+		By("Listing app specs ordered by app ID")
+		specs, err := store.ListSpecs(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(specs).To(HaveLen(2))
+		Expect(specs[0].AppID).To(BeEquivalentTo(100))
+		Expect(specs[1].AppID).To(BeEquivalentTo(200))
+
+		By("Getting an app spec")
+		gotSwapper, err := store.GetSpec(200, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gotSwapper.Name).To(Equal("Swapper"))
+		Expect(gotSwapper.CreatedAt).To(Equal(swapper.CreatedAt))
+
+		By("Removing an app spec")
+		Expect(store.RemoveSpec(200, fileEncKey)).To(Succeed())
+		_, err = store.GetSpec(200, fileEncKey)
+		Expect(err).To(MatchError(app_spec.ErrSpecNotFound))
+		Expect(store.RemoveSpec(200, fileEncKey)).To(MatchError(app_spec.ErrSpecNotFound))
+	})
+
+	It("only lets a dApp replace the app specs it registered", func() {
+		_, err := store.SaveSpec(100, arc56Spec, "dapp1", fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = store.SaveSpec(100, arc32Spec, "dapp2", fileEncKey)
+		Expect(err).To(MatchError(app_spec.ErrSpecExists))
+		_, err = store.SaveSpec(100, arc32Spec, "dapp1", fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Letting the user replace any app spec")
+		_, err = store.SaveSpec(100, arc56Spec, "", fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.SaveSpec(100, arc32Spec, "dapp1", fileEncKey)
+		Expect(err).To(MatchError(app_spec.ErrSpecExists))
+	})
+})
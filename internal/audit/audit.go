@@ -0,0 +1,597 @@
+// Package audit is an append-only log of dApp connect and signing activity
+// that is stored encrypted within a wallet's database file. Each entry in the
+// log contains the hash of the previous entry, which forms a hash chain that
+// can be used to detect if any entries have been modified or removed. The
+// sequence number and hash of the latest entry (the head of the chain) are also
+// kept in a file next to the database file, so that removing the latest entries
+// is detected as well. The head is authenticated with a key that is kept within
+// the database file, so it cannot be forged without the file encryption key.
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"duckysigner/internal/encdb"
+)
+
+// EventType is the type of activity recorded in an audit log entry
+type EventType string
+
+const (
+	// EventSessionInit is for when a dApp initializes a dApp connect session
+	EventSessionInit EventType = "session_init"
+	// EventSessionConfirm is for when a dApp attempts to confirm a dApp connect
+	// session
+	EventSessionConfirm EventType = "session_confirm"
+	// EventSessionEnd is for when a dApp ends a dApp connect session
+	EventSessionEnd EventType = "session_end"
+	// EventSessionRevoke is for when the wallet user revokes a dApp connect
+	// session
+	EventSessionRevoke EventType = "session_revoke"
+	// EventTxnSign is for when a dApp requests for a transaction to be signed
+	EventTxnSign EventType = "txn_sign"
+	// EventTxnGroupSign is for when a dApp requests for transactions in a
+	// transaction group to be signed
+	EventTxnGroupSign EventType = "txn_group_sign"
+	// EventAppSpecRegister is for when a dApp registers the app spec of an
+	// application
+	EventAppSpecRegister EventType = "app_spec_register"
+	// EventAuthFail is for when a request fails Hawk authentication
+	EventAuthFail EventType = "auth_fail"
+)
+
+// Decision is the outcome of the activity recorded in an audit log entry
+type Decision string
+
+const (
+	// DecisionApproved is when the request was approved
+	DecisionApproved Decision = "approved"
+	// DecisionRejected is when the request was rejected
+	DecisionRejected Decision = "rejected"
+	// DecisionTimeout is when there was no response to the request in time
+	DecisionTimeout Decision = "timeout"
+	// DecisionFailed is when the request failed because of an error
+	DecisionFailed Decision = "failed"
+)
+
+// DefaultDataFile is the default name of the encrypted database file that
+// contains the audit log. It is stored in the wallet's directory, next to the
+// wallet's other encrypted database files.
+const DefaultDataFile = "audit.duckdb"
+
+// headFileSuffix is the suffix of the file next to the database file that
+// contains the head of the hash chain. The head file's name is the database
+// file name + this suffix.
+const headFileSuffix = ".head"
+
+// DefaultHeadFile is the default name of the file that contains the head of
+// the hash chain of the audit log
+const DefaultHeadFile = DefaultDataFile + headFileSuffix
+
+// TamperErrMsg is the error message text for when the hash chain of the audit
+// log is broken
+const TamperErrMsg = "audit log has been tampered with"
+
+// logSchema is the SQL statement for creating the audit log table
+const logSchema = `
+CREATE TABLE IF NOT EXISTS db.audit_log (
+    seq UBIGINT PRIMARY KEY,
+    ts TIMESTAMP NOT NULL,
+    event VARCHAR NOT NULL,
+    dapp_id VARCHAR,
+    session_id VARCHAR,
+    txn_id VARCHAR,
+    txn_type VARCHAR,
+    decision VARCHAR,
+    details VARCHAR,
+    prev_hash BLOB NOT NULL,
+    hash BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS db.audit_head_key (
+    key BLOB NOT NULL
+);
+`
+
+// headKeyLen is the length of the key for authenticating the head of the hash
+// chain
+const headKeyLen = 32
+
+// headKeySQL is the SQL statement for getting the key for authenticating the
+// head of the hash chain
+const headKeySQL = "SELECT key FROM db.audit_head_key LIMIT 1"
+
+// entryInsertSQL is the SQL statement for inserting an entry into the audit log
+const entryInsertSQL = "INSERT INTO db.audit_log VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+// lastEntrySQL is the SQL statement for getting the sequence number and hash
+// of the latest entry
+const lastEntrySQL = "SELECT seq, hash FROM db.audit_log ORDER BY seq DESC LIMIT 1"
+
+// selectEntriesSQL is the SQL statement for getting entries. Conditions are
+// to be appended to it.
+const selectEntriesSQL = `SELECT seq, ts, event, dapp_id, session_id, txn_id,
+txn_type, decision, details, prev_hash, hash FROM db.audit_log`
+
+// appendMutex prevents entries from being appended at the same time, which
+// could otherwise cause two entries to be chained to the same previous entry
+var appendMutex sync.Mutex
+
+// Entry is an entry in the audit log
+type Entry struct {
+	// Sequence number of the entry, starting from 1
+	Seq uint64 `json:"seq"`
+	// The date-time the entry was recorded
+	Timestamp time.Time `json:"timestamp"`
+	// The type of activity
+	Event EventType `json:"event"`
+	// ID of the dApp involved, if any
+	DappID string `json:"dapp_id,omitempty"`
+	// ID of the dApp connect session (or confirmation) involved, if any
+	SessionID string `json:"session_id,omitempty"`
+	// ID of the transaction involved, if any
+	TxnID string `json:"txn_id,omitempty"`
+	// Type of the transaction involved, if any
+	TxnType string `json:"txn_type,omitempty"`
+	// The outcome of the activity, if any
+	Decision Decision `json:"decision,omitempty"`
+	// Any other details about the activity
+	Details string `json:"details,omitempty"`
+	// Hash of the previous entry
+	PrevHash []byte `json:"prev_hash"`
+	// Hash of this entry, which includes the hash of the previous entry
+	Hash []byte `json:"hash"`
+}
+
+// chainHead is the sequence number and hash of the latest entry in the log
+type chainHead struct {
+	Seq  uint64 `json:"seq"`
+	Hash []byte `json:"hash"`
+	// Message authentication code of the sequence number and hash
+	MAC []byte `json:"mac"`
+}
+
+// Filter is used for narrowing down which entries are retrieved from the log.
+// Fields that are not set are ignored.
+type Filter struct {
+	// Only get entries with this event type
+	Event EventType `json:"event,omitempty"`
+	// Only get entries involving the dApp with this ID
+	DappID string `json:"dapp_id,omitempty"`
+	// Only get entries involving the session with this ID
+	SessionID string `json:"session_id,omitempty"`
+	// Only get entries involving the transaction with this ID
+	TxnID string `json:"txn_id,omitempty"`
+	// Only get entries recorded at or after this date-time
+	Since time.Time `json:"since,omitzero"`
+	// Only get entries recorded before this date-time
+	Until time.Time `json:"until,omitzero"`
+	// The maximum number of entries to get. Get all entries if 0.
+	Limit uint `json:"limit,omitempty"`
+}
+
+// Log is an audit log stored within an encrypted DuckDB file
+type Log struct {
+	// Path of the encrypted database file that contains the log
+	filePath string
+}
+
+// NewLog creates a new Log that is stored in the encrypted DuckDB file with
+// the given file path
+func NewLog(filePath string) *Log {
+	return &Log{filePath: filePath}
+}
+
+// FilePath returns the path of the database file that contains the log
+func (l *Log) FilePath() string {
+	return l.filePath
+}
+
+// Append adds the given entry to the end of the log using the given file
+// encryption key to access the database file. The sequence number, timestamp
+// and hashes of the given entry are set before it is stored. Returns the
+// entry as it was stored.
+func (l *Log) Append(entry Entry, fileEncKey []byte) (*Entry, error) {
+	appendMutex.Lock()
+	defer appendMutex.Unlock()
+
+	db, err := encdb.Open(l.filePath, fileEncKey, logSchema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	headKey, err := queryHeadKey(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chain the new entry to the latest entry, if there is one
+	var lastSeq uint64
+	prevHash := make([]byte, sha512.Size256)
+	err = tx.QueryRow(lastEntrySQL).Scan(&lastSeq, &prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	entry.Seq = lastSeq + 1
+	// DuckDB timestamps have a precision of microseconds
+	entry.Timestamp = time.Now().UTC().Truncate(time.Microsecond)
+	entry.PrevHash = prevHash
+	entry.Hash = entry.computeHash()
+
+	_, err = tx.Exec(entryInsertSQL,
+		entry.Seq,
+		entry.Timestamp,
+		string(entry.Event),
+		entry.DappID,
+		entry.SessionID,
+		entry.TxnID,
+		entry.TxnType,
+		string(entry.Decision),
+		entry.Details,
+		entry.PrevHash,
+		entry.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	head := chainHead{Seq: entry.Seq, Hash: entry.Hash}
+	head.MAC = head.computeMAC(headKey)
+	if err = l.writeHead(head); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Query retrieves the entries that match the given filter using the given file
+// encryption key to access the database file. The entries are ordered from
+// oldest to newest.
+func (l *Log) Query(filter Filter, fileEncKey []byte) ([]Entry, error) {
+	db, err := encdb.Open(l.filePath, fileEncKey, logSchema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var conditions []string
+	var args []any
+
+	if filter.Event != "" {
+		conditions = append(conditions, "event = ?")
+		args = append(args, string(filter.Event))
+	}
+	if filter.DappID != "" {
+		conditions = append(conditions, "dapp_id = ?")
+		args = append(args, filter.DappID)
+	}
+	if filter.SessionID != "" {
+		conditions = append(conditions, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.TxnID != "" {
+		conditions = append(conditions, "txn_id = ?")
+		args = append(args, filter.TxnID)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "ts >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "ts < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	query := selectEntriesSQL
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEntries(rows)
+}
+
+// Export returns all the entries in the log as JSON using the given file
+// encryption key to access the database file. The hash chain is verified
+// before the log is exported.
+func (l *Log) Export(fileEncKey []byte) ([]byte, error) {
+	entries, err := l.Query(Filter{}, fileEncKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = l.verifyEntries(entries, fileEncKey); err != nil {
+		return nil, err
+	}
+
+	// Make sure an empty log is exported as an empty list instead of null
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	return json.MarshalIndent(entries, "", "  ")
+}
+
+// Verify checks the hash chain of the entire log and that it ends at the head
+// kept next to the database file using the given file encryption key to access
+// the database file. Returns a TamperError if the chain is broken or its latest
+// entries have been removed.
+func (l *Log) Verify(fileEncKey []byte) error {
+	entries, err := l.Query(Filter{}, fileEncKey)
+	if err != nil {
+		return err
+	}
+
+	return l.verifyEntries(entries, fileEncKey)
+}
+
+// verifyEntries checks that the given entries, which should be all the entries
+// of the log ordered from oldest to newest, form an unbroken hash chain that
+// ends at the head kept next to the database file using the given file
+// encryption key to access the database file
+func (l *Log) verifyEntries(entries []Entry, fileEncKey []byte) error {
+	if err := VerifyChain(entries); err != nil {
+		return err
+	}
+
+	head, err := l.readHead()
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		// Only a log without entries has no head
+		if len(entries) > 0 {
+			return &TamperError{Seq: uint64(len(entries))}
+		}
+		return nil
+	}
+
+	db, err := encdb.Open(l.filePath, fileEncKey, logSchema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var headKey []byte
+	err = db.QueryRow(headKeySQL).Scan(&headKey)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if headKey == nil || !hmac.Equal(head.MAC, head.computeMAC(headKey)) {
+		return &TamperError{Seq: head.Seq}
+	}
+
+	return verifyHead(entries, head)
+}
+
+// headFilePath returns the path of the file that contains the head of the hash
+// chain
+func (l *Log) headFilePath() string {
+	return l.filePath + headFileSuffix
+}
+
+// readHead reads the head of the hash chain from the head file. Returns nil if
+// there is no head file, which is the case for a log without entries.
+func (l *Log) readHead() (*chainHead, error) {
+	headJSON, err := os.ReadFile(l.headFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var head chainHead
+	if err = json.Unmarshal(headJSON, &head); err != nil {
+		return nil, err
+	}
+
+	return &head, nil
+}
+
+// writeHead replaces the head file with one that contains the given head of
+// the hash chain
+func (l *Log) writeHead(head chainHead) error {
+	headJSON, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so the head file is never left partly
+	// written
+	tmpPath := l.headFilePath() + ".tmp"
+	if err = os.WriteFile(tmpPath, headJSON, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, l.headFilePath())
+}
+
+// verifyHead checks that the given entries, which form an unbroken hash chain,
+// include the given authenticated head. There may be entries after the head,
+// which is the case if recording the head of the latest entries failed.
+func verifyHead(entries []Entry, head *chainHead) error {
+	if head.Seq == 0 {
+		return &TamperError{Seq: 1}
+	}
+
+	// Check for removed latest entries
+	if uint64(len(entries)) < head.Seq {
+		return &TamperError{Seq: uint64(len(entries)) + 1}
+	}
+
+	if !bytes.Equal(entries[head.Seq-1].Hash, head.Hash) {
+		return &TamperError{Seq: head.Seq}
+	}
+
+	return nil
+}
+
+// queryHeadKey gets the key for authenticating the head of the hash chain using
+// the given database transaction. The key is generated if there is none yet.
+func queryHeadKey(tx *sql.Tx) ([]byte, error) {
+	var headKey []byte
+	err := tx.QueryRow(headKeySQL).Scan(&headKey)
+	if err == nil {
+		return headKey, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	headKey = make([]byte, headKeyLen)
+	if _, err = rand.Read(headKey); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec("INSERT INTO db.audit_head_key VALUES (?)", headKey); err != nil {
+		return nil, err
+	}
+
+	return headKey, nil
+}
+
+// computeMAC computes the message authentication code of the head using the
+// given key
+func (head *chainHead) computeMAC(key []byte) []byte {
+	mac := hmac.New(sha512.New512_256, key)
+	binary.Write(mac, binary.BigEndian, head.Seq)
+	mac.Write(head.Hash)
+	return mac.Sum(nil)
+}
+
+// TamperError is the error returned when the hash chain of the audit log is
+// broken, which indicates that the log has been tampered with
+type TamperError struct {
+	// Sequence number of the first entry where the chain is broken
+	Seq uint64
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("%s: chain broken at entry %d", TamperErrMsg, e.Seq)
+}
+
+// VerifyChain checks that the given entries, which should be all the entries
+// of a log ordered from oldest to newest, form an unbroken hash chain. Returns
+// a TamperError if the chain is broken.
+func VerifyChain(entries []Entry) error {
+	prevHash := make([]byte, sha512.Size256)
+
+	for i, entry := range entries {
+		// Check for removed entries
+		if entry.Seq != uint64(i)+1 {
+			return &TamperError{Seq: uint64(i) + 1}
+		}
+
+		if !bytes.Equal(entry.PrevHash, prevHash) || !bytes.Equal(entry.Hash, entry.computeHash()) {
+			return &TamperError{Seq: entry.Seq}
+		}
+
+		prevHash = entry.Hash
+	}
+
+	return nil
+}
+
+// computeHash calculates the hash of the entry, which covers every field of
+// the entry other than the hash itself
+func (entry *Entry) computeHash() []byte {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.BigEndian, entry.Seq)
+	binary.Write(&buf, binary.BigEndian, entry.Timestamp.UnixMicro())
+	// Each string field is prefixed with its length so that the boundaries
+	// between fields are unambiguous
+	for _, field := range []string{
+		string(entry.Event),
+		entry.DappID,
+		entry.SessionID,
+		entry.TxnID,
+		entry.TxnType,
+		string(entry.Decision),
+		entry.Details,
+	} {
+		binary.Write(&buf, binary.BigEndian, uint64(len(field)))
+		buf.WriteString(field)
+	}
+	buf.Write(entry.PrevHash)
+
+	hash := sha512.Sum512_256(buf.Bytes())
+	return hash[:]
+}
+
+// scanEntries converts the given rows of the audit log table into entries
+func scanEntries(rows *sql.Rows) (entries []Entry, err error) {
+	for rows.Next() {
+		var (
+			entry     Entry
+			event     string
+			dappID    sql.NullString
+			sessionID sql.NullString
+			txnID     sql.NullString
+			txnType   sql.NullString
+			decision  sql.NullString
+			details   sql.NullString
+		)
+
+		err = rows.Scan(
+			&entry.Seq,
+			&entry.Timestamp,
+			&event,
+			&dappID,
+			&sessionID,
+			&txnID,
+			&txnType,
+			&decision,
+			&details,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return
+		}
+
+		entry.Timestamp = entry.Timestamp.UTC()
+		entry.Event = EventType(event)
+		entry.DappID = dappID.String
+		entry.SessionID = sessionID.String
+		entry.TxnID = txnID.String
+		entry.TxnType = txnType.String
+		entry.Decision = Decision(decision.String)
+		entry.Details = details.String
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,217 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/audit"
+	"duckysigner/internal/encdb"
+)
+
+var fileEncKey = []byte("01234567890123456789012345678901")
+
+var _ = Describe("Audit Log", func() {
+	var auditLog *audit.Log
+
+	BeforeEach(func() {
+		auditLog = audit.NewLog(filepath.Join(GinkgoT().TempDir(), audit.DefaultDataFile))
+	})
+
+	Describe("Log.Append()", func() {
+		It("adds entries that are chained together", func() {
+			first, err := auditLog.Append(audit.Entry{
+				Event:    audit.EventSessionInit,
+				DappID:   "dapp1",
+				Decision: audit.DecisionApproved,
+			}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first.Seq).To(Equal(uint64(1)))
+			Expect(first.PrevHash).To(Equal(make([]byte, 32)), "First entry is chained to nothing")
+			Expect(first.Hash).To(HaveLen(32))
+
+			second, err := auditLog.Append(audit.Entry{
+				Event:    audit.EventTxnSign,
+				DappID:   "dapp1",
+				TxnID:    "TXNID",
+				TxnType:  "pay",
+				Decision: audit.DecisionRejected,
+			}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second.Seq).To(Equal(uint64(2)))
+			Expect(second.PrevHash).To(Equal(first.Hash), "Second entry is chained to the first")
+			Expect(second.Timestamp).ToNot(BeTemporally("<", first.Timestamp))
+
+			Expect(auditLog.Verify(fileEncKey)).To(Succeed())
+		})
+	})
+
+	Describe("Log.Query()", func() {
+		BeforeEach(func() {
+			for _, e := range []audit.Entry{
+				{Event: audit.EventSessionInit, DappID: "dapp1"},
+				{Event: audit.EventSessionConfirm, DappID: "dapp1", Decision: audit.DecisionApproved},
+				{Event: audit.EventSessionInit, DappID: "dapp2"},
+				{Event: audit.EventTxnSign, DappID: "dapp1", TxnID: "TXNID"},
+			} {
+				_, err := auditLog.Append(e, fileEncKey)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("gets all entries in order when no filter is given", func() {
+			entries, err := auditLog.Query(audit.Filter{}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(4))
+			for i, entry := range entries {
+				Expect(entry.Seq).To(Equal(uint64(i + 1)))
+			}
+		})
+
+		It("gets entries that match the filter", func() {
+			entries, err := auditLog.Query(audit.Filter{DappID: "dapp1"}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(3))
+
+			entries, err = auditLog.Query(audit.Filter{Event: audit.EventSessionInit}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			entries, err = auditLog.Query(audit.Filter{TxnID: "TXNID"}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Event).To(Equal(audit.EventTxnSign))
+
+			entries, err = auditLog.Query(audit.Filter{Until: time.Now().Add(-time.Hour)}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+
+			entries, err = auditLog.Query(audit.Filter{Limit: 2}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+		})
+	})
+
+	Describe("Log.Export()", func() {
+		It("exports all entries as JSON", func() {
+			_, err := auditLog.Append(audit.Entry{Event: audit.EventAuthFail, Details: "bad mac"}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			exported, err := auditLog.Export(fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			var entries []audit.Entry
+			Expect(json.Unmarshal(exported, &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Event).To(Equal(audit.EventAuthFail))
+			Expect(entries[0].Details).To(Equal("bad mac"))
+		})
+
+		It("exports an empty list when there are no entries", func() {
+			exported, err := auditLog.Export(fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(exported)).To(Equal("[]"))
+		})
+	})
+
+	Describe("Log.Verify()", func() {
+		BeforeEach(func() {
+			for _, e := range []audit.Entry{
+				{Event: audit.EventSessionInit, DappID: "dapp1"},
+				{Event: audit.EventSessionConfirm, DappID: "dapp1", Decision: audit.DecisionApproved},
+				{Event: audit.EventTxnSign, DappID: "dapp1", Decision: audit.DecisionApproved},
+			} {
+				_, err := auditLog.Append(e, fileEncKey)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("detects removed latest entries", func() {
+			db, err := encdb.Open(auditLog.FilePath(), fileEncKey, "")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec("DELETE FROM db.audit_log WHERE seq > 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Close()).To(Succeed())
+
+			err = auditLog.Verify(fileEncKey)
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 2}))
+		})
+
+		It("detects a removed head", func() {
+			Expect(os.Remove(auditLog.FilePath() + ".head")).To(Succeed())
+			err := auditLog.Verify(fileEncKey)
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 3}))
+		})
+
+		It("detects a forged head", func() {
+			db, err := encdb.Open(auditLog.FilePath(), fileEncKey, "")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec("DELETE FROM db.audit_log WHERE seq > 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Close()).To(Succeed())
+
+			// Make the head point to the remaining entry
+			entries, err := auditLog.Query(audit.Filter{}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+			headPath := auditLog.FilePath() + ".head"
+			headJSON, err := os.ReadFile(headPath)
+			Expect(err).ToNot(HaveOccurred())
+			var head map[string]any
+			Expect(json.Unmarshal(headJSON, &head)).To(Succeed())
+			head["seq"] = entries[0].Seq
+			head["hash"] = entries[0].Hash
+			headJSON, err = json.Marshal(head)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(headPath, headJSON, 0600)).To(Succeed())
+
+			err = auditLog.Verify(fileEncKey)
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 1}))
+		})
+	})
+
+	Describe("VerifyChain()", func() {
+		var entries []audit.Entry
+
+		BeforeEach(func() {
+			for _, e := range []audit.Entry{
+				{Event: audit.EventSessionInit, DappID: "dapp1"},
+				{Event: audit.EventSessionConfirm, DappID: "dapp1", Decision: audit.DecisionApproved},
+				{Event: audit.EventTxnSign, DappID: "dapp1", Decision: audit.DecisionApproved},
+			} {
+				_, err := auditLog.Append(e, fileEncKey)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			var err error
+			entries, err = auditLog.Query(audit.Filter{}, fileEncKey)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("succeeds when the chain is unbroken", func() {
+			Expect(audit.VerifyChain(entries)).To(Succeed())
+			Expect(audit.VerifyChain(nil)).To(Succeed(), "Empty log is valid")
+		})
+
+		It("detects a modified entry", func() {
+			entries[1].Decision = audit.DecisionRejected
+			err := audit.VerifyChain(entries)
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 2}))
+		})
+
+		It("detects a removed entry", func() {
+			err := audit.VerifyChain(append(entries[:1], entries[2:]...))
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 2}))
+		})
+
+		It("detects a removed latest entry when the hash is re-computed", func() {
+			// Removing the last entry alone cannot be detected by the chain,
+			// but replacing it with a forged one can
+			entries[2].Hash = entries[1].Hash
+			err := audit.VerifyChain(entries)
+			Expect(err).To(MatchError(&audit.TamperError{Seq: 3}))
+		})
+	})
+})
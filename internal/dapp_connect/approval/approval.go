@@ -0,0 +1,174 @@
+// Package approval defines how the user is asked to approve requests made by
+// dApps through dApp connect, independent of how the user is asked (e.g. a UI
+// window or a terminal)
+package approval
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/note_analyzer"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_group"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
+)
+
+// SessionPromptEventName is the name for the event for triggering a UI to
+// prompt the user to approve a dApp connect session confirmation request
+const SessionPromptEventName string = "session_confirm_prompt"
+
+// SessionRespEventName is the name for the event that a UI uses to forward the
+// user's response to a dApp connect session confirmation request
+const SessionRespEventName string = "session_confirm_response"
+
+// TxnPromptEventName is the name for the event for triggering a UI to prompt
+// the user to approve signing a transaction
+const TxnPromptEventName string = "txn_sign_prompt"
+
+// TxnRespEventName is the name for the event that a UI uses to forward the
+// user's response to a transaction signing request
+const TxnRespEventName string = "txn_sign_response"
+
+// GroupPromptEventName is the name for the event for triggering a UI to prompt
+// the user to approve signing transactions in a transaction group
+const GroupPromptEventName string = "txn_group_sign_prompt"
+
+// GroupRespEventName is the name for the event that a UI uses to forward the
+// user's response to a transaction group signing request
+const GroupRespEventName string = "txn_group_sign_response"
+
+// ErrTimeout is the error returned when the user does not respond to an
+// approval request in time
+var ErrTimeout = errors.New("timed out waiting for user response")
+
+type (
+	// SessionPromptData is the data given to the user when asking the user to
+	// approve a dApp connect session
+	SessionPromptData struct {
+		DappData dc.DappData `json:"dapp"`
+		// Names of the profiles of the networks the user can bind the session
+		// to, which are the networks requested by the dApp or all configured
+		// networks if the dApp did not request any
+		Networks []string `json:"networks,omitempty"`
+	}
+
+	// SessionResponse is the user's response when asked to approve a dApp
+	// connect session
+	SessionResponse struct {
+		// The confirmation code entered by the user. The session was rejected if
+		// it is empty.
+		Code string `json:"code"`
+		// The addresses the user chose to connect to the session
+		Addresses []string `json:"addrs"`
+		// Names of the profiles of the networks the user chose to bind the
+		// session to. The dApp cannot request signatures for other networks.
+		Networks []string `json:"networks"`
+	}
+
+	// TxnData is the transaction data of a transaction signing request
+	TxnData struct {
+		// Base64-encoded unsigned transaction
+		Txn string `json:"transaction"`
+		// Address of the signer
+		Signer string `json:"signer,omitempty"`
+	}
+
+	// TxnPromptData is the data given to the user when asking the user to
+	// approve signing a transaction
+	TxnPromptData struct {
+		TxnData TxnData `json:"data"`
+		// Human-readable summary of the transaction decoded by the backend,
+		// which the user should base the decision on instead of any data
+		// given by the dApp
+		Summary *txn_decoder.Summary `json:"summary,omitempty"`
+		// Risks found in the transaction. The user must acknowledge all of them
+		// for the transaction to be signed.
+		Risks []txn_risk.Flag `json:"risks,omitempty"`
+		// What was found in the transaction's note, which can contain links
+		// to phishing sites. It is not given if the transaction has no note.
+		NoteAnalysis *note_analyzer.Analysis `json:"note_analysis,omitempty"`
+		// What simulating the transaction showed it would do (e.g. balance
+		// changes). It is not given if simulation is disabled.
+		Simulation *txn_simulator.Result `json:"simulation,omitempty"`
+	}
+
+	// GroupPromptData is the data given to the user when asking the user to
+	// approve signing transactions in a transaction group. The user approves
+	// the group as a whole, so the response is a TxnResponse.
+	GroupPromptData struct {
+		// Base64-encoded unsigned transactions of the whole group
+		Group []string `json:"group"`
+		// Indexes (within the group) of the transactions to be signed
+		SignIndexes []int `json:"sign_indexes"`
+		// Human-readable summaries of the transactions in the group decoded by
+		// the backend, which the user should base the decision on instead of
+		// any data given by the dApp
+		Summaries []*txn_decoder.Summary `json:"summaries"`
+		// What was found in the notes of the transactions in the group, which
+		// can contain links to phishing sites. It has an analysis for each
+		// transaction in the group, which is nil if the transaction has no
+		// note.
+		NoteAnalyses []*note_analyzer.Analysis `json:"note_analyses"`
+		// What the wallet's accounts send and receive across the whole group
+		NetFlows []txn_group.Flow `json:"net_flows"`
+		// Risks found in the transactions to be signed. The user must
+		// acknowledge all of them for the transactions to be signed.
+		Risks []txn_risk.Flag `json:"risks,omitempty"`
+		// What simulating the group showed it would do (e.g. balance
+		// changes). It is not given if simulation is disabled.
+		Simulation *txn_simulator.Result `json:"simulation,omitempty"`
+	}
+
+	// TxnResponse is the user's response when asked to approve signing a
+	// transaction
+	TxnResponse struct {
+		// If the transaction has been approved
+		Approved bool `json:"approved"`
+		// Codes of the risks the user has explicitly acknowledged. Approving a
+		// transaction is not enough to sign it if it has risks that were not
+		// acknowledged.
+		AcknowledgedRisks []txn_risk.Code `json:"acknowledged_risks,omitempty"`
+	}
+)
+
+// Approver asks the user to approve requests from dApps. Each method blocks
+// until the user responds or the given context is done. If the context is
+// done because its deadline passed, ErrTimeout is returned.
+type Approver interface {
+	// ApproveSession asks the user to approve establishing a dApp connect
+	// session with the dApp described in the given prompt data
+	ApproveSession(ctx context.Context, promptData SessionPromptData) (*SessionResponse, error)
+	// ApproveTransaction asks the user to approve signing the transaction in
+	// the given prompt data
+	ApproveTransaction(ctx context.Context, promptData TxnPromptData) (*TxnResponse, error)
+	// ApproveGroup asks the user to approve signing the transactions of the
+	// transaction group in the given prompt data
+	ApproveGroup(ctx context.Context, promptData GroupPromptData) (*TxnResponse, error)
+}
+
+// DecodeTxn decodes the Base64-encoded unsigned transaction in the given
+// transaction data
+func (txnData TxnData) DecodeTxn() (txn types.Transaction, err error) {
+	txnBytes, err := base64.StdEncoding.DecodeString(txnData.Txn)
+	if err != nil {
+		return
+	}
+
+	err = msgpack.Decode(txnBytes, &txn)
+	return
+}
+
+// ctxErr converts the error of the given context that is done into the error
+// an Approver should return
+func ctxErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,166 @@
+package approval
+
+import (
+	"context"
+	"time"
+
+	"duckysigner/internal/txn_risk"
+)
+
+type (
+	// SessionRule is a rule for responding to dApp connect session approval
+	// requests. Empty match fields match anything.
+	SessionRule struct {
+		// Only match dApps with this name
+		DappName string
+		// Only match dApps with this URL
+		DappURL string
+		// If the session should be approved
+		Approve bool
+		// The confirmation code to respond with when approving. This is
+		// usually taken from the response to the `POST /session/init` request.
+		Code string
+		// The addresses to connect when approving
+		Addresses []string
+		// Names of the profiles of the networks to bind the session to when
+		// approving. All the networks offered in the prompt are chosen if
+		// empty.
+		Networks []string
+	}
+
+	// TxnRule is a rule for responding to transaction signing approval
+	// requests. Empty match fields match anything.
+	TxnRule struct {
+		// Only match transactions with this sender address
+		Sender string
+		// Only match requests with this signer address
+		Signer string
+		// Only match transactions of this type (e.g. "pay", "axfer")
+		TxnType string
+		// If the transaction should be approved
+		Approve bool
+		// If the risks found in the transaction should be acknowledged when
+		// approving it
+		AcknowledgeRisks bool
+	}
+)
+
+// ScriptedApprover is an Approver that responds to requests according to rules
+// instead of asking a person, which is useful for automated testing. The rules
+// are checked in order and the first matching rule decides the response.
+// Requests that do not match any rule are rejected.
+//
+// The rules should not be modified while the approver is in use.
+type ScriptedApprover struct {
+	// Rules for responding to session approval requests
+	SessionRules []SessionRule
+	// Rules for responding to transaction signing approval requests
+	TxnRules []TxnRule
+	// How long to wait before responding, which can be used for simulating a
+	// slow user or a user who does not respond
+	Delay time.Duration
+}
+
+// ApproveSession responds to the session approval request according to the
+// session rules
+func (a *ScriptedApprover) ApproveSession(ctx context.Context, promptData SessionPromptData) (*SessionResponse, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	dapp := promptData.DappData
+	for _, rule := range a.SessionRules {
+		if !matches(rule.DappName, dapp.Name) || !matches(rule.DappURL, dapp.URL) {
+			continue
+		}
+		if !rule.Approve {
+			break
+		}
+		networks := rule.Networks
+		if len(networks) == 0 {
+			networks = promptData.Networks
+		}
+		return &SessionResponse{Code: rule.Code, Addresses: rule.Addresses, Networks: networks}, nil
+	}
+
+	return &SessionResponse{}, nil
+}
+
+// ApproveTransaction responds to the transaction signing approval request
+// according to the transaction rules
+func (a *ScriptedApprover) ApproveTransaction(ctx context.Context, promptData TxnPromptData) (*TxnResponse, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	txn, err := promptData.TxnData.DecodeTxn()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range a.TxnRules {
+		if matches(rule.Sender, txn.Sender.String()) &&
+			matches(rule.Signer, promptData.TxnData.Signer) &&
+			matches(rule.TxnType, string(txn.Type)) {
+			resp := TxnResponse{Approved: rule.Approve}
+			if rule.Approve && rule.AcknowledgeRisks {
+				resp.AcknowledgedRisks = txn_risk.Codes(promptData.Risks)
+			}
+			return &resp, nil
+		}
+	}
+
+	return &TxnResponse{Approved: false}, nil
+}
+
+// ApproveGroup responds to the transaction group signing approval request
+// according to the transaction rules. A rule decides the response if it
+// matches every transaction to be signed.
+func (a *ScriptedApprover) ApproveGroup(ctx context.Context, promptData GroupPromptData) (*TxnResponse, error) {
+	if err := a.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range a.TxnRules {
+		matchesAll := true
+		for _, i := range promptData.SignIndexes {
+			summary := promptData.Summaries[i]
+			if !matches(rule.Sender, summary.Sender) ||
+				!matches(rule.Signer, "") ||
+				!matches(rule.TxnType, summary.Type) {
+				matchesAll = false
+				break
+			}
+		}
+		if matchesAll {
+			resp := TxnResponse{Approved: rule.Approve}
+			if rule.Approve && rule.AcknowledgeRisks {
+				resp.AcknowledgedRisks = txn_risk.Codes(promptData.Risks)
+			}
+			return &resp, nil
+		}
+	}
+
+	return &TxnResponse{Approved: false}, nil
+}
+
+// wait waits for the delay, if any. Returns an error if the context is done
+// before the delay is over.
+func (a *ScriptedApprover) wait(ctx context.Context) error {
+	if a.Delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(a.Delay):
+		return nil
+	case <-ctx.Done():
+		return ctxErr(ctx)
+	}
+}
+
+// matches checks if the given value matches the given rule field. An empty
+// rule field matches any value.
+func matches(ruleField, value string) bool {
+	return ruleField == "" || ruleField == value
+}
@@ -0,0 +1,186 @@
+package approval_test
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_risk"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+// makeTxnPromptData creates transaction prompt data for a payment transaction
+// from the given sender
+func makeTxnPromptData(sender string) approval.TxnPromptData {
+	txn, err := transaction.MakePaymentTxn(sender, testAddrB, 1000, nil, "", types.SuggestedParams{
+		Fee:             1000,
+		GenesisID:       "testnet-v1.0",
+		GenesisHash:     make([]byte, 32),
+		FirstRoundValid: 1,
+		LastRoundValid:  1000,
+		FlatFee:         true,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return approval.TxnPromptData{TxnData: approval.TxnData{
+		Txn: base64.StdEncoding.EncodeToString(msgpack.Encode(txn)),
+	}}
+}
+
+// makeGroupPromptData creates transaction group prompt data for a group of
+// payment transactions from the given senders, where the transactions at the
+// given indexes are to be signed
+func makeGroupPromptData(senders []string, signIndexes []int) approval.GroupPromptData {
+	promptData := approval.GroupPromptData{SignIndexes: signIndexes}
+	for _, sender := range senders {
+		txnData := makeTxnPromptData(sender).TxnData
+		txn, err := txnData.DecodeTxn()
+		Expect(err).ToNot(HaveOccurred())
+		promptData.Group = append(promptData.Group, txnData.Txn)
+		promptData.Summaries = append(promptData.Summaries, txn_decoder.Decode(txn))
+	}
+	return promptData
+}
+
+var _ = Describe("ScriptedApprover", func() {
+	Describe("ApproveSession()", func() {
+		approver := &approval.ScriptedApprover{
+			SessionRules: []approval.SessionRule{
+				{DappName: "Bad DApp", Approve: false},
+				{DappName: "LocalNet DApp", Approve: true, Code: "5678", Networks: []string{"LocalNet"}},
+				{DappURL: "https://example.com", Approve: true, Code: "1234", Addresses: []string{testAddrA}},
+			},
+		}
+
+		It("approves a session using the first matching rule", func() {
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "Good DApp", URL: "https://example.com"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Code).To(Equal("1234"))
+			Expect(resp.Addresses).To(Equal([]string{testAddrA}))
+		})
+
+		It("binds the session to the rule's networks or else the offered networks", func() {
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "LocalNet DApp"},
+				Networks: []string{"TestNet", "LocalNet"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Networks).To(Equal([]string{"LocalNet"}))
+
+			resp, err = approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "Good DApp", URL: "https://example.com"},
+				Networks: []string{"TestNet", "LocalNet"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Networks).To(Equal([]string{"TestNet", "LocalNet"}))
+		})
+
+		It("rejects a session when the first matching rule rejects", func() {
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "Bad DApp", URL: "https://example.com"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Code).To(BeEmpty())
+		})
+
+		It("rejects a session when no rule matches", func() {
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "Other DApp", URL: "https://example.org"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Code).To(BeEmpty())
+		})
+	})
+
+	Describe("ApproveTransaction()", func() {
+		approver := &approval.ScriptedApprover{
+			TxnRules: []approval.TxnRule{
+				{Sender: testAddrA, TxnType: "pay", Approve: true},
+			},
+		}
+
+		It("approves a transaction that matches a rule", func() {
+			resp, err := approver.ApproveTransaction(context.Background(), makeTxnPromptData(testAddrA))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeTrue())
+		})
+
+		It("rejects a transaction that does not match any rule", func() {
+			resp, err := approver.ApproveTransaction(context.Background(), makeTxnPromptData(testAddrB))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeFalse())
+		})
+
+		It("only acknowledges risks when the matching rule says to", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			promptData.Risks = []txn_risk.Flag{{Code: txn_risk.CodeRekey}}
+
+			resp, err := approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.AcknowledgedRisks).To(BeEmpty())
+
+			ackApprover := &approval.ScriptedApprover{
+				TxnRules: []approval.TxnRule{{Approve: true, AcknowledgeRisks: true}},
+			}
+			resp, err = ackApprover.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.AcknowledgedRisks).To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+		})
+
+		It("fails if the transaction cannot be decoded", func() {
+			_, err := approver.ApproveTransaction(context.Background(), approval.TxnPromptData{
+				TxnData: approval.TxnData{Txn: "not a transaction"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ApproveGroup()", func() {
+		approver := &approval.ScriptedApprover{
+			TxnRules: []approval.TxnRule{
+				{Sender: testAddrA, TxnType: "pay", Approve: true, AcknowledgeRisks: true},
+			},
+		}
+
+		It("approves a group when a rule matches every transaction to be signed", func() {
+			promptData := makeGroupPromptData([]string{testAddrA, testAddrB, testAddrA}, []int{0, 2})
+			promptData.Risks = []txn_risk.Flag{{Code: txn_risk.CodeRekey}}
+			resp, err := approver.ApproveGroup(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeTrue())
+			Expect(resp.AcknowledgedRisks).To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+		})
+
+		It("rejects a group when no rule matches every transaction to be signed", func() {
+			promptData := makeGroupPromptData([]string{testAddrA, testAddrB}, []int{0, 1})
+			resp, err := approver.ApproveGroup(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeFalse())
+		})
+	})
+
+	It("times out if the delay is longer than the deadline", func() {
+		approver := &approval.ScriptedApprover{
+			TxnRules: []approval.TxnRule{{Approve: true}},
+			Delay:    time.Second,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := approver.ApproveTransaction(ctx, makeTxnPromptData(testAddrA))
+		Expect(err).To(MatchError(approval.ErrTimeout))
+	})
+})
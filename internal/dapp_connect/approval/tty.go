@@ -0,0 +1,313 @@
+package approval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_group"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
+)
+
+// ttyAcknowledgePhrase is what the user must type to acknowledge the risks of a
+// transaction
+const ttyAcknowledgePhrase = "I understand"
+
+// TTYApprover is an Approver that asks the user for approval through a
+// terminal. Only one request is asked at a time. Other requests wait for their
+// turn.
+type TTYApprover struct {
+	// Where the user's input is read from (e.g. `os.Stdin`)
+	in io.Reader
+	// Where the requests are written to (e.g. `os.Stdout`)
+	out io.Writer
+	// Ensures only one request is asked at a time
+	mutex sync.Mutex
+	// Lines read from the input
+	lines chan string
+	// Ensures only one goroutine reads from the input
+	readOnce sync.Once
+}
+
+// NewTTYApprover creates a new TTYApprover that reads the user's input from the
+// given reader and writes the requests to the given writer
+func NewTTYApprover(in io.Reader, out io.Writer) *TTYApprover {
+	return &TTYApprover{
+		in:    in,
+		out:   out,
+		lines: make(chan string),
+	}
+}
+
+// ApproveSession asks the user to approve the dApp connect session through the
+// terminal by asking for the confirmation code, the addresses to connect and
+// the networks to bind the session to. An empty confirmation code rejects the
+// session. All the offered networks are chosen if none are entered.
+func (a *TTYApprover) ApproveSession(ctx context.Context, promptData SessionPromptData) (*SessionResponse, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	dapp := promptData.DappData
+	fmt.Fprintln(a.out, "\nA dApp wants to connect:")
+	fmt.Fprintln(a.out, "  Name:       ", dapp.Name)
+	fmt.Fprintln(a.out, "  URL:        ", dapp.URL)
+	fmt.Fprintln(a.out, "  Description:", dapp.Description)
+
+	code, err := a.ask(ctx, "Confirmation code (leave blank to reject): ")
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return &SessionResponse{}, nil
+	}
+
+	addrsInput, err := a.ask(ctx, "Addresses to connect (comma-separated): ")
+	if err != nil {
+		return nil, err
+	}
+
+	networks := promptData.Networks
+	if len(promptData.Networks) > 0 {
+		networksInput, err := a.ask(ctx, fmt.Sprintf(
+			"Networks to allow (comma-separated, leave blank for %s): ",
+			strings.Join(promptData.Networks, ", "),
+		))
+		if err != nil {
+			return nil, err
+		}
+		if networksInput != "" {
+			networks = ttySplitList(networksInput)
+		}
+	}
+
+	return &SessionResponse{Code: code, Addresses: ttySplitList(addrsInput), Networks: networks}, nil
+}
+
+// ApproveTransaction asks the user to approve signing the transaction through
+// the terminal. Anything other than "y" or "yes" rejects the transaction.
+func (a *TTYApprover) ApproveTransaction(ctx context.Context, promptData TxnPromptData) (*TxnResponse, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	summary := promptData.Summary
+	if summary == nil {
+		txn, err := promptData.TxnData.DecodeTxn()
+		if err != nil {
+			return nil, err
+		}
+		summary = txn_decoder.Decode(txn)
+	}
+	summaryJSON, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(a.out, "\nA dApp wants a transaction to be signed:")
+	fmt.Fprintln(a.out, "Type:  ", summary.TypeName)
+	fmt.Fprintln(a.out, "Sender:", summary.Sender)
+	if summary.AppCall != nil && summary.AppCall.Method != nil {
+		fmt.Fprintln(a.out, "Method:", summary.AppCall.Method.Text)
+	}
+	fmt.Fprintln(a.out, string(summaryJSON))
+	if promptData.TxnData.Signer != "" {
+		fmt.Fprintln(a.out, "Signer:", promptData.TxnData.Signer)
+	}
+
+	if len(summary.Addresses) > 0 {
+		fmt.Fprintln(a.out, "Addresses:")
+		for _, addr := range slices.Sorted(maps.Keys(summary.Addresses)) {
+			fmt.Fprintf(a.out, "  - %s %s\n", addr, ttyAddressLabel(summary.Addresses[addr]))
+		}
+	}
+
+	if err := a.printSimulation(promptData.Simulation); err != nil {
+		return nil, err
+	}
+
+	if promptData.NoteAnalysis != nil && len(promptData.NoteAnalysis.Findings) > 0 {
+		fmt.Fprintln(a.out, "WARNING: The note of this transaction may be a scam:")
+		for _, finding := range promptData.NoteAnalysis.Findings {
+			fmt.Fprintf(a.out, "  - [%s] %s\n", finding.Code, finding.Message)
+		}
+	}
+
+	if len(promptData.Risks) > 0 {
+		fmt.Fprintln(a.out, "WARNING: This transaction is risky:")
+	}
+
+	return a.askTxnApproval(ctx, "Approve transaction? [y/N]: ", promptData.Risks)
+}
+
+// ApproveGroup asks the user to approve signing the transactions of the
+// transaction group through the terminal. The group is approved as a whole.
+// Anything other than "y" or "yes" rejects the group.
+func (a *TTYApprover) ApproveGroup(ctx context.Context, promptData GroupPromptData) (*TxnResponse, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	fmt.Fprintf(
+		a.out,
+		"\nA dApp wants %d of the %d transactions in a group to be signed:\n",
+		len(promptData.SignIndexes),
+		len(promptData.Summaries),
+	)
+	for i, summary := range promptData.Summaries {
+		mark := " "
+		if slices.Contains(promptData.SignIndexes, i) {
+			mark = "*"
+		}
+		fmt.Fprintf(a.out, "%s [%d] %s from %s\n", mark, i, summary.TypeName, summary.Sender)
+		if summary.AppCall != nil && summary.AppCall.Method != nil {
+			fmt.Fprintln(a.out, "      Method:", summary.AppCall.Method.Text)
+		}
+	}
+	fmt.Fprintln(a.out, "(* = to be signed)")
+
+	if len(promptData.NetFlows) > 0 {
+		fmt.Fprintln(a.out, "What the wallet's accounts send and receive:")
+		for _, flow := range promptData.NetFlows {
+			fmt.Fprintf(a.out, "  - %s: %s\n", flow.Account, ttyFlow(flow))
+		}
+	}
+
+	if err := a.printSimulation(promptData.Simulation); err != nil {
+		return nil, err
+	}
+
+	for i, noteAnalysis := range promptData.NoteAnalyses {
+		if noteAnalysis != nil && len(noteAnalysis.Findings) > 0 {
+			fmt.Fprintf(a.out, "WARNING: The note of transaction %d may be a scam:\n", i)
+			for _, finding := range noteAnalysis.Findings {
+				fmt.Fprintf(a.out, "  - [%s] %s\n", finding.Code, finding.Message)
+			}
+		}
+	}
+
+	if len(promptData.Risks) > 0 {
+		fmt.Fprintln(a.out, "WARNING: This transaction group is risky:")
+	}
+
+	return a.askTxnApproval(ctx, "Approve transaction group? [y/N]: ", promptData.Risks)
+}
+
+// printSimulation writes the given simulation result, if any, to the terminal
+func (a *TTYApprover) printSimulation(simulation *txn_simulator.Result) error {
+	if simulation == nil {
+		return nil
+	}
+	if simulation.Error != "" {
+		fmt.Fprintln(a.out, "Could not simulate the transaction:", simulation.Error)
+		return nil
+	}
+
+	simulationJSON, err := json.MarshalIndent(simulation, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "Simulation:")
+	fmt.Fprintln(a.out, string(simulationJSON))
+
+	return nil
+}
+
+// askTxnApproval lists the given risks, asks the user the given approval
+// question and asks the user to acknowledge the risks if the user approves
+func (a *TTYApprover) askTxnApproval(ctx context.Context, question string, risks []txn_risk.Flag) (*TxnResponse, error) {
+	for _, risk := range risks {
+		fmt.Fprintf(a.out, "  - [%s] %s\n", risk.Code, risk.Message)
+	}
+
+	answer, err := a.ask(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	answer = strings.ToLower(answer)
+	resp := TxnResponse{Approved: answer == "y" || answer == "yes"}
+
+	if resp.Approved && len(risks) > 0 {
+		answer, err = a.ask(ctx, "Type \""+ttyAcknowledgePhrase+"\" to acknowledge the risks: ")
+		if err != nil {
+			return nil, err
+		}
+		if answer == ttyAcknowledgePhrase {
+			resp.AcknowledgedRisks = txn_risk.Codes(risks)
+		}
+	}
+
+	return &resp, nil
+}
+
+// ttyFlow describes what an account sends and receives for the terminal
+func ttyFlow(flow txn_group.Flow) string {
+	if flow.AssetID == 0 {
+		return fmt.Sprintf(
+			"sends %s Algos, receives %s Algos",
+			txn_decoder.NewAlgoAmount(flow.Sent).Algos,
+			txn_decoder.NewAlgoAmount(flow.Received).Algos,
+		)
+	}
+	return fmt.Sprintf("sends %d, receives %d of asset %d", flow.Sent, flow.Received, flow.AssetID)
+}
+
+// ttySplitList splits the given comma-separated list entered by the user,
+// leaving out empty items
+func ttySplitList(input string) (items []string) {
+	for item := range strings.SplitSeq(input, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// ttyAddressLabel describes what is known about an address for the terminal
+func ttyAddressLabel(info txn_decoder.AddressInfo) string {
+	switch {
+	case !info.Known:
+		return "(UNKNOWN ADDRESS)"
+	case info.Label != "" && info.Trusted:
+		return "(" + info.Label + ", trusted)"
+	case info.Label != "":
+		return "(" + info.Label + ")"
+	default:
+		return "(in wallet)"
+	}
+}
+
+// ask writes the given question and waits for the user to enter a line of
+// input. Returns the line without surrounding whitespace.
+func (a *TTYApprover) ask(ctx context.Context, question string) (string, error) {
+	// Reading from the input cannot be interrupted, so a single goroutine does
+	// all the reading. Otherwise, a line meant for one question could be read
+	// by a reader left over from a question that was canceled.
+	a.readOnce.Do(func() {
+		go func() {
+			scanner := bufio.NewScanner(a.in)
+			for scanner.Scan() {
+				a.lines <- scanner.Text()
+			}
+			close(a.lines)
+		}()
+	})
+
+	fmt.Fprint(a.out, question)
+
+	select {
+	case line, ok := <-a.lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return strings.TrimSpace(line), nil
+	case <-ctx.Done():
+		fmt.Fprintln(a.out, "\nNo longer waiting for a response")
+		return "", ctxErr(ctx)
+	}
+}
@@ -0,0 +1,228 @@
+package approval_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
+	"duckysigner/internal/note_analyzer"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_group"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
+)
+
+var _ = Describe("TTYApprover", func() {
+	Describe("ApproveSession()", func() {
+		It("approves a session with the entered code and addresses", func() {
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(
+				strings.NewReader("1234\n"+testAddrA+", "+testAddrB+"\n"),
+				&out,
+			)
+
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				DappData: dc.DappData{Name: "Foo DApp", URL: "https://example.com"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Code).To(Equal("1234"))
+			Expect(resp.Addresses).To(Equal([]string{testAddrA, testAddrB}))
+			Expect(out.String()).To(ContainSubstring("Foo DApp"))
+			Expect(out.String()).To(ContainSubstring("https://example.com"))
+		})
+
+		It("binds the session to the entered networks", func() {
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("1234\n"+testAddrA+"\nLocalNet\n"), &out)
+
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				Networks: []string{"TestNet", "LocalNet"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Networks).To(Equal([]string{"LocalNet"}))
+			Expect(out.String()).To(ContainSubstring("leave blank for TestNet, LocalNet"))
+		})
+
+		It("binds the session to all offered networks when none are entered", func() {
+			approver := approval.NewTTYApprover(strings.NewReader("1234\n"+testAddrA+"\n\n"), io.Discard)
+
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{
+				Networks: []string{"TestNet", "LocalNet"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Networks).To(Equal([]string{"TestNet", "LocalNet"}))
+		})
+
+		It("rejects a session when no code is entered", func() {
+			approver := approval.NewTTYApprover(strings.NewReader("\n"), io.Discard)
+
+			resp, err := approver.ApproveSession(context.Background(), approval.SessionPromptData{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Code).To(BeEmpty())
+		})
+	})
+
+	Describe("ApproveTransaction()", func() {
+		It("approves a transaction when the user answers yes", func() {
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("Y\n"), &out)
+
+			resp, err := approver.ApproveTransaction(context.Background(), makeTxnPromptData(testAddrA))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeTrue())
+			Expect(out.String()).To(ContainSubstring(testAddrA), "Shows the transaction")
+		})
+
+		It("rejects a transaction when the user answers anything else", func() {
+			approver := approval.NewTTYApprover(strings.NewReader("nope\n"), io.Discard)
+
+			resp, err := approver.ApproveTransaction(context.Background(), makeTxnPromptData(testAddrA))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeFalse())
+		})
+
+		It("shows the labels of the addresses and points out unknown ones", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			txn, err := promptData.TxnData.DecodeTxn()
+			Expect(err).ToNot(HaveOccurred())
+			promptData.Summary = txn_decoder.Decode(txn)
+			promptData.Summary.Addresses = map[string]txn_decoder.AddressInfo{
+				testAddrA: {Label: "Alice", Known: true},
+				testAddrB: {},
+			}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err = approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring(testAddrA + " (Alice)"))
+			Expect(out.String()).To(ContainSubstring(testAddrB + " (UNKNOWN ADDRESS)"))
+		})
+
+		It("shows the decoded ABI method call", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			txn, err := promptData.TxnData.DecodeTxn()
+			Expect(err).ToNot(HaveOccurred())
+			promptData.Summary = txn_decoder.Decode(txn)
+			promptData.Summary.AppCall = &txn_decoder.AppCallDetails{
+				AppID:  42,
+				Method: &txn_decoder.MethodCall{Name: "swap", Text: "swap(asset_in=31566704, amount=1000000)"},
+			}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err = approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("Method: swap(asset_in=31566704, amount=1000000)"))
+		})
+
+		It("warns about what was found in the note", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			promptData.NoteAnalysis = note_analyzer.Analyze([]byte("Claim at https://example.com"))
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err := approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("Note has a link: https://example.com"))
+		})
+
+		It("shows the simulation results", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			promptData.Simulation = &txn_simulator.Result{
+				WouldSucceed: true,
+				BalanceChanges: []txn_simulator.BalanceChange{
+					{Address: testAddrA, Sent: 1_001_000},
+				},
+			}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err := approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("Simulation:"))
+			Expect(out.String()).To(ContainSubstring(`"sent": 1001000`))
+
+			By("showing why the transaction could not be simulated")
+			promptData.Simulation = &txn_simulator.Result{Error: "algod is unreachable"}
+			out.Reset()
+			approver = approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err = approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("Could not simulate the transaction: algod is unreachable"))
+		})
+
+		It("asks the user to approve a transaction group as a whole", func() {
+			promptData := makeGroupPromptData([]string{testAddrA, testAddrB}, []int{0})
+			promptData.NetFlows = []txn_group.Flow{
+				{Account: testAddrA, Sent: 1_001_000},
+				{Account: testAddrA, AssetID: 10, Received: 5},
+			}
+			promptData.Risks = []txn_risk.Flag{{Code: txn_risk.CodeRekey, Message: "Rekeyed"}}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("y\nI understand\n"), &out)
+			resp, err := approver.ApproveGroup(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeTrue())
+			Expect(resp.AcknowledgedRisks).To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+			Expect(out.String()).To(ContainSubstring("1 of the 2 transactions in a group"))
+			Expect(out.String()).To(ContainSubstring("* [0] Payment from " + testAddrA))
+			Expect(out.String()).To(ContainSubstring(testAddrA + ": sends 1.001000 Algos, receives 0.000000 Algos"))
+			Expect(out.String()).To(ContainSubstring(testAddrA + ": sends 0, receives 5 of asset 10"))
+			Expect(out.String()).To(ContainSubstring("[rekey] Rekeyed"))
+		})
+
+		It("warns about what was found in the notes of a transaction group", func() {
+			promptData := makeGroupPromptData([]string{testAddrA, testAddrB}, []int{0})
+			promptData.NoteAnalyses = []*note_analyzer.Analysis{
+				nil,
+				note_analyzer.Analyze([]byte("Claim at https://example.com")),
+			}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("n\n"), &out)
+			_, err := approver.ApproveGroup(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("The note of transaction 1 may be a scam"))
+			Expect(out.String()).To(ContainSubstring("Note has a link: https://example.com"))
+			Expect(out.String()).ToNot(ContainSubstring("The note of transaction 0"))
+		})
+
+		It("asks the user to acknowledge the risks of a risky transaction", func() {
+			promptData := makeTxnPromptData(testAddrA)
+			promptData.Risks = []txn_risk.Flag{{Code: txn_risk.CodeRekey, Message: "Rekeyed"}}
+
+			var out bytes.Buffer
+			approver := approval.NewTTYApprover(strings.NewReader("y\nI understand\n"), &out)
+			resp, err := approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Approved).To(BeTrue())
+			Expect(resp.AcknowledgedRisks).To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+			Expect(out.String()).To(ContainSubstring("Rekeyed"), "Shows the risks")
+
+			approver = approval.NewTTYApprover(strings.NewReader("y\nok\n"), io.Discard)
+			resp, err = approver.ApproveTransaction(context.Background(), promptData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.AcknowledgedRisks).To(BeEmpty(), "Wrong phrase does not acknowledge")
+		})
+
+		It("times out when the user does not answer", func() {
+			// A reader that never has any input
+			inReader, _ := io.Pipe()
+			approver := approval.NewTTYApprover(inReader, io.Discard)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := approver.ApproveTransaction(ctx, makeTxnPromptData(testAddrA))
+			Expect(err).To(MatchError(approval.ErrTimeout))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"duckysigner/internal/app_spec"
+	"duckysigner/internal/audit"
+	dc "duckysigner/internal/dapp_connect"
+	mw "duckysigner/internal/dapp_connect/middleware"
+	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/wallet_session"
+)
+
+type (
+	// AppSpecPostReq is the request data for `POST /app/spec`
+	AppSpecPostReq struct {
+		// ID of the application the app spec is for
+		AppID uint64 `json:"app_id" validate:"required"`
+		// ARC-32 or ARC-56 application specification
+		Spec json.RawMessage `json:"spec" validate:"required"`
+	}
+
+	// AppSpecPostResp is the response data to a `POST /app/spec` request
+	AppSpecPostResp struct {
+		// ID of the application the app spec is for
+		AppID uint64 `json:"app_id"`
+		// Name of the application's contract
+		Name string `json:"name"`
+		// Format of the app spec (i.e. "arc32" or "arc56")
+		Format string `json:"format"`
+	}
+)
+
+// AppSpecPost is the route handler for `POST /app/spec`, which lets a dApp
+// register the app spec of an application so the calls to the application's
+// ABI methods can be decoded for the user. A dApp can only replace the app
+// specs it registered.
+func AppSpecPost(
+	echoInstance *echo.Echo,
+	walletSession *wallet_session.WalletSession,
+	sessionManager *session.Manager,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if walletSession == nil {
+			apiErr := dc.ApiError{
+				Name:    "no_wallet_session",
+				Message: "There is currently no valid wallet session. Log in to a wallet and try again.",
+			}
+			return c.JSON(http.StatusInternalServerError, apiErr)
+		}
+
+		// Read request data
+		rawReqBody, err := dc.GetRawRequestBody(c.Request())
+		if err != nil {
+			apiErr := dc.ApiError{Name: "bad_request", Message: err.Error()}
+			return c.JSON(http.StatusBadRequest, apiErr)
+		}
+		// Parse request data
+		reqData := new(AppSpecPostReq)
+		if err := json.Unmarshal(rawReqBody, &reqData); err != nil {
+			apiErr := dc.ApiError{Name: "bad_request", Message: err.Error()}
+			return c.JSON(http.StatusBadRequest, apiErr)
+		}
+
+		// Hawk authentication
+		hawkOpt := mw.HawkOptions{
+			EchoContext:  c,
+			EchoInstance: echoInstance,
+			CredentialStore: mw.SessionCredentialStore{
+				EchoContext:    c,
+				WalletSession:  walletSession,
+				SessionManager: sessionManager,
+			},
+			WalletSession: walletSession,
+		}
+		hawkServer, cred, apiErr := mw.HawkAuth(rawReqBody, &hawkOpt)
+		if apiErr != nil {
+			// Set WWW-Authenticate header
+			c.Response().Header().Set("WWW-Authenticate", "Hawk")
+			// Respond with 401 Unauthorized
+			return c.JSON(http.StatusUnauthorized, apiErr)
+		}
+
+		dappId := sessionDappId(c, walletSession, sessionManager, cred.ID)
+		auditEntry := audit.Entry{
+			Event:     audit.EventAppSpecRegister,
+			DappID:    dappId,
+			SessionID: cred.ID,
+			Decision:  audit.DecisionFailed,
+		}
+		defer func() { dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger) }()
+
+		// Validate request data
+		if err := c.Validate(reqData); err != nil {
+			auditEntry.Details = "invalid request"
+			apiErr := dc.ApiError{Name: "validation_error", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		}
+		if dappId == "" {
+			echoInstance.Logger.Error("could not get the dApp of the dApp connect session")
+			apiErr := dc.ApiError{Name: "app_spec_save_fail", Message: "Failed to save the app spec"}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		// Check the app spec before saving it so a bad app spec is reported as
+		// the dApp's fault
+		if _, err := app_spec.Parse(reqData.AppID, string(reqData.Spec)); err != nil {
+			auditEntry.Details = "invalid app spec"
+			apiErr := dc.ApiError{Name: "invalid_app_spec", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		spec, err := walletSession.SaveAppSpec(reqData.AppID, string(reqData.Spec), dappId)
+		if errors.Is(err, app_spec.ErrSpecExists) {
+			auditEntry.Decision = audit.DecisionRejected
+			auditEntry.Details = "app spec already added by someone else"
+			apiErr := dc.ApiError{Name: "app_spec_exists", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusConflict, apiErr, hawkServer, cred, &hawkOpt)
+		}
+		if err != nil {
+			echoInstance.Logger.Error(err)
+			apiErr := dc.ApiError{Name: "app_spec_save_fail", Message: "Failed to save the app spec"}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		auditEntry.Decision = audit.DecisionApproved
+		auditEntry.Details = fmt.Sprintf("%s app spec for app %d", spec.Format, spec.AppID)
+
+		resp := AppSpecPostResp{AppID: spec.AppID, Name: spec.Name, Format: spec.Format}
+		return mw.HawkRespJSON(http.StatusOK, resp, hawkServer, cred, &hawkOpt)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/handlers"
+	"duckysigner/internal/dapp_connect/session"
+)
+
+const appSpecPostUri = "http://localhost:" + appSpecPostPort + "/app/spec"
+
+var _ = Describe("POST /app/spec", Ordered, func() {
+	// Pre-generated keys for dApp connect session
+	const (
+		dappIdB64     = "c+2pz3JaUkIEMnbi1vuv7RWdGpfyiv6O3xaYbYbieAg="
+		sessionKeyB64 = "OA7vIBYGze5Vapw/qO3iPr+F9nRnaxsWSVnViTEZ1Ag="
+		// ARC-56 app spec with a single method
+		testSpec = `{"name":"Swapper","methods":[{"name":"swap","args":[{"type":"asset","name":"asset_in"},{"type":"uint64","name":"amount"}],"returns":{"type":"void"}}]}`
+	)
+	var sessionManager *session.Manager
+	var testSession *session.Session
+
+	// createSession creates and stores a session for the dApp with the given
+	// public key
+	createSession := func(dappIdBytes []byte, dappName string) *session.Session {
+		dappPk, err := curve.NewPublicKey(dappIdBytes)
+		Expect(err).NotTo(HaveOccurred())
+		sess, err := sessionManager.GenerateSession(dappPk, &dc.DappData{Name: dappName}, nil, []string{"TestNet"})
+		Expect(err).NotTo(HaveOccurred())
+		mek, err := kmdService.Session().GetMasterKey()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sessionManager.StoreSession(sess, mek)).To(Succeed())
+		return sess
+	}
+
+	// postAppSpec makes an authenticated request with the given body using the
+	// given session and returns the response status code and body
+	postAppSpec := func(sess *session.Session, reqBody string) (int, []byte) {
+		hawkHeader := createPostReqHawkHeader(sess, appSpecPostUri, reqBody)
+
+		By("Making an authenticated request to server")
+		req, err := http.NewRequest("POST", appSpecPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Processing response from server")
+		body, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+		return resp.StatusCode, body
+	}
+
+	BeforeAll(func() {
+		By("Setting up dApp connect server")
+		setUpDcService(appSpecPostPort, sessionKeyB64)
+
+		By("Creating a session")
+		sessionManager = session.NewManager(curve, &session.SessionConfig{
+			DataDir: kmdService.Session().FilePath,
+		})
+		dappIdBytes, err := base64.StdEncoding.DecodeString(dappIdB64)
+		Expect(err).NotTo(HaveOccurred())
+		testSession = createSession(dappIdBytes, "Foobar")
+	})
+
+	It("registers the app spec of an application", func() {
+		status, respBody := postAppSpec(testSession, `{"app_id":1234,"spec":`+testSpec+`}`)
+		Expect(status).To(Equal(http.StatusOK))
+
+		var respData handlers.AppSpecPostResp
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData).To(Equal(handlers.AppSpecPostResp{AppID: 1234, Name: "Swapper", Format: "arc56"}))
+
+		By("Checking the app spec was saved in the wallet")
+		spec, err := kmdService.Session().GetAppSpec(1234)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Methods).To(HaveLen(1))
+		Expect(spec.DappID).To(Equal(dappIdB64))
+	})
+
+	It("fails if the app spec is invalid", func() {
+		status, respBody := postAppSpec(testSession, `{"app_id":1234,"spec":{"name":"Empty","methods":[]}}`)
+		Expect(status).To(Equal(http.StatusBadRequest))
+
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("invalid_app_spec"))
+	})
+
+	It("fails if another dApp registered the app spec", func() {
+		By("Creating a session for another dApp")
+		otherDappKey, err := curve.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		otherSession := createSession(otherDappKey.PublicKey().Bytes(), "Other")
+
+		status, respBody := postAppSpec(otherSession, `{"app_id":1234,"spec":`+testSpec+`}`)
+		Expect(status).To(Equal(http.StatusConflict))
+
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("app_spec_exists"))
+	})
+})
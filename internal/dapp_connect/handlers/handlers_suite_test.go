@@ -2,6 +2,8 @@ package handlers_test
 
 import (
 	"crypto/ecdh"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -30,6 +32,21 @@ func getResponseBody(resp *http.Response) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+// splitPromptData separates the request ID from the rest of the given prompt
+// event data. Returns the request ID and the rest of the data as JSON.
+func splitPromptData(data any) (requestId string, promptData string) {
+	var fields map[string]json.RawMessage
+	Expect(json.Unmarshal([]byte(fmt.Sprint(data)), &fields)).To(Succeed())
+	Expect(json.Unmarshal(fields["request_id"], &requestId)).To(Succeed())
+	Expect(requestId).ToNot(BeEmpty(), "Prompt has a request ID")
+	delete(fields, "request_id")
+
+	promptDataBytes, err := json.Marshal(fields)
+	Expect(err).ToNot(HaveOccurred())
+
+	return requestId, string(promptDataBytes)
+}
+
 var dcService DappConnectService
 var kmdService *KMDService
 var curve = ecdh.X25519()
@@ -40,6 +57,8 @@ const sessionInitPostPort = "1385"
 const sessionConfirmPostPort = "1386"
 const transactionSignPostPort = "1387"
 const sessionEndGetPort = "1388"
+const transactionGroupSignPostPort = "1389"
+const appSpecPostPort = "1390"
 
 func setUpDcService(port string, mockSessionKey string) {
 	walletDirName := ".test_dc_handlers_" + port
@@ -93,6 +112,7 @@ func createKmdService(walletDirName string) *KMDService {
 				SQLiteWalletDriverConfig:  config.SQLiteWalletDriverConfig{Disable: true, UnsafeScrypt: true, WalletsDir: "duckdb_wallets"},
 				LedgerWalletDriverConfig:  config.LedgerWalletDriverConfig{Disable: true},
 			},
+			Networks: config.DefaultNetworkProfiles(),
 		},
 	}
 
@@ -21,10 +21,12 @@ func RootGet(
 			EchoContext:  c,
 			EchoInstance: echoInstance,
 			CredentialStore: mw.SessionCredentialStore{
+				EchoContext:    c,
 				WalletSession:  walletSession,
 				SessionManager: sessionManager,
 			},
-			OptionalAuth: true,
+			WalletSession: walletSession,
+			OptionalAuth:  true,
 		}
 		// Hawk authentication (optional)
 		hawkServer, cred, apiErr := mw.HawkAuth(nil, &hawkOpt)
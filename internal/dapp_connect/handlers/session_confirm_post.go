@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/hiyosi/hawk"
 	"github.com/labstack/echo/v4"
-	"github.com/wailsapp/wails/v3/pkg/application"
 
+	"duckysigner/internal/audit"
 	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
 	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/kmd/config"
 	"duckysigner/internal/tools"
 	"duckysigner/internal/wallet_session"
 )
@@ -23,6 +29,9 @@ type (
 		Token string `json:"token" validate:"required"`
 		// DApp data
 		DappData dc.DappData `json:"dapp"`
+		// Genesis IDs of the networks the dApp wants to request signatures
+		// for. The user can choose from all configured networks if empty.
+		Networks []string `json:"networks"`
 	}
 
 	// SessionConfirmPostResp is the response data to a `POST /session/confirm`
@@ -34,25 +43,26 @@ type (
 		Expiration int64 `json:"exp"`
 		// The addresses that are allowed to sign things in the session
 		Addresses []string `json:"addrs"`
+		// Names of the profiles of the networks the dApp can request
+		// signatures for in the session
+		Networks []string `json:"networks"`
 	}
 
 	// ApproveSessionPromptData is the data passed to the UI when prompting the
 	// user to approve the session
-	ApproveSessionPromptData struct {
-		DappData dc.DappData `json:"dapp"`
-	}
+	ApproveSessionPromptData = approval.SessionPromptData
 
 	// ApproveSessionRespData is the data the UI sends when the user responds to
 	// the prompt to approve the session
-	ApproveSessionRespData struct {
-		Code      string   `json:"code"`
-		Addresses []string `json:"addrs"`
-	}
+	ApproveSessionRespData = approval.SessionResponse
 
 	// ConfirmCredentialStoreConfig is the configuration for a
 	// ConfirmCredentialStore. The primary purpose of this is to contain
 	// pointers that can be set within the credential store function.
 	ConfirmCredentialStoreConfig struct {
+		// The Echo context of the request being authenticated, which is used
+		// to reuse the file encryption key for the rest of the request
+		EchoContext echo.Context
 		// The current wallet session
 		WalletSession *wallet_session.WalletSession
 		// An instance of the session manager
@@ -77,16 +87,16 @@ type (
 // SessionConfirmPromptEventName is the name for the event for triggering the
 // UI to prompt the user to approve the dApp connect session confirmation
 // request
-const SessionConfirmPromptEventName string = "session_confirm_prompt"
+const SessionConfirmPromptEventName string = approval.SessionPromptEventName
 
 // SessionConfirmRespEventName is the name for the event that the UI uses to
 // forward the user's response to the dApp connect session confirmation request
-const SessionConfirmRespEventName string = "session_confirm_response"
+const SessionConfirmRespEventName string = approval.SessionRespEventName
 
 // SessionInitPost is the route handler for `POST /session/init`
 func SessionConfirmPost(
 	echoInstance *echo.Echo,
-	wailsApp *application.App,
+	approver approval.Approver,
 	walletSession *wallet_session.WalletSession,
 	sessionManager *session.Manager,
 	ecdhCurve tools.ECDHCurve,
@@ -119,6 +129,7 @@ func SessionConfirmPost(
 		// Set up Hawk server
 		rawReqData := c.Request()
 		credStoreConfig := ConfirmCredentialStoreConfig{
+			EchoContext:    c,
 			WalletSession:  walletSession,
 			SessionManager: sessionManager,
 			ECDHCurve:      ecdhCurve,
@@ -132,6 +143,10 @@ func SessionConfirmPost(
 		// Authenticate the Hawk request header
 		cred, err := hawkServer.Authenticate(rawReqData)
 		if err != nil {
+			dc.RecordAuditEntry(c, walletSession, audit.Entry{
+				Event:   audit.EventAuthFail,
+				Details: rawReqData.Method + " " + rawReqData.URL.Path + ": " + err.Error(),
+			}, echoInstance.Logger)
 			// Set WWW-Authenticate header
 			c.Response().Header().Set("WWW-Authenticate", "Hawk")
 			// Respond with 401 Unauthorized
@@ -141,16 +156,52 @@ func SessionConfirmPost(
 			})
 		}
 
+		auditEntry := audit.Entry{
+			Event:     audit.EventSessionConfirm,
+			DappID:    base64.StdEncoding.EncodeToString(credStoreConfig.ExtractedConfirm.DappId().Bytes()),
+			SessionID: cred.ID,
+			Decision:  audit.DecisionFailed,
+		}
+		defer func() { dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger) }()
+
 		// Check if confirmation has expired
 		if credStoreConfig.ExtractedConfirm.Expiration().Before(time.Now()) {
+			auditEntry.Details = "confirmation expired"
 			return c.JSON(http.StatusUnauthorized, dc.ApiError{
 				Name:    "confirm_expired",
 				Message: "The confirmation has expired. Initialize the session again.",
 			})
 		}
 
-		// Prompt user to approve dApp connect session
-		promptDataJSON, err := json.Marshal(ApproveSessionPromptData{DappData: reqData.DappData})
+		// Find the networks the user can bind the session to
+		offeredNetworks := offeredSessionNetworks(walletSession.NetworkProfiles(), reqData.Networks)
+		if len(offeredNetworks) == 0 {
+			auditEntry.Details = "no configured networks for: " + strings.Join(reqData.Networks, ", ")
+			return c.JSON(http.StatusBadRequest, dc.ApiError{
+				Name:    "unknown_networks",
+				Message: "None of the requested networks are configured in the wallet",
+			})
+		}
+
+		// Ask user to approve dApp connect session and wait for user
+		// response...
+		ctx, cancel := context.WithTimeout(c.Request().Context(), sessionManager.ApprovalTimeout())
+		defer cancel()
+		var userRespData *approval.SessionResponse
+		dc.WithoutDataFiles(c, walletSession, func() {
+			userRespData, err = approver.ApproveSession(
+				ctx,
+				ApproveSessionPromptData{DappData: reqData.DappData, Networks: offeredNetworks},
+			)
+		})
+		if errors.Is(err, approval.ErrTimeout) { // Time ran out
+			echoInstance.Logger.Info("Ran out of time waiting for user response")
+			auditEntry.Decision = audit.DecisionTimeout
+			return c.JSON(
+				http.StatusRequestTimeout,
+				dc.ApiError{Name: "confirm_timeout", Message: "User did not respond"},
+			)
+		}
 		if err != nil {
 			echoInstance.Logger.Error(err)
 			return c.JSON(http.StatusInternalServerError, dc.ApiError{
@@ -158,145 +209,146 @@ func SessionConfirmPost(
 				Message: "Failed to prompt the user",
 			})
 		}
-		userResp, err := dc.PromptUIOnce(
-			string(promptDataJSON),
-			SessionConfirmPromptEventName,
-			SessionConfirmRespEventName,
-			wailsApp,
-			echoInstance.Logger,
+
+		echoInstance.Logger.Debug("Received dApp connect user response:", *userRespData)
+
+		userRespCode := userRespData.Code
+
+		// If no confirmation code is given, that means the user rejected
+		if userRespCode == "" {
+			auditEntry.Decision = audit.DecisionRejected
+			return c.JSON(
+				http.StatusForbidden,
+				dc.ApiError{Name: "session_rejected", Message: "Session was rejected"},
+			)
+		}
+
+		// Check if the code is correct
+		if userRespCode != credStoreConfig.ExtractedConfirm.Code() {
+			auditEntry.Decision = audit.DecisionRejected
+			auditEntry.Details = "wrong confirmation code"
+			return c.JSON(
+				http.StatusForbidden,
+				dc.ApiError{
+					Name:    "wrong_confirm_code",
+					Message: "The user did not enter the correct confirmation code",
+				},
+			)
+		}
+
+		// Check if the user chose which of the offered networks to bind the
+		// session to
+		if len(userRespData.Networks) == 0 || slices.ContainsFunc(userRespData.Networks, func(network string) bool {
+			return !slices.Contains(offeredNetworks, network)
+		}) {
+			auditEntry.Decision = audit.DecisionRejected
+			auditEntry.Details = "invalid networks: " + strings.Join(userRespData.Networks, ", ")
+			return c.JSON(
+				http.StatusForbidden,
+				dc.ApiError{
+					Name:    "invalid_networks",
+					Message: "The user did not choose any of the offered networks for the session",
+				},
+			)
+		}
+
+		echoInstance.Logger.Debug(
+			"DApp connection has been confirmed. Establishing session...",
+		)
+
+		// Establish session
+		session, err := sessionManager.EstablishSessionWithConfirm(
+			credStoreConfig.ExtractedConfirm,
+			userRespCode,
+			&reqData.DappData,
+			userRespData.Addresses,
+			userRespData.Networks,
 		)
-		// Remove listener for UI response event when the server request ends,
-		// which is definitely after the UI response event data is received from
-		// the channel
-		defer wailsApp.Event.Off(SessionConfirmRespEventName)
 		if err != nil {
 			echoInstance.Logger.Error(err)
 			return c.JSON(http.StatusInternalServerError, dc.ApiError{
-				Name:    "unexpected_fail",
-				Message: err.Error(),
+				Name:    "session_create_fail",
+				Message: "Failed to create dApp connect session",
 			})
 		}
 
-		// Wait for user response...
-		select {
-		case <-time.After(sessionManager.ApprovalTimeout()): // Time ran out
-			echoInstance.Logger.Info("Ran out of time waiting for user response")
-			return c.JSON(
-				http.StatusRequestTimeout,
-				dc.ApiError{Name: "confirm_timeout", Message: "User did not respond"},
-			)
-		case dataJSON := <-userResp: // Got user's response
-			echoInstance.Logger.Debug("Received dApp connect user response:", dataJSON)
-
-			var userRespData ApproveSessionRespData
-
-			err := json.Unmarshal([]byte(dataJSON), &userRespData)
-			if err != nil {
-				echoInstance.Logger.Error(err)
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "user_response_fail",
-					Message: "Failed to process user response",
-				})
-			}
-
-			userRespCode := userRespData.Code
-
-			// If no confirmation code is given, that means the user rejected
-			if userRespCode == "" {
-				return c.JSON(
-					http.StatusForbidden,
-					dc.ApiError{Name: "session_rejected", Message: "Session was rejected"},
-				)
-			}
-
-			// Check if the code is correct
-			if userRespCode != credStoreConfig.ExtractedConfirm.Code() {
-				return c.JSON(
-					http.StatusForbidden,
-					dc.ApiError{
-						Name:    "wrong_confirm_code",
-						Message: "The user did not enter the correct confirmation code",
-					},
-				)
-			}
-
-			echoInstance.Logger.Debug(
-				"DApp connection has been confirmed. Establishing session...",
-			)
+		sessionShared, _ := session.SharedKey()
+		echoInstance.Logger.Debug("Session shared key:", base64.StdEncoding.EncodeToString(sessionShared))
 
-			// Establish session
-			session, err := sessionManager.EstablishSessionWithConfirm(
-				credStoreConfig.ExtractedConfirm,
-				userRespCode,
-				&reqData.DappData,
-				userRespData.Addresses,
-			)
-			if err != nil {
-				echoInstance.Logger.Error(err)
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "session_create_fail",
-					Message: "Failed to create dApp connect session",
-				})
-			}
-
-			sessionShared, _ := session.SharedKey()
-			echoInstance.Logger.Debug("Session shared key:", base64.StdEncoding.EncodeToString(sessionShared))
-
-			// Get encryption key needed to modify the session database
-			mek, err := walletSession.GetMasterKey()
-			if err != nil {
-				echoInstance.Logger.Error(err)
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "session_create_fail",
-					Message: "Failed to create dApp connect session",
-				})
-			}
-
-			// Store generated session
-			err = sessionManager.StoreSession(session, mek)
-			if err != nil {
-				echoInstance.Logger.Error(err)
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "session_create_fail",
-					Message: "Failed to create dApp connect session",
-				})
-			}
-
-			// Prepare response
-			resp := SessionConfirmPostResp{
-				Id:         base64.StdEncoding.EncodeToString(session.ID().Bytes()),
-				Expiration: session.Expiration().Unix(),
-				Addresses:  userRespData.Addresses,
-			}
-			respJSON, err := json.Marshal(resp)
-			if err != nil {
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "confirm_auth_response_failed",
-					Message: err.Error(),
-				})
-			}
-
-			// Add Hawk header to response
-			hawkRespHeader, err := hawkServer.Header(rawReqData, cred, &hawk.Option{
-				TimeStamp:   time.Now().Unix(),
-				Payload:     string(respJSON),
-				ContentType: "application/json",
+		// Get encryption key needed to modify the session database
+		mek, err := dc.RequestFileKey(c, walletSession)
+		if err != nil {
+			echoInstance.Logger.Error(err)
+			return c.JSON(http.StatusInternalServerError, dc.ApiError{
+				Name:    "session_create_fail",
+				Message: "Failed to create dApp connect session",
+			})
+		}
+
+		// Store generated session
+		err = sessionManager.StoreSession(session, mek)
+		if err != nil {
+			echoInstance.Logger.Error(err)
+			return c.JSON(http.StatusInternalServerError, dc.ApiError{
+				Name:    "session_create_fail",
+				Message: "Failed to create dApp connect session",
+			})
+		}
+
+		// Record the ID of the established session instead of the
+		// confirmation ID
+		auditEntry.SessionID = base64.StdEncoding.EncodeToString(session.ID().Bytes())
+		auditEntry.Decision = audit.DecisionApproved
+		auditEntry.Details = "addresses: " + strings.Join(userRespData.Addresses, ", ") +
+			"; networks: " + strings.Join(userRespData.Networks, ", ")
+
+		// Prepare response
+		resp := SessionConfirmPostResp{
+			Id:         base64.StdEncoding.EncodeToString(session.ID().Bytes()),
+			Expiration: session.Expiration().Unix(),
+			Addresses:  userRespData.Addresses,
+			Networks:   userRespData.Networks,
+		}
+		respJSON, err := json.Marshal(resp)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, dc.ApiError{
+				Name:    "confirm_auth_response_failed",
+				Message: err.Error(),
 			})
-			if err != nil {
-				return c.JSON(http.StatusInternalServerError, dc.ApiError{
-					Name:    "confirm_auth_response_failed",
-					Message: err.Error(),
-				})
-			}
-			c.Response().Header().Set("Server-Authorization", hawkRespHeader)
-
-			return c.JSON(http.StatusOK, resp)
+		}
+
+		// Add Hawk header to response
+		hawkRespHeader, err := hawkServer.Header(rawReqData, cred, &hawk.Option{
+			TimeStamp:   time.Now().Unix(),
+			Payload:     string(respJSON),
+			ContentType: "application/json",
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, dc.ApiError{
+				Name:    "confirm_auth_response_failed",
+				Message: err.Error(),
+			})
+		}
+		c.Response().Header().Set("Server-Authorization", hawkRespHeader)
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// offeredSessionNetworks gives the names of the given network profiles that
+// have one of the given requested genesis IDs, or the names of all the given
+// profiles if no genesis IDs were requested
+func offeredSessionNetworks(profiles []config.NetworkProfile, requestedGenesisIDs []string) (names []string) {
+	for _, profile := range profiles {
+		if len(requestedGenesisIDs) == 0 || slices.Contains(requestedGenesisIDs, profile.GenesisID) {
+			names = append(names, profile.Name)
 		}
 	}
+	return
 }
 
 func (store ConfirmCredentialStore) GetCredential(id string) (*hawk.Credential, error) {
-	mek, err := store.config.WalletSession.GetMasterKey()
+	mek, err := dc.RequestFileKey(store.config.EchoContext, store.config.WalletSession)
 	if err != nil {
 		return nil, err
 	}
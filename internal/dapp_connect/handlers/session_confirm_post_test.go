@@ -5,7 +5,6 @@ import (
 	"crypto/ecdh"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -108,11 +107,12 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve session connection")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"dapp":{"name":"foo"}}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["MainNet","TestNet","BetaNet","LocalNet"]}`))
 			By("Wallet user: Approving session connection")
 			dcService.WailsApp.Event.Emit(
 				handlers.SessionConfirmRespEventName,
-				`{"code":"`+testConfirm.Code()+`","addrs":["account 1","account 2"]}`,
+				`{"request_id":"`+requestId+`","code":"`+testConfirm.Code()+`","addrs":["account 1","account 2"],"networks":["TestNet"]}`,
 			)
 		})
 
@@ -125,6 +125,7 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		Expect(respData.Id).To(HaveLen(44), "Session ID is within response")
 		Expect(respData.Expiration).To(BeNumerically(">", time.Now().Unix()),
 			"Expiry is within response")
+		Expect(respData.Networks).To(Equal([]string{"TestNet"}), "Bound networks are within response")
 	})
 
 	It("fails when attempting to confirm a session again", func() {
@@ -181,11 +182,12 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve session connection")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"dapp":{"name":"foo"}}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["MainNet","TestNet","BetaNet","LocalNet"]}`))
 			By("Wallet user: Approving session connection")
 			dcService.WailsApp.Event.Emit(
 				handlers.SessionConfirmRespEventName,
-				`{"code":"`+testConfirm.Code()+`","addrs":["account 1","account 2"]}`,
+				`{"request_id":"`+requestId+`","code":"`+testConfirm.Code()+`","addrs":["account 1","account 2"],"networks":["TestNet"]}`,
 			)
 		})
 
@@ -506,7 +508,8 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve session connection")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"dapp":{"name":"foo"}}`))
+			_, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["MainNet","TestNet","BetaNet","LocalNet"]}`))
 			By("Wallet user: Not responding...")
 		})
 
@@ -576,9 +579,10 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve session connection")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"dapp":{"name":"foo"}}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["MainNet","TestNet","BetaNet","LocalNet"]}`))
 			By("Wallet user: Approving session connection")
-			dcService.WailsApp.Event.Emit(handlers.SessionConfirmRespEventName, `{"code":"","addrs":[]}`)
+			dcService.WailsApp.Event.Emit(handlers.SessionConfirmRespEventName, `{"request_id":"`+requestId+`","code":"","addrs":[]}`)
 		})
 
 		// Wait for request to complete before trying to parse & check the response
@@ -647,11 +651,12 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve session connection")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"dapp":{"name":"foo"}}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["MainNet","TestNet","BetaNet","LocalNet"]}`))
 			By("Wallet user: Approving session connection")
 			dcService.WailsApp.Event.Emit(
 				handlers.SessionConfirmRespEventName,
-				`{"code":"0000","addrs":["account 1","account 2"]}`,
+				`{"request_id":"`+requestId+`","code":"0000","addrs":["account 1","account 2"],"networks":["TestNet"]}`,
 			)
 		})
 
@@ -663,6 +668,149 @@ var _ = Describe("POST /session/confirm", Ordered, func() {
 		json.Unmarshal(respBody, &respData)
 		Expect(respData.Name).To(Equal("wrong_confirm_code"))
 	})
+
+	It("fails when user chooses a network that was not requested", func() {
+		By("Creating and storing a session confirmation")
+		confirm, err := sessionManager.GenerateConfirmation(dappPk)
+		Expect(err).NotTo(HaveOccurred())
+		mek, err := kmdService.Session().GetMasterKey()
+		Expect(err).NotTo(HaveOccurred())
+		sessionManager.StoreConfirmKey(confirm.Key(), mek)
+		token, err := confirm.GenerateTokenString()
+		Expect(err).NotTo(HaveOccurred())
+
+		var reqBody = `{"token":"` + token + `","dapp":{"name":"foo"},"networks":["testnet-v1.0"]}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			By("Creating Hawk request header")
+			confirmSharedKey, err := confirm.SharedKey()
+			Expect(err).NotTo(HaveOccurred())
+			nonce, err := hawk.Nonce(4) // Generate nonce that is 4 bytes long
+			Expect(err).NotTo(HaveOccurred())
+			hawkClient := hawk.NewClient(
+				&hawk.Credential{
+					ID:  base64.StdEncoding.EncodeToString(confirm.ID().Bytes()),
+					Key: base64.StdEncoding.EncodeToString(confirmSharedKey),
+					Alg: hawk.SHA256,
+				},
+				&hawk.Option{
+					TimeStamp:   time.Now().Unix(),
+					Payload:     reqBody,
+					ContentType: "application/json",
+					Nonce:       nonce,
+				},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			hawkHeader, err := hawkClient.Header("POST", uri)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Making an authenticated request to server with valid dApp data")
+			req, err := http.NewRequest("POST", uri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve session connection")
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(`{"dapp":{"name":"foo"},"networks":["TestNet"]}`))
+			By("Wallet user: Approving session connection for a network that was not offered")
+			dcService.WailsApp.Event.Emit(
+				handlers.SessionConfirmRespEventName,
+				`{"request_id":"`+requestId+`","code":"`+confirm.Code()+`","addrs":["account 1"],"networks":["MainNet"]}`,
+			)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("invalid_networks"))
+	})
+
+	It("fails without prompting the user when none of the requested networks are configured", func() {
+		By("Creating and storing a session confirmation")
+		confirm, err := sessionManager.GenerateConfirmation(dappPk)
+		Expect(err).NotTo(HaveOccurred())
+		mek, err := kmdService.Session().GetMasterKey()
+		Expect(err).NotTo(HaveOccurred())
+		sessionManager.StoreConfirmKey(confirm.Key(), mek)
+		token, err := confirm.GenerateTokenString()
+		Expect(err).NotTo(HaveOccurred())
+
+		var reqBody = `{"token":"` + token + `","dapp":{"name":"foo"},"networks":["unknown-v1"]}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			By("Creating Hawk request header")
+			confirmSharedKey, err := confirm.SharedKey()
+			Expect(err).NotTo(HaveOccurred())
+			nonce, err := hawk.Nonce(4) // Generate nonce that is 4 bytes long
+			Expect(err).NotTo(HaveOccurred())
+			hawkClient := hawk.NewClient(
+				&hawk.Credential{
+					ID:  base64.StdEncoding.EncodeToString(confirm.ID().Bytes()),
+					Key: base64.StdEncoding.EncodeToString(confirmSharedKey),
+					Alg: hawk.SHA256,
+				},
+				&hawk.Option{
+					TimeStamp:   time.Now().Unix(),
+					Payload:     reqBody,
+					ContentType: "application/json",
+					Nonce:       nonce,
+				},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			hawkHeader, err := hawkClient.Header("POST", uri)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Making an authenticated request to server with valid dApp data")
+			req, err := http.NewRequest("POST", uri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Fail if the user is prompted
+		dcService.WailsApp.Event.On(handlers.SessionConfirmPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not have been prompted")
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("unknown_networks"))
+	})
 })
 
 // CreateSessionConfirmPostReqHawkHeader creates a new Hawk authentication
@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"duckysigner/internal/audit"
 	dc "duckysigner/internal/dapp_connect"
 	mw "duckysigner/internal/dapp_connect/middleware"
 	"duckysigner/internal/dapp_connect/session"
@@ -22,9 +23,11 @@ func SessionEndGet(
 			EchoContext:  c,
 			EchoInstance: echoInstance,
 			CredentialStore: mw.SessionCredentialStore{
+				EchoContext:    c,
 				WalletSession:  walletSession,
 				SessionManager: sessionManager,
 			},
+			WalletSession: walletSession,
 		}
 		// Hawk authentication
 		hawkServer, cred, apiErr := mw.HawkAuth(nil, &hawkOpt)
@@ -35,9 +38,17 @@ func SessionEndGet(
 			return mw.HawkRespJSON(http.StatusUnauthorized, apiErr, hawkServer, cred, &hawkOpt)
 		}
 
+		auditEntry := audit.Entry{
+			Event:     audit.EventSessionEnd,
+			DappID:    sessionDappId(c, walletSession, sessionManager, cred.ID),
+			SessionID: cred.ID,
+		}
+		defer func() { dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger) }()
+
 		// Retrieve the master encryption key of the currently opened wallet
-		mek, err := walletSession.GetMasterKey()
+		mek, err := dc.RequestFileKey(c, walletSession)
 		if err != nil {
+			auditEntry.Decision = audit.DecisionFailed
 			return c.JSON(http.StatusInternalServerError, dc.ApiError{
 				Name:    confirmCreateFailName,
 				Message: confirmCreateFailMsg,
@@ -48,6 +59,8 @@ func SessionEndGet(
 		err = sessionManager.RemoveSession(cred.ID, mek)
 		if err != nil {
 			echoInstance.Logger.Error(err.Error())
+			auditEntry.Decision = audit.DecisionFailed
+			auditEntry.Details = err.Error()
 		}
 
 		return c.JSON(http.StatusOK, "OK")
@@ -40,7 +40,7 @@ var _ = Describe("GET /session/end", Ordered, func() {
 		sessionManager = session.NewManager(curve, &session.SessionConfig{
 			DataDir: kmdService.Session().FilePath,
 		})
-		testSession, err = sessionManager.GenerateSession(dappPk, &dc.DappData{Name: "Foobar"}, nil)
+		testSession, err = sessionManager.GenerateSession(dappPk, &dc.DappData{Name: "Foobar"}, nil, nil)
 		Expect(err).NotTo(HaveOccurred())
 		mek, err := kmdService.Session().GetMasterKey()
 		Expect(err).NotTo(HaveOccurred())
@@ -6,6 +6,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"duckysigner/internal/audit"
 	dc "duckysigner/internal/dapp_connect"
 	"duckysigner/internal/dapp_connect/session"
 	"duckysigner/internal/tools"
@@ -76,6 +77,15 @@ func SessionInitPost(
 			return c.JSON(http.StatusBadRequest, dappIdApiErr)
 		}
 
+		// Initializing a session does not involve a decision, so the decision
+		// is only recorded if initialization fails
+		auditEntry := audit.Entry{
+			Event:    audit.EventSessionInit,
+			DappID:   reqData.DappId,
+			Decision: audit.DecisionFailed,
+		}
+		defer func() { dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger) }()
+
 		// Create confirmation
 		confirm, err := sessionManager.GenerateConfirmation(dappId)
 		if err != nil {
@@ -86,7 +96,7 @@ func SessionInitPost(
 		}
 
 		// Retrieve the master encryption key of the currently opened wallet
-		mek, err := walletSession.GetMasterKey()
+		mek, err := dc.RequestFileKey(c, walletSession)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, dc.ApiError{
 				Name:    confirmCreateFailName,
@@ -111,6 +121,9 @@ func SessionInitPost(
 			})
 		}
 
+		auditEntry.SessionID = base64.StdEncoding.EncodeToString(confirm.ID().Bytes())
+		auditEntry.Decision = ""
+
 		confirmShared, _ := confirm.SharedKey()
 		echoInstance.Logger.Debug("Confirmation shared key:", base64.StdEncoding.EncodeToString(confirmShared))
 
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
+	"github.com/labstack/echo/v4"
+
+	"duckysigner/internal/audit"
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
+	mw "duckysigner/internal/dapp_connect/middleware"
+	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/note_analyzer"
+	"duckysigner/internal/tools"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_group"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
+	"duckysigner/internal/wallet_session"
+)
+
+type (
+	// TransactionGroupSignPostReq is the request data for
+	// `POST /transaction/group/sign`
+	TransactionGroupSignPostReq struct {
+		// Unsigned transactions of the whole group, in the order of the group
+		Group []string `json:"group" validate:"required,min=1,max=16"`
+		// Unsigned transactions to be signed, which must appear in the group
+		// in the same order
+		Txns []string `json:"transactions" validate:"required,min=1,max=16"`
+	}
+
+	// TransactionGroupSignPostResp is the response data to a
+	// `POST /transaction/group/sign` request
+	TransactionGroupSignPostResp struct {
+		// Signed transactions, in the order they were given in the request
+		SignedTxns []string `json:"signed_transactions"`
+	}
+
+	// TxnGroupSignPromptEvtData is the data passed to the UI when prompting the
+	// user to sign the transactions of a group
+	TxnGroupSignPromptEvtData = approval.GroupPromptData
+)
+
+// TxnGroupSignPromptEventName is the name for the event for triggering the UI
+// to prompt the user to approve signing the transactions of a group
+const TxnGroupSignPromptEventName string = approval.GroupPromptEventName
+
+// TxnGroupSignRespEventName is the name for the event that the UI uses to
+// forward the user's response to the transaction group signing request
+const TxnGroupSignRespEventName string = approval.GroupRespEventName
+
+func TransactionGroupSignPost(
+	echoInstance *echo.Echo,
+	approver approval.Approver,
+	riskChecker *txn_risk.Checker,
+	simulator *txn_simulator.Simulator,
+	walletSession *wallet_session.WalletSession,
+	sessionManager *session.Manager,
+	ecdhCurve tools.ECDHCurve,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if walletSession == nil {
+			apiErr := dc.ApiError{
+				Name:    "no_wallet_session",
+				Message: "There is currently no valid wallet session. Log in to a wallet and try again.",
+			}
+			return c.JSON(http.StatusInternalServerError, apiErr)
+		}
+
+		// Read request data
+		rawReqBody, err := dc.GetRawRequestBody(c.Request())
+		if err != nil {
+			apiErr := dc.ApiError{Name: "bad_request", Message: err.Error()}
+			return c.JSON(http.StatusBadRequest, apiErr)
+		}
+		// Parse request data
+		reqData := new(TransactionGroupSignPostReq)
+		if err := json.Unmarshal(rawReqBody, &reqData); err != nil {
+			apiErr := dc.ApiError{Name: "bad_request", Message: err.Error()}
+			return c.JSON(http.StatusBadRequest, apiErr)
+		}
+
+		// Hawk authentication
+		hawkOpt := mw.HawkOptions{
+			EchoContext:  c,
+			EchoInstance: echoInstance,
+			CredentialStore: mw.SessionCredentialStore{
+				EchoContext:    c,
+				WalletSession:  walletSession,
+				SessionManager: sessionManager,
+			},
+			WalletSession: walletSession,
+		}
+		hawkServer, cred, apiErr := mw.HawkAuth(rawReqBody, &hawkOpt)
+		if apiErr != nil {
+			// Set WWW-Authenticate header
+			c.Response().Header().Set("WWW-Authenticate", "Hawk")
+			// Respond with 401 Unauthorized
+			return c.JSON(http.StatusUnauthorized, apiErr)
+		}
+
+		dcSession := getDcSession(c, walletSession, sessionManager, cred.ID)
+		dappId, dappData := dappOf(dcSession)
+		auditEntry := audit.Entry{
+			Event:     audit.EventTxnGroupSign,
+			DappID:    dappId,
+			SessionID: cred.ID,
+			Decision:  audit.DecisionFailed,
+		}
+		// The entry is recorded before responding with the signed
+		// transactions, or else once the request is handled
+		auditRecorded := false
+		defer func() {
+			if !auditRecorded {
+				dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger)
+			}
+		}()
+
+		// Sessions established before sessions were bound to networks cannot
+		// be used to sign, so the dApp has to connect again
+		if dcSession != nil {
+			if err := dcSession.CheckNetworks(); err != nil {
+				auditEntry.Details = "session not bound to any network"
+				apiErr := dc.ApiError{Name: "session_not_bound", Message: err.Error()}
+				return mw.HawkRespJSON(http.StatusUnauthorized, apiErr, hawkServer, cred, &hawkOpt)
+			}
+		}
+
+		// Validate request data
+		if err := c.Validate(reqData); err != nil {
+			auditEntry.Details = "invalid request"
+			apiErr := dc.ApiError{Name: "validation_error", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		// Decode transaction data
+		group := make([]algoTypes.Transaction, len(reqData.Group))
+		for i, txnB64 := range reqData.Group {
+			group[i], err = approval.TxnData{Txn: txnB64}.DecodeTxn()
+			if err != nil {
+				auditEntry.Details = "invalid transaction"
+				apiErr := dc.ApiError{
+					Name:    "invalid_txn",
+					Message: fmt.Sprintf("Group transaction %d: %s", i, err),
+				}
+				return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+			}
+		}
+		txns := make([]algoTypes.Transaction, len(reqData.Txns))
+		txIDs := make([]string, len(reqData.Txns))
+		for i, txnB64 := range reqData.Txns {
+			txns[i], err = approval.TxnData{Txn: txnB64}.DecodeTxn()
+			if err != nil {
+				auditEntry.Details = "invalid transaction"
+				apiErr := dc.ApiError{
+					Name:    "invalid_txn",
+					Message: fmt.Sprintf("Transaction %d: %s", i, err),
+				}
+				return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+			}
+			txIDs[i] = crypto.GetTxID(txns[i])
+		}
+
+		auditEntry.TxnID = strings.Join(txIDs, ",")
+
+		// Check if the group ID is for the transactions given as the group and
+		// if the transactions to be signed are in the group
+		if err := txn_group.Verify(group); err != nil {
+			auditEntry.Details = "invalid transaction group"
+			apiErr := dc.ApiError{Name: "invalid_group", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		}
+		signIndexes, err := txn_group.Locate(group, txns)
+		if err != nil {
+			auditEntry.Details = "transactions not in group"
+			apiErr := dc.ApiError{Name: "invalid_group", Message: err.Error()}
+			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		// Check the transactions to be signed before bothering the user with
+		// them
+		checker := txnChecker{
+			echoInstance:  echoInstance,
+			riskChecker:   riskChecker,
+			simulator:     simulator,
+			walletSession: walletSession,
+			auditEntry:    &auditEntry,
+		}
+		checked, fail := checker.check(c.Request().Context(), cred.ID, dcSession, dappId, dappData, &txnsToCheck{
+			Txns:         txns,
+			GroupIndexes: signIndexes,
+			Group:        group,
+		})
+		if fail != nil {
+			return mw.HawkRespJSON(fail.Status, fail.ApiErr, hawkServer, cred, &hawkOpt)
+		}
+		risks := checked.Risks
+
+		// What the transactions send no longer counts toward the spending
+		// limits if the transactions are not signed
+		spendConfirmed := false
+		defer func() {
+			if !spendConfirmed {
+				checker.releaseSpend(checked.Reservation)
+			}
+		}()
+
+		if !checked.AllowedByPolicy {
+			// Summarize every transaction in the group so the user can see the
+			// whole group, analyze their notes, label the addresses so the user
+			// can tell which ones are unknown, and decode the ABI methods being
+			// called. The prompt can still be shown without the labels or the
+			// decoded methods.
+			summaries := make([]*txn_decoder.Summary, len(group))
+			noteAnalyses := make([]*note_analyzer.Analysis, len(group))
+			for i, txn := range group {
+				if len(txn.Note) > 0 {
+					noteAnalyses[i] = note_analyzer.Analyze(txn.Note)
+				}
+				summaries[i] = txn_decoder.Decode(txn)
+				if err := summaries[i].ResolveAddresses(walletSession); err != nil {
+					echoInstance.Logger.Error(err)
+				}
+				if err := summaries[i].DecodeMethod(walletSession); err != nil {
+					echoInstance.Logger.Error(err)
+				}
+			}
+
+			// Add up what the whole group does to the wallet's accounts, so
+			// the user can approve the group as a whole
+			netFlows, err := txn_group.NetFlows(group, walletSession.CheckAddrInWallet)
+			if err != nil {
+				echoInstance.Logger.Error(err)
+				apiErr := dc.ApiError{
+					Name:    "txn_group_analysis_fail",
+					Message: "Failed to work out what the transaction group does",
+				}
+				return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+			}
+
+			// Ask user to approve the group and wait for user response...
+			ctx, cancel := context.WithTimeout(c.Request().Context(), sessionManager.ApprovalTimeout())
+			defer cancel()
+			promptData := TxnGroupSignPromptEvtData{
+				Group:        reqData.Group,
+				SignIndexes:  signIndexes,
+				Summaries:    summaries,
+				NoteAnalyses: noteAnalyses,
+				NetFlows:     netFlows,
+				Risks:        risks,
+				Simulation:   checked.Simulation,
+			}
+			var userRespData *approval.TxnResponse
+			dc.WithoutDataFiles(c, walletSession, func() {
+				userRespData, err = approver.ApproveGroup(ctx, promptData)
+			})
+			if errors.Is(err, approval.ErrTimeout) { // Time ran out
+				echoInstance.Logger.Info("Ran out of time waiting for user response")
+				auditEntry.Decision = audit.DecisionTimeout
+				apiErr := dc.ApiError{Name: "txn_sign_timeout", Message: "User did not respond"}
+				return mw.HawkRespJSON(http.StatusRequestTimeout, apiErr, hawkServer, cred, &hawkOpt)
+			}
+			if err != nil {
+				echoInstance.Logger.Error(err)
+				apiErr := dc.ApiError{
+					Name:    "prompt_user_fail",
+					Message: "Failed to prompt the user",
+				}
+				return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+			}
+
+			echoInstance.Logger.Debug("Received transaction group approval user response:", *userRespData)
+
+			// Respond with error if user rejects
+			if !userRespData.Approved {
+				auditEntry.Decision = audit.DecisionRejected
+				apiErr := dc.ApiError{
+					Name:    "txn_sign_rejected",
+					Message: "User rejected the transaction group",
+				}
+				return mw.HawkRespJSON(http.StatusForbidden, apiErr, hawkServer, cred, &hawkOpt)
+			}
+
+			// Respond with error if user did not explicitly acknowledge every
+			// risk
+			if unacked := txn_risk.Unacknowledged(risks, userRespData.AcknowledgedRisks); len(unacked) > 0 {
+				auditEntry.Decision = audit.DecisionRejected
+				auditEntry.Details = fmt.Sprint("unacknowledged risks: ", unacked)
+				apiErr := dc.ApiError{
+					Name:    "txn_risk_not_acknowledged",
+					Message: fmt.Sprint("User did not acknowledge the risks of the transaction group: ", unacked),
+				}
+				return mw.HawkRespJSON(http.StatusForbidden, apiErr, hawkServer, cred, &hawkOpt)
+			}
+
+			if len(risks) > 0 {
+				auditEntry.Details = fmt.Sprint("acknowledged risks: ", txn_risk.Codes(risks))
+			}
+		}
+
+		resp := TransactionGroupSignPostResp{SignedTxns: make([]string, len(reqData.Txns))}
+		for i, txnB64 := range reqData.Txns {
+			resp.SignedTxns[i], err = walletSession.SignTransaction(txnB64, "")
+			if err != nil {
+				echoInstance.Logger.Error(err)
+				apiErr := dc.ApiError{
+					Name:    "txn_sign_fail",
+					Message: "Failed to sign transaction group",
+				}
+				return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+			}
+		}
+
+		// Count what the signed transactions send toward the spending
+		// limits. The signed transactions are not given to the dApp if they
+		// cannot be counted.
+		if err := walletSession.ConfirmSpend(checked.Reservation); err != nil {
+			echoInstance.Logger.Error(err)
+			auditEntry.Details = "could not record what was sent toward the spending limits"
+			apiErr := dc.ApiError{
+				Name:    "spend_record_fail",
+				Message: "Failed to record what the transactions send toward the spending limits",
+			}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+		spendConfirmed = true
+
+		auditEntry.Decision = audit.DecisionApproved
+
+		// The dApp is not given what was signed unless signing it is in the
+		// audit log
+		auditRecorded = true
+		if err := dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger); err != nil {
+			apiErr := dc.ApiError{
+				Name:    "audit_record_fail",
+				Message: "Failed to record signing the transaction group in the audit log",
+			}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		return mw.HawkRespJSON(http.StatusOK, resp, hawkServer, cred, &hawkOpt)
+	}
+}
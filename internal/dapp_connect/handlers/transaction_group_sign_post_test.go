@@ -0,0 +1,275 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/handlers"
+	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/note_analyzer"
+	"duckysigner/internal/txn_group"
+)
+
+const txnGroupSignPostUri = "http://localhost:" + transactionGroupSignPostPort + "/transaction/group/sign"
+
+var _ = Describe("POST /transaction/group/sign", Ordered, func() {
+	// Pre-generated keys for dApp connect session
+	const (
+		dappIdB64     = "c+2pz3JaUkIEMnbi1vuv7RWdGpfyiv6O3xaYbYbieAg="
+		sessionKeyB64 = "OA7vIBYGze5Vapw/qO3iPr+F9nRnaxsWSVnViTEZ1Ag="
+		// An account that is not in the wallet
+		otherAddr = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+	)
+	var testSession *session.Session
+	var acctAddr string
+	// Group where the wallet account pays 1 Algo and the fees of both
+	// transactions to the other account, which pays 0.5 Algos back
+	var testGroup []algoTypes.Transaction
+
+	// makePayment creates a payment transaction for TestNet
+	makePayment := func(from, to string, amount uint64, fee algoTypes.MicroAlgos) algoTypes.Transaction {
+		genesisHash, err := base64.StdEncoding.DecodeString("SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI=")
+		Expect(err).NotTo(HaveOccurred())
+		txn, err := transaction.MakePaymentTxn(from, to, amount, nil, "", algoTypes.SuggestedParams{
+			Fee:             fee,
+			GenesisID:       "testnet-v1.0",
+			GenesisHash:     genesisHash,
+			FirstRoundValid: 10000,
+			LastRoundValid:  11000,
+			FlatFee:         true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return txn
+	}
+
+	// encodeTxns encodes the given transactions into the JSON array of
+	// Base64-encoded transactions for the request body
+	encodeTxns := func(txns ...algoTypes.Transaction) string {
+		txnsB64 := make([]string, len(txns))
+		for i, txn := range txns {
+			txnsB64[i] = base64.StdEncoding.EncodeToString(msgpack.Encode(txn))
+		}
+		txnsJSON, err := json.Marshal(txnsB64)
+		Expect(err).NotTo(HaveOccurred())
+		return string(txnsJSON)
+	}
+
+	// postTxnGroupSign makes an authenticated request with the given body and
+	// gives the response body through the returned channel
+	postTxnGroupSign := func(reqBody string) <-chan []byte {
+		respSignal := make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := createPostReqHawkHeader(testSession, txnGroupSignPostUri, reqBody)
+
+			By("Making an authenticated request to server")
+			req, err := http.NewRequest("POST", txnGroupSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+		return respSignal
+	}
+
+	BeforeAll(func() {
+		var err error
+
+		By("Setting up dApp connect server")
+		setUpDcService(transactionGroupSignPostPort, sessionKeyB64)
+
+		By("Generating an account in the wallet")
+		acctAddr, err = kmdService.Session().GenerateAccount()
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Creating a session")
+		dappIdBytes, err := base64.StdEncoding.DecodeString(dappIdB64)
+		Expect(err).NotTo(HaveOccurred())
+		dappPk, err := curve.NewPublicKey(dappIdBytes)
+		Expect(err).NotTo(HaveOccurred())
+		sessionManager := session.NewManager(curve, &session.SessionConfig{
+			DataDir: kmdService.Session().FilePath,
+		})
+		testSession, err = sessionManager.GenerateSession(dappPk, &dc.DappData{Name: "Foobar"}, nil, []string{"TestNet"})
+		Expect(err).NotTo(HaveOccurred())
+		mek, err := kmdService.Session().GetMasterKey()
+		Expect(err).NotTo(HaveOccurred())
+		err = sessionManager.StoreSession(testSession, mek)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Creating a test transaction group")
+		testGroup = []algoTypes.Transaction{
+			makePayment(acctAddr, otherAddr, 1_000_000, 2000),
+			makePayment(otherAddr, acctAddr, 500_000, 0),
+		}
+		gid, err := crypto.ComputeGroupID(testGroup)
+		Expect(err).NotTo(HaveOccurred())
+		for i := range testGroup {
+			testGroup[i].Group = gid
+		}
+	})
+
+	AfterEach(func() {
+		dcService.WailsApp.Event.Reset()
+	})
+
+	It("shows the net effect of the group and signs the transactions", func() {
+		reqBody := `{"group":` + encodeTxns(testGroup...) + `,"transactions":` + encodeTxns(testGroup[0]) + `}`
+		respSignal := postTxnGroupSign(reqBody)
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnGroupSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction group")
+			requestId, promptDataJSON := splitPromptData(e.Data)
+			var promptData handlers.TxnGroupSignPromptEvtData
+			Expect(json.Unmarshal([]byte(promptDataJSON), &promptData)).To(Succeed())
+			Expect(promptData.SignIndexes).To(Equal([]int{0}))
+			Expect(promptData.Summaries).To(HaveLen(2))
+			Expect(promptData.NetFlows).To(Equal([]txn_group.Flow{
+				{Account: acctAddr, AssetID: 0, Sent: 1_002_000, Received: 500_000},
+			}))
+			By("Wallet user: Approving transaction group")
+			dcService.WailsApp.Event.Emit(handlers.TxnGroupSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with signed transaction data")
+		var respData handlers.TransactionGroupSignPostResp
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.SignedTxns).To(HaveLen(1))
+		stxnBytes, err := base64.StdEncoding.DecodeString(respData.SignedTxns[0])
+		Expect(err).NotTo(HaveOccurred())
+		var stxn algoTypes.SignedTxn
+		Expect(msgpack.Decode(stxnBytes, &stxn)).To(Succeed())
+		Expect(stxn.Txn.Group).To(Equal(testGroup[0].Group))
+	})
+
+	It("fails if the user rejects the group", func() {
+		reqBody := `{"group":` + encodeTxns(testGroup...) + `,"transactions":` + encodeTxns(testGroup[0]) + `}`
+		respSignal := postTxnGroupSign(reqBody)
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnGroupSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			requestId, _ := splitPromptData(e.Data)
+			By("Wallet user: Rejecting transaction group")
+			dcService.WailsApp.Event.Emit(handlers.TxnGroupSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
+		})
+
+		respBody := <-respSignal
+
+		By("Checking if server responds with error")
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("txn_sign_rejected"))
+	})
+
+	It("sends what was found in the notes of the group's transactions with the prompt", func() {
+		By("Creating a group with a link in the note of a transaction not to be signed")
+		noteGroup := []algoTypes.Transaction{
+			makePayment(acctAddr, otherAddr, 1_000_000, 2000),
+			makePayment(otherAddr, acctAddr, 500_000, 0),
+		}
+		noteGroup[1].Note = []byte("Claim your reward at https://example.com")
+		gid, err := crypto.ComputeGroupID(noteGroup)
+		Expect(err).NotTo(HaveOccurred())
+		for i := range noteGroup {
+			noteGroup[i].Group = gid
+		}
+		reqBody := `{"group":` + encodeTxns(noteGroup...) + `,"transactions":` + encodeTxns(noteGroup[0]) + `}`
+		respSignal := postTxnGroupSign(reqBody)
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnGroupSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction group with the note analyses")
+			requestId, promptDataJSON := splitPromptData(e.Data)
+			var promptData handlers.TxnGroupSignPromptEvtData
+			Expect(json.Unmarshal([]byte(promptDataJSON), &promptData)).To(Succeed())
+			Expect(promptData.NoteAnalyses).To(Equal([]*note_analyzer.Analysis{
+				nil,
+				note_analyzer.Analyze(noteGroup[1].Note),
+			}))
+			By("Wallet user: Rejecting transaction group")
+			dcService.WailsApp.Event.Emit(handlers.TxnGroupSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
+		})
+
+		respBody := <-respSignal
+
+		By("Checking if server responds with error")
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("txn_sign_rejected"))
+	})
+
+	It("fails if the group ID does not match the transactions in the group", func() {
+		// Swap a transaction for one that is not in the original group
+		tamperedGroup := []algoTypes.Transaction{testGroup[0], makePayment(otherAddr, acctAddr, 1, 0)}
+		tamperedGroup[1].Group = testGroup[0].Group
+		reqBody := `{"group":` + encodeTxns(tamperedGroup...) + `,"transactions":` + encodeTxns(testGroup[0]) + `}`
+
+		dcService.WailsApp.Event.On(handlers.TxnGroupSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not be prompted")
+		})
+
+		respBody := <-postTxnGroupSign(reqBody)
+
+		By("Checking if server responds with error")
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("invalid_group"))
+	})
+
+	It("fails if a transaction to be signed is not in the group", func() {
+		notInGroup := makePayment(acctAddr, otherAddr, 2_000_000, 1000)
+		notInGroup.Group = testGroup[0].Group
+		reqBody := `{"group":` + encodeTxns(testGroup...) + `,"transactions":` + encodeTxns(notInGroup) + `}`
+
+		dcService.WailsApp.Event.On(handlers.TxnGroupSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not be prompted")
+		})
+
+		respBody := <-postTxnGroupSign(reqBody)
+
+		By("Checking if server responds with error")
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("invalid_group"))
+	})
+
+	It("fails if the sender of a transaction to be signed is not in the wallet", func() {
+		reqBody := `{"group":` + encodeTxns(testGroup...) + `,"transactions":` + encodeTxns(testGroup[1]) + `}`
+
+		respBody := <-postTxnGroupSign(reqBody)
+
+		By("Checking if server responds with error")
+		var respData dc.ApiError
+		Expect(json.Unmarshal(respBody, &respData)).To(Succeed())
+		Expect(respData.Name).To(Equal("invalid_sender"))
+	})
+})
@@ -1,20 +1,26 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"time"
 
-	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
 	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
 	"github.com/labstack/echo/v4"
-	"github.com/wailsapp/wails/v3/pkg/application"
 
+	"duckysigner/internal/audit"
 	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
 	mw "duckysigner/internal/dapp_connect/middleware"
 	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/note_analyzer"
 	"duckysigner/internal/tools"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
 	"duckysigner/internal/wallet_session"
 )
 
@@ -36,29 +42,26 @@ type (
 
 	// TxnSignPromptEvtData is the data passed to the UI when prompting the user to
 	// sign the transaction
-	TxnSignPromptEvtData struct {
-		TxnData TransactionSignPostReq `json:"data"`
-	}
+	TxnSignPromptEvtData = approval.TxnPromptData
 
 	// TxnSignRespEvtData is the data the UI sends when the user responds to the
 	// prompt to sign the transaction
-	TxnSignRespEvtData struct {
-		// If the transaction has been approved
-		Approved bool `json:"approved"`
-	}
+	TxnSignRespEvtData = approval.TxnResponse
 )
 
 // TxnSignPromptEventName is the name for the event for triggering the
 // UI to prompt the user to approve the signing a transaction
-const TxnSignPromptEventName string = "txn_sign_prompt"
+const TxnSignPromptEventName string = approval.TxnPromptEventName
 
 // TxnSignRespEventName is the name for the event that the UI uses to
 // forward the user's response to the transaction signing request
-const TxnSignRespEventName string = "txn_sign_response"
+const TxnSignRespEventName string = approval.TxnRespEventName
 
 func TransactionSignPost(
 	echoInstance *echo.Echo,
-	wailsApp *application.App,
+	approver approval.Approver,
+	riskChecker *txn_risk.Checker,
+	simulator *txn_simulator.Simulator,
 	walletSession *wallet_session.WalletSession,
 	sessionManager *session.Manager,
 	ecdhCurve tools.ECDHCurve,
@@ -90,9 +93,11 @@ func TransactionSignPost(
 			EchoContext:  c,
 			EchoInstance: echoInstance,
 			CredentialStore: mw.SessionCredentialStore{
+				EchoContext:    c,
 				WalletSession:  walletSession,
 				SessionManager: sessionManager,
 			},
+			WalletSession: walletSession,
 		}
 		hawkServer, cred, apiErr := mw.HawkAuth(rawReqBody, &hawkOpt)
 		if apiErr != nil {
@@ -102,104 +107,128 @@ func TransactionSignPost(
 			return c.JSON(http.StatusUnauthorized, apiErr)
 		}
 
+		dcSession := getDcSession(c, walletSession, sessionManager, cred.ID)
+		dappId, dappData := dappOf(dcSession)
+		auditEntry := audit.Entry{
+			Event:     audit.EventTxnSign,
+			DappID:    dappId,
+			SessionID: cred.ID,
+			Decision:  audit.DecisionFailed,
+		}
+		// The entry is recorded before responding with a signed transaction, or
+		// else once the request is handled
+		auditRecorded := false
+		defer func() {
+			if !auditRecorded {
+				dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger)
+			}
+		}()
+
+		// Sessions established before sessions were bound to networks cannot
+		// be used to sign, so the dApp has to connect again
+		if dcSession != nil {
+			if err := dcSession.CheckNetworks(); err != nil {
+				auditEntry.Details = "session not bound to any network"
+				apiErr := dc.ApiError{Name: "session_not_bound", Message: err.Error()}
+				return mw.HawkRespJSON(http.StatusUnauthorized, apiErr, hawkServer, cred, &hawkOpt)
+			}
+		}
+
 		// Validate request data
 		if err := c.Validate(reqData); err != nil {
+			auditEntry.Details = "invalid request"
 			apiErr := dc.ApiError{Name: "validation_error", Message: err.Error()}
 			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
 		}
 
-		// Decode and check transaction data
-		var unsignedTxn algoTypes.Transaction
-		unsignedTxnBytes, err := base64.StdEncoding.DecodeString(reqData.Txn)
-		if err != nil {
-			apiErr := dc.ApiError{Name: "invalid_txn", Message: err.Error()}
-			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
-		}
-		err = msgpack.Decode(unsignedTxnBytes, &unsignedTxn)
+		// Decode transaction data
+		txnData := approval.TxnData{Txn: reqData.Txn, Signer: reqData.Signer}
+		unsignedTxn, err := txnData.DecodeTxn()
 		if err != nil {
+			auditEntry.Details = "invalid transaction"
 			apiErr := dc.ApiError{Name: "invalid_txn", Message: err.Error()}
 			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
 		}
 
-		// Check if sender exists in wallet
-		senderInWallet, err := walletSession.CheckAddrInWallet(unsignedTxn.Sender.String())
-		if err != nil {
-			echoInstance.Logger.Error(err)
-			apiErr := dc.ApiError{Name: "invalid_sender", Message: "Failed to parse sender address"}
-			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		auditEntry.TxnID = crypto.GetTxID(unsignedTxn)
+		auditEntry.TxnType = string(unsignedTxn.Type)
+
+		// Check the transaction before bothering the user with it
+		checker := txnChecker{
+			echoInstance:  echoInstance,
+			riskChecker:   riskChecker,
+			simulator:     simulator,
+			walletSession: walletSession,
+			auditEntry:    &auditEntry,
 		}
-		if !senderInWallet {
-			apiErr := dc.ApiError{Name: "invalid_sender", Message: "Sender account not in wallet"}
-			return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+		checked, fail := checker.check(c.Request().Context(), cred.ID, dcSession, dappId, dappData, &txnsToCheck{
+			Txns:   []algoTypes.Transaction{unsignedTxn},
+			Group:  []algoTypes.Transaction{unsignedTxn},
+			Signer: reqData.Signer,
+		})
+		if fail != nil {
+			return mw.HawkRespJSON(fail.Status, fail.ApiErr, hawkServer, cred, &hawkOpt)
 		}
+		risks := checked.Risks
 
-		// Check if signer exists in wallet
-		if reqData.Signer != "" {
-			signerInWallet, err := walletSession.CheckAddrInWallet(reqData.Signer)
-			if err != nil {
+		// What the transaction sends no longer counts toward the spending
+		// limits if the transaction is not signed
+		spendConfirmed := false
+		defer func() {
+			if !spendConfirmed {
+				checker.releaseSpend(checked.Reservation)
+			}
+		}()
+
+		if !checked.AllowedByPolicy {
+			// Label the addresses in the transaction summary so the user can
+			// tell which ones are unknown, and decode the ABI method being
+			// called with the app spec of the application, if any. The prompt
+			// can still be shown without the labels or the decoded method.
+			summary := txn_decoder.Decode(unsignedTxn)
+			if err := summary.ResolveAddresses(walletSession); err != nil {
 				echoInstance.Logger.Error(err)
-				apiErr := dc.ApiError{
-					Name:    "invalid_signer",
-					Message: "Failed to parse signer address",
-				}
-				return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
 			}
-			if !signerInWallet {
-				apiErr := dc.ApiError{Name: "invalid_signer", Message: "Signer account not in wallet"}
-				return mw.HawkRespJSON(http.StatusBadRequest, apiErr, hawkServer, cred, &hawkOpt)
+			if err := summary.DecodeMethod(walletSession); err != nil {
+				echoInstance.Logger.Error(err)
 			}
-		}
 
-		// Prompt user to approve transaction
-		promptDataJSON, err := json.Marshal(TxnSignPromptEvtData{TxnData: *reqData})
-		if err != nil {
-			echoInstance.Logger.Error(err)
-			apiErr := dc.ApiError{
-				Name:    "prompt_user_fail",
-				Message: "Failed to prompt the user",
+			// Ask user to approve transaction and wait for user response...
+			ctx, cancel := context.WithTimeout(c.Request().Context(), sessionManager.ApprovalTimeout())
+			defer cancel()
+			promptData := TxnSignPromptEvtData{
+				TxnData:    txnData,
+				Summary:    summary,
+				Risks:      risks,
+				Simulation: checked.Simulation,
+			}
+			if len(unsignedTxn.Note) > 0 {
+				promptData.NoteAnalysis = note_analyzer.Analyze(unsignedTxn.Note)
+			}
+			var userRespData *TxnSignRespEvtData
+			dc.WithoutDataFiles(c, walletSession, func() {
+				userRespData, err = approver.ApproveTransaction(ctx, promptData)
+			})
+			if errors.Is(err, approval.ErrTimeout) { // Time ran out
+				echoInstance.Logger.Info("Ran out of time waiting for user response")
+				auditEntry.Decision = audit.DecisionTimeout
+				apiErr := dc.ApiError{Name: "txn_sign_timeout", Message: "User did not respond"}
+				return mw.HawkRespJSON(http.StatusRequestTimeout, apiErr, hawkServer, cred, &hawkOpt)
 			}
-			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
-		}
-		userResp, err := dc.PromptUIOnce(
-			string(promptDataJSON),
-			TxnSignPromptEventName,
-			TxnSignRespEventName,
-			wailsApp,
-			echoInstance.Logger,
-		)
-		// Remove listener for UI response event when the server request ends,
-		// which is definitely after the UI response event data is received from
-		// the channel
-		defer wailsApp.Event.Off(SessionConfirmRespEventName)
-		if err != nil {
-			echoInstance.Logger.Error(err)
-			apiErr := dc.ApiError{Name: "unexpected_fail", Message: err.Error()}
-			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
-		}
-
-		// Wait for user response...
-		select {
-		case <-time.After(sessionManager.ApprovalTimeout()): // Time ran out
-			echoInstance.Logger.Info("Ran out of time waiting for user response")
-			apiErr := dc.ApiError{Name: "txn_sign_timeout", Message: "User did not respond"}
-			return mw.HawkRespJSON(http.StatusRequestTimeout, apiErr, hawkServer, cred, &hawkOpt)
-		case dataJSON := <-userResp: // Got user's response
-			echoInstance.Logger.Debug("Received transaction approval user response:", dataJSON)
-
-			var userRespData TxnSignRespEvtData
-
-			err := json.Unmarshal([]byte(dataJSON), &userRespData)
 			if err != nil {
 				echoInstance.Logger.Error(err)
 				apiErr := dc.ApiError{
-					Name:    "user_response_fail",
-					Message: "Failed to process user response",
+					Name:    "prompt_user_fail",
+					Message: "Failed to prompt the user",
 				}
 				return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
 			}
 
+			echoInstance.Logger.Debug("Received transaction approval user response:", *userRespData)
+
 			// Respond with error if user rejects
 			if !userRespData.Approved {
+				auditEntry.Decision = audit.DecisionRejected
 				apiErr := dc.ApiError{
 					Name:    "txn_sign_rejected",
 					Message: "User rejected the transaction",
@@ -207,19 +236,62 @@ func TransactionSignPost(
 				return mw.HawkRespJSON(http.StatusForbidden, apiErr, hawkServer, cred, &hawkOpt)
 			}
 
-			stxn, err := walletSession.SignTransaction(reqData.Txn, reqData.Signer)
-			if err != nil {
-				echoInstance.Logger.Error(err)
+			// Respond with error if user did not explicitly acknowledge every
+			// risk
+			if unacked := txn_risk.Unacknowledged(risks, userRespData.AcknowledgedRisks); len(unacked) > 0 {
+				auditEntry.Decision = audit.DecisionRejected
+				auditEntry.Details = fmt.Sprint("unacknowledged risks: ", unacked)
 				apiErr := dc.ApiError{
-					Name:    "txn_sign_fail",
-					Message: "Failed to sign transaction",
+					Name:    "txn_risk_not_acknowledged",
+					Message: fmt.Sprint("User did not acknowledge the risks of the transaction: ", unacked),
 				}
-				return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+				return mw.HawkRespJSON(http.StatusForbidden, apiErr, hawkServer, cred, &hawkOpt)
+			}
+
+			if len(risks) > 0 {
+				auditEntry.Details = fmt.Sprint("acknowledged risks: ", txn_risk.Codes(risks))
+			}
+		}
+
+		stxn, err := walletSession.SignTransaction(reqData.Txn, reqData.Signer)
+		if err != nil {
+			echoInstance.Logger.Error(err)
+			apiErr := dc.ApiError{
+				Name:    "txn_sign_fail",
+				Message: "Failed to sign transaction",
+			}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+
+		// Count what the signed transaction sends toward the spending
+		// limits. The signed transaction is not given to the dApp if it
+		// cannot be counted.
+		if err := walletSession.ConfirmSpend(checked.Reservation); err != nil {
+			echoInstance.Logger.Error(err)
+			auditEntry.Details = "could not record what was sent toward the spending limits"
+			apiErr := dc.ApiError{
+				Name:    "spend_record_fail",
+				Message: "Failed to record what the transaction sends toward the spending limits",
 			}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
+		}
+		spendConfirmed = true
 
-			resp := TransactionSignPostResp{SignedTxn: stxn}
+		auditEntry.Decision = audit.DecisionApproved
 
-			return mw.HawkRespJSON(http.StatusOK, resp, hawkServer, cred, &hawkOpt)
+		// The dApp is not given what was signed unless signing it is in the
+		// audit log
+		auditRecorded = true
+		if err := dc.RecordAuditEntry(c, walletSession, auditEntry, echoInstance.Logger); err != nil {
+			apiErr := dc.ApiError{
+				Name:    "audit_record_fail",
+				Message: "Failed to record signing the transaction in the audit log",
+			}
+			return mw.HawkRespJSON(http.StatusInternalServerError, apiErr, hawkServer, cred, &hawkOpt)
 		}
+
+		resp := TransactionSignPostResp{SignedTxn: stxn}
+
+		return mw.HawkRespJSON(http.StatusOK, resp, hawkServer, cred, &hawkOpt)
 	}
 }
@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
+	"math"
 	"net/http"
 	"time"
 
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
 	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
 	"github.com/algorand/go-algorand-sdk/v2/transaction"
 	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
@@ -16,9 +17,18 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/wailsapp/wails/v3/pkg/application"
 
+	"duckysigner/internal/address_book"
+	"duckysigner/internal/algod"
 	dc "duckysigner/internal/dapp_connect"
 	"duckysigner/internal/dapp_connect/handlers"
 	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/note_analyzer"
+	"duckysigner/internal/policy"
+	"duckysigner/internal/spend_limit"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
 )
 
 const txnSignPostUri = "http://localhost:" + transactionSignPostPort + "/transaction/sign"
@@ -36,6 +46,7 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 	var acctAddr string
 	var testTxn algoTypes.Transaction
 	var encodedTestTxn []byte
+	var testNetGenesisHash []byte
 
 	BeforeAll(func() {
 		var err error
@@ -55,7 +66,7 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		sessionManager := session.NewManager(curve, &session.SessionConfig{
 			DataDir: kmdService.Session().FilePath,
 		})
-		testSession, err = sessionManager.GenerateSession(dappPk, &dc.DappData{Name: "Foobar"}, nil)
+		testSession, err = sessionManager.GenerateSession(dappPk, &dc.DappData{Name: "Foobar"}, nil, []string{"TestNet"})
 		Expect(err).NotTo(HaveOccurred())
 		mek, err := kmdService.Session().GetMasterKey()
 		Expect(err).NotTo(HaveOccurred())
@@ -63,12 +74,16 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		By("Creating a test transaction")
+		testNetGenesisHash, err = base64.StdEncoding.DecodeString("SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI=")
+		Expect(err).NotTo(HaveOccurred())
 		testTxn, err = transaction.MakePaymentTxn(acctAddr, acctAddr, 1000000, nil, "", algoTypes.SuggestedParams{
 			Fee:             1000,
 			GenesisID:       "testnet-v1.0",
-			GenesisHash:     []byte("SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI="),
+			GenesisHash:     testNetGenesisHash,
 			FirstRoundValid: 10000,
 			LastRoundValid:  11000,
+			// A flat fee keeps the fee low enough to not be flagged as risky
+			FlatFee: true,
 		})
 		Expect(err).NotTo(HaveOccurred())
 		encodedTestTxn = msgpack.Encode(testTxn)
@@ -106,9 +121,10 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve transaction")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"data":` + reqBody + `}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(txnPromptDataJSON(reqBody, testTxn)))
 			By("Wallet user: Approving transaction")
-			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"approved":true}`)
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
 		})
 
 		// Wait for request to complete before trying to parse & check the response
@@ -120,6 +136,39 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		Expect(respData.SignedTxn).ToNot(BeEmpty())
 	})
 
+	It("lets the wallet's password be changed while waiting for the user", func() {
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("Wallet user: Changing the password before approving transaction")
+			Expect(kmdService.SessionChangePassword("test password", "new password")).To(Succeed())
+			requestId, _ := splitPromptData(e.Data)
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+		})
+
+		hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+		By("Making an authenticated request to server with valid data")
+		req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		respBody, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking if server responds with signed transaction data")
+		var respData handlers.TransactionSignPostResp
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.SignedTxn).ToNot(BeEmpty())
+
+		By("Changing the password back")
+		Expect(kmdService.SessionChangePassword("new password", "test password")).To(Succeed())
+	})
+
 	It("fails if request is not authenticated", func() {
 		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
 		// Signal for when the request has yielded a response
@@ -217,6 +266,83 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		Expect(respData.Name).To(Equal("invalid_txn"))
 	})
 
+	It("fails without prompting the user if the transaction fee is absurdly high", func() {
+		By("Creating a transaction with an absurdly high fee")
+		txn := testTxn
+		txn.Fee = 5_000_000
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(txn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("invalid_txn"))
+		Expect(respData.Message).To(ContainSubstring("higher than the maximum"))
+	})
+
+	It("fails without prompting the user if the transaction is for a network the session is not bound to", func() {
+		By("Creating a MainNet transaction")
+		mainNetGenesisHash, err := base64.StdEncoding.DecodeString("wGHE2Pwdvd7S12BL5FaOP20EGYesN73ktiC1qzkkit8=")
+		Expect(err).NotTo(HaveOccurred())
+		txn := testTxn
+		txn.GenesisID = "mainnet-v1.0"
+		txn.GenesisHash = algoTypes.Digest(mainNetGenesisHash)
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(txn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("wrong_network"))
+		Expect(respData.Message).To(ContainSubstring("TestNet"))
+	})
+
 	It("fails if invalid signer is given", func() {
 		var reqBody = `{"transaction":"` +
 			base64.StdEncoding.EncodeToString(encodedTestTxn) +
@@ -287,6 +413,89 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		Expect(respData.Name).To(Equal("invalid_signer"))
 	})
 
+	It("fails if signer account is watch-only", func() {
+		const watchAcctAddr = "3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY"
+
+		By("Adding a watch-only account to the wallet")
+		_, err := kmdService.Session().AddWatchAccount(watchAcctAddr, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		var reqBody = `{"transaction":"` +
+			base64.StdEncoding.EncodeToString(encodedTestTxn) +
+			`","signer":"` + watchAcctAddr + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("watch_only"))
+	})
+
+	It("fails if signer account is a multisig account", func() {
+		By("Adding a multisig account to the wallet")
+		msigAcct, err := kmdService.Session().AddMultisigAccount(1, 1, []string{
+			"3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY",
+			"RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A",
+		}, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		var reqBody = `{"transaction":"` +
+			base64.StdEncoding.EncodeToString(encodedTestTxn) +
+			`","signer":"` + msigAcct.Address + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("no_signing_key"))
+	})
+
 	It("fails if transaction's sender is not in wallet (when signer is not specified)", func() {
 		By("Creating a test transaction with a sender that (probably) does not exist in wallet")
 		txn, err := transaction.MakePaymentTxn(
@@ -296,7 +505,7 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 			algoTypes.SuggestedParams{
 				Fee:             1000,
 				GenesisID:       "testnet-v1.0",
-				GenesisHash:     []byte("SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI="),
+				GenesisHash:     testNetGenesisHash,
 				FirstRoundValid: 10000,
 				LastRoundValid:  11000,
 			},
@@ -364,7 +573,8 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve transaction")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"data":` + reqBody + `}`))
+			_, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(txnPromptDataJSON(reqBody, testTxn)))
 			By("Wallet user: Not responding...")
 		})
 
@@ -405,9 +615,218 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
 			defer GinkgoRecover()
 			By("UI: Prompting user to approve transaction")
-			Expect(fmt.Sprint(e.Data)).To(Equal(`{"data":` + reqBody + `}`))
+			requestId, promptData := splitPromptData(e.Data)
+			Expect(promptData).To(Equal(txnPromptDataJSON(reqBody, testTxn)))
 			By("Wallet user: Approving transaction")
-			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"approved":false}`)
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("txn_sign_rejected"))
+	})
+
+	It("fails when user approves a risky transaction without acknowledging the risks", func() {
+		By("Creating a transaction that rekeys the sender")
+		riskyTxn := testTxn
+		riskyTxn.RekeyTo = riskyTxn.Sender
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(riskyTxn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction with its risks")
+			requestId, promptData := splitPromptData(e.Data)
+			var parsedPromptData handlers.TxnSignPromptEvtData
+			Expect(json.Unmarshal([]byte(promptData), &parsedPromptData)).To(Succeed())
+			Expect(txn_risk.Codes(parsedPromptData.Risks)).To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+			By("Wallet user: Approving transaction without acknowledging the risks")
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("txn_risk_not_acknowledged"))
+	})
+
+	It("signs a risky transaction when user acknowledges the risks", func() {
+		By("Creating a transaction that rekeys the sender")
+		riskyTxn := testTxn
+		riskyTxn.RekeyTo = riskyTxn.Sender
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(riskyTxn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction with its risks")
+			requestId, _ := splitPromptData(e.Data)
+			By("Wallet user: Approving transaction and acknowledging the risks")
+			dcService.WailsApp.Event.Emit(
+				handlers.TxnSignRespEventName,
+				`{"request_id":"`+requestId+`","approved":true,"acknowledged_risks":["rekey"]}`,
+			)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with signed transaction data")
+		var respData handlers.TransactionSignPostResp
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.SignedTxn).ToNot(BeEmpty())
+	})
+
+	It("labels the addresses in the prompt using the address book", func() {
+		const contactAddr = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+		const unknownAddr = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+
+		By("Saving a contact")
+		_, err := kmdService.Session().SaveContact(address_book.Contact{Address: contactAddr, Label: "Bob"})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(kmdService.Session().RemoveContact(contactAddr)).To(Succeed())
+		})
+
+		By("Creating a transaction that pays the contact and closes to an unknown address")
+		txn := testTxn
+		txn.Receiver, _ = algoTypes.DecodeAddress(contactAddr)
+		txn.CloseRemainderTo, _ = algoTypes.DecodeAddress(unknownAddr)
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(txn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction with labeled addresses")
+			requestId, promptData := splitPromptData(e.Data)
+			var parsedPromptData handlers.TxnSignPromptEvtData
+			Expect(json.Unmarshal([]byte(promptData), &parsedPromptData)).To(Succeed())
+			Expect(parsedPromptData.Summary.Addresses).To(Equal(map[string]txn_decoder.AddressInfo{
+				acctAddr:    {InWallet: true, Known: true},
+				contactAddr: {Label: "Bob", Known: true},
+				unknownAddr: {},
+			}))
+			By("Wallet user: Rejecting transaction")
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
+		})
+
+		// Wait for request to complete before trying to parse & check the response
+		respBody := <-respSignal
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("txn_sign_rejected"))
+	})
+
+	It("sends what was found in the transaction's note with the prompt", func() {
+		By("Creating a transaction with a link in its note")
+		txn := testTxn
+		txn.Note = []byte("Claim your airdrop at https://example.com")
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(msgpack.Encode(txn)) + `"}`
+		// Signal for when the request has yielded a response
+		var respSignal = make(chan []byte)
+		go func() {
+			defer GinkgoRecover()
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server with valid data")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Processing response from server")
+			body, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+			// Signal that request has completed
+			respSignal <- body
+			close(respSignal)
+		}()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("UI: Prompting user to approve transaction with the note analysis")
+			requestId, promptData := splitPromptData(e.Data)
+			var parsedPromptData handlers.TxnSignPromptEvtData
+			Expect(json.Unmarshal([]byte(promptData), &parsedPromptData)).To(Succeed())
+			Expect(parsedPromptData.NoteAnalysis).To(Equal(note_analyzer.Analyze(txn.Note)))
+			Expect(parsedPromptData.NoteAnalysis.URLs).To(Equal([]string{"https://example.com"}))
+			By("Wallet user: Rejecting transaction")
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
 		})
 
 		// Wait for request to complete before trying to parse & check the response
@@ -418,11 +837,422 @@ var _ = Describe("POST /transaction/sign", Ordered, func() {
 		json.Unmarshal(respBody, &respData)
 		Expect(respData.Name).To(Equal("txn_sign_rejected"))
 	})
+
+	It("signs without prompting the user when the signing policy allows it", func() {
+		By("Adding a policy rule that allows the transaction")
+		rule, err := kmdService.Session().AddPolicyRule(policy.Rule{
+			Action:     policy.ActionAllow,
+			Conditions: policy.Conditions{DappIDs: []string{dappIdB64}, TxnTypes: []string{"pay"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(kmdService.Session().RemovePolicyRule(rule.ID)).To(Succeed()) })
+
+		// Fail if the user is prompted
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not be prompted")
+		})
+
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+		hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+		By("Making an authenticated request to server with valid data")
+		req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		respBody, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking if server responds with signed transaction data")
+		var respData handlers.TransactionSignPostResp
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.SignedTxn).ToNot(BeEmpty())
+	})
+
+	It("fails without prompting the user when the signing policy denies it", func() {
+		By("Adding a policy rule that denies the transaction")
+		rule, err := kmdService.Session().AddPolicyRule(policy.Rule{
+			Action:     policy.ActionDeny,
+			Conditions: policy.Conditions{Receivers: []string{acctAddr}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(kmdService.Session().RemovePolicyRule(rule.ID)).To(Succeed()) })
+
+		// Fail if the user is prompted
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not be prompted")
+		})
+
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+		hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+		By("Making an authenticated request to server with valid data")
+		req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		respBody, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("txn_sign_denied"))
+	})
+
+	It("fails without prompting the user when a spending limit would be exceeded", func() {
+		By("Adding a spending limit that is less than what the transaction sends")
+		limit, err := kmdService.Session().AddSpendLimit(spend_limit.Limit{
+			Account:    acctAddr,
+			WindowSecs: 3600,
+			Max:        1000,
+			Action:     spend_limit.ActionDeny,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(kmdService.Session().RemoveSpendLimit(limit.ID)).To(Succeed()) })
+
+		// Fail if the user is prompted
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			Fail("User should not be prompted")
+		})
+
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+		hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+		By("Making an authenticated request to server with valid data")
+		req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		respBody, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking if server responds with error data")
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("spend_limit_exceeded"))
+	})
+
+	It("counts the transactions waiting to be signed toward the spending limits", func() {
+		By("Finding out how much the account has sent so far")
+		limit, err := kmdService.Session().AddSpendLimit(spend_limit.Limit{
+			Account:    acctAddr,
+			WindowSecs: 3600,
+			Max:        math.MaxUint64,
+			Action:     spend_limit.ActionDeny,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		statuses, err := kmdService.Session().ListSpendLimits()
+		Expect(err).NotTo(HaveOccurred())
+		var spent uint64
+		for _, status := range statuses {
+			if status.Limit.ID == limit.ID {
+				spent = status.Spent
+			}
+		}
+		Expect(kmdService.Session().RemoveSpendLimit(limit.ID)).To(Succeed())
+
+		By("Adding a spending limit that only one more transaction fits within")
+		limit, err = kmdService.Session().AddSpendLimit(spend_limit.Limit{
+			Account:    acctAddr,
+			WindowSecs: 3600,
+			Max:        spent + uint64(testTxn.Amount)*3/2,
+			Action:     spend_limit.ActionDeny,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(kmdService.Session().RemoveSpendLimit(limit.ID)).To(Succeed()) })
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("Wallet user: Approving transaction")
+			requestId, _ := splitPromptData(e.Data)
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+		})
+
+		By("Making two authenticated requests to server at the same time")
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+		respSignal := make(chan []byte, 2)
+		for range 2 {
+			go func() {
+				defer GinkgoRecover()
+				hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+				req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", hawkHeader)
+				resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				body, err := getResponseBody(resp)
+				Expect(err).NotTo(HaveOccurred())
+				respSignal <- body
+			}()
+		}
+
+		By("Checking that only one of the transactions was signed")
+		var signedTxns, errNames []string
+		for range 2 {
+			var respData struct {
+				handlers.TransactionSignPostResp
+				dc.ApiError
+			}
+			json.Unmarshal(<-respSignal, &respData)
+			if respData.SignedTxn != "" {
+				signedTxns = append(signedTxns, respData.SignedTxn)
+			} else {
+				errNames = append(errNames, respData.Name)
+			}
+		}
+		Expect(signedTxns).To(HaveLen(1))
+		Expect(errNames).To(Equal([]string{"spend_limit_exceeded"}))
+	})
+
+	It("does not count a transaction the user rejects toward the spending limits", func() {
+		limit, err := kmdService.Session().AddSpendLimit(spend_limit.Limit{
+			Account:    acctAddr,
+			WindowSecs: 3600,
+			Max:        math.MaxUint64,
+			Action:     spend_limit.ActionDeny,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(kmdService.Session().RemoveSpendLimit(limit.ID)).To(Succeed()) })
+		spentOf := func() (spent uint64) {
+			statuses, err := kmdService.Session().ListSpendLimits()
+			Expect(err).NotTo(HaveOccurred())
+			for _, status := range statuses {
+				if status.Limit.ID == limit.ID {
+					spent = status.Spent
+				}
+			}
+			return
+		}
+		spent := spentOf()
+
+		// Mock UI/user response to prompt event emitted from server
+		dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			By("Checking the transaction counts toward the limit while it waits to be signed")
+			Expect(spentOf()).To(Equal(spent + uint64(testTxn.Amount) + uint64(testTxn.Fee)))
+
+			By("Wallet user: Rejecting transaction")
+			requestId, _ := splitPromptData(e.Data)
+			dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":false}`)
+		})
+
+		var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+		hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+		By("Making an authenticated request to server with valid data")
+		req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", hawkHeader)
+		resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		respBody, err := getResponseBody(resp)
+		Expect(err).NotTo(HaveOccurred())
+
+		var respData dc.ApiError
+		json.Unmarshal(respBody, &respData)
+		Expect(respData.Name).To(Equal("txn_sign_rejected"))
+
+		By("Checking the rejected transaction no longer counts toward the limit")
+		Expect(spentOf()).To(Equal(spent))
+	})
+
+	Context("when simulation is enabled", Ordered, func() {
+		var fakeAlgod *algod.Fake
+
+		// restartDcService restarts the dApp connect server with the given
+		// simulation configuration
+		restartDcService := func(simConfig config.SimulationConfig) {
+			dcService.Stop()
+			kmdService.Config.Simulation = simConfig
+			dcService.Start()
+			Eventually(func() error {
+				_, err := http.Get("http://localhost:" + transactionSignPostPort + "/")
+				return err
+			}).Should(Succeed())
+		}
+
+		BeforeAll(func() {
+			By("Restarting dApp connect server with simulation enabled")
+			fakeAlgod = algod.NewFake()
+			dcService.AlgodProvider = fakeAlgod.Provider()
+			restartDcService(config.SimulationConfig{Enabled: true, FailClosed: true})
+			DeferCleanup(func() {
+				restartDcService(config.SimulationConfig{})
+			})
+		})
+
+		AfterEach(func() {
+			fakeAlgod.SimulateFunc = nil
+		})
+
+		It("sends the simulation results with the prompt", func() {
+			fakeAlgod.SimulateFunc = func(request models.SimulateRequest) (models.SimulateResponse, error) {
+				defer GinkgoRecover()
+				Expect(request.AllowEmptySignatures).To(BeTrue())
+				Expect(request.TxnGroups[0].Txns).To(Equal([]algoTypes.SignedTxn{{Txn: testTxn}}))
+				return models.SimulateResponse{
+					TxnGroups: []models.SimulateTransactionGroupResult{{
+						TxnResults: []models.SimulateTransactionResult{{}},
+					}},
+				}, nil
+			}
+
+			var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+			// Signal for when the request has yielded a response
+			var respSignal = make(chan []byte)
+			go func() {
+				defer GinkgoRecover()
+				hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+				By("Making an authenticated request to server with valid data")
+				req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", hawkHeader)
+				resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Processing response from server")
+				body, err := getResponseBody(resp)
+				Expect(err).NotTo(HaveOccurred())
+				// Signal that request has completed
+				respSignal <- body
+				close(respSignal)
+			}()
+
+			// Mock UI/user response to prompt event emitted from server
+			dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+				defer GinkgoRecover()
+				By("UI: Prompting user to approve transaction with the simulation results")
+				requestId, promptData := splitPromptData(e.Data)
+				var parsedPromptData handlers.TxnSignPromptEvtData
+				Expect(json.Unmarshal([]byte(promptData), &parsedPromptData)).To(Succeed())
+				Expect(parsedPromptData.Risks).To(BeEmpty())
+				Expect(parsedPromptData.Simulation).NotTo(BeNil())
+				Expect(parsedPromptData.Simulation.WouldSucceed).To(BeTrue())
+				Expect(parsedPromptData.Simulation.BalanceChanges).To(Equal([]txn_simulator.BalanceChange{
+					{Address: acctAddr, AssetID: 0, Sent: 1_001_000, Received: 1_000_000},
+				}))
+				By("Wallet user: Approving transaction")
+				dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+			})
+
+			// Wait for request to complete before trying to parse & check the response
+			respBody := <-respSignal
+
+			By("Checking if server responds with signed transaction data")
+			var respData handlers.TransactionSignPostResp
+			json.Unmarshal(respBody, &respData)
+			Expect(respData.SignedTxn).ToNot(BeEmpty())
+		})
+
+		It("flags a transaction that fails when simulated as risky", func() {
+			fakeAlgod.SimulateFunc = func(request models.SimulateRequest) (models.SimulateResponse, error) {
+				return models.SimulateResponse{
+					TxnGroups: []models.SimulateTransactionGroupResult{{
+						FailureMessage: "transaction rejected: overspend",
+						FailedAt:       []uint64{0},
+						TxnResults:     []models.SimulateTransactionResult{{}},
+					}},
+				}, nil
+			}
+
+			var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+			// Signal for when the request has yielded a response
+			var respSignal = make(chan []byte)
+			go func() {
+				defer GinkgoRecover()
+				hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+				By("Making an authenticated request to server with valid data")
+				req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", hawkHeader)
+				resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Processing response from server")
+				body, err := getResponseBody(resp)
+				Expect(err).NotTo(HaveOccurred())
+				// Signal that request has completed
+				respSignal <- body
+				close(respSignal)
+			}()
+
+			// Mock UI/user response to prompt event emitted from server
+			dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+				defer GinkgoRecover()
+				By("UI: Prompting user to approve transaction with the failed simulation")
+				requestId, promptData := splitPromptData(e.Data)
+				var parsedPromptData handlers.TxnSignPromptEvtData
+				Expect(json.Unmarshal([]byte(promptData), &parsedPromptData)).To(Succeed())
+				Expect(txn_risk.Codes(parsedPromptData.Risks)).To(Equal([]txn_risk.Code{txn_risk.CodeSimulationFailed}))
+				Expect(parsedPromptData.Simulation.FailureMessage).To(Equal("transaction rejected: overspend"))
+				By("Wallet user: Approving transaction without acknowledging the risks")
+				dcService.WailsApp.Event.Emit(handlers.TxnSignRespEventName, `{"request_id":"`+requestId+`","approved":true}`)
+			})
+
+			// Wait for request to complete before trying to parse & check the response
+			respBody := <-respSignal
+
+			By("Checking if server responds with error data")
+			var respData dc.ApiError
+			json.Unmarshal(respBody, &respData)
+			Expect(respData.Name).To(Equal("txn_risk_not_acknowledged"))
+		})
+
+		It("fails without prompting the user when the transaction cannot be simulated", func() {
+			// Fail if the user is prompted
+			dcService.WailsApp.Event.On(handlers.TxnSignPromptEventName, func(e *application.CustomEvent) {
+				defer GinkgoRecover()
+				Fail("User should not be prompted")
+			})
+
+			var reqBody = `{"transaction":"` + base64.StdEncoding.EncodeToString(encodedTestTxn) + `"}`
+			hawkHeader := CreateTransactionSignPostReqHawkHeader(testSession, reqBody)
+
+			By("Making an authenticated request to server while algod cannot simulate")
+			req, err := http.NewRequest("POST", txnSignPostUri, bytes.NewReader([]byte(reqBody)))
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", hawkHeader)
+			resp, err := (&http.Client{Timeout: 1 * time.Minute}).Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			respBody, err := getResponseBody(resp)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking if server responds with error data")
+			var respData dc.ApiError
+			json.Unmarshal(respBody, &respData)
+			Expect(respData.Name).To(Equal("txn_simulation_fail"))
+		})
+	})
 })
 
 // CreateTransactionSignPostReqHawkHeader creates a new Hawk authentication
 // header for a `/transaction/sign` request
 func CreateTransactionSignPostReqHawkHeader(sess *session.Session, reqBody string) (hawkHeader string) {
+	return createPostReqHawkHeader(sess, txnSignPostUri, reqBody)
+}
+
+// createPostReqHawkHeader creates the Hawk header for a POST request to the
+// given URI with the given body that is authenticated with the given session
+func createPostReqHawkHeader(sess *session.Session, uri string, reqBody string) (hawkHeader string) {
 	By("Creating Hawk request header")
 	sessionSharedKey, err := sess.SharedKey()
 	Expect(err).NotTo(HaveOccurred())
@@ -442,7 +1272,18 @@ func CreateTransactionSignPostReqHawkHeader(sess *session.Session, reqBody strin
 		},
 	)
 	Expect(err).NotTo(HaveOccurred())
-	hawkHeader, err = hawkClient.Header("POST", txnSignPostUri)
+	hawkHeader, err = hawkClient.Header("POST", uri)
 	Expect(err).NotTo(HaveOccurred())
 	return
 }
+
+// txnPromptDataJSON gives the JSON of the prompt data (without the request ID)
+// that is expected to be sent to the UI for the given request body and its
+// transaction
+func txnPromptDataJSON(reqBody string, txn algoTypes.Transaction) string {
+	summary := txn_decoder.Decode(txn)
+	Expect(summary.ResolveAddresses(kmdService.Session())).To(Succeed())
+	summaryJSON, err := json.Marshal(summary)
+	Expect(err).ToNot(HaveOccurred())
+	return `{"data":` + reqBody + `,"summary":` + string(summaryJSON) + `}`
+}
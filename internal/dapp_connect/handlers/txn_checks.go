@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
+	"github.com/labstack/echo/v4"
+
+	"duckysigner/internal/audit"
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/policy"
+	"duckysigner/internal/spend_limit"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
+	"duckysigner/internal/txn_validator"
+	"duckysigner/internal/wallet_session"
+)
+
+type (
+	// txnCheckFail is how to respond to a transaction signing request with
+	// transactions that did not pass the checks done before asking the user
+	txnCheckFail struct {
+		// HTTP status code of the response
+		Status int
+		// Error to respond with
+		ApiErr dc.ApiError
+	}
+
+	// txnCheckResult is what the checks done on the transactions of a
+	// transaction signing request found
+	txnCheckResult struct {
+		// Risks the user must acknowledge for the transactions to be signed
+		Risks []txn_risk.Flag
+		// Reservation of what the transactions send from the wallet's
+		// accounts, which counts toward the spending limits until it is
+		// confirmed once the transactions are signed or released if they are
+		// not
+		Reservation *spend_limit.Reservation
+		// What simulating the transactions showed they would do
+		Simulation *txn_simulator.Result
+		// If the signing policy allows the transactions to be signed without
+		// asking the user
+		AllowedByPolicy bool
+	}
+
+	// txnChecker does the checks that the transactions of a transaction
+	// signing request go through before the user is asked to approve signing
+	// them. When a check does not pass, the reason is put into the audit entry
+	// of the request. Errors that stop a check from being done are logged.
+	txnChecker struct {
+		echoInstance  *echo.Echo
+		riskChecker   *txn_risk.Checker
+		simulator     *txn_simulator.Simulator
+		walletSession *wallet_session.WalletSession
+		// Audit entry of the request
+		auditEntry *audit.Entry
+	}
+
+	// txnsToCheck are the transactions of a transaction signing request
+	txnsToCheck struct {
+		// Transactions to be signed
+		Txns []algoTypes.Transaction
+		// Indexes of the transactions to be signed within their group, which
+		// tell the transactions apart in messages. It is nil if a single
+		// transaction is to be signed.
+		GroupIndexes []int
+		// The whole group the transactions to be signed are in, or only the
+		// transaction to be signed if it is not signed as part of a group
+		Group []algoTypes.Transaction
+		// Address of the account to sign with instead of the account the
+		// sender is rekeyed to, if any
+		Signer string
+	}
+)
+
+// check checks the given transactions of a transaction signing request made in
+// the dApp connect session with the given ID. If the transactions pass, what
+// they send is reserved toward the spending limits.
+func (tc *txnChecker) check(
+	ctx context.Context,
+	sessionID string,
+	dcSession *session.Session,
+	dappId string,
+	dappData *dc.DappData,
+	toCheck *txnsToCheck,
+) (checked *txnCheckResult, fail *txnCheckFail) {
+	// Check if the accounts that would sign the transactions can sign
+	for i, txn := range toCheck.Txns {
+		if fail := tc.checkSigner(txn, toCheck.Signer); fail != nil {
+			return nil, fail.forTxn(toCheck, i)
+		}
+	}
+
+	// Check if the transactions are sane before bothering the user with them
+	for i, txn := range toCheck.Txns {
+		if fail := tc.validate(txn, toCheck.what()); fail != nil {
+			return nil, fail.forTxn(toCheck, i)
+		}
+	}
+
+	// Check if the whole group is for one of the networks the dApp connect
+	// session is bound to
+	var sessionNetworks []string
+	if dcSession != nil {
+		sessionNetworks = dcSession.Networks()
+	}
+	for _, txn := range toCheck.Group {
+		if err := tc.walletSession.ValidateTransactionNetwork(txn, sessionNetworks); err != nil {
+			tc.auditEntry.Details = "transaction for a network the session is not bound to"
+			return nil, &txnCheckFail{
+				Status: http.StatusForbidden,
+				ApiErr: dc.ApiError{Name: "wrong_network", Message: err.Error()},
+			}
+		}
+	}
+
+	// Check the transactions for risks the user needs to acknowledge
+	result := txnCheckResult{Risks: []txn_risk.Flag{}}
+	for i, txn := range toCheck.Txns {
+		risks, err := tc.riskChecker.Check(txn)
+		if err != nil {
+			tc.echoInstance.Logger.Error(err)
+			return nil, &txnCheckFail{
+				Status: http.StatusInternalServerError,
+				ApiErr: dc.ApiError{
+					Name:    "txn_risk_check_fail",
+					Message: fmt.Sprintf("Failed to check the %s for risks", toCheck.what()),
+				},
+			}
+		}
+		for _, risk := range risks {
+			risk.Message = toCheck.txnMessage(i, risk.Message)
+			result.Risks = append(result.Risks, risk)
+		}
+	}
+
+	// Check if the transactions would together exceed any spending limit, and
+	// reserve what they send so that other requests cannot exceed a limit
+	// together with them while they are waiting to be signed. Limits that do
+	// not deny the transactions become risks the user must acknowledge.
+	var outflows []spend_limit.Outflow
+	for _, txn := range toCheck.Txns {
+		outflows = append(outflows, spend_limit.Outflows(txn)...)
+	}
+	reservation, exceededLimits, err := tc.walletSession.ReserveSpend(sessionID, outflows)
+	if err != nil {
+		tc.echoInstance.Logger.Error(err)
+		return nil, &txnCheckFail{
+			Status: http.StatusInternalServerError,
+			ApiErr: dc.ApiError{Name: "spend_limit_check_fail", Message: "Failed to check the spending limits"},
+		}
+	}
+	for _, exceeded := range exceededLimits {
+		if exceeded.Limit.Action == spend_limit.ActionDeny {
+			tc.auditEntry.Decision = audit.DecisionRejected
+			tc.auditEntry.Details = exceeded.Message()
+			return nil, &txnCheckFail{
+				Status: http.StatusForbidden,
+				ApiErr: dc.ApiError{Name: "spend_limit_exceeded", Message: exceeded.Message()},
+			}
+		}
+		result.Risks = append(result.Risks, txn_risk.Flag{
+			Code:    txn_risk.CodeSpendLimit,
+			Message: exceeded.Message(),
+		})
+	}
+	result.Reservation = reservation
+	defer func() {
+		if fail != nil {
+			tc.releaseSpend(reservation)
+		}
+	}()
+
+	// Simulate the whole group to show the user what it would do. A group that
+	// would fail becomes a risk the user must acknowledge.
+	result.Simulation, err = tc.simulator.Simulate(ctx, toCheck.Group)
+	if err != nil {
+		tc.echoInstance.Logger.Error(err)
+		tc.auditEntry.Details = "could not simulate " + toCheck.what()
+		return nil, &txnCheckFail{
+			Status: http.StatusServiceUnavailable,
+			ApiErr: dc.ApiError{
+				Name:    "txn_simulation_fail",
+				Message: fmt.Sprintf("Failed to simulate the %s", toCheck.what()),
+			},
+		}
+	}
+	if result.Simulation.Failed() {
+		result.Risks = append(result.Risks, txn_risk.Flag{
+			Code: txn_risk.CodeSimulationFailed,
+			Message: fmt.Sprintf(
+				"The %s failed when it was simulated: %s",
+				toCheck.what(), result.Simulation.FailureMessage,
+			),
+		})
+	}
+
+	// Check if the wallet's signing policy decides what to do with the
+	// transactions before asking the user. The transactions are only signed
+	// without asking if every one of them is allowed, and are denied if any of
+	// them is denied.
+	result.AllowedByPolicy = true
+	allowRules := make([]string, 0, len(toCheck.Txns))
+	for _, txn := range toCheck.Txns {
+		policyReq := policy.Request{DappID: dappId, Txn: txn, Risky: len(result.Risks) > 0}
+		if dappData != nil {
+			policyReq.DappURL = dappData.URL
+		}
+		policyDecision, err := tc.walletSession.EvaluatePolicy(&policyReq)
+		if err != nil {
+			tc.echoInstance.Logger.Error(err)
+			return nil, &txnCheckFail{
+				Status: http.StatusInternalServerError,
+				ApiErr: dc.ApiError{Name: "policy_eval_fail", Message: "Failed to evaluate the signing policy"},
+			}
+		}
+
+		switch policyDecision.Action {
+		case policy.ActionDeny:
+			tc.auditEntry.Decision = audit.DecisionRejected
+			tc.auditEntry.Details = fmt.Sprintf("denied by policy rule %d", policyDecision.Rule.ID)
+			return nil, &txnCheckFail{
+				Status: http.StatusForbidden,
+				ApiErr: dc.ApiError{
+					Name:    "txn_sign_denied",
+					Message: fmt.Sprintf("The %s was denied by the wallet's signing policy", toCheck.what()),
+				},
+			}
+		case policy.ActionAllow:
+			allowRules = append(allowRules, fmt.Sprint(policyDecision.Rule.ID))
+		default:
+			result.AllowedByPolicy = false
+		}
+	}
+	if result.AllowedByPolicy {
+		tc.auditEntry.Details = "allowed by policy rule " + strings.Join(allowRules, ", ")
+	}
+
+	return &result, nil
+}
+
+// releaseSpend releases the given reservation toward the spending limits of
+// transactions that are not signed. A reservation that cannot be released
+// stops counting toward the limits once it runs out.
+func (tc *txnChecker) releaseSpend(reservation *spend_limit.Reservation) {
+	if err := tc.walletSession.ReleaseSpend(reservation); err != nil {
+		tc.echoInstance.Logger.Error(err)
+	}
+}
+
+// checkSigner checks if the account that would sign the given transaction is
+// in the wallet and can sign. The transaction is signed by the given signer, or
+// by the account the sender is rekeyed to if no signer is given.
+func (tc *txnChecker) checkSigner(txn algoTypes.Transaction, signer string) *txnCheckFail {
+	ws := tc.walletSession
+	sender := txn.Sender.String()
+
+	senderInWallet, err := ws.CheckAddrInWallet(sender)
+	if err != nil {
+		tc.echoInstance.Logger.Error(err)
+		return &txnCheckFail{
+			Status: http.StatusBadRequest,
+			ApiErr: dc.ApiError{Name: "invalid_sender", Message: "Failed to parse sender address"},
+		}
+	}
+	if !senderInWallet {
+		tc.auditEntry.Details = "sender account not in wallet"
+		return &txnCheckFail{
+			Status: http.StatusBadRequest,
+			ApiErr: dc.ApiError{Name: "invalid_sender", Message: "Sender account not in wallet"},
+		}
+	}
+
+	if signer != "" {
+		signerInWallet, err := ws.CheckAddrInWallet(signer)
+		if err != nil {
+			tc.echoInstance.Logger.Error(err)
+			return &txnCheckFail{
+				Status: http.StatusBadRequest,
+				ApiErr: dc.ApiError{Name: "invalid_signer", Message: "Failed to parse signer address"},
+			}
+		}
+		if !signerInWallet {
+			tc.auditEntry.Details = "signer account not in wallet"
+			return &txnCheckFail{
+				Status: http.StatusBadRequest,
+				ApiErr: dc.ApiError{Name: "invalid_signer", Message: "Signer account not in wallet"},
+			}
+		}
+	} else {
+		// Without a signer, the transaction is signed by the account the
+		// sender is rekeyed to if it is rekeyed
+		signer, err = ws.AuthAddr(sender)
+		if err != nil {
+			tc.echoInstance.Logger.Error(err)
+			return &txnCheckFail{
+				Status: http.StatusInternalServerError,
+				ApiErr: dc.ApiError{Name: "invalid_sender", Message: "Failed to look up sender account"},
+			}
+		}
+		if signer != sender {
+			authInWallet, err := ws.CheckAddrInWallet(signer)
+			if err != nil {
+				tc.echoInstance.Logger.Error(err)
+				return &txnCheckFail{
+					Status: http.StatusInternalServerError,
+					ApiErr: dc.ApiError{Name: "invalid_signer", Message: "Failed to look up signer account"},
+				}
+			}
+			if !authInWallet {
+				tc.auditEntry.Details = "account sender is rekeyed to not in wallet"
+				return &txnCheckFail{
+					Status: http.StatusBadRequest,
+					ApiErr: dc.ApiError{
+						Name:    "invalid_signer",
+						Message: "Sender account is rekeyed to an account not in wallet",
+					},
+				}
+			}
+		}
+	}
+
+	isWatchAcct, err := ws.IsWatchAccount(signer)
+	if err != nil {
+		tc.echoInstance.Logger.Error(err)
+		return &txnCheckFail{
+			Status: http.StatusInternalServerError,
+			ApiErr: dc.ApiError{Name: "invalid_signer", Message: "Failed to look up signer account"},
+		}
+	}
+	if isWatchAcct {
+		tc.auditEntry.Details = "signer account is watch-only"
+		return &txnCheckFail{
+			Status: http.StatusBadRequest,
+			ApiErr: dc.ApiError{Name: "watch_only", Message: "Signer account is watch-only and cannot sign"},
+		}
+	}
+	hasSigningKey, err := ws.HasSigningKey(signer)
+	if err != nil {
+		tc.echoInstance.Logger.Error(err)
+		return &txnCheckFail{
+			Status: http.StatusInternalServerError,
+			ApiErr: dc.ApiError{Name: "invalid_signer", Message: "Failed to look up signer account"},
+		}
+	}
+	if !hasSigningKey {
+		tc.auditEntry.Details = "signer account has no key in wallet"
+		return &txnCheckFail{
+			Status: http.StatusBadRequest,
+			ApiErr: dc.ApiError{
+				Name:    "no_signing_key",
+				Message: "Signer account has no key in wallet to sign with",
+			},
+		}
+	}
+
+	return nil
+}
+
+// validate checks if the given transaction is sane. The given description of
+// what is being signed (e.g. "transaction group") is used in the error message
+// of a validation that could not be done.
+func (tc *txnChecker) validate(txn algoTypes.Transaction, what string) *txnCheckFail {
+	err := tc.walletSession.ValidateTransaction(txn)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *txn_validator.ValidationError
+	if errors.As(err, &validationErr) {
+		tc.auditEntry.Details = "invalid transaction: " + string(validationErr.Code)
+		return &txnCheckFail{
+			Status: http.StatusBadRequest,
+			ApiErr: dc.ApiError{Name: "invalid_txn", Message: validationErr.Message},
+		}
+	}
+	tc.echoInstance.Logger.Error(err)
+	return &txnCheckFail{
+		Status: http.StatusInternalServerError,
+		ApiErr: dc.ApiError{
+			Name:    "txn_validation_fail",
+			Message: fmt.Sprintf("Failed to validate the %s", what),
+		},
+	}
+}
+
+// what gives a description of what is being signed for messages
+func (toCheck *txnsToCheck) what() string {
+	if toCheck.GroupIndexes == nil {
+		return "transaction"
+	}
+	return "transaction group"
+}
+
+// txnMessage gives the given message about the transaction to be signed at the
+// given index, which says which transaction of the group it is about if the
+// transaction is signed as part of a group
+func (toCheck *txnsToCheck) txnMessage(i int, message string) string {
+	if toCheck.GroupIndexes == nil {
+		return message
+	}
+	return fmt.Sprintf("Transaction %d: %s", toCheck.GroupIndexes[i], message)
+}
+
+// forTxn makes the error message of the failed check say which transaction of
+// the given transactions (at the given index) did not pass
+func (fail *txnCheckFail) forTxn(toCheck *txnsToCheck, i int) *txnCheckFail {
+	fail.ApiErr.Message = toCheck.txnMessage(i, fail.ApiErr.Message)
+	return fail
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/base64"
+
+	"github.com/labstack/echo/v4"
+
+	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/session"
+	"duckysigner/internal/wallet_session"
+)
+
+// sessionDappId returns the Base64-encoded ID of the dApp of the dApp connect
+// session with the given ID. Returns an empty string if the session could not
+// be retrieved.
+func sessionDappId(
+	c echo.Context,
+	walletSession *wallet_session.WalletSession,
+	sessionManager *session.Manager,
+	sessionId string,
+) string {
+	dappId, _ := dappOf(getDcSession(c, walletSession, sessionManager, sessionId))
+	return dappId
+}
+
+// getDcSession returns the dApp connect session with the given ID using the
+// file encryption key for the request with the given Echo context. Returns nil
+// if the session could not be retrieved.
+func getDcSession(
+	c echo.Context,
+	walletSession *wallet_session.WalletSession,
+	sessionManager *session.Manager,
+	sessionId string,
+) *session.Session {
+	mek, err := dc.RequestFileKey(c, walletSession)
+	if err != nil {
+		return nil
+	}
+
+	dcSession, err := sessionManager.GetSession(sessionId, mek)
+	if err != nil {
+		return nil
+	}
+
+	return dcSession
+}
+
+// dappOf returns the Base64-encoded ID and the data of the dApp of the given
+// dApp connect session. Returns an empty ID and nil data if the session is nil.
+func dappOf(dcSession *session.Session) (dappId string, dappData *dc.DappData) {
+	if dcSession == nil {
+		return
+	}
+
+	return base64.StdEncoding.EncodeToString(dcSession.DappId().Bytes()), dcSession.DappData()
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"duckysigner/internal/wallet_session"
+)
+
+// UseWalletDataFiles is middleware that marks the data files of the given
+// wallet session as in use while a request is being handled. This keeps the
+// wallet's password from being changed, which re-encrypts the data files, while
+// the route handlers are reading from or writing to them. Route handlers that
+// wait for the user to approve a request do so within
+// dapp_connect.WithoutDataFiles so that the password can be changed while they
+// wait.
+func UseWalletDataFiles(walletSession *wallet_session.WalletSession) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			walletSession.UseDataFiles()
+			defer walletSession.UnuseDataFiles()
+
+			return next(c)
+		}
+	}
+}
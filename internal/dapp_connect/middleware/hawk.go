@@ -10,6 +10,7 @@ import (
 	"github.com/hiyosi/hawk"
 	"github.com/labstack/echo/v4"
 
+	"duckysigner/internal/audit"
 	dc "duckysigner/internal/dapp_connect"
 	"duckysigner/internal/dapp_connect/session"
 	"duckysigner/internal/wallet_session"
@@ -34,6 +35,9 @@ type HawkOptions struct {
 	OptionalAuth bool
 	// The store that contains a function for retrieving credentials.
 	CredentialStore hawk.CredentialStore
+	// The current wallet session. If set, failed authentication attempts are
+	// recorded in the wallet's audit log.
+	WalletSession *wallet_session.WalletSession
 }
 
 // HawkAuth is the part of the Hawk "middleware" that handles the request. It
@@ -70,6 +74,10 @@ func HawkAuth(reqBody []byte, opt *HawkOptions) (*hawk.Server, *hawk.Credential,
 	// Authenticate the Hawk request header
 	cred, err := hawkServer.Authenticate(req)
 	if err != nil {
+		dc.RecordAuditEntry(opt.EchoContext, opt.WalletSession, audit.Entry{
+			Event:   audit.EventAuthFail,
+			Details: req.Method + " " + req.URL.Path + ": " + err.Error(),
+		}, opt.EchoInstance.Logger)
 		return nil, nil, &dc.ApiError{Name: "auth_request_failed", Message: err.Error()}
 	}
 
@@ -124,6 +132,9 @@ func HawkRespJSON(
 // SessionCredentialStore is a Hawk CredentialStore use for retrieving dApp
 // connect session credentials
 type SessionCredentialStore struct {
+	// The Echo context of the request being authenticated, which is used to
+	// reuse the file encryption key for the rest of the request
+	EchoContext    echo.Context
 	WalletSession  *wallet_session.WalletSession
 	SessionManager *session.Manager
 }
@@ -133,7 +144,7 @@ type SessionCredentialStore struct {
 func (store SessionCredentialStore) GetCredential(id string) (*hawk.Credential, error) {
 	// The wallet session's master key is needed to read the dApp connect
 	// session database file
-	mek, err := store.WalletSession.GetMasterKey()
+	mek, err := dc.RequestFileKey(store.EchoContext, store.WalletSession)
 	if err != nil {
 		return nil, err
 	}
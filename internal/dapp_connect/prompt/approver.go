@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"duckysigner/internal/dapp_connect/approval"
+)
+
+// WailsApprover is an approval.Approver that asks the user for approval
+// through the UI of the Wails app by using a prompt broker
+type WailsApprover struct {
+	broker *Broker
+}
+
+// NewWailsApprover creates a new WailsApprover that sends its prompts to the UI
+// using the given broker
+func NewWailsApprover(broker *Broker) *WailsApprover {
+	return &WailsApprover{broker: broker}
+}
+
+// ApproveSession prompts the user through the UI to approve a dApp connect
+// session
+func (a *WailsApprover) ApproveSession(
+	ctx context.Context,
+	promptData approval.SessionPromptData,
+) (*approval.SessionResponse, error) {
+	var resp approval.SessionResponse
+	err := a.prompt(
+		ctx,
+		approval.SessionPromptEventName,
+		approval.SessionRespEventName,
+		promptData,
+		&resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ApproveTransaction prompts the user through the UI to approve signing a
+// transaction
+func (a *WailsApprover) ApproveTransaction(
+	ctx context.Context,
+	promptData approval.TxnPromptData,
+) (*approval.TxnResponse, error) {
+	var resp approval.TxnResponse
+	err := a.prompt(
+		ctx,
+		approval.TxnPromptEventName,
+		approval.TxnRespEventName,
+		promptData,
+		&resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ApproveGroup prompts the user through the UI to approve signing the
+// transactions of a transaction group
+func (a *WailsApprover) ApproveGroup(
+	ctx context.Context,
+	promptData approval.GroupPromptData,
+) (*approval.TxnResponse, error) {
+	var resp approval.TxnResponse
+	err := a.prompt(
+		ctx,
+		approval.GroupPromptEventName,
+		approval.GroupRespEventName,
+		promptData,
+		&resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// prompt sends the given prompt data to the UI and parses the UI's response
+// into the given response data
+func (a *WailsApprover) prompt(
+	ctx context.Context,
+	promptEvent string,
+	respEvent string,
+	promptData any,
+	respData any,
+) error {
+	// The deadline of the context is used as the timeout
+	respJSON, err := a.broker.Prompt(ctx, promptEvent, respEvent, promptData, 0)
+	if errors.Is(err, ErrTimeout) {
+		return approval.ErrTimeout
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(respJSON), respData)
+}
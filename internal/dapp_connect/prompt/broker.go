@@ -0,0 +1,279 @@
+// Package prompt handles prompting the user through the UI and routing the
+// user's responses back to whatever requested the prompt
+package prompt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// CancelEventName is the name of the event that is emitted to the UI when a
+// prompt is no longer waiting for a response (e.g. it timed out). The event
+// data contains the request ID of the prompt.
+const CancelEventName string = "prompt_cancel"
+
+// requestIdLen is the number of random bytes in a request ID
+const requestIdLen = 16
+
+var (
+	// ErrTimeout is the error returned when the user does not respond to a
+	// prompt in time
+	ErrTimeout = errors.New("timed out waiting for user response")
+	// ErrNoEventManager is the error returned when the broker does not have
+	// an event manager to send prompts to the UI with
+	ErrNoEventManager = errors.New("missing event manager, dApp connect service not properly initialized")
+)
+
+// EventManager is what the broker uses to send events to and receive events
+// from the UI. It is typically the event manager of the Wails app (e.g.
+// `app.Event`).
+type EventManager interface {
+	// Emit emits an event with the given name and data
+	Emit(name string, data ...any) bool
+	// On registers a listener for events with the given name. Returns a
+	// function that removes the listener.
+	On(name string, callback func(event *application.CustomEvent)) func()
+}
+
+// RequestData contains the request ID that is added to the data of each
+// prompt. The UI must include this in its response to the prompt so the
+// response can be routed back to the prompt.
+type RequestData struct {
+	RequestID string `json:"request_id"`
+}
+
+// responseListener is a listener for a response event that is shared by all
+// the prompts waiting on that response event
+type responseListener struct {
+	// Removes the listener from the event manager
+	off func()
+	// Number of prompts using the listener
+	refs uint
+}
+
+// Broker sends prompts to the UI and routes the UI's responses back to the
+// prompts using a unique request ID for each prompt. This allows for multiple
+// prompts to be waiting for a response at the same time without one getting
+// the response meant for another.
+type Broker struct {
+	// The event manager used to communicate with the UI
+	events EventManager
+	// Logger for debug messages
+	logger echo.Logger
+	// Guards pending and listeners
+	mutex sync.Mutex
+	// Channels for sending the UI response of each pending prompt, keyed by
+	// request ID
+	pending map[string]chan string
+	// Listeners for each response event, keyed by response event name
+	listeners map[string]*responseListener
+}
+
+// NewBroker creates a new prompt broker that uses the given event manager to
+// communicate with the UI
+func NewBroker(events EventManager, logger echo.Logger) *Broker {
+	return &Broker{
+		events:    events,
+		logger:    logger,
+		pending:   make(map[string]chan string),
+		listeners: make(map[string]*responseListener),
+	}
+}
+
+// Prompt sends the given prompt data to the UI by emitting an event with the
+// given "prompt event" name, then waits for the UI to respond with an event
+// with the given "response event" name. The prompt data must be something that
+// is converted to a JSON object, which is given a unique `request_id` before
+// being sent. Only a response containing the same `request_id` is accepted.
+// Returns the UI's response as a JSON string.
+//
+// Waiting stops when the given context is done or the given timeout is
+// reached, whichever is first. A timeout of 0 means there is no timeout. When
+// waiting stops without a response, the cancel event is emitted to let the UI
+// know that the prompt is no longer valid.
+func (b *Broker) Prompt(
+	ctx context.Context,
+	promptEvent string,
+	respEvent string,
+	promptData any,
+	timeout time.Duration,
+) (resp string, err error) {
+	if b == nil || b.events == nil {
+		return "", ErrNoEventManager
+	}
+
+	requestId, err := generateRequestId()
+	if err != nil {
+		return
+	}
+
+	promptJSON, err := addRequestId(promptData, requestId)
+	if err != nil {
+		return
+	}
+
+	respCh := b.register(requestId, respEvent)
+	// Always clean up, no matter how waiting for the response ends
+	defer b.unregister(requestId, respEvent)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Prompt the UI *after* setting up the listener for the UI response
+	// because the UI could respond before the listener is set up otherwise
+	b.logger.Debug("Emitting ", promptEvent, " event to UI with request ID ", requestId)
+	b.events.Emit(promptEvent, promptJSON)
+
+	// Wait for user response...
+	select {
+	case resp = <-respCh:
+		return resp, nil
+	case <-ctx.Done():
+		b.logger.Debug("Canceling prompt with request ID ", requestId)
+		cancelData, _ := json.Marshal(RequestData{RequestID: requestId})
+		b.events.Emit(CancelEventName, string(cancelData))
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", ErrTimeout
+		}
+		return "", ctx.Err()
+	}
+}
+
+// Pending returns the number of prompts that are waiting for a response
+func (b *Broker) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.pending)
+}
+
+// register adds a pending prompt with the given request ID that is waiting on
+// the given response event. A listener for the response event is set up if
+// there is not one already. Returns the channel the response is sent through.
+func (b *Broker) register(requestId, respEvent string) chan string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Buffered so the listener never blocks, even if the prompt has stopped
+	// waiting
+	respCh := make(chan string, 1)
+	b.pending[requestId] = respCh
+
+	listener, ok := b.listeners[respEvent]
+	if !ok {
+		b.logger.Debug("Listening for ", respEvent, " event from UI")
+		listener = &responseListener{
+			off: b.events.On(respEvent, func(e *application.CustomEvent) {
+				b.route(respEvent, eventDataString(e.Data))
+			}),
+		}
+		b.listeners[respEvent] = listener
+	}
+	listener.refs++
+
+	return respCh
+}
+
+// unregister removes the pending prompt with the given request ID. The
+// listener for the given response event is removed if no other prompts are
+// using it.
+func (b *Broker) unregister(requestId, respEvent string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.pending, requestId)
+
+	listener, ok := b.listeners[respEvent]
+	if !ok {
+		return
+	}
+	listener.refs--
+	if listener.refs == 0 {
+		b.logger.Debug("No longer listening for ", respEvent, " event from UI")
+		listener.off()
+		delete(b.listeners, respEvent)
+	}
+}
+
+// route sends the given response data to the pending prompt with the request
+// ID found in the data. Responses for unknown request IDs are ignored.
+func (b *Broker) route(respEvent, data string) {
+	var reqData RequestData
+	if err := json.Unmarshal([]byte(data), &reqData); err != nil || reqData.RequestID == "" {
+		b.logger.Warn("Ignoring ", respEvent, " event without a valid request ID")
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	respCh, ok := b.pending[reqData.RequestID]
+	if !ok {
+		b.logger.Warn("Ignoring ", respEvent, " event for unknown request ID ", reqData.RequestID)
+		return
+	}
+	// Only the first response to a prompt is accepted
+	delete(b.pending, reqData.RequestID)
+	respCh <- data
+}
+
+// addRequestId converts the given prompt data to a JSON object and adds the
+// given request ID to it
+func addRequestId(promptData any, requestId string) (string, error) {
+	dataJSON, err := json.Marshal(promptData)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(dataJSON, &fields); err != nil {
+		return "", fmt.Errorf("prompt data must be a JSON object: %w", err)
+	}
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+
+	fields["request_id"], _ = json.Marshal(requestId)
+
+	dataJSON, err = json.Marshal(fields)
+	return string(dataJSON), err
+}
+
+// eventDataString converts the given event data into a string. The data of an
+// event sent from the UI is sometimes within an array.
+func eventDataString(data any) string {
+	switch d := data.(type) {
+	case string:
+		return d
+	case []any:
+		if len(d) == 1 {
+			if s, ok := d[0].(string); ok {
+				return s
+			}
+		}
+	}
+
+	return fmt.Sprint(data)
+}
+
+// generateRequestId generates a random request ID
+func generateRequestId() (string, error) {
+	idBytes := make([]byte, requestIdLen)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(idBytes), nil
+}
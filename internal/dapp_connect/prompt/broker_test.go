@@ -0,0 +1,203 @@
+package prompt_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	"duckysigner/internal/dapp_connect/prompt"
+)
+
+// fakeEventManager is a minimal event manager that dispatches events to its
+// listeners in the background, like the UI would
+type fakeEventManager struct {
+	mutex     sync.Mutex
+	nextId    int
+	listeners map[string]map[int]func(*application.CustomEvent)
+	// Every emitted event, in order
+	emitted []*application.CustomEvent
+}
+
+func newFakeEventManager() *fakeEventManager {
+	return &fakeEventManager{listeners: make(map[string]map[int]func(*application.CustomEvent))}
+}
+
+func (em *fakeEventManager) Emit(name string, data ...any) bool {
+	event := &application.CustomEvent{Name: name, Data: data[0]}
+
+	em.mutex.Lock()
+	em.emitted = append(em.emitted, event)
+	var callbacks []func(*application.CustomEvent)
+	for _, cb := range em.listeners[name] {
+		callbacks = append(callbacks, cb)
+	}
+	em.mutex.Unlock()
+
+	for _, cb := range callbacks {
+		go cb(event)
+	}
+	return true
+}
+
+func (em *fakeEventManager) On(name string, callback func(*application.CustomEvent)) func() {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+
+	if em.listeners[name] == nil {
+		em.listeners[name] = make(map[int]func(*application.CustomEvent))
+	}
+	id := em.nextId
+	em.nextId++
+	em.listeners[name][id] = callback
+
+	return func() {
+		em.mutex.Lock()
+		defer em.mutex.Unlock()
+		delete(em.listeners[name], id)
+	}
+}
+
+func (em *fakeEventManager) listenerCount(name string) int {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+	return len(em.listeners[name])
+}
+
+func (em *fakeEventManager) emittedNamed(name string) (events []*application.CustomEvent) {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+	for _, e := range em.emitted {
+		if e.Name == name {
+			events = append(events, e)
+		}
+	}
+	return
+}
+
+// requestIdOf returns the request ID within the given event data
+func requestIdOf(data any) string {
+	var reqData prompt.RequestData
+	Expect(json.Unmarshal([]byte(fmt.Sprint(data)), &reqData)).To(Succeed())
+	return reqData.RequestID
+}
+
+var _ = Describe("Prompt Broker", func() {
+	const promptEvt = "test_prompt"
+	const respEvt = "test_response"
+
+	var events *fakeEventManager
+	var broker *prompt.Broker
+
+	BeforeEach(func() {
+		events = newFakeEventManager()
+		broker = prompt.NewBroker(events, log.New("test"))
+	})
+
+	It("sends the prompt data with a request ID and returns the matching response", func() {
+		events.On(promptEvt, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			var data map[string]any
+			Expect(json.Unmarshal([]byte(fmt.Sprint(e.Data)), &data)).To(Succeed())
+			Expect(data["foo"]).To(Equal("bar"))
+			Expect(data["request_id"]).ToNot(BeEmpty())
+			events.Emit(respEvt, fmt.Sprintf(`{"request_id":"%s","answer":42}`, data["request_id"]))
+		})
+
+		resp, err := broker.Prompt(context.Background(), promptEvt, respEvt, map[string]string{"foo": "bar"}, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp).To(ContainSubstring(`"answer":42`))
+		Expect(broker.Pending()).To(Equal(0))
+		Expect(events.listenerCount(respEvt)).To(Equal(0), "Response listener is removed")
+	})
+
+	It("routes concurrent responses to the correct prompts", func() {
+		// Respond to prompts in the reverse order they were sent
+		var promptIds []string
+		var promptIdsMutex sync.Mutex
+		events.On(promptEvt, func(e *application.CustomEvent) {
+			defer GinkgoRecover()
+			promptIdsMutex.Lock()
+			defer promptIdsMutex.Unlock()
+			promptIds = append(promptIds, requestIdOf(e.Data))
+			if len(promptIds) == 2 {
+				for i := len(promptIds) - 1; i >= 0; i-- {
+					events.Emit(respEvt, fmt.Sprintf(`{"request_id":"%s","for":"%s"}`, promptIds[i], promptIds[i]))
+				}
+			}
+		})
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				resp, err := broker.Prompt(context.Background(), promptEvt, respEvt, struct{}{}, 5*time.Second)
+				Expect(err).ToNot(HaveOccurred())
+				var respData struct {
+					RequestID string `json:"request_id"`
+					For       string `json:"for"`
+				}
+				Expect(json.Unmarshal([]byte(resp), &respData)).To(Succeed())
+				Expect(respData.For).To(Equal(respData.RequestID), "Got response meant for this prompt")
+			}()
+		}
+		wg.Wait()
+
+		Expect(broker.Pending()).To(Equal(0))
+		Expect(events.listenerCount(respEvt)).To(Equal(0), "Response listener is removed")
+	})
+
+	It("ignores responses with an unknown or missing request ID", func() {
+		events.On(promptEvt, func(e *application.CustomEvent) {
+			events.Emit(respEvt, `{"answer":"no id"}`)
+			events.Emit(respEvt, `{"request_id":"unknown","answer":"wrong id"}`)
+		})
+
+		_, err := broker.Prompt(context.Background(), promptEvt, respEvt, struct{}{}, 100*time.Millisecond)
+		Expect(err).To(MatchError(prompt.ErrTimeout))
+	})
+
+	It("times out and emits a cancel event when there is no response", func() {
+		_, err := broker.Prompt(context.Background(), promptEvt, respEvt, struct{}{}, 50*time.Millisecond)
+		Expect(err).To(MatchError(prompt.ErrTimeout))
+
+		promptEvents := events.emittedNamed(promptEvt)
+		Expect(promptEvents).To(HaveLen(1))
+		cancelEvents := events.emittedNamed(prompt.CancelEventName)
+		Expect(cancelEvents).To(HaveLen(1))
+		Expect(requestIdOf(cancelEvents[0].Data)).To(Equal(requestIdOf(promptEvents[0].Data)))
+
+		Expect(broker.Pending()).To(Equal(0))
+		Expect(events.listenerCount(respEvt)).To(Equal(0), "Response listener is removed")
+	})
+
+	It("stops waiting when the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		events.On(promptEvt, func(e *application.CustomEvent) { cancel() })
+
+		_, err := broker.Prompt(ctx, promptEvt, respEvt, struct{}{}, 0)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(events.emittedNamed(prompt.CancelEventName)).To(HaveLen(1))
+		Expect(events.listenerCount(respEvt)).To(Equal(0), "Response listener is removed")
+	})
+
+	It("fails if the prompt data is not a JSON object", func() {
+		_, err := broker.Prompt(context.Background(), promptEvt, respEvt, "not an object", time.Second)
+		Expect(err).To(HaveOccurred())
+		Expect(events.emittedNamed(promptEvt)).To(BeEmpty())
+	})
+
+	It("fails if there is no event manager", func() {
+		_, err := prompt.NewBroker(nil, log.New("test")).
+			Prompt(context.Background(), promptEvt, respEvt, struct{}{}, time.Second)
+		Expect(err).To(MatchError(prompt.ErrNoEventManager))
+	})
+})
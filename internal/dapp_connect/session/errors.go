@@ -13,6 +13,10 @@ const (
 	// NoDappIdGivenErrMsg is the error message text for when no dApp ID is
 	// given
 	NoDappIdGivenErrMsg = "no dApp ID was given"
+	// NoSessionNetworksErrMsg is the error message text for when a session is
+	// not bound to any network because it was established before sessions
+	// were bound to networks
+	NoSessionNetworksErrMsg = "session is not bound to any network, connect again to establish a new session"
 	// RemoveSessionNotExistErrMsg is the error message text for when there is
 	// an attempt to remove a session that is not stored
 	RemoveSessionNotStoredErrMsg = "cannot remove session that is not stored"
@@ -72,8 +72,13 @@ CREATE TABLE IF NOT EXISTS db.sessions (
     dapp_desc VARCHAR,
     dapp_icon VARCHAR,
     addrs VARCHAR[],
+    networks VARCHAR[],
 );
 
+-- Add the networks column to sessions data files created before sessions were
+-- bound to networks
+ALTER TABLE db.sessions ADD COLUMN IF NOT EXISTS networks VARCHAR[];
+
 CREATE TABLE IF NOT EXISTS db.confirms (
     id VARCHAR PRIMARY KEY,
     key BLOB NOT NULL
@@ -81,7 +86,7 @@ CREATE TABLE IF NOT EXISTS db.confirms (
 `
 
 // sessionInsertSQL is the SQL statement for inserting a session into a table
-const sessionInsertSQL = "INSERT INTO db.sessions VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+const sessionInsertSQL = "INSERT INTO db.sessions VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 // confirmInsertSQL is the SQL statement for inserting a confirmation key pair
 // into a table
@@ -250,8 +255,13 @@ func (sm *Manager) ApprovalTimeout() time.Duration {
 
 // GenerateSession creates a new unestablished session (with no established-at
 // date-time) by generating a new session key pair for the dApp with the given
-// ID with the given dApp data
-func (sm *Manager) GenerateSession(dappId *ecdh.PublicKey, dappData *dc.DappData, addrs []string) (session *Session, err error) {
+// ID with the given dApp data, connect addresses and network profile names
+func (sm *Manager) GenerateSession(
+	dappId *ecdh.PublicKey,
+	dappData *dc.DappData,
+	addrs []string,
+	networks []string,
+) (session *Session, err error) {
 	// Generate session key pair
 	sessionKey, err := sm.curve.GenerateKey(rand.Reader)
 	if err != nil {
@@ -265,6 +275,7 @@ func (sm *Manager) GenerateSession(dappId *ecdh.PublicKey, dappData *dc.DappData
 		dappId:   dappId,
 		dappData: dappData,
 		addrs:    addrs,
+		networks: networks,
 	}
 
 	return
@@ -292,6 +303,7 @@ func (sm *Manager) GetSession(sessionId string, fileEncKey []byte) (*Session, er
 		retrievedDappDesc        string
 		retrievedDappIcon        string
 		retrievedAddrs           duckdb.Composite[[]string]
+		retrievedNetworks        duckdb.Composite[[]string]
 	)
 	sessionRow := db.QueryRow(fmt.Sprintf(findItemByIdSQL, sessionsTblName), sessionId)
 	err = sessionRow.Scan(
@@ -305,6 +317,7 @@ func (sm *Manager) GetSession(sessionId string, fileEncKey []byte) (*Session, er
 		&retrievedDappDesc,
 		&retrievedDappIcon,
 		&retrievedAddrs,
+		&retrievedNetworks,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -324,6 +337,7 @@ func (sm *Manager) GetSession(sessionId string, fileEncKey []byte) (*Session, er
 		retrievedDappDesc,
 		retrievedDappIcon,
 		retrievedAddrs.Get(),
+		retrievedNetworks.Get(),
 	)
 }
 
@@ -354,6 +368,7 @@ func (sm *Manager) GetAllSessions(fileEncKey []byte) (retrievedSessions []*Sessi
 			retrievedDappDesc        string
 			retrievedDappIcon        string
 			retrievedAddrs           duckdb.Composite[[]string]
+			retrievedNetworks        duckdb.Composite[[]string]
 		)
 
 		err = sessionsRows.Scan(
@@ -367,6 +382,7 @@ func (sm *Manager) GetAllSessions(fileEncKey []byte) (retrievedSessions []*Sessi
 			&retrievedDappDesc,
 			&retrievedDappIcon,
 			&retrievedAddrs,
+			&retrievedNetworks,
 		)
 		if err != nil {
 			// An unexpected error occurred
@@ -384,6 +400,7 @@ func (sm *Manager) GetAllSessions(fileEncKey []byte) (retrievedSessions []*Sessi
 			retrievedDappDesc,
 			retrievedDappIcon,
 			retrievedAddrs.Get(),
+			retrievedNetworks.Get(),
 		)
 		if convertErr != nil {
 			// Return the incomplete set along with the error
@@ -444,6 +461,7 @@ func (sm *Manager) StoreSession(session *Session, fileEncKey []byte) (err error)
 		dappData.Description,
 		dappData.Icon,
 		session.addrs,
+		session.networks,
 	)
 	if err != nil {
 		// If the session already exists
@@ -512,8 +530,9 @@ func (sm *Manager) PurgeExpiredSessions(fileEncKey []byte) (numPurged uint, err
 }
 
 // EstablishSession creates a new established session using the given dApp data
-// and connect addresses after checking the given confirmation token, code and
-// key. NOTE: The established session is not saved into the data file. Use
+// and connect addresses, and bound to the networks with the given profile
+// names, after checking the given confirmation token, code and key.
+// NOTE: The established session is not saved into the data file. Use
 // `StoreSession()` to save the session.
 func (sm *Manager) EstablishSession(
 	token string,
@@ -521,6 +540,7 @@ func (sm *Manager) EstablishSession(
 	key *ecdh.PrivateKey,
 	dappData *dc.DappData,
 	connectAddrs []string,
+	networks []string,
 ) (*Session, error) {
 	// Check token
 	if token == "" {
@@ -547,14 +567,16 @@ func (sm *Manager) EstablishSession(
 		establishedAt: now,
 		exp:           now.Add(sm.sessionLifetime),
 		addrs:         connectAddrs,
+		networks:      networks,
 	}
 
 	return &session, nil
 }
 
 // EstablishSessionWithConfirm creates a new established session using the given
-// dApp data and connect addresses after checking the given confirmation code
-// (given by the wallet user) using the data within the given confirmation.
+// dApp data and connect addresses, and bound to the networks with the given
+// profile names, after checking the given confirmation code (given by the
+// wallet user) using the data within the given confirmation.
 // NOTE: The established session is not saved into the data file. Use
 // `StoreSession()` to save the session.
 func (sm *Manager) EstablishSessionWithConfirm(
@@ -562,6 +584,7 @@ func (sm *Manager) EstablishSessionWithConfirm(
 	codeFromUser string,
 	dappData *dc.DappData,
 	connectAddrs []string,
+	networks []string,
 ) (*Session, error) {
 	// Check confirmation
 	if confirm == nil {
@@ -582,6 +605,7 @@ func (sm *Manager) EstablishSessionWithConfirm(
 		establishedAt: now,
 		exp:           now.Add(sm.sessionLifetime),
 		addrs:         connectAddrs,
+		networks:      networks,
 	}
 
 	return &session, nil
@@ -824,6 +848,7 @@ func (sm *Manager) rowToSession(
 	dappDesc string,
 	dappIcon string,
 	addrs []string,
+	networks []string,
 ) (*Session, error) {
 	// Convert session key bytes to an ECDH private key
 	retrievedSessionKey, err := sm.curve.NewPrivateKey(sessionKeyBytes)
@@ -852,7 +877,8 @@ func (sm *Manager) rowToSession(
 			Description: dappDesc,
 			Icon:        dappIcon,
 		},
-		addrs: addrs,
+		addrs:    addrs,
+		networks: networks,
 	}, nil
 }
 
@@ -84,7 +84,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 			sessionManager := session.NewManager(mockCurve, nil)
 			newSession, err := sessionManager.GenerateSession(dappId, &dc.DappData{}, []string{
 				"RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A",
-			})
+			}, []string{"TestNet"})
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Checking the newly created session")
@@ -95,6 +95,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				"Has correct expiry",
 			)
 			Expect(newSession.DappId()).To(Equal(dappId), "Has correct dApp ID")
+			Expect(newSession.Networks()).To(Equal([]string{"TestNet"}), "Has correct networks")
 		})
 	})
 
@@ -161,6 +162,36 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				"Retrieved session has correct dApp icon")
 			Expect(retrievedSession.Addresses()).To(HaveLen(1),
 				"Retrieved session has correct number of addresses in address list")
+			Expect(retrievedSession.Networks()).To(Equal([]string{"TestNet"}),
+				"Retrieved session has correct networks")
+		})
+
+		It("gets a session stored without networks as a session that has to be established again", func() {
+			By("Storing a session that is not bound to any network")
+			dappKey, err := curve.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			sessionKey, err := curve.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			testSession := session.New(
+				sessionKey,
+				dappKey.PublicKey(),
+				time.Now().Add(5*time.Minute),
+				time.Now(),
+				&dc.DappData{Name: "My Old DApp"},
+				[]string{"RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"},
+				nil,
+			)
+			Expect(sessionManager.StoreSession(&testSession, fileEncryptKey[:])).To(Succeed())
+
+			By("Retrieving the stored session")
+			sessionId := b64encoder.EncodeToString(sessionKey.PublicKey().Bytes())
+			retrievedSession, err := sessionManager.GetSession(sessionId, fileEncryptKey[:])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retrievedSession).ToNot(BeNil())
+
+			By("Checking that the session has to be established again")
+			Expect(retrievedSession.Networks()).To(BeEmpty())
+			Expect(retrievedSession.CheckNetworks()).To(MatchError(session.NoSessionNetworksErrMsg))
 		})
 
 		It("returns nil when attempting to get a session that does not exist", func() {
@@ -249,7 +280,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 			}
 
 			By("Creating a session")
-			testSession := session.New(sessionKey, dappId, exp, est, &testDappData, nil)
+			testSession := session.New(sessionKey, dappId, exp, est, &testDappData, nil, []string{"TestNet", "LocalNet"})
 
 			By("Attempting to store session")
 			// Generate file encryption key
@@ -275,6 +306,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				storedDappDesc   string
 				storedDappIcon   string
 				storedAddrs      duckdb.Composite[[]string]
+				storedNetworks   duckdb.Composite[[]string]
 			)
 			storedSessionRow := db.QueryRow("FROM db.sessions LIMIT 1")
 			storedSessionRow.Scan(
@@ -283,6 +315,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				&storedDappId,
 				&storedDappName, &storedDappURL, &storedDappDesc, &storedDappIcon,
 				&storedAddrs,
+				&storedNetworks,
 			)
 
 			Expect(storedSessionId).To(Equal(b64encoder.EncodeToString(sessionId.Bytes())),
@@ -305,6 +338,8 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				"Stored session has correct dApp icon")
 			Expect(storedAddrs.Get()).To(HaveLen(0),
 				"Stored session has correct number of addresses")
+			Expect(storedNetworks.Get()).To(Equal([]string{"TestNet", "LocalNet"}),
+				"Stored session has correct networks")
 		})
 
 		It("can add session to a data file that already exists", func() {
@@ -325,7 +360,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				URL:         "https://example.com",
 				Description: "This is a test.",
 				Icon:        "",
-			}, nil)
+			}, nil, nil)
 
 			By("Storing the first session")
 			// Generate file encryption key
@@ -350,7 +385,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				Description: "This is another test.",
 				Icon:        "",
 			}
-			testSession2 := session.New(sessionKey2, dappId2, exp2, est2, &testDappData, nil)
+			testSession2 := session.New(sessionKey2, dappId2, exp2, est2, &testDappData, nil, nil)
 
 			By("Attempting to store the second session")
 			err = sessionManager.StoreSession(&testSession2, fileEncryptKey[:])
@@ -372,6 +407,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				storedDappDesc   string
 				storedDappIcon   string
 				storedAddrs      duckdb.Composite[[]string]
+				storedNetworks   duckdb.Composite[[]string]
 			)
 			storedSessionRow := db.QueryRow("FROM db.sessions WHERE id=?", sessionId2B64)
 			storedSessionRow.Scan(
@@ -380,6 +416,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				&storedDappId,
 				&storedDappName, &storedDappURL, &storedDappDesc, &storedDappIcon,
 				&storedAddrs,
+				&storedNetworks,
 			)
 
 			Expect(storedSessionId).To(Equal(sessionId2B64),
@@ -424,7 +461,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				Description: "This is a test.",
 				Icon:        "",
 			}
-			testSession := session.New(sessionKey, dappId, exp, est, &testDappData, nil)
+			testSession := session.New(sessionKey, dappId, exp, est, &testDappData, nil, nil)
 
 			By("Attempting to store session")
 			// Generate file encryption key
@@ -450,6 +487,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				storedDappDesc   string
 				storedDappIcon   string
 				storedAddrs      duckdb.Composite[[]string]
+				storedNetworks   duckdb.Composite[[]string]
 			)
 			storedSessionRow := db.QueryRow("FROM db.sessions LIMIT 1")
 			storedSessionRow.Scan(
@@ -458,6 +496,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				&storedDappId,
 				&storedDappName, &storedDappURL, &storedDappDesc, &storedDappIcon,
 				&storedAddrs,
+				&storedNetworks,
 			)
 
 			Expect(storedSessionId).To(Equal(b64encoder.EncodeToString(sessionId.Bytes())),
@@ -512,7 +551,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				URL:         "https://example.com",
 				Description: "This is a test.",
 				Icon:        "",
-			}, nil)
+			}, nil, nil)
 
 			By("Attempting to store session with no session key")
 			// Generate file encryption key
@@ -541,7 +580,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				URL:         "https://example.com",
 				Description: "This is a test.",
 				Icon:        "",
-			}, nil)
+			}, nil, nil)
 
 			By("Storing the session")
 			// Generate file encryption key
@@ -571,7 +610,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				URL:         "https://example.com",
 				Description: "This is a test.",
 				Icon:        "",
-			}, nil)
+			}, nil, nil)
 
 			By("Attempting to store session with no dApp ID")
 			// Generate file encryption key
@@ -738,6 +777,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 					Icon:        "",
 				},
 				nil,
+				nil,
 			)
 			sessionManager.StoreSession(&testSession, fileEncryptKey[:])
 			By("Creating session #2 (valid session)")
@@ -806,7 +846,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				Icon:        "",
 			}
 			newSession, err := sessionManager.EstablishSession(
-				token, confirm.Code(), confirm.Key(), &testDappData, nil,
+				token, confirm.Code(), confirm.Key(), &testDappData, nil, nil,
 			)
 			Expect(err).ToNot(HaveOccurred())
 
@@ -846,7 +886,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 
 			By("Attempting to confirm session without a token")
 			_, err = sessionManager.EstablishSession(
-				"", confirm.Code(), confirm.Key(), &dc.DappData{}, nil,
+				"", confirm.Code(), confirm.Key(), &dc.DappData{}, nil, nil,
 			)
 			Expect(err).To(MatchError(session.NoConfirmTokenGivenErrMsg))
 		})
@@ -867,7 +907,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Attempting to confirm session using token using incorrect confirmation code")
-			_, err = sessionManager.EstablishSession(token, "XXXXX", confirm.Key(), &dc.DappData{}, nil)
+			_, err = sessionManager.EstablishSession(token, "XXXXX", confirm.Key(), &dc.DappData{}, nil, nil)
 			Expect(err).To(MatchError(session.WrongConfirmCodeErrMsg))
 		})
 
@@ -894,7 +934,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				Icon:        "",
 			}
 			_, err = sessionManager.EstablishSession(
-				token, confirm.Code(), dappKey, &testDappData, nil,
+				token, confirm.Code(), dappKey, &testDappData, nil, nil,
 			)
 			Expect(err).To(HaveOccurred())
 		})
@@ -920,7 +960,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				Icon:        "",
 			}
 			newSession, err := sessionManager.EstablishSessionWithConfirm(
-				confirm, confirm.Code(), &testDappData, nil,
+				confirm, confirm.Code(), &testDappData, nil, []string{"TestNet"},
 			)
 			Expect(err).ToNot(HaveOccurred())
 
@@ -935,6 +975,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 				"Has correct established-at date-time",
 			)
 			Expect(newSession.DappId()).To(Equal(dappId), "Has correct dApp ID")
+			Expect(newSession.Networks()).To(Equal([]string{"TestNet"}), "Has correct networks")
 
 			newSessionDappData := newSession.DappData()
 			Expect(newSessionDappData.Name).To(Equal(testDappData.Name),
@@ -960,7 +1001,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 
 			By("Attempting to confirm session without a confirmation")
 			_, err = sessionManager.EstablishSessionWithConfirm(
-				nil, confirm.Code(), &dc.DappData{}, nil,
+				nil, confirm.Code(), &dc.DappData{}, nil, nil,
 			)
 			Expect(err).To(MatchError(session.NoConfirmGivenErrMsg))
 		})
@@ -977,7 +1018,7 @@ var _ = Describe("DApp Connect Session Manager", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Attempting to confirm session using token using incorrect confirmation code")
-			_, err = sessionManager.EstablishSessionWithConfirm(confirm, "XXXXX", &dc.DappData{}, nil)
+			_, err = sessionManager.EstablishSessionWithConfirm(confirm, "XXXXX", &dc.DappData{}, nil, nil)
 			Expect(err).To(MatchError(session.WrongConfirmCodeErrMsg))
 		})
 	})
@@ -1311,6 +1352,7 @@ func generateAndStoreSession(sessionManager *session.Manager, fileEncryptKey []b
 		time.Now(),
 		dappData,
 		[]string{"RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"},
+		[]string{"TestNet"},
 	)
 	sessionManager.StoreSession(&testSession, fileEncryptKey)
 
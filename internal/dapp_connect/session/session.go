@@ -2,6 +2,7 @@ package session
 
 import (
 	"crypto/ecdh"
+	"errors"
 	"time"
 
 	dc "duckysigner/internal/dapp_connect"
@@ -24,6 +25,10 @@ type Session struct {
 	// List of addresses the dApp is allowed to connect to. If empty or nil, the
 	// dApp is allowed to connect to all addresses in the wallet.
 	addrs []string
+	// Names of the profiles of the networks the dApp is allowed to request
+	// signatures for. If empty or nil, the dApp is not allowed to request
+	// signatures for any network.
+	networks []string
 }
 
 // New creates a new Session using the given session data
@@ -34,6 +39,7 @@ func New(
 	establishedAt time.Time,
 	dappData *dc.DappData,
 	addrs []string,
+	networks []string,
 ) Session {
 	return Session{
 		key:           key,
@@ -42,6 +48,7 @@ func New(
 		establishedAt: establishedAt,
 		dappData:      dappData,
 		addrs:         addrs,
+		networks:      networks,
 	}
 }
 
@@ -86,6 +93,23 @@ func (session *Session) Addresses() []string {
 	return session.addrs
 }
 
+// Networks returns the names of the profiles of the networks the dApp is
+// allowed to request signatures for
+func (session *Session) Networks() []string {
+	return session.networks
+}
+
+// CheckNetworks returns an error if the session is not bound to any network,
+// which is the case for a session that was established before sessions were
+// bound to networks. Such a session has to be established again.
+func (session *Session) CheckNetworks() error {
+	if len(session.networks) == 0 {
+		return errors.New(NoSessionNetworksErrMsg)
+	}
+
+	return nil
+}
+
 // SharedKey returns the session shared secret key that is derived from session
 // secret key and the dApp ID
 func (session *Session) SharedKey() ([]byte, error) {
@@ -22,7 +22,7 @@ var _ = Describe("DApp Connect Session", func() {
 			sessionId := sessionKey.PublicKey()
 
 			By("Creating a session using generated session key pair")
-			testSession := session.New(sessionKey, nil, time.Time{}, time.Time{}, nil, nil)
+			testSession := session.New(sessionKey, nil, time.Time{}, time.Time{}, nil, nil, nil)
 
 			Expect(testSession.ID()).To(Equal(sessionId))
 		})
@@ -35,7 +35,7 @@ var _ = Describe("DApp Connect Session", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			By("Creating a session using session key")
-			testSession := session.New(sessionKey, nil, time.Time{}, time.Time{}, nil, nil)
+			testSession := session.New(sessionKey, nil, time.Time{}, time.Time{}, nil, nil, nil)
 
 			Expect(testSession.Key()).To(Equal(sessionKey))
 		})
@@ -49,7 +49,7 @@ var _ = Describe("DApp Connect Session", func() {
 			dappId := dappKey.PublicKey()
 
 			By("Creating a session using generated dApp ID")
-			testSession := session.New(nil, dappId, time.Time{}, time.Time{}, nil, nil)
+			testSession := session.New(nil, dappId, time.Time{}, time.Time{}, nil, nil, nil)
 
 			Expect(testSession.DappId()).To(Equal(dappId))
 		})
@@ -58,7 +58,7 @@ var _ = Describe("DApp Connect Session", func() {
 	Describe("Session.Expiration()", func() {
 		It("returns the expiration date-time", func() {
 			testTime := time.Now()
-			testSession := session.New(nil, nil, testTime, time.Time{}, nil, nil)
+			testSession := session.New(nil, nil, testTime, time.Time{}, nil, nil, nil)
 			Expect(testSession.Expiration()).To(Equal(testTime))
 		})
 	})
@@ -66,7 +66,7 @@ var _ = Describe("DApp Connect Session", func() {
 	Describe("Session.EstablishedAt()", func() {
 		It("returns the establishment date-time", func() {
 			testTime := time.Now()
-			testSession := session.New(nil, nil, time.Time{}, testTime, nil, nil)
+			testSession := session.New(nil, nil, time.Time{}, testTime, nil, nil, nil)
 			Expect(testSession.EstablishedAt()).To(Equal(testTime))
 		})
 	})
@@ -83,7 +83,7 @@ var _ = Describe("DApp Connect Session", func() {
 			}
 
 			By("Creating a session using dApp data")
-			testSession := session.New(nil, nil, time.Time{}, time.Time{}, &dappData, nil)
+			testSession := session.New(nil, nil, time.Time{}, time.Time{}, &dappData, nil, nil)
 
 			By("Checking dApp data within session")
 			Expect(testSession.DappData().Name).To(Equal("Foo Bar"))
@@ -99,11 +99,30 @@ var _ = Describe("DApp Connect Session", func() {
 				"RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A",
 				"H3PFTYORQCTLIN7PEPDCYI4ALUHNE4CE5GJIPLZA3ZBKWG23TWND4IP47A",
 				"V3NC4VRDRP33OI2R5AQXEOOXFXXRYHWDKJOCGB64C7QRCF2IWNWHPFZ4QU",
-			})
+			}, nil)
 			Expect(testSession.Addresses()).To(HaveLen(3))
 		})
 	})
 
+	Describe("Session.Networks()", func() {
+		It("returns the names of the networks the session is bound to", func() {
+			testSession := session.New(nil, nil, time.Time{}, time.Time{}, nil, nil, []string{"TestNet", "LocalNet"})
+			Expect(testSession.Networks()).To(Equal([]string{"TestNet", "LocalNet"}))
+		})
+	})
+
+	Describe("Session.CheckNetworks()", func() {
+		It("succeeds if the session is bound to at least one network", func() {
+			testSession := session.New(nil, nil, time.Time{}, time.Time{}, nil, nil, []string{"TestNet"})
+			Expect(testSession.CheckNetworks()).To(Succeed())
+		})
+
+		It("fails if the session is not bound to any network", func() {
+			testSession := session.New(nil, nil, time.Time{}, time.Time{}, nil, nil, nil)
+			Expect(testSession.CheckNetworks()).To(MatchError(session.NoSessionNetworksErrMsg))
+		})
+	})
+
 	Describe("Session.SharedKey()", func() {
 		It("returns the session shared secret key", func() {
 			By("Generating a session key pair (session ID & key)")
@@ -117,7 +136,7 @@ var _ = Describe("DApp Connect Session", func() {
 			dappId := dappKey.PublicKey()
 
 			By("Creating a session using generated session key pair and dApp ID")
-			testSession := session.New(sessionKey, dappId, time.Time{}, time.Time{}, nil, nil)
+			testSession := session.New(sessionKey, dappId, time.Time{}, time.Time{}, nil, nil, nil)
 
 			By("Deriving shared key using dApp key and session ID")
 			sharedKey, err := dappKey.ECDH(sessionId)
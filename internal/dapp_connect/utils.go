@@ -2,16 +2,15 @@ package dapp_connect
 
 import (
 	"crypto/ecdh"
+	"duckysigner/internal/audit"
 	"duckysigner/internal/tools"
+	"duckysigner/internal/wallet_session"
 	"encoding/base64"
-	"errors"
-	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
-	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 // DefaultServerAddr is the default address for the dApp connect server
@@ -71,76 +70,75 @@ func ValidateDappID(dAppID string, curve tools.ECDHCurve) (dappIdPk *ecdh.Public
 	return
 }
 
-// PromptUIOnce sends the given data to the UI by emitting an event with given
-// "prompt event" name to the UI once. It listens and waits only for the first
-// response event from the UI with the given "response event" name. Subsequent
-// responses are ignored. Returns a "UI response" channel that will contain data
-// sent by the UI when the UI responds.
-//
-// NOTE: The event listener for the "response event" should be closed after
-// reading the returned UI response channel or timing out waiting for data to
-// come through the channel.
-//
-// Example:
-//
-//	func f() (string, error) {
-//	    uiRespCh, err := PromptUIOnce(data, "prompt_ui_evt", "ui_resp_evt", app, logger)
-//	    defer wailsApp.OffEvent("ui_resp_evt")
-//	    // Check and possibly return error AFTER setting up to remove the
-//	    // listener when the function terminates
-//	    if err != nil {
-//	        return "", err
-//	    }
-//	    // Wait for UI response...
-//		select {
-//		case <-time.After(5 * time.Minute)
-//	        return "Time ran out waiting for UI to respond", nil
-//	    case uiResp := <-uiRespCh: // Got response from UI
-//	        return uiResp, nil
-//	    }
-//	}
-func PromptUIOnce(
-	promptData string,
-	promptEvent string,
-	respEvent string,
-	wailsApp *application.App,
+// fileKeyContextKey is the key of the file encryption key in the Echo context
+// of a request
+const fileKeyContextKey = "file_key"
+
+// RequestFileKey gives the file encryption key (the master encryption key of the
+// wallet in the given wallet session) for handling the request with the given
+// Echo context. Deriving the key from the wallet password is slow, so the key is
+// derived the first time it is needed while handling the request and then kept
+// in the Echo context for the rest of the request. The key is derived every time
+// if the Echo context is nil.
+func RequestFileKey(c echo.Context, walletSession *wallet_session.WalletSession) ([]byte, error) {
+	if c != nil {
+		if mek, ok := c.Get(fileKeyContextKey).([]byte); ok {
+			return mek, nil
+		}
+	}
+
+	mek, err := walletSession.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil {
+		c.Set(fileKeyContextKey, mek)
+	}
+
+	return mek, nil
+}
+
+// WithoutDataFiles calls the given function, which waits for the user (e.g. to
+// approve a request), with the data files of the wallet in the given wallet
+// session marked as no longer in use by the request with the given Echo
+// context, so that the user can change the wallet's password in the meantime.
+// The data files are marked as in use again before returning. Changing the
+// password changes the file encryption key, so the key kept for the request is
+// dropped.
+func WithoutDataFiles(c echo.Context, walletSession *wallet_session.WalletSession, fn func()) {
+	walletSession.UnuseDataFiles()
+	defer walletSession.UseDataFiles()
+
+	c.Set(fileKeyContextKey, nil)
+	fn()
+}
+
+// RecordAuditEntry adds the given entry to the audit log of the wallet in the
+// given wallet session using the file encryption key for the request with the
+// given Echo context. Failing to record the entry is logged using the given
+// logger. The error is also returned for requests that must not succeed
+// without being recorded (e.g. signing requests), but can be ignored so that it
+// does not interrupt the handling of other requests.
+func RecordAuditEntry(
+	c echo.Context,
+	walletSession *wallet_session.WalletSession,
+	entry audit.Entry,
 	logger echo.Logger,
-) (uiRespCh chan string, err error) {
-	// Check if Wails app is properly initialized
-	if wailsApp == nil {
-		err = errors.New("missing Wails app instance, dApp connect service not properly initialized")
-		return
+) error {
+	if walletSession == nil {
+		return nil
 	}
 
-	// Contains the UI's response to prompt
-	uiRespCh = make(chan string)
-
-	logger.Debug("Removing any existing event listeners for: ", respEvent)
-	wailsApp.Event.Off(respEvent)
-
-	// Set up listener for event that will contain UI's response
-	logger.Debug("Listening for ", respEvent, " event from UI")
-	wailsApp.Event.On(respEvent, func(e *application.CustomEvent) {
-		logger.Debug("Event from UI: ", respEvent, "\nEvent data:", e.Data)
-		// NOTE: For some reason, the actual event data is always within an array
-		uiRespCh <- fmt.Sprint(e.Data)
-		// Only need to know about the first instance of this response, so
-		// immediately close the channel once we get a response. The UI should
-		// only be able to respond to emitted prompt event once. Closing this
-		// channel does not remove the event listener. That will need to be done
-		// somewhere else after the channel value is received and read.
-		close(uiRespCh)
-	})
-
-	// Prompt the UI *after* setting up the listener for the UI response event
-	// because the UI could quickly respond to the emitted prompt event before
-	// the server has the chance to set up the listener to receive the UI's
-	// response. The server misses the UI's only response, which can be a
-	// problem when unit testing.
-	logger.Debug("Emitted ", promptEvent, " event to UI")
-	wailsApp.Event.Emit(promptEvent, promptData)
+	mek, err := RequestFileKey(c, walletSession)
+	if err == nil {
+		_, err = walletSession.AuditLog().Append(entry, mek)
+	}
+	if err != nil {
+		logger.Error("Failed to record audit log entry: ", err)
+	}
 
-	return
+	return err
 }
 
 func GetRawRequestBody(req *http.Request) ([]byte, error) {
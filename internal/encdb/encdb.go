@@ -0,0 +1,85 @@
+// Package encdb contains helpers for working with the encrypted DuckDB database
+// files that store a wallet's data, such as the wallet's accounts database file
+package encdb
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+// attachEncDuckDbSQL is the SQL statement for opening or creating an encrypted
+// DuckDB file. The file encryption key is used for encrypting and decrypting
+// all the encrypted Duck DB files. Requires the file path, the name to attach
+// the file as, the file encryption key in Base64 and any other attach options.
+// NOTE: These SQL statements will create the file if it does not exist.
+const attachEncDuckDbSQL = `
+LOAD httpfs; -- use OpenSSL library to increase speed
+ATTACH '%s' AS %s (ENCRYPTION_KEY '%s'%s);
+`
+
+// Open opens the encrypted DuckDB file with the given file path using the given
+// file encryption key. The file is attached as the `db` database, so tables
+// within the file must be referred to with a `db.` prefix. If a schema is
+// given, it is run after the file is opened, which is typically used to create
+// tables if they do not exist. The file is created if it does not exist.
+//
+// The returned database connection should be closed when it is no longer
+// needed.
+func Open(filePath string, fileEncKey []byte, schema string) (db *sql.DB, err error) {
+	// Open DuckDB in in-memory mode
+	db, err = sql.Open("duckdb", "")
+	if err != nil {
+		return
+	}
+
+	// Open and decrypt the database file
+	err = Attach(db, filePath, fileEncKey, "db", false)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if schema == "" {
+		return
+	}
+
+	// Create tables if they do not exist
+	_, err = db.Exec(schema)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return
+}
+
+// Attach opens the encrypted DuckDB file with the given file path using the
+// given file encryption key and attaches it to the given database connection
+// with the given name. The file is attached as read-only if readOnly is true.
+// Otherwise, the file is created if it does not exist.
+func Attach(db *sql.DB, filePath string, fileEncKey []byte, name string, readOnly bool) error {
+	var options string
+	if readOnly {
+		options = ", READ_ONLY"
+	}
+
+	_, err := db.Exec(fmt.Sprintf(attachEncDuckDbSQL,
+		EscapeString(filePath),
+		name,
+		base64.StdEncoding.EncodeToString(fileEncKey),
+		options,
+	))
+
+	return err
+}
+
+// EscapeString escapes the single quotes in the given string so it can be put
+// within single quotes in an SQL statement, which prevents the string, such as
+// a file path, from being used for SQL injection
+func EscapeString(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
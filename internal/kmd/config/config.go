@@ -42,6 +42,36 @@ type KMDConfig struct {
 	SessionLifetimeSecs uint64       `json:"session_lifetime_secs"`
 	Address             string       `json:"address"`
 	AllowedOrigins      []string     `json:"allowed_origins"`
+	// Checks done on transactions before they are signed
+	TxnValidation TxnValidationConfig `json:"txn_validation"`
+	// Simulation of transactions before asking the user to sign them
+	Simulation SimulationConfig `json:"simulation"`
+	// The networks transactions can be signed for, which are saved separately
+	// in the networks file within the data directory
+	Networks []NetworkProfile `json:"-"`
+}
+
+// TxnValidationConfig is configuration for the checks done on transactions
+// before they are signed. Zero values use the defaults of the validator.
+type TxnValidationConfig struct {
+	// The highest fee (in microAlgos) a transaction can have
+	MaxFee uint64 `json:"max_fee"`
+	// The most rounds a transaction can be valid for
+	MaxValidRounds uint64 `json:"max_valid_rounds"`
+	// The most rounds a transaction with a lease can be valid for, which is
+	// how long the lease prevents other transactions with the same lease
+	MaxLeaseRounds uint64 `json:"max_lease_rounds"`
+}
+
+// SimulationConfig is configuration for simulating transactions with the algod
+// API of their network before asking the user to sign them
+type SimulationConfig struct {
+	// Simulate transactions before asking the user to sign them
+	Enabled bool `json:"enabled"`
+	// Refuse to sign transactions that could not be simulated (e.g. because
+	// algod could not be reached) instead of asking the user to sign them
+	// without the simulation results
+	FailClosed bool `json:"fail_closed"`
 }
 
 // DriverConfig contains config info specific to each wallet driver
@@ -73,12 +103,55 @@ type ParquetWalletDriverConfig struct {
 	ScryptParams ScryptParams `json:"scrypt"`
 }
 
+// KDFParams gives the key derivation function and its parameters that are
+// configured for the ParquetWalletDriver, which only uses scrypt
+func (cfg ParquetWalletDriverConfig) KDFParams() KDFParams {
+	return KDFParams{
+		KDF:          KDFScrypt,
+		ScryptParams: cfg.ScryptParams,
+	}
+}
+
 // DuckDbWalletDriverConfig is configuration specific to the DuckDbWalletDriver
 type DuckDbWalletDriverConfig struct {
-	Disable      bool         `json:"disable"`
-	WalletsDir   string       `json:"wallets_dir"`
-	UnsafeScrypt bool         `json:"allow_unsafe_scrypt"`
-	ScryptParams ScryptParams `json:"scrypt"`
+	Disable      bool   `json:"disable"`
+	WalletsDir   string `json:"wallets_dir"`
+	UnsafeScrypt bool   `json:"allow_unsafe_scrypt"`
+	// Key derivation function (KDF) used to derive keys from wallet passwords,
+	// which is either KDFScrypt (default) or KDFArgon2id. Wallets protected
+	// using a weaker KDF or weaker parameters are upgraded when unlocked.
+	KDF            string         `json:"kdf"`
+	ScryptParams   ScryptParams   `json:"scrypt"`
+	Argon2idParams Argon2idParams `json:"argon2id"`
+}
+
+// KDFParams gives the key derivation function and its parameters that are
+// configured for the DuckDbWalletDriver
+func (cfg DuckDbWalletDriverConfig) KDFParams() KDFParams {
+	kdf := cfg.KDF
+	if kdf == "" {
+		kdf = KDFScrypt
+	}
+	return KDFParams{
+		KDF:            kdf,
+		ScryptParams:   cfg.ScryptParams,
+		Argon2idParams: cfg.Argon2idParams,
+	}
+}
+
+// Names of the key derivation functions (KDFs) that can be used to derive keys
+// from passwords
+const (
+	KDFScrypt   = "scrypt"
+	KDFArgon2id = "argon2id"
+)
+
+// KDFParams stores which key derivation function (KDF) is used to derive a key
+// from a password along with the parameters of the KDF
+type KDFParams struct {
+	KDF            string
+	ScryptParams   ScryptParams
+	Argon2idParams Argon2idParams
 }
 
 // ScryptParams stores the parameters used for key derivation. This allows
@@ -89,6 +162,16 @@ type ScryptParams struct {
 	ScryptP int `json:"scrypt_p"`
 }
 
+// Argon2idParams stores the parameters used for key derivation using Argon2id
+type Argon2idParams struct {
+	// Number of passes over the memory
+	Time uint32 `json:"time"`
+	// Amount of memory used in KiB
+	MemoryKiB uint32 `json:"memory_kib"`
+	// Number of threads used
+	Threads uint8 `json:"threads"`
+}
+
 // defaultConfig returns the default KMDConfig
 func defaultConfig(dataDir string) KMDConfig {
 	return KMDConfig{
@@ -123,7 +206,7 @@ func (k KMDConfig) Validate() error {
 			return ErrSQLiteWalletNotAbsolute
 		}
 	}
-	return nil
+	return ValidateNetworkProfiles(k.Networks)
 }
 
 // LoadKMDConfig tries to read the the kmd configuration from disk, merging the
@@ -138,13 +221,18 @@ func LoadKMDConfig(dataDir string) (cfg KMDConfig, err error) {
 		// SaveObjectToFile may return an unhandled error because
 		// there is nothing to do if an error occurs
 		codecs.SaveObjectToFile(exampleFilename, cfg, true)
-		return cfg, nil
+		cfg.Networks, err = LoadNetworkProfiles(dataDir)
+		return
 	}
 	// Fill in the non-default values
 	err = json.Unmarshal(dat, &cfg)
 	if err != nil {
 		return
 	}
+	cfg.Networks, err = LoadNetworkProfiles(dataDir)
+	if err != nil {
+		return
+	}
 	err = cfg.Validate()
 	return
 }
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"duckysigner/internal/kmd/codecs"
+)
+
+// NetworksFilename is the name of the file within the data directory where the
+// network profiles are saved
+const NetworksFilename = "networks.json"
+
+// NetworkProfile is a named Algorand network that transactions can be signed
+// for, along with the endpoints used to access it
+type NetworkProfile struct {
+	// Unique name of the profile (e.g. "TestNet")
+	Name string `json:"name"`
+	// Genesis ID of the network (e.g. "testnet-v1.0")
+	GenesisID string `json:"genesis_id"`
+	// Base64-encoded genesis hash of the network. It can be left out for
+	// networks that are often reset (e.g. LocalNet), in which case the network
+	// is identified by its genesis ID only.
+	GenesisHash string `json:"genesis_hash,omitempty"`
+	// URL of the network's algod API
+	AlgodURL string `json:"algod_url,omitempty"`
+	// Token for the network's algod API
+	AlgodToken string `json:"algod_token,omitempty"`
+	// URL of the network's indexer API
+	IndexerURL string `json:"indexer_url,omitempty"`
+	// Token for the network's indexer API
+	IndexerToken string `json:"indexer_token,omitempty"`
+}
+
+// publicGenesisIDs are the genesis IDs of the public networks, which must have
+// their genesis hash pinned
+var publicGenesisIDs = []string{"mainnet-v1.0", "testnet-v1.0", "betanet-v1.0"}
+
+// DefaultNetworkProfiles returns the network profiles used when none have been
+// saved
+func DefaultNetworkProfiles() []NetworkProfile {
+	return []NetworkProfile{
+		{
+			Name:        "MainNet",
+			GenesisID:   "mainnet-v1.0",
+			GenesisHash: "wGHE2Pwdvd7S12BL5FaOP20EGYesN73ktiC1qzkkit8=",
+			AlgodURL:    "https://mainnet-api.algonode.cloud",
+			IndexerURL:  "https://mainnet-idx.algonode.cloud",
+		},
+		{
+			Name:        "TestNet",
+			GenesisID:   "testnet-v1.0",
+			GenesisHash: "SGO1GKSzyE7IEPItTxCByw9x8FmnrCDexi9/cOUJOiI=",
+			AlgodURL:    "https://testnet-api.algonode.cloud",
+			IndexerURL:  "https://testnet-idx.algonode.cloud",
+		},
+		{
+			Name:        "BetaNet",
+			GenesisID:   "betanet-v1.0",
+			GenesisHash: "mFgazF+2uRS1tMiL9dsj01hJGySEmPN28B/TjjvpVW0=",
+			AlgodURL:    "https://betanet-api.algonode.cloud",
+			IndexerURL:  "https://betanet-idx.algonode.cloud",
+		},
+		{
+			Name:         "LocalNet",
+			GenesisID:    "dockernet-v1",
+			AlgodURL:     "http://localhost:4001",
+			AlgodToken:   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			IndexerURL:   "http://localhost:8980",
+			IndexerToken: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+	}
+}
+
+// Validate checks if the network profile is valid
+func (profile *NetworkProfile) Validate() error {
+	if profile.Name == "" {
+		return errors.New("network profile must have a name")
+	}
+	if profile.GenesisID == "" {
+		return fmt.Errorf("network profile '%s' must have a genesis ID", profile.Name)
+	}
+
+	if profile.GenesisHash == "" {
+		// Otherwise, the profile would match transactions for the public
+		// network with any genesis hash
+		if slices.Contains(publicGenesisIDs, profile.GenesisID) {
+			return fmt.Errorf(
+				"network profile '%s' must have a genesis hash for genesis ID '%s'",
+				profile.Name, profile.GenesisID,
+			)
+		}
+		return nil
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(profile.GenesisHash)
+	if err != nil || len(hash) != 32 {
+		return fmt.Errorf("network profile '%s' has an invalid genesis hash", profile.Name)
+	}
+
+	return nil
+}
+
+// Matches checks if the given genesis ID and Base64-encoded genesis hash are of
+// the profile's network. The genesis ID can be empty if the profile has a
+// genesis hash because transactions do not need to have a genesis ID.
+func (profile *NetworkProfile) Matches(genesisID, genesisHash string) bool {
+	if profile.GenesisHash == "" {
+		return profile.GenesisID == genesisID
+	}
+	return profile.GenesisHash == genesisHash && (genesisID == "" || profile.GenesisID == genesisID)
+}
+
+// ValidateNetworkProfiles checks if each of the given network profiles is valid
+// and that no two profiles have the same name
+func ValidateNetworkProfiles(profiles []NetworkProfile) error {
+	names := make(map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("there is more than one network profile named '%s'", profile.Name)
+		}
+		names[profile.Name] = true
+	}
+	return nil
+}
+
+// LoadNetworkProfiles reads the network profiles saved within the given data
+// directory. Returns the default profiles if none have been saved.
+func LoadNetworkProfiles(dataDir string) ([]NetworkProfile, error) {
+	dat, err := os.ReadFile(filepath.Join(dataDir, NetworksFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultNetworkProfiles(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []NetworkProfile
+	if err := json.Unmarshal(dat, &profiles); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// SaveNetworkProfiles saves the given network profiles within the given data
+// directory, creating the directory if needed
+func SaveNetworkProfiles(dataDir string, profiles []NetworkProfile) error {
+	if dataDir != "" {
+		if err := os.MkdirAll(dataDir, 0700); err != nil {
+			return err
+		}
+	}
+
+	return codecs.SaveObjectToFile(filepath.Join(dataDir, NetworksFilename), profiles, true)
+}
@@ -0,0 +1,265 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awnumar/memguard"
+)
+
+// A wallet backup is a single file that contains copies of the files in the
+// wallet's directory. The files are encrypted together using a key derived from
+// a backup passphrase, so a backup can only be restored with the passphrase.
+// Because the copied files are still encrypted with the wallet's master
+// encryption key, the restored wallet is protected by the same password as the
+// wallet that was backed up.
+
+const (
+	// walletBackupVersion is the version of the format of the wallet backups
+	// that are made
+	walletBackupVersion = 1
+	// backupCopySuffix is the suffix of the temporary copy of an encrypted
+	// DuckDB file that is made for a wallet backup. The copy's file name is the
+	// original file name + this suffix.
+	backupCopySuffix = ".backup"
+)
+
+var (
+	// PTWalletBackup is the plaintext type for the contents of a wallet backup
+	PTWalletBackup plaintextType = "wallet_backup"
+)
+
+// walletBackupFile is the outer layer of a wallet backup
+type walletBackupFile struct {
+	// Version of the wallet backup format
+	Version int `codec:"version"`
+	// The encrypted walletBackup
+	Blob []byte `codec:"blob"`
+}
+
+// walletBackup is the contents of a wallet backup
+type walletBackup struct {
+	// Version of the wallet backup format, which must match the version in the
+	// outer layer of the backup
+	Version int `codec:"version"`
+	// Name of the driver of the wallet that was backed up
+	DriverName string `codec:"driver_name"`
+	// Version of the driver of the wallet that was backed up
+	DriverVersion int `codec:"driver_version"`
+	// The contents of the backed up files, by file name
+	Files map[string][]byte `codec:"files"`
+}
+
+// BackupWallet checks the given password of the wallet with the given id and
+// gives a backup of the wallet that is encrypted with the given passphrase. The
+// backup contains the wallet's metadata and accounts database files. Other
+// files in the wallet directory (e.g. dApp connect session data) are included
+// if their file names are given in extraFiles and they exist. The encrypted
+// DuckDB files are copied through DuckDB, so the backup has a consistent copy
+// of their data even if they are being written to.
+func (ddbwd *DuckDbWalletDriver) BackupWallet(id []byte, pw []byte, passphrase []byte, extraFiles []string) ([]byte, error) {
+	fetchedWallet, err := ddbwd.FetchWallet(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the password by decrypting the master encryption key, which is
+	// needed for copying the encrypted DuckDB files
+	mek, err := fetchedWallet.(*DuckDbWallet).DecryptAndGetMasterKey(pw)
+	if err != nil {
+		return nil, err
+	}
+	defer memguard.WipeBytes(mek)
+
+	walletPath := filepath.Join(ddbwd.walletsDir(), string(id))
+	backup := walletBackup{
+		Version:       walletBackupVersion,
+		DriverName:    duckDbWalletDriverName,
+		DriverVersion: duckDbWalletDriverVersion,
+		Files:         map[string][]byte{},
+	}
+
+	fileNames := append([]string{DuckDbWalletMetadataFile, DuckDbWalletAcctsFile}, extraFiles...)
+	for i, fileName := range fileNames {
+		if !isBackupFileName(fileName) {
+			return nil, errBackupFile
+		}
+
+		filePath := filepath.Join(walletPath, fileName)
+		_, err := os.Stat(filePath)
+		if os.IsNotExist(err) && i >= 2 {
+			// Skip the extra files that do not exist
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var contents []byte
+		if strings.HasSuffix(fileName, encDuckDbFileExt) {
+			contents, err = readDuckDbFileCopy(filePath, mek)
+		} else {
+			contents, err = os.ReadFile(filePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		backup.Files[fileName] = contents
+	}
+
+	kdf := ddbwd.duckDbCfg.KDFParams()
+	blob, err := encryptBlobWithKDF(msgpackEncode(backup), PTWalletBackup, passphrase, &kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpackEncode(walletBackupFile{Version: walletBackupVersion, Blob: blob}), nil
+}
+
+// RestoreWallet decrypts the given wallet backup using the given passphrase and
+// restores the backed up wallet as a new wallet with the given id. The restored
+// wallet can be opened with the password the wallet had when it was backed up.
+func (ddbwd *DuckDbWalletDriver) RestoreWallet(id []byte, backupData []byte, passphrase []byte) error {
+	if len(id) == 0 {
+		return fmt.Errorf("no ID is given")
+	}
+
+	if len(id) > duckDbMaxWalletIDLen {
+		return errIDTooLong
+	}
+
+	// Decrypt the backup
+	backupFile := walletBackupFile{}
+	err := msgpackDecode(backupData, &backupFile)
+	if err != nil {
+		return err
+	}
+	if backupFile.Version != walletBackupVersion {
+		return errBackupVersion
+	}
+	backupContents, err := decryptBlobWithPassword(backupFile.Blob, PTWalletBackup, passphrase)
+	if err != nil {
+		return err
+	}
+	backup := walletBackup{}
+	err = msgpackDecode(backupContents, &backup)
+	if err != nil {
+		return err
+	}
+
+	// Check the backup
+	if backup.Version != backupFile.Version {
+		return errBackupVersion
+	}
+	if backup.DriverName != duckDbWalletDriverName {
+		return errBackupDriver
+	}
+	if backup.DriverVersion != duckDbWalletDriverVersion {
+		return errWrongDriverVer
+	}
+	for fileName := range backup.Files {
+		if !isBackupFileName(fileName) {
+			return errBackupFile
+		}
+	}
+	if backup.Files[DuckDbWalletMetadataFile] == nil || backup.Files[DuckDbWalletAcctsFile] == nil {
+		return errBackupFile
+	}
+
+	// Give the restored wallet the new ID
+	metadata := DuckDbWalletMetadata{}
+	err = json.Unmarshal(backup.Files[DuckDbWalletMetadataFile], &metadata)
+	if err != nil {
+		return err
+	}
+	metadata.WalletId = string(id)
+	backup.Files[DuckDbWalletMetadataFile], err = json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	walletPath := ddbwd.idToPath(id)
+
+	// Create directory for restored wallet
+	err = os.Mkdir(walletPath, duckDbWalletsDirPermissions)
+	if os.IsExist(err) {
+		return errSameID
+	}
+	if err != nil {
+		return err
+	}
+
+	// Write the files, with the metadata file written last so the directory
+	// does not look like a wallet until all the files are written
+	for fileName, contents := range backup.Files {
+		if fileName == DuckDbWalletMetadataFile {
+			continue
+		}
+		err = writeFileSync(filepath.Join(walletPath, fileName), contents, duckDbWalletsDirPermissions)
+		if err != nil {
+			wipeDir(walletPath)
+			return err
+		}
+	}
+	err = writeFileSync(
+		filepath.Join(walletPath, DuckDbWalletMetadataFile),
+		backup.Files[DuckDbWalletMetadataFile],
+		duckDbWalletsDirPermissions,
+	)
+	if err != nil {
+		wipeDir(walletPath)
+		return err
+	}
+
+	return nil
+}
+
+// readDuckDbFileCopy copies the data in the encrypted DuckDB file with the given
+// file path into a temporary file that is encrypted with the same key and gives
+// the contents of the copy. Unlike the original file, the copy has all of the
+// data written into it, so it does not need the write-ahead log of the
+// original file.
+func readDuckDbFileCopy(filePath string, key []byte) ([]byte, error) {
+	copyPath := filePath + backupCopySuffix
+
+	// Remove the leftovers of a backup that was interrupted
+	err := removeDuckDbFileCopy(copyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer removeDuckDbFileCopy(copyPath)
+
+	err = rekeyDuckDbFile(filePath, copyPath, key, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not copy %s: %w", filepath.Base(filePath), err)
+	}
+
+	return os.ReadFile(copyPath)
+}
+
+// removeDuckDbFileCopy removes the DuckDB file with the given file path along
+// with its write-ahead log, if they exist
+func removeDuckDbFileCopy(copyPath string) error {
+	for _, path := range []string{copyPath, copyPath + ".wal"} {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isBackupFileName checks if the given file name can be the name of a file in
+// a wallet backup, which means it is the name of a file directly within the
+// wallet directory
+func isBackupFileName(fileName string) bool {
+	return fileName != "" &&
+		fileName != "." &&
+		fileName != ".." &&
+		filepath.Base(fileName) == fileName &&
+		!strings.ContainsAny(fileName, `/\`)
+}
@@ -45,6 +45,14 @@ type Driver interface {
 	CreateWallet(name []byte, id []byte, pw []byte, mdk types.MasterDerivationKey) error
 	RenameWallet(newName []byte, id []byte, pw []byte) error
 	FetchWallet(id []byte) (wallet.Wallet, error)
+	DeleteWallet(id []byte, pw []byte) error
+}
+
+// BackupDriver is the interface that wallet drivers expose if they can back up
+// a wallet into a single encrypted file and restore a wallet from it
+type BackupDriver interface {
+	BackupWallet(id []byte, pw []byte, passphrase []byte, extraFiles []string) ([]byte, error)
+	RestoreWallet(id []byte, backupData []byte, passphrase []byte) error
 }
 
 // InitWalletDrivers accepts a KMDConfig and uses it to initialize each driver
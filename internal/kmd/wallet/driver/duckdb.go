@@ -7,6 +7,7 @@
 package driver
 
 import (
+	"bytes"
 	"crypto/ed25519"
 	"crypto/subtle"
 	"database/sql"
@@ -18,8 +19,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"duckysigner/internal/encdb"
 	"duckysigner/internal/kmd/config"
 	kmdCrypto "duckysigner/internal/kmd/crypto"
 	"duckysigner/internal/kmd/wallet"
@@ -61,15 +65,6 @@ var duckDbWalletSupportedTxs = []types.TxType{
 }
 var disallowedDuckDbFilenameRegex = regexp.MustCompile("[^a-zA-Z0-9_-]*")
 
-// attachEncDuckDbSQL is the SQL statement for opening or creating an encrypted
-// DuckDB file. The file encryption key is used for encrypting and decrypting
-// all the encrypted Duck DB files. Requires the file encryption key in Base64.
-// NOTE: These SQL statements will create the file if it does not exist.
-const attachEncDuckDbSQL = `
-LOAD httpfs; -- use OpenSSL library to increase speed
-ATTACH '%s' AS db (ENCRYPTION_KEY '%s');
-`
-
 // XXX: DuckDB does not support cascading deletes for foreign keys
 // Source: <https://duckdb.org/docs/stable/sql/statements/create_table#limitations>
 
@@ -85,7 +80,8 @@ CREATE TABLE IF NOT EXISTS db.accounts (
     position_ts TIMESTAMP,
     type TEXT NOT NULL,
     name TEXT,
-    rekeyed BOOL NOT NULL
+    rekeyed BOOL NOT NULL,
+    rekeyed_to BLOB
 );
 
 CREATE TABLE IF NOT EXISTS db.keys (
@@ -102,6 +98,17 @@ CREATE TABLE IF NOT EXISTS db.msig_addrs (
 );
 `
 
+// duckDbAcctsSchemaUpgrade is the SQL statements for bringing the accounts
+// database of a wallet created with an older version of the schema up to date
+const duckDbAcctsSchemaUpgrade = `
+ALTER TABLE db.accounts ADD COLUMN IF NOT EXISTS rekeyed_to BLOB;
+`
+
+// selectDuckDbAcctsSQL is the SQL statement for getting accounts. Conditions
+// are to be appended to it.
+const selectDuckDbAcctsSQL = `SELECT address, position, type, name, rekeyed_to
+FROM db.accounts`
+
 // TODO?: Replace acct_type enum with a table that includes SupportedTransactions for each type
 
 // DuckDbWalletDriver is the default wallet driver used by kmd. Keys are stored
@@ -109,6 +116,7 @@ CREATE TABLE IF NOT EXISTS db.msig_addrs (
 type DuckDbWalletDriver struct {
 	globalCfg config.KMDConfig
 	duckDbCfg config.DuckDbWalletDriverConfig
+	log       *logging.Logger
 }
 
 // DuckDbWallet represents a particular DuckDbWallet under the
@@ -120,6 +128,7 @@ type DuckDbWallet struct {
 	walletPasswordHash   types.Digest
 	walletPasswordHashed bool
 	cfg                  config.DuckDbWalletDriverConfig
+	log                  *logging.Logger
 	id                   string
 	// The parent directory of the wallet where wallets are stored
 	walletsPath string
@@ -146,22 +155,16 @@ type DuckDbWalletMetadata struct {
 func (ddbwd *DuckDbWalletDriver) InitWithConfig(cfg config.KMDConfig, log *logging.Logger) error {
 	ddbwd.globalCfg = cfg
 	ddbwd.duckDbCfg = cfg.DriverConfig.DuckDbWalletDriverConfig
+	ddbwd.log = log
 
-	// Make sure the scrypt params are reasonable
-	if !ddbwd.duckDbCfg.UnsafeScrypt {
-		if ddbwd.duckDbCfg.ScryptParams.ScryptN < minScryptN {
-			return fmt.Errorf("slow scrypt N must be at least %d", minScryptN)
-		}
-		if ddbwd.duckDbCfg.ScryptParams.ScryptR < minScryptR {
-			return fmt.Errorf("slow scrypt R must be at least %d", minScryptR)
-		}
-		if ddbwd.duckDbCfg.ScryptParams.ScryptP < minScryptP {
-			return fmt.Errorf("slow scrypt P must be at least %d", minScryptP)
-		}
+	// Make sure the KDF params are reasonable
+	err := checkKDFParams(ddbwd.duckDbCfg.KDFParams(), ddbwd.duckDbCfg.UnsafeScrypt)
+	if err != nil {
+		return err
 	}
 
 	// Make the wallets directory if it doesn't already exist
-	err := ddbwd.maybeMakeWalletsDir()
+	err = ddbwd.maybeMakeWalletsDir()
 	if err != nil {
 		return err
 	}
@@ -178,6 +181,12 @@ func (ddbwd *DuckDbWalletDriver) ListWalletMetadatas() (metadatas []wallet.Metad
 		return []wallet.Metadata{}, nil
 	}
 
+	return ddbwd.listAllWalletMetadatas()
+}
+
+// listAllWalletMetadatas is the guts of ListWalletMetadatas, which lists the
+// wallets even if this wallet driver is disabled
+func (ddbwd *DuckDbWalletDriver) listAllWalletMetadatas() (metadatas []wallet.Metadata, err error) {
 	paths, err := ddbwd.potentialWalletPaths()
 	if err != nil {
 		return
@@ -242,7 +251,8 @@ func (ddbwd *DuckDbWalletDriver) CreateWallet(name []byte, id []byte, pw []byte,
 
 	// Encrypt the master encryption password using the user's password (which
 	// may be blank)
-	encryptedMEPBlob, err := encryptBlobWithPasswordBlankOK(masterKey[:], PTMasterKey, pw, &ddbwd.duckDbCfg.ScryptParams)
+	kdf := ddbwd.duckDbCfg.KDFParams()
+	encryptedMEPBlob, err := encryptBlobWithKDF(masterKey[:], PTMasterKey, pw, &kdf)
 	if err != nil {
 		return err
 	}
@@ -285,10 +295,7 @@ func (ddbwd *DuckDbWalletDriver) CreateWallet(name []byte, id []byte, pw []byte,
 
 	// Encrypt accounts database file using master encryption password
 	// NOTE: This SQL statement creates the file if it does not exist
-	_, err = db.Exec(fmt.Sprintf(attachEncDuckDbSQL,
-		filepath.Join(walletPath, DuckDbWalletAcctsFile),
-		base64.StdEncoding.EncodeToString(masterKey[:]),
-	))
+	err = encdb.Attach(db, filepath.Join(walletPath, DuckDbWalletAcctsFile), masterKey[:], "db", false)
 	if err != nil {
 		return err
 	}
@@ -336,11 +343,22 @@ func (ddbwd *DuckDbWalletDriver) FetchWallet(id []byte) (wallet.Wallet, error) {
 		return &DuckDbWallet{}, errWalletNotFound
 	}
 
+	// Finish or roll back a password change that was interrupted
+	metadata, err := duckDbWalletMetadataFromPath(filepath.Join(walletsDir, string(id)))
+	if err != nil {
+		return &DuckDbWallet{}, err
+	}
+	err = recoverPwChange(filepath.Join(walletsDir, string(id)), metadata.MEPEncrypted)
+	if err != nil {
+		return &DuckDbWallet{}, err
+	}
+
 	// Fill in the wallet details
 	return &DuckDbWallet{
 		id:          string(id),
 		walletsPath: walletsDir,
 		cfg:         ddbwd.duckDbCfg,
+		log:         ddbwd.log,
 	}, nil
 }
 
@@ -392,6 +410,30 @@ func (ddbwd *DuckDbWalletDriver) RenameWallet(newName []byte, id []byte, pw []by
 	return nil
 }
 
+// DeleteWallet deletes the wallet with the given id if the given password is
+// correct. The wallet directory is wiped, which includes all of the wallet's
+// data files, such as its dApp connect session data.
+func (ddbwd *DuckDbWalletDriver) DeleteWallet(id []byte, pw []byte) error {
+	// Only delete directories within the wallets directory
+	walletPath := ddbwd.idToPath(id)
+	if walletPath != filepath.Join(ddbwd.walletsDir(), string(id)) {
+		return errWalletNotFound
+	}
+
+	fetchedWallet, err := ddbwd.FetchWallet(id)
+	if err != nil {
+		return err
+	}
+
+	// Check the password
+	err = fetchedWallet.CheckPassword(pw)
+	if err != nil {
+		return err
+	}
+
+	return wipeDir(walletPath)
+}
+
 /*******************************************************************************
  * Wallet
  ******************************************************************************/
@@ -432,6 +474,16 @@ func (ddbw *DuckDbWallet) Init(pw []byte) error {
 		return err
 	}
 
+	// Protect the master encryption key using the configured KDF if it is
+	// stronger than the one it is currently protected with. The wallet is
+	// still unlocked if this fails, and it will be tried again next time.
+	// NOTE: This must be done before the key is moved into an enclave, which
+	// wipes the key.
+	err = ddbw.maybeUpgradeKDF(pw, masterEncryptionKey)
+	if err != nil && ddbw.log != nil {
+		ddbw.log.Warnf("failed to upgrade the key derivation function of wallet %s: %v", ddbw.id, err)
+	}
+
 	// Initialize wallet
 	ddbw.masterEncryptionKey = memguard.NewEnclave(masterEncryptionKey)
 	ddbw.masterDerivationKey = memguard.NewEnclave(masterDerivationKey)
@@ -442,6 +494,12 @@ func (ddbw *DuckDbWallet) Init(pw []byte) error {
 	ddbw.walletPasswordHash = fastHashWithSalt(pw, ddbw.walletPasswordSalt[:])
 	ddbw.walletPasswordHashed = true
 
+	// Make sure every key has an account
+	err = ddbw.syncAccounts()
+	if err != nil {
+		return err
+	}
+
 	ddbw.initialized = true
 
 	return nil
@@ -463,8 +521,93 @@ func (ddbw *DuckDbWallet) CheckPassword(pw []byte) error {
 	return err
 }
 
+// ChangePassword changes the wallet's password from the old password to the new
+// password. The wallet's files are re-encrypted with a new master encryption
+// key that is protected by the new password. The change is either fully applied
+// or, if it is interrupted, finished or rolled back when the wallet is fetched.
+func (ddbw *DuckDbWallet) ChangePassword(oldPw, newPw []byte) error {
+	if !ddbw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
+
+	// Check the password
+	err := ddbw.CheckPassword(oldPw)
+	if err != nil {
+		return err
+	}
+
+	walletPath := filepath.Join(ddbw.walletsPath, ddbw.id)
+
+	// Decrypt the master encryption key stored in enclave into a local copy
+	mekBuf, err := ddbw.masterEncryptionKey.Open()
+	if err != nil {
+		return err
+	}
+	defer mekBuf.Destroy() // Destroy the copy when we return
+
+	// Generate the new master encryption key and encrypt it using the new
+	// password (which may be blank)
+	var newMasterKey [masterKeyLen]byte
+	err = fillRandomBytes(newMasterKey[:])
+	if err != nil {
+		return err
+	}
+	kdf := ddbw.cfg.KDFParams()
+	newMEPEncrypted, err := encryptBlobWithKDF(newMasterKey[:], PTMasterKey, newPw, &kdf)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := duckDbWalletMetadataFromPath(walletPath)
+	if err != nil {
+		return err
+	}
+	metadata.MEPEncrypted = newMEPEncrypted
+	metadataJson, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	err = beginPwChange(walletPath, newMEPEncrypted)
+	if err != nil {
+		return err
+	}
+
+	// Make copies of the database files encrypted with the new key
+	err = rekeyDuckDbFiles(walletPath, mekBuf.Bytes(), newMasterKey[:])
+	if err == nil {
+		err = writeFileSync(
+			filepath.Join(walletPath, DuckDbWalletMetadataFile+rekeySuffix),
+			metadataJson,
+			duckDbWalletsDirPermissions,
+		)
+	}
+	if err != nil {
+		rollBackPwChange(walletPath)
+		return err
+	}
+
+	// Commit the change by replacing the metadata file
+	err = os.Rename(
+		filepath.Join(walletPath, DuckDbWalletMetadataFile+rekeySuffix),
+		filepath.Join(walletPath, DuckDbWalletMetadataFile),
+	)
+	if err != nil {
+		rollBackPwChange(walletPath)
+		return err
+	}
+
+	// Use the new key and password from now on, even if the copies fail to
+	// replace the original files. The change is committed, so it will be
+	// finished when the wallet is fetched again.
+	ddbw.masterEncryptionKey = memguard.NewEnclave(newMasterKey[:])
+	ddbw.walletPasswordHash = fastHashWithSalt(newPw, ddbw.walletPasswordSalt[:])
+
+	return finishPwChange(walletPath)
+}
+
 // CheckAddrInWallet checks if the account with the given address is stored in
-// the wallet
+// the wallet, with or without its key
 func (ddbw *DuckDbWallet) CheckAddrInWallet(addr string) (bool, error) {
 	if !ddbw.initialized {
 		return false, fmt.Errorf("wallet not initialized")
@@ -477,15 +620,29 @@ func (ddbw *DuckDbWallet) CheckAddrInWallet(addr string) (bool, error) {
 
 	// Attempt to fetch key
 	_, err = ddbw.fetchSecretKey(types.Digest(decodedAddr))
-	if err == errKeyNotFound {
-		return false, nil
+	if err == nil {
+		return true, nil
 	}
-	if err != nil {
+	if err != errKeyNotFound {
 		// Some unexpected error occurred
 		return false, err
 	}
 
-	return true, nil
+	// There is no key, but the account may still be in the wallet without one
+	// (e.g. a watch-only account)
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	var cnt int
+	err = db.QueryRow("SELECT COUNT(1) FROM db.accounts WHERE address = ?", decodedAddr[:]).Scan(&cnt)
+	if err != nil {
+		return false, err
+	}
+
+	return cnt != 0, nil
 }
 
 // ListKeys lists all the addresses of KMD or standalone type of accounts
@@ -574,8 +731,15 @@ func (ddbw *DuckDbWallet) ImportKey(rawSK ed25519.PrivateKey) (addr types.Digest
 	}
 	defer db.Close()
 
+	// The key and its account are added together
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
 	// Insert the pk, e(sk) into the temporary database
-	_, err = db.Exec("INSERT INTO db.keys (address, key) VALUES(?, ?)", addr[:], sk.Seed())
+	_, err = tx.Exec("INSERT INTO db.keys (address, key) VALUES(?, ?)", addr[:], sk.Seed())
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "constraint") {
 			// If it was a constraint error, that means we already have the key.
@@ -587,6 +751,14 @@ func (ddbw *DuckDbWallet) ImportKey(rawSK ed25519.PrivateKey) (addr types.Digest
 		return
 	}
 
+	// Add the account for the key to the end of the list of accounts
+	err = insertDuckDbKeyAcct(tx, addr, wallet.AcctTypeStandalone)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
 	return
 }
 
@@ -681,8 +853,15 @@ func (ddbw *DuckDbWallet) GenerateKey(displayMnemonic bool) (addr types.Digest,
 		nextIndex++
 	}
 
+	// The key, its account and the new max index are stored together
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
 	// Add new key into keys table
-	_, err = db.Exec(
+	_, err = tx.Exec(
 		"INSERT INTO db.keys (address, key, key_idx) VALUES(?, ?, ?)",
 		addr[:], genSK.Seed(), nextIndex,
 	)
@@ -690,8 +869,19 @@ func (ddbw *DuckDbWallet) GenerateKey(displayMnemonic bool) (addr types.Digest,
 		return
 	}
 
+	// Add the account for the key to the end of the list of accounts
+	err = insertDuckDbKeyAcct(tx, addr, wallet.AcctTypeKMD)
+	if err != nil {
+		return
+	}
+
 	// Update `info` table with new max index (nextIndex)
-	_, err = db.Exec("UPDATE db.info SET max_key_idx = ? ", nextIndex)
+	_, err = tx.Exec("UPDATE db.info SET max_key_idx = ? ", nextIndex)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return
 	}
@@ -719,13 +909,28 @@ func (ddbw *DuckDbWallet) DeleteKey(addr types.Digest, pw []byte) (err error) {
 	}
 	defer db.Close()
 
+	// The key and its account are removed together
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
 	// Delete the key
-	_, err = db.Exec("DELETE FROM db.keys WHERE address=?", addr[:])
+	_, err = tx.Exec("DELETE FROM db.keys WHERE address=?", addr[:])
 	if err != nil {
 		err = errDatabase
 		return
 	}
 
+	// Delete the key's account
+	err = deleteDuckDbAcct(tx, addr)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
 	return
 }
 
@@ -759,13 +964,29 @@ func (ddbw *DuckDbWallet) ImportMultisigAddr(version, threshold uint8, pks []ed2
 		return addr, fmt.Errorf("multisignature address already exists in wallet")
 	}
 
+	// The multisig address and its account are added together
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
 	// Insert multisig address into database
-	_, err = db.Exec("INSERT INTO db.msig_addrs (address, version, threshold, pks) VALUES (?, ?, ?, ?)",
+	_, err = tx.Exec("INSERT INTO db.msig_addrs (address, version, threshold, pks) VALUES (?, ?, ?, ?)",
 		addr[:], version, threshold, msgpackEncode(pks))
 	if err != nil {
 		return
 	}
 
+	// Add the account for the multisig address to the end of the list of
+	// accounts
+	err = insertDuckDbKeyAcct(tx, addr, wallet.AcctTypeMsig)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+
 	return
 }
 
@@ -814,6 +1035,7 @@ func (ddbw *DuckDbWallet) LookupMultisigPreimage(addr types.Digest) (version, th
 }
 
 // DeleteMultisigAddr deletes the multisig address and preimage from the wallet
+// along with its account
 func (ddbw *DuckDbWallet) DeleteMultisigAddr(addr types.Digest, pw []byte) (err error) {
 	if !ddbw.initialized {
 		return fmt.Errorf("wallet not initialized")
@@ -832,12 +1054,26 @@ func (ddbw *DuckDbWallet) DeleteMultisigAddr(addr types.Digest, pw []byte) (err
 	}
 	defer db.Close()
 
-	// Delete the key
-	_, err = db.Exec("DELETE FROM db.msig_addrs WHERE address=?", addr[:])
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// Delete the multisig preimage
+	_, err = tx.Exec("DELETE FROM db.msig_addrs WHERE address=?", addr[:])
+	if err != nil {
+		return
+	}
+
+	// Delete its account
+	err = deleteDuckDbAcct(tx, addr)
 	if err != nil {
 		return
 	}
 
+	err = tx.Commit()
+
 	return
 }
 
@@ -882,7 +1118,8 @@ func (ddbw *DuckDbWallet) ListMultisigAddrs() (addrs []types.Digest, err error)
 
 // SignTransaction signs the passed transaction with the private key whose
 // public key is provided, or if the provided public key is zero, inferring the
-// required private key from the transaction itself
+// required private key from the transaction itself and the account the sender
+// is rekeyed to, if it is rekeyed
 func (ddbw *DuckDbWallet) SignTransaction(tx types.Transaction, pk ed25519.PublicKey, pw []byte) (stx []byte, err error) {
 	if !ddbw.initialized {
 		return stx, fmt.Errorf("wallet not initialized")
@@ -894,18 +1131,28 @@ func (ddbw *DuckDbWallet) SignTransaction(tx types.Transaction, pk ed25519.Publi
 		return
 	}
 
-	// Fetch the required key
+	// Fetch the required key, which is the key of the account the sender is
+	// rekeyed to if the sender is a rekeyed account
 	var sk ed25519.PrivateKey
 	if (slices.Equal(pk, ed25519.PublicKey{})) {
-		sk, err = ddbw.fetchSecretKey(types.Digest(tx.Sender))
+		var authAddr types.Digest
+		authAddr, err = ddbw.fetchAuthAddr(types.Digest(tx.Sender))
+		if err != nil {
+			return
+		}
+		sk, err = ddbw.fetchSigningKey(authAddr)
+		if err == errKeyNotFound && authAddr != types.Digest(tx.Sender) {
+			err = errAuthKeyNotFound
+		}
 	} else {
-		sk, err = ddbw.fetchSecretKey(types.Digest(pk))
+		sk, err = ddbw.fetchSigningKey(types.Digest(pk))
 	}
 	if err != nil {
 		return
 	}
 
-	// Sign the transaction with the required key
+	// Sign the transaction with the required key. The "auth address" of the
+	// signed transaction is set if the key is not the sender's.
 	_, stx, err = crypto.SignTransaction(sk, tx)
 
 	return
@@ -924,7 +1171,7 @@ func (ddbw *DuckDbWallet) SignProgram(data []byte, src types.Digest, pw []byte)
 	}
 
 	// Fetch the required key
-	sk, err := ddbw.fetchSecretKey(types.Digest(src))
+	sk, err := ddbw.fetchSigningKey(types.Digest(src))
 	if err != nil {
 		return
 	}
@@ -962,7 +1209,7 @@ func (ddbw *DuckDbWallet) MultisigSignTransaction(tx types.Transaction, pk ed255
 		// Fetch the required secret key (the secret key for the given public
 		// key)
 		var sk ed25519.PrivateKey
-		sk, err = ddbw.fetchSecretKey(publicKeyToAddress(pk))
+		sk, err = ddbw.fetchSigningKey(publicKeyToAddress(pk))
 		if err != nil {
 			return
 		}
@@ -1047,7 +1294,7 @@ func (ddbw *DuckDbWallet) MultisigSignTransaction(tx types.Transaction, pk ed255
 	}
 
 	// Fetch the required secret key
-	sk, err := ddbw.fetchSecretKey(publicKeyToAddress(pk))
+	sk, err := ddbw.fetchSigningKey(publicKeyToAddress(pk))
 	if err != nil {
 		return
 	}
@@ -1114,7 +1361,7 @@ func (ddbw *DuckDbWallet) MultisigSignProgram(data []byte, src types.Digest, pk
 
 		// Fetch the required secret key
 		var sk ed25519.PrivateKey
-		sk, err = ddbw.fetchSecretKey(publicKeyToAddress(pk))
+		sk, err = ddbw.fetchSigningKey(publicKeyToAddress(pk))
 		if err != nil {
 			return
 		}
@@ -1186,7 +1433,7 @@ func (ddbw *DuckDbWallet) MultisigSignProgram(data []byte, src types.Digest, pk
 	}
 
 	// Fetch the required secret key
-	sk, err := ddbw.fetchSecretKey(publicKeyToAddress(pk))
+	sk, err := ddbw.fetchSigningKey(publicKeyToAddress(pk))
 	if err != nil {
 		return
 	}
@@ -1229,108 +1476,270 @@ func (ddbw *DuckDbWallet) MultisigSignProgram(data []byte, src types.Digest, pk
 // ListAccounts list the accounts in the wallet. The list is ordered according
 // to position number.
 func (ddbw *DuckDbWallet) ListAccounts() ([]wallet.Account, error) {
-	// TODO
-	return nil, errNotSupported
-}
+	if !ddbw.initialized {
+		return nil, fmt.Errorf("wallet not initialized")
+	}
 
-// GetAccount gets the information of the account with the given address
-func (ddbw *DuckDbWallet) GetAccount(addr string) (*wallet.Account, error) {
-	// TODO
-	return nil, errNotSupported
-}
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
 
-// TODO?: Get account by name
+	rows, err := db.Query(selectDuckDbAcctsSQL + " ORDER BY position, position_ts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-// DeleteAccount deletes the account with the given address
-func (ddbw *DuckDbWallet) DeleteAccount(addr string, pw []byte) error {
-	// TODO
-	return errNotSupported
-}
+	accts := []wallet.Account{}
+	for rows.Next() {
+		acct, err := scanDuckDbAcct(rows)
+		if err != nil {
+			return nil, err
+		}
+		accts = append(accts, *acct)
+	}
 
-// UpdaAccountName updates the name of the account with the given address to the
-// given name
-func (ddbw *DuckDbWallet) UpdateAccountName(addr string, name string, pw []byte) (*wallet.Account, error) {
-	// TODO
-	return nil, errNotSupported
+	return accts, rows.Err()
 }
 
-// UpdateAccountRekeyedTo updates the "rekeyed to" address of the account with
-// given address to the given new "rekeyed to" address
-func (ddbw *DuckDbWallet) UpdateAccountRekeyedTo(addr string, rekeyedTo string, pw []byte) (*wallet.Account, error) {
-	// TODO
-	return nil, errNotSupported
-}
+// GetAccount gets the information of the account with the given address
+func (ddbw *DuckDbWallet) GetAccount(addr string) (*wallet.Account, error) {
+	if !ddbw.initialized {
+		return nil, fmt.Errorf("wallet not initialized")
+	}
 
-// AddAccountAbove adds the given account  above the "reference" account with
-// the given address in the list of accounts. If no reference address is given,
-// the account is placed first in the list.
-func (ddbw *DuckDbWallet) AddAccountAbove(acct *wallet.Account, refAddr string) error {
-	// TODO
-	return errNotSupported
-}
+	decodedAddr, err := types.DecodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
 
-// AddAccountBelow moves the account with the given address below the
-// "reference" account with the given address in the list of accounts. If no
-// reference address is given, the account is placed last in the list.
-func (ddbw *DuckDbWallet) AddAccountBelow(acct *wallet.Account, refAddr string) error {
-	// TODO
-	return errNotSupported
-}
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
 
-// MoveAccountAbove moves the account with the given address above the
-// "reference" account with the given address in the list of accounts. If no
-// reference address is given, the account is placed first in the list.
-func (ddbw *DuckDbWallet) MoveAccountAbove(moveAddr string, refAddr string) error {
-	// TODO
-	return errNotSupported
-}
+	acct, err := scanDuckDbAcct(db.QueryRow(selectDuckDbAcctsSQL+" WHERE address = ?", decodedAddr[:]))
+	if err == sql.ErrNoRows {
+		return nil, errAcctNotFound
+	}
 
-// MoveAccountBelow moves the account with the given address below the
-// "reference" account with the given address in the list of accounts. If no
-// reference address is given, the account is placed last in the list.
-func (ddbw *DuckDbWallet) MoveAccountBelow(moveAddr string, refAddr string) error {
-	// TODO
-	return errNotSupported
+	return acct, err
 }
 
-/*******************************************************************************
- * Helpers
- ******************************************************************************/
+// TODO?: Get account by name
 
-// Initialize the codec
-func init() {
-	codecHandle = new(codec.MsgpackHandle)
-	codecHandle.ErrorIfNoField = true
-	codecHandle.ErrorIfNoArrayExpand = true
-	codecHandle.Canonical = true
-	codecHandle.RecursiveEmptyCheck = true
-	codecHandle.WriteExt = true
-	codecHandle.PositiveIntUnsigned = true
-}
+// DeleteAccount deletes the account with the given address along with its key
+// or multisig preimage, if there is one
+func (ddbw *DuckDbWallet) DeleteAccount(addr string, pw []byte) error {
+	if !ddbw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
 
-// duckDbWalletMetadataFromPath accepts path to a directory for a wallet and
-// returns a Metadata struct with information about it
-func duckDbWalletMetadataFromPath(walletPath string) (metadata DuckDbWalletMetadata, err error) {
-	// Read wallet's metadata.json
-	metadataFileContents, err := os.ReadFile(filepath.Join(walletPath, DuckDbWalletMetadataFile))
+	// Check the password
+	err := ddbw.CheckPassword(pw)
 	if err != nil {
-		return
+		return err
 	}
 
-	// Parse JSON contents
-	metadata = DuckDbWalletMetadata{}
-	err = json.Unmarshal(metadataFileContents, &metadata)
+	decodedAddr, err := types.DecodeAddress(addr)
 	if err != nil {
-		return
+		return err
 	}
 
-	return
-}
-
-/********** Wallet Driver Helpers **********/
-
-// potentialWalletPaths lists paths to plausible wallet directories in the
-// wallets directory. This means things that are directories and contain a
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM db.keys WHERE address = ?", decodedAddr[:])
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM db.msig_addrs WHERE address = ?", decodedAddr[:])
+	if err != nil {
+		return err
+	}
+	err = deleteDuckDbAcct(tx, types.Digest(decodedAddr))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateAccountName updates the name of the account with the given address to
+// the given name. An empty name removes the account's name.
+func (ddbw *DuckDbWallet) UpdateAccountName(addr string, name string, pw []byte) (*wallet.Account, error) {
+	if !ddbw.initialized {
+		return nil, fmt.Errorf("wallet not initialized")
+	}
+
+	// Check the password
+	err := ddbw.CheckPassword(pw)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedAddr, err := types.DecodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	result, err := db.Exec(
+		"UPDATE db.accounts SET name = ? WHERE address = ?",
+		sql.NullString{String: name, Valid: name != ""},
+		decodedAddr[:],
+	)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, errAcctNotFound
+	}
+
+	return ddbw.GetAccount(addr)
+}
+
+// UpdateAccountRekeyedTo updates the "rekeyed to" address of the account with
+// given address to the given new "rekeyed to" address. Giving an empty address
+// or the account's own address marks the account as no longer rekeyed.
+func (ddbw *DuckDbWallet) UpdateAccountRekeyedTo(addr string, rekeyedTo string, pw []byte) (*wallet.Account, error) {
+	if !ddbw.initialized {
+		return nil, fmt.Errorf("wallet not initialized")
+	}
+
+	// Check the password
+	err := ddbw.CheckPassword(pw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the account exists before looking at the "rekeyed to" address
+	if _, err = ddbw.GetAccount(addr); err != nil {
+		return nil, err
+	}
+	decodedAddr, err := types.DecodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var rekeyedToBlob []byte
+	if rekeyedTo != "" && rekeyedTo != addr {
+		decodedRekeyedTo, err := types.DecodeAddress(rekeyedTo)
+		if err != nil {
+			return nil, err
+		}
+		rekeyedToBlob = decodedRekeyedTo[:]
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(
+		"UPDATE db.accounts SET rekeyed = ?, rekeyed_to = ? WHERE address = ?",
+		rekeyedToBlob != nil,
+		rekeyedToBlob,
+		decodedAddr[:],
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ddbw.GetAccount(addr)
+}
+
+// AddAccountAbove adds the given account above the "reference" account with
+// the given address in the list of accounts. If no reference address is given,
+// the account is placed first in the list.
+func (ddbw *DuckDbWallet) AddAccountAbove(acct *wallet.Account, refAddr string) error {
+	return ddbw.addAccount(acct, refAddr, false)
+}
+
+// AddAccountBelow adds the given account below the "reference" account with
+// the given address in the list of accounts. If no reference address is given,
+// the account is placed last in the list.
+func (ddbw *DuckDbWallet) AddAccountBelow(acct *wallet.Account, refAddr string) error {
+	return ddbw.addAccount(acct, refAddr, true)
+}
+
+// MoveAccountAbove moves the account with the given address above the
+// "reference" account with the given address in the list of accounts. If no
+// reference address is given, the account is placed first in the list.
+func (ddbw *DuckDbWallet) MoveAccountAbove(moveAddr string, refAddr string) error {
+	return ddbw.moveAccount(moveAddr, refAddr, false)
+}
+
+// MoveAccountBelow moves the account with the given address below the
+// "reference" account with the given address in the list of accounts. If no
+// reference address is given, the account is placed last in the list.
+func (ddbw *DuckDbWallet) MoveAccountBelow(moveAddr string, refAddr string) error {
+	return ddbw.moveAccount(moveAddr, refAddr, true)
+}
+
+/*******************************************************************************
+ * Helpers
+ ******************************************************************************/
+
+// Initialize the codec
+func init() {
+	codecHandle = new(codec.MsgpackHandle)
+	codecHandle.ErrorIfNoField = true
+	codecHandle.ErrorIfNoArrayExpand = true
+	codecHandle.Canonical = true
+	codecHandle.RecursiveEmptyCheck = true
+	codecHandle.WriteExt = true
+	codecHandle.PositiveIntUnsigned = true
+}
+
+// duckDbWalletMetadataFromPath accepts path to a directory for a wallet and
+// returns a Metadata struct with information about it
+func duckDbWalletMetadataFromPath(walletPath string) (metadata DuckDbWalletMetadata, err error) {
+	// Read wallet's metadata.json
+	metadataFileContents, err := os.ReadFile(filepath.Join(walletPath, DuckDbWalletMetadataFile))
+	if err != nil {
+		return
+	}
+
+	// Parse JSON contents
+	metadata = DuckDbWalletMetadata{}
+	err = json.Unmarshal(metadataFileContents, &metadata)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+/********** Wallet Driver Helpers **********/
+
+// potentialWalletPaths lists paths to plausible wallet directories in the
+// wallets directory. This means things that are directories and contain a
 // `metadata.json` file.
 func (ddbwd *DuckDbWalletDriver) potentialWalletPaths() (paths []string, err error) {
 	// List all files and folders in the wallets directory
@@ -1358,6 +1767,12 @@ func (ddbwd *DuckDbWalletDriver) potentialWalletPaths() (paths []string, err err
 	return
 }
 
+// migratedFilePath gives the path of the file that marks the wallet with the
+// given id as migrated to another driver
+func (ddbwd *DuckDbWalletDriver) migratedFilePath(id []byte) (string, error) {
+	return filepath.Join(ddbwd.walletsDir(), string(id), migratedFile), nil
+}
+
 // maybeMakeWalletsDir tries to create the wallets directory if it doesn't
 // already exist
 func (ddbwd *DuckDbWalletDriver) maybeMakeWalletsDir() error {
@@ -1421,6 +1836,56 @@ func (ddbw *DuckDbWallet) DecryptAndGetMasterKey(pw []byte) ([]byte, error) {
 	return mep, nil
 }
 
+// maybeUpgradeKDF re-encrypts the given master encryption key using the given
+// password with the configured KDF if the KDF or its parameters that the
+// stored master encryption key is encrypted with are weaker. Only the wallet's
+// metadata file is changed because the master encryption key stays the same.
+func (ddbw *DuckDbWallet) maybeUpgradeKDF(pw []byte, mek []byte) error {
+	walletPath := filepath.Join(ddbw.walletsPath, ddbw.id)
+	metadata, err := duckDbWalletMetadataFromPath(walletPath)
+	if err != nil {
+		return err
+	}
+
+	currentKDF, err := blobKDFParams(metadata.MEPEncrypted)
+	if err != nil {
+		return err
+	}
+	targetKDF := ddbw.cfg.KDFParams()
+	if currentKDF == nil || !isKDFWeaker(*currentKDF, targetKDF) {
+		return nil
+	}
+
+	metadata.MEPEncrypted, err = encryptBlobWithKDF(mek, PTMasterKey, pw, &targetKDF)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the wallet can still be unlocked before replacing anything
+	checkMek, err := decryptBlobWithPassword(metadata.MEPEncrypted, PTMasterKey, pw)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(checkMek, mek) {
+		return errDecrypt
+	}
+	metadataJson, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	// Replace the metadata file using a copy so it is never partly written. A
+	// copy that is left behind is removed like one left by a password change.
+	copyPath := filepath.Join(walletPath, DuckDbWalletMetadataFile+rekeySuffix)
+	err = writeFileSync(copyPath, metadataJson, duckDbWalletsDirPermissions)
+	if err != nil {
+		os.Remove(copyPath)
+		return err
+	}
+
+	return os.Rename(copyPath, filepath.Join(walletPath, DuckDbWalletMetadataFile))
+}
+
 // decryptAndGetMasterDerivationKey fetches the MDK from the accounts database
 // that is encrypted with the master encryption key
 func (ddbw *DuckDbWallet) decryptAndGetMasterDerivationKey(pw []byte) ([]byte, error) {
@@ -1440,9 +1905,7 @@ func (ddbw *DuckDbWallet) decryptAndGetMasterDerivationKey(pw []byte) ([]byte, e
 	acctsPath := filepath.Join(ddbw.walletsPath, ddbw.id, DuckDbWalletAcctsFile)
 
 	// Open and decrypt accounts database file
-	_, err = db.Exec(fmt.Sprintf(attachEncDuckDbSQL, acctsPath,
-		base64.StdEncoding.EncodeToString(mek),
-	))
+	err = encdb.Attach(db, acctsPath, mek, "db", false)
 	if err != nil {
 		return nil, err
 	}
@@ -1505,6 +1968,439 @@ func (ddbw *DuckDbWallet) fetchSecretKey(addr types.Digest) (sk ed25519.PrivateK
 	return
 }
 
+// fetchSigningKey retrieves the private key for a given public key so it can be
+// used to sign something. Returns wallet.ErrWatchOnly instead of errKeyNotFound
+// if the key is for a watch-only account.
+func (ddbw *DuckDbWallet) fetchSigningKey(addr types.Digest) (sk ed25519.PrivateKey, err error) {
+	sk, err = ddbw.fetchSecretKey(addr)
+	if err != errKeyNotFound {
+		return
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	var acctType string
+	err = db.QueryRow("SELECT type FROM db.accounts WHERE address = ?", addr[:]).Scan(&acctType)
+	if err == sql.ErrNoRows {
+		return nil, errKeyNotFound
+	}
+	if err != nil {
+		return
+	}
+	if acctType == wallet.AcctTypeWatch.String() {
+		return nil, wallet.ErrWatchOnly
+	}
+
+	return nil, errKeyNotFound
+}
+
+// fetchAuthAddr retrieves the address of the account whose key is used to sign
+// for the account with the given address, which is the address of the account
+// it is rekeyed to if it is rekeyed. Otherwise, it is the given address.
+func (ddbw *DuckDbWallet) fetchAuthAddr(addr types.Digest) (authAddr types.Digest, err error) {
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	var rekeyedTo []byte
+	err = db.QueryRow("SELECT rekeyed_to FROM db.accounts WHERE address = ?", addr[:]).Scan(&rekeyedTo)
+	if err == sql.ErrNoRows || (err == nil && rekeyedTo == nil) {
+		return addr, nil
+	}
+	if err != nil {
+		return
+	}
+
+	copy(authAddr[:], rekeyedTo)
+	return
+}
+
+// keyIndexes gives the index of each generated key in the wallet by the key's
+// address along with the highest index a key has been generated with
+func (ddbw *DuckDbWallet) keyIndexes() (keyIdxs map[types.Digest]uint64, maxKeyIdx uint64, err error) {
+	if !ddbw.initialized {
+		return nil, 0, fmt.Errorf("wallet not initialized")
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	row := db.QueryRow("SELECT max_key_idx FROM db.info LIMIT 1")
+	err = row.Scan(&maxKeyIdx)
+	if err != nil {
+		return
+	}
+
+	rows, err := db.Query("SELECT address, key_idx FROM db.keys WHERE key_idx IS NOT NULL")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	keyIdxs = map[types.Digest]uint64{}
+	for rows.Next() {
+		var addrBytes []byte
+		var keyIdx uint64
+		err = rows.Scan(&addrBytes, &keyIdx)
+		if err != nil {
+			return
+		}
+
+		var addr types.Digest
+		copy(addr[:], addrBytes)
+		keyIdxs[addr] = keyIdx
+	}
+
+	return keyIdxs, maxKeyIdx, rows.Err()
+}
+
+// importIndexedKeys imports the given keys, storing the index of each key that
+// is in keyIdxs, and sets the highest index a key has been generated with to
+// maxKeyIdx. The accounts of the keys are added when the wallet is initialized.
+func (ddbw *DuckDbWallet) importIndexedKeys(keys map[types.Digest]ed25519.PrivateKey, keyIdxs map[types.Digest]uint64, maxKeyIdx uint64) error {
+	if !ddbw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// The keys and the new max index are stored together
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for addr, sk := range keys {
+		var keyIdx any // NULL if the key was not generated
+		if idx, ok := keyIdxs[addr]; ok {
+			keyIdx = idx
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO db.keys (address, key, key_idx) VALUES(?, ?, ?)",
+			addr[:], sk.Seed(), keyIdx,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE db.info SET max_key_idx = ? ", maxKeyIdx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// syncAccounts brings the accounts database up to date with the current schema
+// and adds an account for each key or multisig address that does not have one,
+// which is the case for wallets created before accounts were stored. The added
+// accounts are placed at the end of the list of accounts, generated keys first
+// and multisig accounts last.
+func (ddbw *DuckDbWallet) syncAccounts() error {
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(duckDbAcctsSchemaUpgrade)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO db.accounts (address, position, position_ts, type, rekeyed)
+SELECT address,
+	(SELECT COALESCE(MAX(position) + 1, 0) FROM db.accounts)
+		+ ROW_NUMBER() OVER (ORDER BY key_idx NULLS LAST, address) - 1,
+	?,
+	CASE WHEN key_idx IS NULL THEN ? ELSE ? END,
+	false
+FROM db.keys
+WHERE address NOT IN (SELECT address FROM db.accounts)`,
+		time.Now().UTC(),
+		wallet.AcctTypeStandalone.String(),
+		wallet.AcctTypeKMD.String(),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO db.accounts (address, position, position_ts, type, rekeyed)
+SELECT address,
+	(SELECT COALESCE(MAX(position) + 1, 0) FROM db.accounts)
+		+ ROW_NUMBER() OVER (ORDER BY address) - 1,
+	?,
+	?,
+	false
+FROM db.msig_addrs
+WHERE address NOT IN (SELECT address FROM db.accounts)`,
+		time.Now().UTC(),
+		wallet.AcctTypeMsig.String(),
+	)
+
+	return err
+}
+
+// addAccount adds the given account above or below the "reference" account
+// with the given address. If no reference address is given, the account is
+// placed first or last in the list of accounts.
+func (ddbw *DuckDbWallet) addAccount(acct *wallet.Account, refAddr string, below bool) error {
+	if !ddbw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var cnt int
+	err = tx.QueryRow("SELECT COUNT(1) FROM db.accounts WHERE address = ?", acct.Address[:]).Scan(&cnt)
+	if err != nil {
+		return err
+	}
+	if cnt != 0 {
+		return errAcctExists
+	}
+
+	position, err := duckDbAcctTargetPosition(tx, acct.Address, refAddr, below)
+	if err != nil {
+		return err
+	}
+
+	// Make room for the account
+	_, err = tx.Exec("UPDATE db.accounts SET position = position + 1 WHERE position >= ?", position)
+	if err != nil {
+		return err
+	}
+
+	var rekeyedTo []byte
+	if acct.RekeyedTo != (types.Digest{}) {
+		rekeyedTo = acct.RekeyedTo[:]
+	}
+	_, err = tx.Exec(
+		`INSERT INTO db.accounts (address, position, position_ts, type, name, rekeyed, rekeyed_to)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		acct.Address[:],
+		position,
+		time.Now().UTC(),
+		acct.Type.String(),
+		sql.NullString{String: acct.Name, Valid: acct.Name != ""},
+		rekeyedTo != nil,
+		rekeyedTo,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// moveAccount moves the account with the given address above or below the
+// "reference" account with the given address. If no reference address is
+// given, the account is placed first or last in the list of accounts.
+func (ddbw *DuckDbWallet) moveAccount(moveAddr string, refAddr string, below bool) error {
+	if !ddbw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
+
+	decodedMoveAddr, err := types.DecodeAddress(moveAddr)
+	if err != nil {
+		return err
+	}
+
+	// Open database
+	db, err := ddbw.openAccountsDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldPosition uint64
+	err = tx.QueryRow("SELECT position FROM db.accounts WHERE address = ?", decodedMoveAddr[:]).
+		Scan(&oldPosition)
+	if err == sql.ErrNoRows {
+		return errAcctNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if refAddr == moveAddr {
+		// Moving the account next to itself does not change anything
+		return nil
+	}
+
+	// Take the account out of the list
+	_, err = tx.Exec("UPDATE db.accounts SET position = position - 1 WHERE position > ?", oldPosition)
+	if err != nil {
+		return err
+	}
+
+	position, err := duckDbAcctTargetPosition(tx, types.Digest(decodedMoveAddr), refAddr, below)
+	if err != nil {
+		return err
+	}
+
+	// Put the account back into the list at its new position
+	_, err = tx.Exec(
+		"UPDATE db.accounts SET position = position + 1 WHERE position >= ? AND address != ?",
+		position,
+		decodedMoveAddr[:],
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE db.accounts SET position = ?, position_ts = ? WHERE address = ?",
+		position,
+		time.Now().UTC(),
+		decodedMoveAddr[:],
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// duckDbAcctTargetPosition determines the position the account with the given
+// address is to be placed at so it is above or below the "reference" account
+// with the given address. If no reference address is given, the position is
+// first or last in the list of accounts, not counting the account itself.
+func duckDbAcctTargetPosition(tx *sql.Tx, addr types.Digest, refAddr string, below bool) (position uint64, err error) {
+	if refAddr == "" {
+		if !below {
+			return 0, nil
+		}
+		err = tx.QueryRow(
+			"SELECT COALESCE(MAX(position) + 1, 0) FROM db.accounts WHERE address != ?",
+			addr[:],
+		).Scan(&position)
+		return
+	}
+
+	decodedRefAddr, err := types.DecodeAddress(refAddr)
+	if err != nil {
+		return
+	}
+	err = tx.QueryRow("SELECT position FROM db.accounts WHERE address = ?", decodedRefAddr[:]).
+		Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, errRefAcctNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	if below {
+		position++
+	}
+
+	return
+}
+
+// insertDuckDbKeyAcct adds the account for the key or multisig address with the
+// given address to the end of the list of accounts. If the account already
+// exists, only its type is updated.
+func insertDuckDbKeyAcct(tx *sql.Tx, addr types.Digest, acctType wallet.AccountType) error {
+	_, err := tx.Exec(`INSERT INTO db.accounts (address, position, position_ts, type, rekeyed)
+VALUES (?, (SELECT COALESCE(MAX(position) + 1, 0) FROM db.accounts), ?, ?, false)
+ON CONFLICT (address) DO UPDATE SET type = excluded.type`,
+		addr[:],
+		time.Now().UTC(),
+		acctType.String(),
+	)
+	return err
+}
+
+// deleteDuckDbAcct removes the account with the given address from the list of
+// accounts, moving the accounts below it up. It does nothing if the account
+// does not exist.
+func deleteDuckDbAcct(tx *sql.Tx, addr types.Digest) error {
+	var position uint64
+	err := tx.QueryRow("SELECT position FROM db.accounts WHERE address = ?", addr[:]).Scan(&position)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM db.accounts WHERE address = ?", addr[:])
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE db.accounts SET position = position - 1 WHERE position > ?", position)
+	return err
+}
+
+// scanDuckDbAcct converts the given row of the accounts table into an account
+func scanDuckDbAcct(row interface{ Scan(...any) error }) (*wallet.Account, error) {
+	var (
+		addrBlob      []byte
+		position      sql.NullInt64
+		acctTypeName  string
+		name          sql.NullString
+		rekeyedToBlob []byte
+	)
+
+	err := row.Scan(&addrBlob, &position, &acctTypeName, &name, &rekeyedToBlob)
+	if err != nil {
+		return nil, err
+	}
+
+	acctType, err := wallet.ParseAccountType(acctTypeName)
+	if err != nil {
+		return nil, err
+	}
+
+	acct := wallet.Account{
+		Type:     acctType,
+		Position: strconv.FormatInt(position.Int64, 10),
+		Name:     name.String,
+	}
+	copy(acct.Address[:], addrBlob)
+	copy(acct.RekeyedTo[:], rekeyedToBlob)
+
+	return &acct, nil
+}
+
 // openAccountsDb opens the encrypted accounts database file using the master
 // encryption key
 func (ddbw *DuckDbWallet) openAccountsDb() (db *sql.DB, err error) {
@@ -1524,10 +2420,7 @@ func (ddbw *DuckDbWallet) openAccountsDb() (db *sql.DB, err error) {
 	acctsPath := filepath.Join(ddbw.walletsPath, ddbw.id, DuckDbWalletAcctsFile)
 
 	// Open and decrypt accounts database file
-	_, err = db.Exec(fmt.Sprintf(attachEncDuckDbSQL,
-		acctsPath,
-		base64.StdEncoding.EncodeToString(mekBuf.Bytes()),
-	))
+	err = encdb.Attach(db, acctsPath, mekBuf.Bytes(), "db", false)
 	if err != nil {
 		return
 	}
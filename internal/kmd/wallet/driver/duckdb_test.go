@@ -1,15 +1,20 @@
 package driver_test
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
 	"os"
+	"path/filepath"
 
 	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
 	"github.com/algorand/go-algorand-sdk/v2/mnemonic"
 	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
 	logging "github.com/sirupsen/logrus"
 
+	"duckysigner/internal/encdb"
 	"duckysigner/internal/kmd/config"
 	"duckysigner/internal/kmd/wallet"
 	"duckysigner/internal/kmd/wallet/driver"
@@ -277,6 +282,209 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 			})
 		})
 
+		Describe("DeleteWallet()", Ordered, func() {
+			const walletDirName = ".test_ddb_delete_wallet"
+			var duckDbDriver driver.DuckDbWalletDriver
+
+			BeforeAll(func() {
+				setupDuckDbWalletDriver(&duckDbDriver, walletDirName)
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+			})
+
+			It("fails if the given password is incorrect", func() {
+				By("Creating wallets")
+				for _, walletId := range []string{"000", "111"} {
+					err := duckDbDriver.CreateWallet(
+						[]byte("Foo"),
+						[]byte(walletId),
+						[]byte("password"),
+						algoTypes.MasterDerivationKey{},
+					)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				By("Attempting to delete a wallet with an incorrect password")
+				err := duckDbDriver.DeleteWallet([]byte("000"), []byte("not the password"))
+				Expect(err).To(HaveOccurred())
+				Expect(walletDirName+"/000").To(BeADirectory(), "The wallet is not deleted")
+			})
+
+			It("deletes the wallet with the given ID along with its data files", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the wallets have been created
+
+				By("Adding a data file to the wallet directory")
+				err := os.WriteFile(walletDirName+"/000/sessions.duckdb", []byte("session data"), 0600)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Deleting the wallet")
+				err = duckDbDriver.DeleteWallet([]byte("000"), []byte("password"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(walletDirName+"/000").NotTo(BeAnExistingFile(), "The wallet directory is removed")
+
+				By("Checking the wallet can no longer be fetched")
+				_, err = duckDbDriver.FetchWallet([]byte("000"))
+				Expect(err).To(MatchError("wallet not found"))
+
+				By("Checking the other wallet is still there")
+				metadatas, err := duckDbDriver.ListWalletMetadatas()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadatas).To(HaveLen(1))
+				Expect(metadatas[0].ID).To(Equal([]byte("111")))
+			})
+
+			It("fails when a wallet with the given ID does not exist", func() {
+				err := duckDbDriver.DeleteWallet([]byte("000"), []byte("password"))
+				Expect(err).To(MatchError("wallet not found"))
+			})
+
+			It("fails if the ID is not a wallet directory name", func() {
+				err := duckDbDriver.DeleteWallet([]byte("../"+walletDirName), []byte("password"))
+				Expect(err).To(MatchError("wallet not found"))
+				Expect(walletDirName).To(BeADirectory())
+			})
+		})
+
+		Describe("BackupWallet() & RestoreWallet()", Ordered, func() {
+			const walletDirName = ".test_ddb_backup_wallet"
+			var duckDbDriver driver.DuckDbWalletDriver
+			var backupData []byte
+			var walletAddrs []algoTypes.Digest
+			var msigAddr algoTypes.Digest
+
+			const walletId = "000"
+			const restoredWalletId = "111"
+			const walletPassword = "password"
+			const backupPassphrase = "backup passphrase"
+
+			const acctMnemonic = "minor print what witness play daughter matter light sign tip blossom anger artwork profit cart garment buzz resemble warm hole speed super bamboo abandon bonus"
+
+			BeforeAll(func() {
+				setupDuckDbWalletDriver(&duckDbDriver, walletDirName)
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+			})
+
+			It("backs up the wallet with the given ID", func() {
+				By("Creating a wallet")
+				err := duckDbDriver.CreateWallet(
+					[]byte("Foo"),
+					[]byte(walletId),
+					[]byte(walletPassword),
+					algoTypes.MasterDerivationKey{},
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Adding accounts to the wallet")
+				genAddr, err := testWallet.GenerateKey(false)
+				Expect(err).ToNot(HaveOccurred())
+				sk, err := mnemonic.ToPrivateKey(acctMnemonic)
+				Expect(err).ToNot(HaveOccurred())
+				importedAddr, err := testWallet.ImportKey(sk)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = testWallet.UpdateAccountName(algoTypes.Address(importedAddr).String(), "Imported", []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				walletAddrs = []algoTypes.Digest{genAddr, importedAddr}
+				msigAcct, err := crypto.MultisigAccountWithParams(1, 1, []algoTypes.Address{
+					algoTypes.Address(genAddr),
+					algoTypes.Address(importedAddr),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				msigAddr, err = testWallet.ImportMultisigAddr(msigAcct.Version, msigAcct.Threshold, msigAcct.Pks)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Adding data files to the wallet directory")
+				mek, err := testWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				db, err := encdb.Open(walletDirName+"/"+walletId+"/sessions.duckdb", mek, "CREATE TABLE db.foo (bar TEXT);")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = db.Exec("INSERT INTO db.foo VALUES ('baz')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(db.Close()).To(Succeed())
+				err = os.WriteFile(walletDirName+"/"+walletId+"/session_config.paseto", []byte("session config"), 0600)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Failing to back up the wallet with the wrong password")
+				_, err = duckDbDriver.BackupWallet([]byte(walletId), []byte("not the password"), []byte(backupPassphrase), nil)
+				Expect(err).To(HaveOccurred())
+
+				By("Backing up the wallet")
+				backupData, err = duckDbDriver.BackupWallet(
+					[]byte(walletId),
+					[]byte(walletPassword),
+					[]byte(backupPassphrase),
+					[]string{"sessions.duckdb", "session_config.paseto", "spend_limits.duckdb"},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(backupData)).NotTo(ContainSubstring("session config"), "The backup is encrypted")
+				Expect(walletDirName+"/"+walletId+"/sessions.duckdb.backup").NotTo(BeAnExistingFile(),
+					"The copy made for the backup is removed")
+			})
+
+			It("fails to restore the backup with the wrong passphrase", func() {
+				err := duckDbDriver.RestoreWallet([]byte(restoredWalletId), backupData, []byte("not the passphrase"))
+				Expect(err).To(HaveOccurred())
+				Expect(walletDirName + "/" + restoredWalletId).NotTo(BeAnExistingFile())
+			})
+
+			It("restores the backup as a new wallet with the given ID", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the wallet has been backed up
+				By("Restoring the backup")
+				err := duckDbDriver.RestoreWallet([]byte(restoredWalletId), backupData, []byte(backupPassphrase))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Fetching the restored wallet and initializing it with the password of the backed up wallet")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(restoredWalletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				metadata, err := testWallet.Metadata()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata.ID).To(Equal([]byte(restoredWalletId)))
+				Expect(metadata.Name).To(Equal([]byte("Foo")))
+
+				By("Checking the restored wallet has the accounts of the backed up wallet")
+				keys, err := testWallet.ListKeys()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(keys).To(ConsistOf(walletAddrs))
+				msigAddrs, err := testWallet.ListMultisigAddrs()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msigAddrs).To(Equal([]algoTypes.Digest{msigAddr}))
+				acct, err := testWallet.GetAccount(algoTypes.Address(walletAddrs[1]).String())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(acct.Name).To(Equal("Imported"))
+
+				By("Checking the data files were restored")
+				mek, err := testWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				db, err := encdb.Open(walletDirName+"/"+restoredWalletId+"/sessions.duckdb", mek, "")
+				Expect(err).ToNot(HaveOccurred())
+				defer db.Close()
+				var bar string
+				Expect(db.QueryRow("SELECT bar FROM db.foo").Scan(&bar)).To(Succeed())
+				Expect(bar).To(Equal("baz"))
+				contents, err := os.ReadFile(walletDirName + "/" + restoredWalletId + "/session_config.paseto")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("session config"))
+				Expect(walletDirName + "/" + restoredWalletId + "/spend_limits.duckdb").NotTo(BeAnExistingFile())
+			})
+
+			It("fails to restore the backup if a wallet with the given ID exists", func() {
+				err := duckDbDriver.RestoreWallet([]byte(walletId), backupData, []byte(backupPassphrase))
+				Expect(err).To(MatchError("wallet with same id already exists"))
+			})
+		})
+
 		Describe("FetchWallet()", Ordered, func() {
 			const walletDirName = ".test_ddb_fetch_wallet"
 			var duckDbDriver driver.DuckDbWalletDriver
@@ -417,6 +625,393 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 			})
 		})
 
+		Describe("ChangePassword()", Ordered, func() {
+			const walletDirName = ".test_ddb_wallet_change_pw"
+			var duckDbDriver driver.DuckDbWalletDriver
+
+			const walletId = "000"
+			const walletPassword = "password"
+			const newWalletPassword = "new password"
+
+			const acctMnemonic = "minor print what witness play daughter matter light sign tip blossom anger artwork profit cart garment buzz resemble warm hole speed super bamboo abandon bonus"
+
+			walletPath := filepath.Join(walletDirName, walletId)
+
+			BeforeAll(func() {
+				setupDuckDbWalletDriver(&duckDbDriver, walletDirName)
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+			})
+
+			It("re-encrypts the wallet with the new password", func() {
+				By("Creating a wallet")
+				err := duckDbDriver.CreateWallet(
+					[]byte("Foo"),
+					[]byte(walletId),
+					[]byte(walletPassword),
+					algoTypes.MasterDerivationKey{},
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Adding keys to the wallet")
+				genAddr, err := testWallet.GenerateKey(false)
+				Expect(err).ToNot(HaveOccurred())
+				sk, err := mnemonic.ToPrivateKey(acctMnemonic)
+				Expect(err).ToNot(HaveOccurred())
+				importedAddr, err := testWallet.ImportKey(sk)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Storing data in another data file within the wallet directory")
+				mek, err := testWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				db, err := encdb.Open(filepath.Join(walletPath, "sessions.duckdb"), mek, "CREATE TABLE db.foo (bar TEXT);")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = db.Exec("INSERT INTO db.foo VALUES ('baz')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(db.Close()).To(Succeed())
+
+				By("Changing the password")
+				err = testWallet.ChangePassword([]byte(walletPassword), []byte(newWalletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.CheckPassword([]byte(newWalletPassword))).To(Succeed())
+				Expect(testWallet.CheckPassword([]byte(walletPassword))).ToNot(Succeed())
+				Expect(filepath.Join(walletPath, "password_change.json")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(walletPath, driver.DuckDbWalletAcctsFile+".rekey")).NotTo(BeAnExistingFile())
+
+				By("Initializing the wallet with the new password")
+				testWallet, err = duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(walletPassword))).ToNot(Succeed(), "The old password no longer works")
+				Expect(testWallet.Init([]byte(newWalletPassword))).To(Succeed())
+
+				By("Checking the keys are still in the wallet")
+				keys, err := testWallet.ListKeys()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(keys).To(ConsistOf(genAddr, importedAddr))
+				exportedSk, err := testWallet.ExportKey(importedAddr, []byte(newWalletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(exportedSk).To(Equal(sk))
+
+				By("Checking the data in the other data file with the new master key")
+				newMek, err := testWallet.DecryptAndGetMasterKey([]byte(newWalletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(newMek).NotTo(Equal(mek), "The master key was replaced")
+				db, err = encdb.Open(filepath.Join(walletPath, "sessions.duckdb"), newMek, "")
+				Expect(err).ToNot(HaveOccurred())
+				defer db.Close()
+				var bar string
+				Expect(db.QueryRow("SELECT bar FROM db.foo").Scan(&bar)).To(Succeed())
+				Expect(bar).To(Equal("baz"))
+			})
+
+			It("rolls back an interrupted password change that was not committed", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the password of the wallet has been changed
+
+				By("Leaving what an interrupted password change would leave")
+				err := os.WriteFile(filepath.Join(walletPath, "password_change.json"), []byte(`{"mep_encrypted":"Zm9v"}`), 0600)
+				Expect(err).ToNot(HaveOccurred())
+				err = os.WriteFile(filepath.Join(walletPath, driver.DuckDbWalletAcctsFile+".rekey"), []byte("partial copy"), 0600)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(newWalletPassword))).To(Succeed())
+				keys, err := testWallet.ListKeys()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(keys).To(HaveLen(2))
+
+				By("Checking the leftovers of the interrupted password change are removed")
+				Expect(filepath.Join(walletPath, "password_change.json")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(walletPath, driver.DuckDbWalletAcctsFile+".rekey")).NotTo(BeAnExistingFile())
+			})
+
+			It("finishes an interrupted password change that was committed", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the password of the wallet has been changed
+
+				acctsPath := filepath.Join(walletPath, driver.DuckDbWalletAcctsFile)
+
+				By("Keeping a copy of the accounts file encrypted with the current master key")
+				acctsFile, err := os.ReadFile(acctsPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Changing the password")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(newWalletPassword))).To(Succeed())
+				err = testWallet.ChangePassword([]byte(newWalletPassword), []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Making it look like the change was interrupted after it was committed")
+				err = os.Rename(acctsPath, acctsPath+".rekey")
+				Expect(err).ToNot(HaveOccurred())
+				err = os.WriteFile(acctsPath, acctsFile, 0600)
+				Expect(err).ToNot(HaveOccurred())
+				metadataJson, err := os.ReadFile(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile))
+				Expect(err).ToNot(HaveOccurred())
+				metadata := driver.DuckDbWalletMetadata{}
+				Expect(json.Unmarshal(metadataJson, &metadata)).To(Succeed())
+				changeJson, err := json.Marshal(map[string][]byte{"mep_encrypted": metadata.MEPEncrypted})
+				Expect(err).ToNot(HaveOccurred())
+				err = os.WriteFile(filepath.Join(walletPath, "password_change.json"), changeJson, 0600)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Fetching the wallet and initializing it with the new password")
+				testWallet, err = duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(walletPassword))).To(Succeed())
+				keys, err := testWallet.ListKeys()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(keys).To(HaveLen(2))
+				Expect(filepath.Join(walletPath, "password_change.json")).NotTo(BeAnExistingFile())
+				Expect(acctsPath + ".rekey").NotTo(BeAnExistingFile())
+			})
+
+			It("fails if given the wrong password", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that a wallet has been created
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(walletPassword))).To(Succeed())
+
+				By("Attempting to change the password with the wrong password")
+				err = testWallet.ChangePassword([]byte("not the password"), []byte(newWalletPassword))
+				Expect(err).To(HaveOccurred())
+				Expect(testWallet.CheckPassword([]byte(walletPassword))).To(Succeed(), "The password is unchanged")
+			})
+
+			It("re-encrypts data files with a quote in their names", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that a wallet has been created
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testWallet.Init([]byte(walletPassword))).To(Succeed())
+
+				By("Storing data in a data file with a quote in its name")
+				dataFilePath := filepath.Join(walletPath, "it's.duckdb")
+				mek, err := testWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				db, err := encdb.Open(dataFilePath, mek, "CREATE TABLE db.foo (bar TEXT);")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = db.Exec("INSERT INTO db.foo VALUES ('baz')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(db.Close()).To(Succeed())
+
+				By("Changing the password")
+				err = testWallet.ChangePassword([]byte(walletPassword), []byte(newWalletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Checking the data in the data file with the new master key")
+				newMek, err := testWallet.DecryptAndGetMasterKey([]byte(newWalletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				db, err = encdb.Open(dataFilePath, newMek, "")
+				Expect(err).ToNot(HaveOccurred())
+				defer db.Close()
+				var bar string
+				Expect(db.QueryRow("SELECT bar FROM db.foo").Scan(&bar)).To(Succeed())
+				Expect(bar).To(Equal("baz"))
+			})
+		})
+
+		Describe("Init() with a stronger KDF configured", Ordered, func() {
+			const walletDirName = ".test_ddb_wallet_kdf_upgrade"
+			var scryptDriver, argon2idDriver driver.DuckDbWalletDriver
+
+			const walletId = "000"
+			const walletPassword = "password"
+
+			walletPath := filepath.Join(walletDirName, walletId)
+
+			// storedKDF gives the KDF recorded in the header of the wallet's
+			// encrypted master encryption key
+			storedKDF := func() string {
+				metadataJson, err := os.ReadFile(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile))
+				Expect(err).ToNot(HaveOccurred())
+				metadata := driver.DuckDbWalletMetadata{}
+				Expect(json.Unmarshal(metadataJson, &metadata)).To(Succeed())
+				blob := map[string]interface{}{}
+				Expect(msgpack.Decode(metadata.MEPEncrypted, &blob)).To(Succeed())
+				Expect(blob).To(HaveKeyWithValue("kdf_version", BeEquivalentTo(1)))
+				return string(blob["kdf"].([]byte))
+			}
+
+			BeforeAll(func() {
+				setupDuckDbWalletDriver(&scryptDriver, walletDirName)
+				setupDuckDbWalletDriverWithKDF(&argon2idDriver, walletDirName, config.KDFArgon2id)
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+			})
+
+			It("re-encrypts the master encryption key using the stronger KDF", func() {
+				By("Creating a wallet using scrypt")
+				err := scryptDriver.CreateWallet(
+					[]byte("Foo"),
+					[]byte(walletId),
+					[]byte(walletPassword),
+					algoTypes.MasterDerivationKey{},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(storedKDF()).To(Equal(config.KDFScrypt))
+				scryptWallet, err := scryptDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				mek, err := scryptWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Unlocking the wallet with Argon2id configured")
+				testWallet, err := argon2idDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(storedKDF()).To(Equal(config.KDFArgon2id))
+				Expect(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile+".rekey")).NotTo(BeAnExistingFile())
+
+				By("Checking the master encryption key is unchanged")
+				upgradedMek, err := testWallet.DecryptAndGetMasterKey([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(upgradedMek).To(Equal(mek))
+				_, err = testWallet.DecryptAndGetMasterKey([]byte("not the password"))
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("does not downgrade the KDF", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the wallet has been upgraded to Argon2id
+
+				testWallet, err := scryptDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred(), "The wallet can be unlocked with scrypt configured")
+				Expect(storedKDF()).To(Equal(config.KDFArgon2id))
+			})
+
+			It("refuses to derive a key using KDF parameters that are too costly", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the wallet has been upgraded to Argon2id
+
+				By("Tampering with the Argon2id parameters stored in the wallet")
+				metadataPath := filepath.Join(walletPath, driver.DuckDbWalletMetadataFile)
+				metadataJson, err := os.ReadFile(metadataPath)
+				Expect(err).ToNot(HaveOccurred())
+				metadata := driver.DuckDbWalletMetadata{}
+				Expect(json.Unmarshal(metadataJson, &metadata)).To(Succeed())
+				blob := map[string]interface{}{}
+				Expect(msgpack.Decode(metadata.MEPEncrypted, &blob)).To(Succeed())
+				blob["argon2id"] = map[string]interface{}{"time": 1, "memory_kib": uint32(1 << 31), "threads": 1}
+				metadata.MEPEncrypted = msgpack.Encode(blob)
+				metadataJson, err = json.Marshal(metadata)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(os.WriteFile(metadataPath, metadataJson, 0600)).To(Succeed())
+
+				By("Attempting to unlock the wallet")
+				testWallet, err := argon2idDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).To(MatchError("key derivation function parameters exceed the maximum cost"))
+			})
+
+			It("fails to initialize the driver with an unknown KDF", func() {
+				var badDriver driver.DuckDbWalletDriver
+				err := badDriver.InitWithConfig(config.KMDConfig{
+					DriverConfig: config.DriverConfig{
+						DuckDbWalletDriverConfig: config.DuckDbWalletDriverConfig{
+							WalletsDir:   walletDirName,
+							UnsafeScrypt: true,
+							KDF:          "md5",
+						},
+					},
+				}, logging.New())
+				Expect(err).To(MatchError("unknown key derivation function: md5"))
+			})
+
+			It("fails to initialize the driver with unsafe Argon2id parameters", func() {
+				var badDriver driver.DuckDbWalletDriver
+				err := badDriver.InitWithConfig(config.KMDConfig{
+					DriverConfig: config.DriverConfig{
+						DuckDbWalletDriverConfig: config.DuckDbWalletDriverConfig{
+							WalletsDir:     walletDirName,
+							KDF:            config.KDFArgon2id,
+							Argon2idParams: config.Argon2idParams{Time: 1, MemoryKiB: 64, Threads: 1},
+						},
+					},
+				}, logging.New())
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("Init() when upgrading the KDF fails", func() {
+			const walletDirName = ".test_ddb_wallet_kdf_upgrade_fail"
+			var scryptDriver, argon2idDriver driver.DuckDbWalletDriver
+
+			const walletId = "000"
+			const walletPassword = "password"
+
+			walletPath := filepath.Join(walletDirName, walletId)
+
+			It("still unlocks the wallet and logs the failure", func() {
+				var logOut bytes.Buffer
+				logger := logging.New()
+				logger.SetOutput(&logOut)
+
+				setupDuckDbWalletDriver(&scryptDriver, walletDirName)
+				err := argon2idDriver.InitWithConfig(config.KMDConfig{
+					SessionLifetimeSecs: 3600,
+					DriverConfig: config.DriverConfig{
+						DuckDbWalletDriverConfig: config.DuckDbWalletDriverConfig{
+							WalletsDir:     walletDirName,
+							UnsafeScrypt:   true, // For testing purposes only
+							KDF:            config.KDFArgon2id,
+							Argon2idParams: config.Argon2idParams{Time: 1, MemoryKiB: 64, Threads: 1},
+						},
+					},
+				}, logger)
+				Expect(err).ToNot(HaveOccurred())
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+
+				By("Creating a wallet using scrypt")
+				err = scryptDriver.CreateWallet(
+					[]byte("Foo"),
+					[]byte(walletId),
+					[]byte(walletPassword),
+					algoTypes.MasterDerivationKey{},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				origMetadata, err := os.ReadFile(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Blocking the copy of the metadata file from being written")
+				testWallet, err := argon2idDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(os.Mkdir(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile+".rekey"), 0700)).To(Succeed())
+
+				By("Unlocking the wallet with Argon2id configured")
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logOut.String()).To(ContainSubstring("failed to upgrade the key derivation function of wallet " + walletId))
+
+				By("Checking the metadata file is unchanged")
+				metadata, err := os.ReadFile(filepath.Join(walletPath, driver.DuckDbWalletMetadataFile))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata).To(Equal(origMetadata))
+				Expect(testWallet.CheckPassword([]byte(walletPassword))).To(Succeed())
+			})
+		})
+
 		Describe("ExportMasterDerivationKey()", Ordered, func() {
 			const walletDirName = ".test_ddb_wallet_export_mdk"
 			var duckDbDriver driver.DuckDbWalletDriver
@@ -526,6 +1121,24 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(check).To(BeTrue())
 			})
+
+			It("returns true if address is of a watch-only account in wallet", func() {
+				const watchAcctAddr = "3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY"
+
+				By("Adding a watch-only account")
+				decodedAddr, err := algoTypes.DecodeAddress(watchAcctAddr)
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.AddAccountBelow(&wallet.Account{
+					Address: algoTypes.Digest(decodedAddr),
+					Type:    wallet.AcctTypeWatch,
+				}, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Checking if the address is stored in wallet")
+				check, err := testWallet.CheckAddrInWallet(watchAcctAddr)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(check).To(BeTrue())
+			})
 		})
 
 		Describe("ListKeys()", Ordered, func() {
@@ -1041,6 +1654,11 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				addrs, err := wallet.ListMultisigAddrs()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(addrs).To(HaveLen(1), "New multisig address was added to the file")
+
+				By("Checking if an account was added for the multisig address")
+				acct, err := wallet.GetAccount(msigAddr.String())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(acct.Type.String()).To(Equal("msig"))
 			})
 
 			It("imports the multisig address when there is at least one multisig address in the wallet", func() {
@@ -1356,6 +1974,11 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				By("Checking if multisig address has been removed")
 				_, _, _, err = wallet.LookupMultisigPreimage(algoTypes.Digest(addr))
 				Expect(err).To(HaveOccurred(), "Key was removed")
+
+				By("Checking if the account of the multisig address has been removed")
+				accts, err := wallet.ListAccounts()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accts).To(HaveLen(0), "Account was removed")
 			})
 
 			It("fails if given the wrong password", func() {
@@ -1501,6 +2124,100 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				)
 				Expect(err).To(HaveOccurred(), "Signing transaction failed")
 			})
+
+			It("fails with a watch-only error if the account is watch-only", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that a wallet has been created
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Adding a watch-only account")
+				const watchAcctAddrStr = "3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY"
+				watchAcctAddr, err := algoTypes.DecodeAddress(watchAcctAddrStr)
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.AddAccountBelow(&wallet.Account{
+					Address: algoTypes.Digest(watchAcctAddr),
+					Type:    wallet.AcctTypeWatch,
+				}, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Attempting to sign a transaction with the watch-only account")
+				_, err = testWallet.SignTransaction(
+					knownSignedTxn.Txn,
+					watchAcctAddr[:],
+					[]byte(walletPassword),
+				)
+				Expect(err).To(MatchError(wallet.ErrWatchOnly))
+			})
+
+			It("signs for a rekeyed account with the key of the account it is rekeyed to", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that a wallet has been created with the key of
+				// `acctAddr` and a watch-only account in it
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Recording the watch-only account is rekeyed to an account with its key in the wallet")
+				const rekeyedAcctAddrStr = "3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY"
+				rekeyedAcctAddr, err := algoTypes.DecodeAddress(rekeyedAcctAddrStr)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = testWallet.UpdateAccountRekeyedTo(rekeyedAcctAddrStr, acctAddrStr, []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Signing a transaction sent by the rekeyed account")
+				txn := knownSignedTxn.Txn
+				txn.Sender = rekeyedAcctAddr
+				stxBytes, err := testWallet.SignTransaction(txn, ed25519.PublicKey{}, []byte(walletPassword))
+				Expect(err).NotTo(HaveOccurred())
+
+				var stx algoTypes.SignedTxn
+				err = msgpack.Decode(stxBytes, &stx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stx.AuthAddr).To(Equal(acctAddr), "Auth address is the account the sender is rekeyed to")
+				sk, err := mnemonic.ToPrivateKey(acctMnemonic)
+				Expect(err).ToNot(HaveOccurred())
+				_, expectedStxBytes, err := crypto.SignTransaction(sk, txn)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stxBytes).To(Equal(expectedStxBytes), "Transaction was signed with the key of the account the sender is rekeyed to")
+			})
+
+			It("fails if the account the sender is rekeyed to does not have its key in the wallet", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that a wallet has been created
+
+				By("Fetching the wallet and initializing it")
+				testWallet, err := duckDbDriver.FetchWallet([]byte(walletId))
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.Init([]byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Adding a watch-only account rekeyed to an account that is not in the wallet")
+				const rekeyedAcctAddrStr = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+				rekeyedAcctAddr, err := algoTypes.DecodeAddress(rekeyedAcctAddrStr)
+				Expect(err).ToNot(HaveOccurred())
+				authAcctAddr, err := algoTypes.DecodeAddress("H3PFTYORQCTLIN7PEPDCYI4ALUHNE4CE5GJIPLZA3ZBKWG23TWND4IP47A")
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.AddAccountBelow(&wallet.Account{
+					Address:   algoTypes.Digest(rekeyedAcctAddr),
+					Type:      wallet.AcctTypeWatch,
+					RekeyedTo: algoTypes.Digest(authAcctAddr),
+				}, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Attempting to sign a transaction sent by the rekeyed account")
+				txn := knownSignedTxn.Txn
+				txn.Sender = rekeyedAcctAddr
+				_, err = testWallet.SignTransaction(txn, ed25519.PublicKey{}, []byte(walletPassword))
+				Expect(err).To(MatchError("key of the account the sender is rekeyed to does not exist in this wallet"))
+			})
 		})
 
 		// PDescribe("MultisigSignTransaction()", Ordered, func() {
@@ -1542,6 +2259,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 			var testWallet wallet.Wallet
 
 			const acct1Addr = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+			const acct1Mnemonic = "minor print what witness play daughter matter light sign tip blossom anger artwork profit cart garment buzz resemble warm hole speed super bamboo abandon bonus"
 			const acct2Addr = "3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY"
 
 			BeforeAll(func() {
@@ -1566,14 +2284,14 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("returns no accounts if there are no accounts stored", func() {
+			It("returns no accounts if there are no accounts stored", func() {
 				By("Listing all accounts")
 				accts, err := testWallet.ListAccounts()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(accts).To(HaveLen(0), "No accounts were returned")
 			})
 
-			XIt("returns all accounts stored within the wallet", func() {
+			It("returns all accounts stored within the wallet", func() {
 				By("Adding Account #1")
 				decodedAddr1, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1597,6 +2315,41 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(addrs).To(HaveLen(2), "All accounts were returned")
 			})
+
+			It("includes the accounts of generated and imported keys", func() {
+				By("Generating a key")
+				genAddr, err := testWallet.GenerateKey(false)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Importing the key of Account #1")
+				sk, err := mnemonic.ToPrivateKey(acct1Mnemonic)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = testWallet.ImportKey(sk)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Listing all accounts")
+				accts, err := testWallet.ListAccounts()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accts).To(HaveLen(3), "Imported key did not add another account")
+				Expect(accts[0].Type).To(Equal(wallet.AcctTypeStandalone), "Account #1 is now a standalone account")
+				Expect(accts[2].Address).To(Equal(genAddr), "Generated account is last")
+				Expect(accts[2].Type).To(Equal(wallet.AcctTypeKMD))
+			})
+
+			It("no longer includes the account of a deleted key", func() {
+				By("Deleting the key of Account #1")
+				decodedAddr1, err := algoTypes.DecodeAddress(acct1Addr)
+				Expect(err).ToNot(HaveOccurred())
+				err = testWallet.DeleteKey(algoTypes.Digest(decodedAddr1), []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Listing all accounts")
+				accts, err := testWallet.ListAccounts()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accts).To(HaveLen(2), "Account was removed with its key")
+				Expect(accts[0].Position).To(Equal("0"), "Accounts below were moved up")
+				Expect(accts[1].Position).To(Equal("1"), "Accounts below were moved up")
+			})
 		})
 
 		Describe("GetAccount()", Ordered, func() {
@@ -1632,13 +2385,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("fails if there are no accounts stored in the wallet", func() {
+			It("fails if there are no accounts stored in the wallet", func() {
 				By("Attempting to get account")
 				_, err := testWallet.GetAccount(acctAddr)
 				Expect(err).To(MatchError("account does not exist in this wallet"), "Account retrieval failed")
 			})
 
-			XIt("returns the account for the given address if it is stored in the wallet", func() {
+			It("returns the account for the given address if it is stored in the wallet", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acctAddr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1657,9 +2410,9 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(acct.Type).To(Equal(wallet.AcctTypeKMD), "Returned account has correct type")
 			})
 
-			XIt("fails if the account for the given address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given address is not stored in the non-empty wallet", func() {
 				By("Attempting to get account")
-				_, err := testWallet.GetAccount(acctAddr)
+				_, err := testWallet.GetAccount("3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY")
 				Expect(err).To(MatchError("account does not exist in this wallet"), "Account retrieval failed")
 			})
 		})
@@ -1697,13 +2450,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("does not fail if there are no accounts", func() {
+			It("does not fail if there are no accounts", func() {
 				By("Attempting to delete an account")
 				err := testWallet.DeleteAccount(acctAddr, []byte(walletPassword))
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("removes the account for the given address", func() {
+			It("removes the account for the given address", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acctAddr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1717,15 +2470,19 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				By("Attempting to delete an account")
 				err = testWallet.DeleteAccount(acctAddr, []byte(walletPassword))
 				Expect(err).ToNot(HaveOccurred())
+
+				By("Checking if the account was removed")
+				_, err = testWallet.GetAccount(acctAddr)
+				Expect(err).To(MatchError("account does not exist in this wallet"))
 			})
 
-			XIt("fails if given the wrong password", func() {
+			It("fails if given the wrong password", func() {
 				By("Attempting to delete an account")
 				err := testWallet.DeleteAccount(acctAddr, []byte("not the password"))
 				Expect(err).To(HaveOccurred(), "Account deletion failed")
 			})
 
-			XIt("does not fail if account to be removed is not in wallet", func() {
+			It("does not fail if account to be removed is not in wallet", func() {
 				By("Attempting to delete an account")
 				err := testWallet.DeleteAccount(acctAddr, []byte("password"))
 				Expect(err).ToNot(HaveOccurred())
@@ -1765,13 +2522,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("fails if there are no accounts stored in the wallet", func() {
+			It("fails if there are no accounts stored in the wallet", func() {
 				By("Attempting to update account")
 				_, err := testWallet.UpdateAccountName(acctAddr, "Updated Account", []byte(walletPassword))
 				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to update account name")
 			})
 
-			XIt("updates the account name and returns the account with the new name", func() {
+			It("updates the account name and returns the account with the new name", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acctAddr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1789,13 +2546,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 					Equal(algoTypes.Digest(decodedAddr)), "Returned account has correct address")
 			})
 
-			XIt("fails if given the wrong password", func() {
+			It("fails if given the wrong password", func() {
 				By("Attempting to update account name")
 				_, err := testWallet.UpdateAccountName(acctAddr, "Updated Account", []byte("not the password"))
 				Expect(err).To(HaveOccurred(), "Failed to update account name")
 			})
 
-			XIt("fails if the account for the given address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given address is not stored in the non-empty wallet", func() {
 				By("Attempting to update account name")
 				_, err := testWallet.UpdateAccountName(acctAddr, "Updated Account", []byte("not the password"))
 				Expect(err).To(HaveOccurred(), "Failed to update account name")
@@ -1835,13 +2592,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("fails if there are no accounts stored in the wallet", func() {
+			It("fails if there are no accounts stored in the wallet", func() {
 				By("Attempting to update account")
 				_, err := testWallet.UpdateAccountRekeyedTo(acctAddr, "Updated Account", []byte(walletPassword))
 				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to update account name")
 			})
 
-			XIt("updates the account rekeyed-to address and returns the account with the new rekeyed-to address", func() {
+			It("updates the account rekeyed-to address and returns the account with the new rekeyed-to address", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acctAddr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1862,11 +2619,12 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(acct.Address).To(
 					Equal(algoTypes.Digest(decodedAddr)), "Returned account has correct address")
 
-				addr, err := algoTypes.DigestFromString("3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY")
-				Expect(acct.RekeyedTo).To(Equal(addr), "Returned account has correct rekeyed-to address")
+				addr, err := algoTypes.DecodeAddress("3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(acct.RekeyedTo).To(Equal(algoTypes.Digest(addr)), "Returned account has correct rekeyed-to address")
 			})
 
-			XIt("fails if given the wrong password", func() {
+			It("fails if given the wrong password", func() {
 				By("Attempting to update account name")
 				_, err := testWallet.UpdateAccountRekeyedTo(
 					acctAddr,
@@ -1876,7 +2634,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).To(HaveOccurred(), "Failed to update account rekeyed-to address")
 			})
 
-			XIt("fails if the account for the given address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given address is not stored in the non-empty wallet", func() {
 				_, err := testWallet.UpdateAccountRekeyedTo(
 					"3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY",
 					"3F3FPW6ZQQYD6JDC7FKKQHNGVVUIBIZOUI5WPSJEHBRABZDRN6LOTBMFEY",
@@ -1921,7 +2679,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("adds the given account if there are no accounts in the wallet", func() {
+			It("adds the given account if there are no accounts in the wallet", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1938,7 +2696,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs).To(HaveLen(1), "Wallet now contains 1 account")
 			})
 
-			XIt("adds the given account above the given reference account", func() {
+			It("adds the given account above the given reference account", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct2Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1957,7 +2715,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[1].Name).To(Equal("Test Account 1"), "Reference account is second")
 			})
 
-			XIt("fails if an account with the given reference address is not stored in the non-empty wallet", func() {
+			It("fails if an account with the given reference address is not stored in the non-empty wallet", func() {
 				By("Attempting to add account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct3Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1966,10 +2724,10 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 					Address: algoTypes.Digest(decodedAddr),
 					Type:    wallet.AcctTypeKMD,
 				}, acct4Addr)
-				Expect(err).To(MatchError("reference account does exist in wallet"), "Failed to add account")
+				Expect(err).To(MatchError("reference account does not exist in this wallet"), "Failed to add account")
 			})
 
-			XIt("adds account to the beginning if no reference address is given", func() {
+			It("adds account to the beginning if no reference address is given", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct3Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -1977,7 +2735,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 					Name:    "Test Account 3",
 					Address: algoTypes.Digest(decodedAddr),
 					Type:    wallet.AcctTypeKMD,
-				}, acct1Addr)
+				}, "")
 				Expect(err).ToNot(HaveOccurred(), "Account added")
 
 				By("Checking if account has been added")
@@ -1989,7 +2747,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 1"))
 			})
 
-			XIt("fails if an account with the same address exists in the wallet", func() {
+			It("fails if an account with the same address exists in the wallet", func() {
 				By("Attempting to add account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2037,7 +2795,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("adds the given account if there are no accounts in the wallet", func() {
+			It("adds the given account if there are no accounts in the wallet", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2054,7 +2812,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs).To(HaveLen(1), "Wallet now contains 1 account")
 			})
 
-			XIt("adds the given account below the given reference account", func() {
+			It("adds the given account below the given reference account", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct2Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2073,7 +2831,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[1].Name).To(Equal("Test Account 2"), "New account is second")
 			})
 
-			XIt("fails if an account with the given reference address is not stored in the non-empty wallet", func() {
+			It("fails if an account with the given reference address is not stored in the non-empty wallet", func() {
 				By("Attempting to add account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct3Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2082,10 +2840,10 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 					Address: algoTypes.Digest(decodedAddr),
 					Type:    wallet.AcctTypeKMD,
 				}, acct4Addr)
-				Expect(err).To(MatchError("reference account does exist in wallet"), "Failed to add account")
+				Expect(err).To(MatchError("reference account does not exist in this wallet"), "Failed to add account")
 			})
 
-			XIt("adds account to the end if no reference address is given", func() {
+			It("adds account to the end if no reference address is given", func() {
 				By("Adding account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct3Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2093,7 +2851,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 					Name:    "Test Account 3",
 					Address: algoTypes.Digest(decodedAddr),
 					Type:    wallet.AcctTypeKMD,
-				}, acct1Addr)
+				}, "")
 				Expect(err).ToNot(HaveOccurred(), "Account added")
 
 				By("Checking if account has been added")
@@ -2105,7 +2863,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 3"), "New account is last")
 			})
 
-			XIt("fails if an account with the same address exists in the wallet", func() {
+			It("fails if an account with the same address exists in the wallet", func() {
 				By("Attempting to add account")
 				decodedAddr, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2153,13 +2911,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("fails if there are no accounts stored in the wallet", func() {
+			It("fails if there are no accounts stored in the wallet", func() {
 				By("Attempting to move account")
 				err := testWallet.MoveAccountAbove(acct1Addr, "")
-				Expect(err).To(MatchError("account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to move account")
 			})
 
-			XIt("moves the given account above the given reference account", func() {
+			It("moves the given account above the given reference account", func() {
 				By("Adding Account #1")
 				decodedAddr1, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2203,7 +2961,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 2"))
 			})
 
-			XIt("moves account to the beginning if no reference address is given", func() {
+			It("moves account to the beginning if no reference address is given", func() {
 				By("Moving Account #2 to the beginning")
 				err := testWallet.MoveAccountAbove(acct2Addr, "")
 				Expect(err).ToNot(HaveOccurred())
@@ -2217,16 +2975,16 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 3"))
 			})
 
-			XIt("fails if the account for the given address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given address is not stored in the non-empty wallet", func() {
 				By("Attempt to move account")
 				err := testWallet.MoveAccountAbove(acct4Addr, "")
-				Expect(err).To(MatchError("account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to move account")
 			})
 
-			XIt("fails if the account for the given reference address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given reference address is not stored in the non-empty wallet", func() {
 				By("Attempt to move account")
 				err := testWallet.MoveAccountAbove(acct1Addr, acct4Addr)
-				Expect(err).To(MatchError("reference account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("reference account does not exist in this wallet"), "Failed to move account")
 			})
 		})
 
@@ -2265,13 +3023,13 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			XIt("fails if there are no accounts stored in the wallet", func() {
+			It("fails if there are no accounts stored in the wallet", func() {
 				By("Attempting to move account")
 				err := testWallet.MoveAccountBelow(acct1Addr, "")
-				Expect(err).To(MatchError("account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to move account")
 			})
 
-			XIt("moves the given account below the given reference account", func() {
+			It("moves the given account below the given reference account", func() {
 				By("Adding Account #1")
 				decodedAddr1, err := algoTypes.DecodeAddress(acct1Addr)
 				Expect(err).ToNot(HaveOccurred())
@@ -2303,7 +3061,7 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				By("Moving Account #2 below Account #3")
-				err = testWallet.MoveAccountBelow(acct3Addr, acct2Addr)
+				err = testWallet.MoveAccountBelow(acct2Addr, acct3Addr)
 				Expect(err).ToNot(HaveOccurred())
 
 				By("Checking order of accounts")
@@ -2315,9 +3073,9 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 2"), "Account was moved")
 			})
 
-			XIt("moves account to the beginning if no reference address is given", func() {
+			It("moves account to the end if no reference address is given", func() {
 				By("Moving Account #1 to the end")
-				err := testWallet.MoveAccountBelow(acct2Addr, "")
+				err := testWallet.MoveAccountBelow(acct1Addr, "")
 				Expect(err).ToNot(HaveOccurred())
 
 				By("Checking order of accounts")
@@ -2329,16 +3087,16 @@ var _ = Describe("DuckDB Wallet Driver", func() {
 				Expect(addrs[2].Name).To(Equal("Test Account 1"), "Account was moved")
 			})
 
-			XIt("fails if the account for the given address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given address is not stored in the non-empty wallet", func() {
 				By("Attempt to move account")
 				err := testWallet.MoveAccountBelow(acct4Addr, "")
-				Expect(err).To(MatchError("account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("account does not exist in this wallet"), "Failed to move account")
 			})
 
-			XIt("fails if the account for the given reference address is not stored in the non-empty wallet", func() {
+			It("fails if the account for the given reference address is not stored in the non-empty wallet", func() {
 				By("Attempt to move account")
 				err := testWallet.MoveAccountBelow(acct1Addr, acct4Addr)
-				Expect(err).To(MatchError("reference account does not exists in wallet"), "Failed to move account")
+				Expect(err).To(MatchError("reference account does not exist in this wallet"), "Failed to move account")
 			})
 		})
 	})
@@ -2369,3 +3127,29 @@ func setupDuckDbWalletDriver(duckDbDriver *driver.DuckDbWalletDriver, walletDirN
 
 	Expect(err).NotTo(HaveOccurred())
 }
+
+func setupDuckDbWalletDriverWithKDF(duckDbDriver *driver.DuckDbWalletDriver, walletDirName string, kdf string) {
+	logger := logging.New()
+	logger.SetLevel(logging.InfoLevel)
+
+	err := duckDbDriver.InitWithConfig(config.KMDConfig{
+		SessionLifetimeSecs: 3600,
+		DriverConfig: config.DriverConfig{
+			DuckDbWalletDriverConfig: config.DuckDbWalletDriverConfig{
+				WalletsDir:     walletDirName,
+				UnsafeScrypt:   true, // For testing purposes only
+				KDF:            kdf,
+				ScryptParams:   config.ScryptParams{ScryptN: 2, ScryptR: 1, ScryptP: 1},
+				Argon2idParams: config.Argon2idParams{Time: 1, MemoryKiB: 64, Threads: 1},
+			},
+			SQLiteWalletDriverConfig: config.SQLiteWalletDriverConfig{
+				UnsafeScrypt: true,
+				Disable:      true,
+				WalletsDir:   walletDirName,
+			},
+			LedgerWalletDriverConfig: config.LedgerWalletDriverConfig{Disable: true},
+		},
+	}, logger)
+
+	Expect(err).NotTo(HaveOccurred())
+}
@@ -0,0 +1,245 @@
+package driver
+
+import (
+	"fmt"
+
+	"duckysigner/internal/kmd/config"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// The key that encrypts a blob can be derived from a password using a key
+// derivation function (KDF). A blob made by encryptBlobWithKDF has a versioned
+// header that records which KDF was used and with what parameters, so the KDF
+// or its parameters can be changed without breaking the existing blobs. Blobs
+// without the header (i.e. version 0), like those made by go-algorand's kmd,
+// were made with scrypt if their "do_scrypt" flag is set.
+
+const (
+	// kdfHeaderVersion is the version of the KDF header of the blobs that are
+	// made
+	kdfHeaderVersion = 1
+	// kdfNone is the KDF recorded in the header of a blob that was encrypted
+	// using a key instead of a password
+	kdfNone = "none"
+	// Minimum Argon2id parameters, which are the minimum recommended by OWASP
+	minArgon2idTime      = 2
+	minArgon2idMemoryKiB = 19 * 1024
+	minArgon2idThreads   = 1
+	// Maximum KDF costs, which keep a crafted blob (e.g. a backup file) from
+	// making key derivation use up the memory or run for hours. They allow
+	// several times the default and recommended parameters.
+	maxScryptNR          = (1 << 30) / 128          // N * R, which is 1 GiB of memory
+	maxScryptWork        = 16 * 65536 * 1 * 32      // N * R * P, 16 times the default
+	maxArgon2idMemoryKiB = 2 * 1024 * 1024          // 2 GiB
+	maxArgon2idWork      = 4 * maxArgon2idMemoryKiB // Time * MemoryKiB
+	maxArgon2idThreads   = 64
+)
+
+// kdfHeader records which KDF was used to derive the key that encrypted a blob
+type kdfHeader struct {
+	// Version of the header, which is 0 if the blob has no header
+	KDFVersion int `codec:"kdf_version,omitempty"`
+	// Name of the KDF (e.g. "scrypt", "argon2id") or kdfNone
+	KDF string `codec:"kdf,omitempty"`
+	// Parameters of Argon2id if it is the KDF. The parameters of scrypt are
+	// stored outside the header for compatibility with blobs without a header.
+	Argon2idParams *config.Argon2idParams `codec:"argon2id,omitempty"`
+}
+
+// kdfParams gives the KDF and its parameters that were used to derive the key
+// that encrypted the blob, or nil if the blob was encrypted using a key
+func (dbblob *encryptedDBBlob) kdfParams() (*config.KDFParams, error) {
+	switch dbblob.KDFVersion {
+	case 0:
+		if !dbblob.DoScrypt {
+			return nil, nil
+		}
+		return &config.KDFParams{KDF: config.KDFScrypt, ScryptParams: dbblob.ScryptParams}, nil
+	case kdfHeaderVersion:
+		switch dbblob.KDF {
+		case kdfNone:
+			return nil, nil
+		case config.KDFScrypt:
+			return &config.KDFParams{KDF: config.KDFScrypt, ScryptParams: dbblob.ScryptParams}, nil
+		case config.KDFArgon2id:
+			if dbblob.Argon2idParams == nil {
+				return nil, errUnknownKDF
+			}
+			return &config.KDFParams{KDF: config.KDFArgon2id, Argon2idParams: *dbblob.Argon2idParams}, nil
+		}
+		return nil, errUnknownKDF
+	}
+
+	return nil, errKDFVersion
+}
+
+// blobKDFParams gives the KDF and its parameters that were used to derive the
+// key that encrypted the given blob, or nil if the blob was encrypted using a
+// key
+func blobKDFParams(blob []byte) (*config.KDFParams, error) {
+	var dbblob encryptedDBBlob
+	err := msgpackDecode(blob, &dbblob)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbblob.kdfParams()
+}
+
+// encryptBlobWithKDF is like encryptBlobWithPasswordBlankOK, but derives the key
+// from the password using the given KDF and gives a blob with a KDF header. If
+// kdf is nil, the password is assumed to be already a cryptographic key.
+func encryptBlobWithKDF(plaintext []byte, ptType plaintextType, password []byte, kdf *config.KDFParams) ([]byte, error) {
+	dbblob := encryptedDBBlob{
+		kdfHeader: kdfHeader{KDFVersion: kdfHeaderVersion, KDF: kdfNone},
+	}
+
+	var key *[masterKeyLen]byte
+	if kdf != nil {
+		// Generate the salt and derive the key
+		err := fillRandomBytes(dbblob.Salt[:])
+		if err != nil {
+			return nil, err
+		}
+		key, err = deriveEncryptionKeyWithKDF(password, &dbblob.Salt, *kdf)
+		if err != nil {
+			return nil, err
+		}
+
+		dbblob.KDF = kdf.KDF
+		switch kdf.KDF {
+		case config.KDFScrypt:
+			dbblob.ScryptParams = kdf.ScryptParams
+			dbblob.DoScrypt = true
+		case config.KDFArgon2id:
+			argon2idParams := kdf.Argon2idParams
+			dbblob.Argon2idParams = &argon2idParams
+		}
+	} else {
+		if len(password) != masterKeyLen {
+			return nil, errDeriveKey
+		}
+		key = new([masterKeyLen]byte)
+		copy(key[:], password)
+	}
+
+	return sealBlob(plaintext, ptType, key, dbblob)
+}
+
+// deriveEncryptionKeyWithKDF derives a key suitable for secretbox from the
+// password and salt using the given KDF
+func deriveEncryptionKeyWithKDF(password []byte, salt *[saltLen]byte, kdf config.KDFParams) (*[masterKeyLen]byte, error) {
+	// The parameters may come from a blob that cannot be trusted
+	if err := checkKDFCost(kdf); err != nil {
+		return nil, err
+	}
+
+	switch kdf.KDF {
+	case config.KDFScrypt:
+		return deriveEncryptionKeyWithSalt(password, salt, kdf.ScryptParams)
+	case config.KDFArgon2id:
+		params := kdf.Argon2idParams
+		// Argon2id cannot be done without at least one pass and thread
+		if params.Time < 1 || params.Threads < 1 {
+			return nil, errDeriveKey
+		}
+		var key [masterKeyLen]byte
+		copy(key[:], argon2.IDKey(password, salt[:], params.Time, params.MemoryKiB, params.Threads, masterKeyLen))
+		return &key, nil
+	}
+
+	return nil, errUnknownKDF
+}
+
+// isKDFWeaker checks if a key derived using the KDF with the given current
+// parameters is easier to crack than one derived using the KDF with the given
+// target parameters. Argon2id is considered stronger than scrypt. The same KDF
+// is weaker if any of its current parameters is lower than the target.
+func isKDFWeaker(current, target config.KDFParams) bool {
+	if current.KDF != target.KDF {
+		return current.KDF == config.KDFScrypt && target.KDF == config.KDFArgon2id
+	}
+
+	switch current.KDF {
+	case config.KDFScrypt:
+		cur, tgt := current.ScryptParams, target.ScryptParams
+		return cur.ScryptN < tgt.ScryptN || cur.ScryptR < tgt.ScryptR || cur.ScryptP < tgt.ScryptP
+	case config.KDFArgon2id:
+		cur, tgt := current.Argon2idParams, target.Argon2idParams
+		return cur.Time < tgt.Time || cur.MemoryKiB < tgt.MemoryKiB || cur.Threads < tgt.Threads
+	}
+
+	return false
+}
+
+// checkKDFCost makes sure deriving a key using the given KDF does not cost more
+// than the maximums
+func checkKDFCost(kdf config.KDFParams) error {
+	switch kdf.KDF {
+	case config.KDFScrypt:
+		params := kdf.ScryptParams
+		if params.ScryptN < 1 || params.ScryptR < 1 || params.ScryptP < 1 {
+			return errDeriveKey
+		}
+		// Checked one at a time so the products cannot overflow
+		n, r, p := uint64(params.ScryptN), uint64(params.ScryptR), uint64(params.ScryptP)
+		if n > maxScryptNR || r > maxScryptNR || n*r > maxScryptNR ||
+			p > maxScryptWork || n*r*p > maxScryptWork {
+			return errKDFTooCostly
+		}
+	case config.KDFArgon2id:
+		params := kdf.Argon2idParams
+		if params.MemoryKiB > maxArgon2idMemoryKiB || params.Threads > maxArgon2idThreads ||
+			uint64(params.Time)*uint64(params.MemoryKiB) > maxArgon2idWork {
+			return errKDFTooCostly
+		}
+	}
+
+	return nil
+}
+
+// checkKDFParams makes sure the given KDF is known and that its parameters are
+// reasonable. The maximums of the parameters are always enforced, but the
+// minimums are only enforced if allowUnsafe is false.
+func checkKDFParams(kdf config.KDFParams, allowUnsafe bool) error {
+	if err := checkKDFCost(kdf); err != nil {
+		return err
+	}
+
+	switch kdf.KDF {
+	case config.KDFScrypt:
+		if allowUnsafe {
+			return nil
+		}
+		if kdf.ScryptParams.ScryptN < minScryptN {
+			return fmt.Errorf("slow scrypt N must be at least %d", minScryptN)
+		}
+		if kdf.ScryptParams.ScryptR < minScryptR {
+			return fmt.Errorf("slow scrypt R must be at least %d", minScryptR)
+		}
+		if kdf.ScryptParams.ScryptP < minScryptP {
+			return fmt.Errorf("slow scrypt P must be at least %d", minScryptP)
+		}
+	case config.KDFArgon2id:
+		if kdf.Argon2idParams.Time < 1 || kdf.Argon2idParams.Threads < 1 {
+			return fmt.Errorf("argon2id time and threads must be at least 1")
+		}
+		if allowUnsafe {
+			return nil
+		}
+		if kdf.Argon2idParams.Time < minArgon2idTime {
+			return fmt.Errorf("argon2id time must be at least %d", minArgon2idTime)
+		}
+		if kdf.Argon2idParams.MemoryKiB < minArgon2idMemoryKiB {
+			return fmt.Errorf("argon2id memory must be at least %d KiB", minArgon2idMemoryKiB)
+		}
+		if kdf.Argon2idParams.Threads < minArgon2idThreads {
+			return fmt.Errorf("argon2id threads must be at least %d", minArgon2idThreads)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownKDF, kdf.KDF)
+	}
+
+	return nil
+}
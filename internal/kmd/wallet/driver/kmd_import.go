@@ -0,0 +1,235 @@
+package driver
+
+import (
+	"crypto/ed25519"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"duckysigner/internal/kmd/wallet"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// A wallet file made by go-algorand's kmd (e.g. using `goal wallet new`) is in
+// the same format as a wallet of the SQLite wallet driver, which is derived
+// from kmd's SQLite wallet driver. The wallet file is imported by reading it as
+// a SQLite wallet and recreating the wallet under another driver the same way
+// a wallet is migrated from one driver to another. Records that cannot be read
+// (e.g. keys that do not match their addresses) are skipped and reported
+// instead of failing the whole import.
+
+// kmdWalletTables are the names of the tables in a kmd wallet file that are
+// imported
+var kmdWalletTables = []string{"metadata", "keys", "msig_addrs"}
+
+// UnsupportedRecord is a record in an imported wallet file that was not
+// imported
+type UnsupportedRecord struct {
+	// Name of the table the record is in
+	Table string
+	// Address of the key or multisig account the record is for, if any
+	Address string
+	// Why the record was not imported
+	Reason string
+}
+
+// KmdWalletImport is the result of importing a kmd wallet file
+type KmdWalletImport struct {
+	// Metadata of the wallet that was created from the wallet file
+	Metadata wallet.Metadata
+	// The records in the wallet file that were not imported
+	UnsupportedRecords []UnsupportedRecord
+}
+
+// ImportKmdWallet creates a new wallet with the given id under the driver with
+// the given driver name from the go-algorand kmd wallet file at the given path,
+// which is decrypted using the given password. The new wallet has the same
+// name, password, MDK, keys (along with the index of each derived key) and
+// multisig addresses as the kmd wallet. The kmd wallet file is not modified.
+// The records in the file that are not imported are given along with the
+// metadata of the new wallet.
+func ImportKmdWallet(dbPath string, pw []byte, dstDriverName string, newID []byte) (result KmdWalletImport, err error) {
+	dstDriver, err := FetchWalletDriver(dstDriverName)
+	if err != nil {
+		return
+	}
+
+	// Work on a copy of the wallet file so the file is not modified (e.g. by
+	// SQLite recovering its journal)
+	tmpDir, err := os.MkdirTemp("", "kmd_import")
+	if err != nil {
+		return
+	}
+	defer wipeDir(tmpDir)
+	tmpDbPath := filepath.Join(tmpDir, filepath.Base(dbPath))
+	err = copyKmdWalletFile(dbPath, tmpDbPath)
+	if err != nil {
+		return
+	}
+
+	// Read the kmd wallet as a SQLite wallet
+	kmdWallet := &SQLiteWallet{dbPath: tmpDbPath}
+	metadata, err := kmdWallet.Metadata()
+	if err != nil {
+		return
+	}
+	err = kmdWallet.Init(pw)
+	if err != nil {
+		return
+	}
+	contents, unsupported, err := readKmdWalletContents(kmdWallet, pw)
+	if err != nil {
+		return
+	}
+
+	err = recreateWallet(dstDriver, metadata.Name, newID, pw, contents)
+	if err != nil {
+		return
+	}
+
+	// Get the new wallet's metadata
+	newWallet, err := dstDriver.FetchWallet(newID)
+	if err != nil {
+		return
+	}
+	result.Metadata, err = newWallet.Metadata()
+	if err != nil {
+		return
+	}
+	result.UnsupportedRecords = unsupported
+
+	return
+}
+
+// copyKmdWalletFile copies the kmd wallet file at the given path to the new
+// path along with the journal files SQLite may have left next to it
+func copyKmdWalletFile(dbPath, newDbPath string) error {
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		err := copyFile(dbPath+suffix, newDbPath+suffix)
+		if os.IsNotExist(err) && suffix != "" {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the file with the given name to a new file with the given
+// new name
+func copyFile(name, newName string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(newName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// readKmdWalletContents reads everything needed to recreate the given
+// initialized kmd wallet with the given password. The keys and multisig
+// addresses that cannot be read are left out and given as unsupported records
+// along with the tables that are not imported.
+func readKmdWalletContents(sw *SQLiteWallet, pw []byte) (contents *walletContents, unsupported []UnsupportedRecord, err error) {
+	contents = &walletContents{
+		keys:  map[types.Digest]ed25519.PrivateKey{},
+		msigs: map[types.Digest]msigPreimage{},
+	}
+	unsupported = []UnsupportedRecord{}
+
+	contents.mdk, err = sw.ExportMasterDerivationKey(pw)
+	if err != nil {
+		return
+	}
+
+	contents.keyIdxs, contents.maxKeyIdx, err = sw.keyIndexes()
+	if err != nil {
+		return
+	}
+
+	addrs, err := sw.ListKeys()
+	if err != nil {
+		return
+	}
+	for _, addr := range addrs {
+		sk, keyErr := sw.ExportKey(addr, pw)
+		if keyErr != nil {
+			unsupported = append(unsupported, UnsupportedRecord{
+				Table:   "keys",
+				Address: types.Address(addr).String(),
+				Reason:  keyErr.Error(),
+			})
+			delete(contents.keyIdxs, addr)
+			continue
+		}
+		contents.keys[addr] = sk
+	}
+
+	msigAddrs, err := sw.ListMultisigAddrs()
+	if err != nil {
+		return
+	}
+	for _, addr := range msigAddrs {
+		preimage := msigPreimage{}
+		var msigErr error
+		preimage.version, preimage.threshold, preimage.pks, msigErr = sw.LookupMultisigPreimage(addr)
+		if msigErr != nil {
+			unsupported = append(unsupported, UnsupportedRecord{
+				Table:   "msig_addrs",
+				Address: types.Address(addr).String(),
+				Reason:  msigErr.Error(),
+			})
+			continue
+		}
+		contents.msigs[addr] = preimage
+	}
+
+	tables, err := sw.tableNames()
+	if err != nil {
+		return
+	}
+	for _, table := range tables {
+		if !slices.Contains(kmdWalletTables, table) {
+			unsupported = append(unsupported, UnsupportedRecord{
+				Table:  table,
+				Reason: "table is not part of the kmd wallet format",
+			})
+		}
+	}
+
+	return
+}
+
+// tableNames gives the names of the tables in the wallet database, excluding
+// SQLite's internal tables
+func (sw *SQLiteWallet) tableNames() (tables []string, err error) {
+	// Connect to the database
+	db, err := sqlx.Connect("sqlite", dbConnectionURL(sw.dbPath))
+	if err != nil {
+		err = errDatabaseConnect
+		return
+	}
+	defer db.Close()
+
+	err = db.Select(&tables, "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		err = errDatabase
+	}
+	return
+}
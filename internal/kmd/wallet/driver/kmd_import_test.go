@@ -0,0 +1,137 @@
+package driver_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
+	logging "github.com/sirupsen/logrus"
+
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/kmd/wallet/driver"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Kmd Wallet Import", func() {
+	Describe("ImportKmdWallet()", Ordered, func() {
+		const walletDirName = ".test_import_kmd_wallet"
+		const walletId = "000"
+		const walletPassword = "password"
+
+		var kmdWalletPath string
+		var kmdWalletFile []byte
+		var mdk algoTypes.MasterDerivationKey
+		var generatedAddrs []algoTypes.Digest
+		var bogusAddr algoTypes.Digest
+
+		BeforeAll(func() {
+			setupMigrationWalletDrivers(walletDirName)
+			DeferCleanup(func() {
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a kmd wallet file")
+			var kmdDriver driver.SQLiteWalletDriver
+			logger := logging.New()
+			logger.SetLevel(logging.InfoLevel)
+			err := kmdDriver.InitWithConfig(config.KMDConfig{
+				DriverConfig: config.DriverConfig{
+					SQLiteWalletDriverConfig: config.SQLiteWalletDriverConfig{
+						WalletsDir:   filepath.Join(walletDirName, "kmd-v0.5"),
+						UnsafeScrypt: true, // For testing purposes only
+						ScryptParams: config.ScryptParams{ScryptN: 2, ScryptR: 1, ScryptP: 1},
+					},
+				},
+			}, logger)
+			Expect(err).ToNot(HaveOccurred())
+			mdk = algoTypes.MasterDerivationKey{4, 5, 6}
+			err = kmdDriver.CreateWallet([]byte("unencrypted-default-wallet"), []byte(walletId), []byte(walletPassword), mdk)
+			Expect(err).ToNot(HaveOccurred())
+			kmdWallet, err := kmdDriver.FetchWallet([]byte(walletId))
+			Expect(err).ToNot(HaveOccurred())
+			err = kmdWallet.Init([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+			for range 2 {
+				addr, err := kmdWallet.GenerateKey(false)
+				Expect(err).ToNot(HaveOccurred())
+				generatedAddrs = append(generatedAddrs, addr)
+			}
+			kmdWalletPath = filepath.Join(walletDirName, "kmd-v0.5", "unencrypted-default-wallet.000.db")
+
+			By("Adding records that cannot be imported")
+			db, err := sql.Open("sqlite", kmdWalletPath)
+			Expect(err).ToNot(HaveOccurred())
+			bogusAddr = algoTypes.Digest{7, 7, 7}
+			// A key that is stored under the wrong address
+			_, err = db.Exec(
+				"INSERT INTO keys (address, secret_key_encrypted) SELECT ?, secret_key_encrypted FROM keys LIMIT 1",
+				bogusAddr[:],
+			)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec("CREATE TABLE foo (bar INT)")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Close()).To(Succeed())
+
+			kmdWalletFile, err = os.ReadFile(kmdWalletPath)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fails if the given password is incorrect", func() {
+			_, err := driver.ImportKmdWallet(kmdWalletPath, []byte("not the password"), "duckdb", []byte("111"))
+			Expect(err).To(HaveOccurred())
+
+			metadatas, err := driver.ListWalletMetadatas()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadatas).To(HaveLen(0), "No wallet was created")
+		})
+
+		It("creates an equivalent wallet and reports the records that were not imported", func() {
+			result, err := driver.ImportKmdWallet(kmdWalletPath, []byte(walletPassword), "duckdb", []byte("111"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Metadata.ID).To(Equal([]byte("111")))
+			Expect(result.Metadata.Name).To(Equal([]byte("unencrypted-default-wallet")))
+
+			By("Checking the unsupported records")
+			Expect(result.UnsupportedRecords).To(ConsistOf(
+				driver.UnsupportedRecord{
+					Table:   "keys",
+					Address: algoTypes.Address(bogusAddr).String(),
+					Reason:  "derived public key mismatch, something fishy is going on with this wallet",
+				},
+				driver.UnsupportedRecord{
+					Table:  "foo",
+					Reason: "table is not part of the kmd wallet format",
+				},
+			))
+
+			By("Checking the imported wallet")
+			duckDbDriver, err := driver.FetchWalletDriver("duckdb")
+			Expect(err).ToNot(HaveOccurred())
+			importedWallet, err := duckDbDriver.FetchWallet([]byte("111"))
+			Expect(err).ToNot(HaveOccurred())
+			err = importedWallet.Init([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+			importedMdk, err := importedWallet.ExportMasterDerivationKey([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(importedMdk).To(Equal(mdk))
+			addrs, err := importedWallet.ListKeys()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(addrs).To(ConsistOf(generatedAddrs[0], generatedAddrs[1]))
+
+			By("Checking the kmd wallet file was not modified")
+			Expect(os.ReadFile(kmdWalletPath)).To(Equal(kmdWalletFile))
+		})
+
+		It("fails if the file is not a kmd wallet file", func() {
+			notWalletPath := filepath.Join(walletDirName, "not_a_wallet.db")
+			err := os.WriteFile(notWalletPath, []byte("not a wallet"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = driver.ImportKmdWallet(notWalletPath, []byte(walletPassword), "duckdb", []byte("222"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
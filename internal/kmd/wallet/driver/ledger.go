@@ -224,6 +224,11 @@ func (lwd *LedgerWalletDriver) RenameWallet(newName []byte, id []byte, pw []byte
 	return errNotSupported
 }
 
+// DeleteWallet implements the Driver interface.
+func (lwd *LedgerWalletDriver) DeleteWallet(id []byte, pw []byte) error {
+	return errNotSupported
+}
+
 // Init implements the wallet interface.
 func (lw *LedgerWallet) Init(pw []byte) error {
 	return nil
@@ -234,6 +239,11 @@ func (lw *LedgerWallet) CheckPassword(pw []byte) error {
 	return nil
 }
 
+// ChangePassword implements the Wallet interface.
+func (lw *LedgerWallet) ChangePassword(oldPw, newPw []byte) error {
+	return errNotSupported
+}
+
 // ExportMasterDerivationKey implements the Wallet interface.
 func (lw *LedgerWallet) ExportMasterDerivationKey(pw []byte) (types.MasterDerivationKey, error) {
 	return types.MasterDerivationKey{}, errNotSupported
@@ -0,0 +1,392 @@
+package driver
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"duckysigner/internal/kmd/wallet"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+)
+
+// A wallet is migrated from one driver to another by reading everything the
+// wallet's keys depend on from the wallet under the source driver and
+// recreating the wallet with it under the target driver. The recreated wallet
+// is then read back and compared key by key with the source wallet. Only if
+// they match is the source wallet marked as migrated. The source wallet is
+// otherwise left as it is.
+
+// migratedFile is the name of the file that marks a wallet as migrated to
+// another driver. For drivers that store each wallet in a single file, it is
+// the suffix of the name of the file next to the wallet's file.
+const migratedFile = "migrated.json"
+
+// migrationSourceDriver is the interface that wallet drivers expose if their
+// wallets can be migrated to another driver
+type migrationSourceDriver interface {
+	Driver
+	// listAllWalletMetadatas is ListWalletMetadatas, but it also lists the
+	// wallets if the driver is disabled
+	listAllWalletMetadatas() ([]wallet.Metadata, error)
+	// migratedFilePath gives the path of the file that marks the wallet with
+	// the given id as migrated
+	migratedFilePath(id []byte) (string, error)
+}
+
+// keyIndexer is the interface that wallets expose if they derive keys from
+// their master derivation key (MDK) and record the index of each derived key
+type keyIndexer interface {
+	// keyIndexes gives the index of each derived key in the wallet by the
+	// key's address along with the highest index a key has been derived with
+	keyIndexes() (keyIdxs map[types.Digest]uint64, maxKeyIdx uint64, err error)
+}
+
+// indexedKeyImporter is the interface that wallets expose if they can import
+// keys along with the index each derived key was derived with
+type indexedKeyImporter interface {
+	// importIndexedKeys imports the given keys, with keyIdxs giving the index
+	// of each key that was derived, and sets the highest index a key has been
+	// derived with to maxKeyIdx
+	importIndexedKeys(keys map[types.Digest]ed25519.PrivateKey, keyIdxs map[types.Digest]uint64, maxKeyIdx uint64) error
+}
+
+// WalletMigration is the record of a wallet that was migrated to another driver
+type WalletMigration struct {
+	// Name of the driver the wallet was migrated to
+	DriverName string `json:"driver_name"`
+	// ID of the wallet under the driver the wallet was migrated to
+	WalletId string `json:"wallet_id"`
+	// When the wallet was migrated
+	MigratedAt time.Time `json:"migrated_at"`
+}
+
+// msigPreimage is the information a multisig address is derived from
+type msigPreimage struct {
+	version   uint8
+	threshold uint8
+	pks       []ed25519.PublicKey
+}
+
+// walletContents is everything needed to recreate a wallet under another
+// driver
+type walletContents struct {
+	mdk       types.MasterDerivationKey
+	maxKeyIdx uint64
+	keys      map[types.Digest]ed25519.PrivateKey
+	keyIdxs   map[types.Digest]uint64
+	msigs     map[types.Digest]msigPreimage
+}
+
+// ListUnmigratedWallets gives the metadatas of the wallets of the driver with
+// the given name that have not been migrated to another driver. The wallets are
+// listed even if the driver is disabled.
+func ListUnmigratedWallets(driverName string) ([]wallet.Metadata, error) {
+	srcDriver, err := fetchMigrationSourceDriver(driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadatas, err := srcDriver.listAllWalletMetadatas()
+	if err != nil {
+		return nil, err
+	}
+
+	unmigrated := []wallet.Metadata{}
+	for _, metadata := range metadatas {
+		migration, err := fetchWalletMigration(srcDriver, metadata.ID)
+		if err != nil {
+			return nil, err
+		}
+		if migration == nil {
+			unmigrated = append(unmigrated, metadata)
+		}
+	}
+
+	return unmigrated, nil
+}
+
+// MigrateWallet recreates the wallet with the given id under the source driver
+// as a new wallet with the given new id under the target driver. The new wallet
+// has the same name, password, MDK, keys (along with the index of each derived
+// key) and multisig addresses. The source wallet is marked as migrated once
+// the new wallet is checked to have the same keys, but it is not deleted.
+func MigrateWallet(srcDriverName string, id []byte, pw []byte, dstDriverName string, newID []byte) error {
+	srcDriver, err := fetchMigrationSourceDriver(srcDriverName)
+	if err != nil {
+		return err
+	}
+
+	dstDriver, err := FetchWalletDriver(dstDriverName)
+	if err != nil {
+		return err
+	}
+
+	migration, err := fetchWalletMigration(srcDriver, id)
+	if err != nil {
+		return err
+	}
+	if migration != nil {
+		return errWalletMigrated
+	}
+
+	// Read the source wallet
+	srcWallet, err := srcDriver.FetchWallet(id)
+	if err != nil {
+		return err
+	}
+	err = srcWallet.Init(pw)
+	if err != nil {
+		return err
+	}
+	srcMetadata, err := srcWallet.Metadata()
+	if err != nil {
+		return err
+	}
+	srcContents, err := readWalletContents(srcWallet, pw)
+	if err != nil {
+		return err
+	}
+
+	err = recreateWallet(dstDriver, srcMetadata.Name, newID, pw, srcContents)
+	if err != nil {
+		return err
+	}
+
+	// Mark the source wallet as migrated
+	migratedPath, err := srcDriver.migratedFilePath(id)
+	if err != nil {
+		return err
+	}
+	migrationJson, err := json.Marshal(WalletMigration{
+		DriverName: dstDriverName,
+		WalletId:   string(newID),
+		MigratedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileSync(migratedPath, migrationJson, 0600)
+}
+
+// recreateWallet creates a wallet with the given name, id and password under
+// the given driver from the given wallet contents. The created wallet is read
+// back and compared with the contents. It is deleted if anything fails.
+func recreateWallet(d Driver, name []byte, id []byte, pw []byte, contents *walletContents) error {
+	err := d.CreateWallet(name, id, pw, contents.mdk)
+	if err != nil {
+		return err
+	}
+	err = writeWalletContents(d, id, pw, contents)
+	if err != nil {
+		d.DeleteWallet(id, pw)
+		return err
+	}
+
+	// Check the new wallet using a freshly fetched copy
+	newWallet, err := d.FetchWallet(id)
+	if err == nil {
+		err = newWallet.Init(pw)
+	}
+	var newContents *walletContents
+	if err == nil {
+		newContents, err = readWalletContents(newWallet, pw)
+	}
+	if err == nil {
+		err = compareWalletContents(contents, newContents)
+	}
+	if err != nil {
+		d.DeleteWallet(id, pw)
+		return err
+	}
+
+	return nil
+}
+
+// fetchMigrationSourceDriver gives the driver with the given name if its
+// wallets can be migrated to another driver
+func fetchMigrationSourceDriver(driverName string) (migrationSourceDriver, error) {
+	d, err := FetchWalletDriver(driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	srcDriver, ok := d.(migrationSourceDriver)
+	if !ok {
+		return nil, errMigrationNotSupported
+	}
+
+	return srcDriver, nil
+}
+
+// fetchWalletMigration gives the record of the migration of the wallet with the
+// given id under the given driver, or nil if the wallet has not been migrated
+func fetchWalletMigration(srcDriver migrationSourceDriver, id []byte) (*WalletMigration, error) {
+	migratedPath, err := srcDriver.migratedFilePath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationJson, err := os.ReadFile(migratedPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	migration := WalletMigration{}
+	err = json.Unmarshal(migrationJson, &migration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migration, nil
+}
+
+// readWalletContents reads everything needed to recreate the given initialized
+// wallet with the given password
+func readWalletContents(w wallet.Wallet, pw []byte) (*walletContents, error) {
+	indexer, ok := w.(keyIndexer)
+	if !ok {
+		return nil, errMigrationNotSupported
+	}
+
+	contents := walletContents{
+		keys:  map[types.Digest]ed25519.PrivateKey{},
+		msigs: map[types.Digest]msigPreimage{},
+	}
+
+	var err error
+	contents.mdk, err = w.ExportMasterDerivationKey(pw)
+	if err != nil {
+		return nil, err
+	}
+
+	contents.keyIdxs, contents.maxKeyIdx, err = indexer.keyIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := w.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		contents.keys[addr], err = w.ExportKey(addr, pw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msigAddrs, err := w.ListMultisigAddrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range msigAddrs {
+		preimage := msigPreimage{}
+		preimage.version, preimage.threshold, preimage.pks, err = w.LookupMultisigPreimage(addr)
+		if err != nil {
+			return nil, err
+		}
+		contents.msigs[addr] = preimage
+	}
+
+	return &contents, nil
+}
+
+// writeWalletContents writes the given wallet contents, except the MDK, into the
+// newly created wallet with the given id under the given driver
+func writeWalletContents(d Driver, id []byte, pw []byte, contents *walletContents) error {
+	w, err := d.FetchWallet(id)
+	if err != nil {
+		return err
+	}
+	err = w.Init(pw)
+	if err != nil {
+		return err
+	}
+
+	importer, ok := w.(indexedKeyImporter)
+	if !ok {
+		return errMigrationNotSupported
+	}
+
+	err = importer.importIndexedKeys(contents.keys, contents.keyIdxs, contents.maxKeyIdx)
+	if err != nil {
+		return err
+	}
+
+	for _, preimage := range contents.msigs {
+		_, err = w.ImportMultisigAddr(preimage.version, preimage.threshold, preimage.pks)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Initialize the wallet again for it to pick up the imported keys (e.g.
+	// for the keys to be given accounts)
+	return w.Init(pw)
+}
+
+// compareWalletContents checks if the wallet contents are the same key by key
+func compareWalletContents(expected, actual *walletContents) error {
+	if expected.mdk != actual.mdk {
+		return fmt.Errorf("%w: master derivation key differs", errMigrationMismatch)
+	}
+
+	if expected.maxKeyIdx != actual.maxKeyIdx {
+		return fmt.Errorf("%w: highest key index differs", errMigrationMismatch)
+	}
+
+	if len(expected.keys) != len(actual.keys) {
+		return fmt.Errorf("%w: number of keys differs", errMigrationMismatch)
+	}
+	for addr, sk := range expected.keys {
+		if !bytes.Equal(sk, actual.keys[addr]) {
+			return fmt.Errorf("%w: key of %s differs", errMigrationMismatch, types.Address(addr))
+		}
+
+		expectedIdx, expectedDerived := expected.keyIdxs[addr]
+		actualIdx, actualDerived := actual.keyIdxs[addr]
+		if expectedDerived != actualDerived || expectedIdx != actualIdx {
+			return fmt.Errorf("%w: key index of %s differs", errMigrationMismatch, types.Address(addr))
+		}
+	}
+
+	if len(expected.msigs) != len(actual.msigs) {
+		return fmt.Errorf("%w: number of multisig addresses differs", errMigrationMismatch)
+	}
+	for addr, preimage := range expected.msigs {
+		actualPreimage, ok := actual.msigs[addr]
+		if !ok ||
+			preimage.version != actualPreimage.version ||
+			preimage.threshold != actualPreimage.threshold ||
+			len(preimage.pks) != len(actualPreimage.pks) {
+			return fmt.Errorf("%w: multisig preimage of %s differs", errMigrationMismatch, types.Address(addr))
+		}
+		for i, pk := range preimage.pks {
+			if !bytes.Equal(pk, actualPreimage.pks[i]) {
+				return fmt.Errorf("%w: multisig preimage of %s differs", errMigrationMismatch, types.Address(addr))
+			}
+		}
+	}
+
+	return nil
+}
+
+// decryptMaxKeyIdx decrypts the given encrypted highest key index using the
+// given master encryption key
+func decryptMaxKeyIdx(blob []byte, mek []byte) (maxKeyIdx uint64, err error) {
+	maxKeyIdxBlob, err := decryptBlobWithPassword(blob, PTMaxKeyIdx, mek)
+	if err != nil {
+		return
+	}
+
+	err = msgpackDecode(maxKeyIdxBlob, &maxKeyIdx)
+	return
+}
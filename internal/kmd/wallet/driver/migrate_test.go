@@ -0,0 +1,228 @@
+package driver_test
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+
+	"github.com/algorand/go-algorand-sdk/v2/mnemonic"
+	algoTypes "github.com/algorand/go-algorand-sdk/v2/types"
+	logging "github.com/sirupsen/logrus"
+
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/kmd/wallet"
+	"duckysigner/internal/kmd/wallet/driver"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Wallet Migration", func() {
+	Describe("MigrateWallet() from SQLite to DuckDB", Ordered, func() {
+		const walletDirName = ".test_migrate_wallet"
+		const walletId = "000"
+		const migratedWalletId = "111"
+		const walletPassword = "password"
+		const acctMnemonic = "minor print what witness play daughter matter light sign tip blossom anger artwork profit cart garment buzz resemble warm hole speed super bamboo abandon bonus"
+
+		var sqliteWallet wallet.Wallet
+		var mdk algoTypes.MasterDerivationKey
+		var generatedAddrs []algoTypes.Digest
+		var importedAddr algoTypes.Digest
+		var msigAddr algoTypes.Digest
+
+		BeforeAll(func() {
+			setupMigrationWalletDrivers(walletDirName)
+			DeferCleanup(func() {
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a SQLite wallet")
+			sqliteDriver, err := driver.FetchWalletDriver("sqlite")
+			Expect(err).ToNot(HaveOccurred())
+			mdk = algoTypes.MasterDerivationKey{1, 2, 3}
+			err = sqliteDriver.CreateWallet(
+				[]byte("Foo"),
+				[]byte(walletId),
+				[]byte(walletPassword),
+				mdk,
+			)
+			Expect(err).ToNot(HaveOccurred())
+			sqliteWallet, err = sqliteDriver.FetchWallet([]byte(walletId))
+			Expect(err).ToNot(HaveOccurred())
+			err = sqliteWallet.Init([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Generating keys and deleting one of them")
+			for range 3 {
+				addr, err := sqliteWallet.GenerateKey(false)
+				Expect(err).ToNot(HaveOccurred())
+				generatedAddrs = append(generatedAddrs, addr)
+			}
+			err = sqliteWallet.DeleteKey(generatedAddrs[1], []byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+			generatedAddrs = []algoTypes.Digest{generatedAddrs[0], generatedAddrs[2]}
+
+			By("Importing a key")
+			sk, err := mnemonic.ToPrivateKey(acctMnemonic)
+			Expect(err).ToNot(HaveOccurred())
+			importedAddr, err = sqliteWallet.ImportKey(sk)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Importing a multisig address")
+			msigPks := []ed25519.PublicKey{}
+			for _, addr := range []algoTypes.Digest{generatedAddrs[0], importedAddr} {
+				msigPks = append(msigPks, ed25519.PublicKey(addr[:]))
+			}
+			msigAddr, err = sqliteWallet.ImportMultisigAddr(1, 2, msigPks)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("lists the wallet as not migrated", func() {
+			metadatas, err := driver.ListUnmigratedWallets("sqlite")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadatas).To(HaveLen(1))
+			Expect(metadatas[0].ID).To(Equal([]byte(walletId)))
+		})
+
+		It("lists the accounts of the keys and multisig address in the SQLite wallet", func() {
+			accts, err := sqliteWallet.ListAccounts()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accts).To(HaveExactElements(
+				wallet.Account{Address: generatedAddrs[0], Type: wallet.AcctTypeKMD, Position: "0"},
+				wallet.Account{Address: generatedAddrs[1], Type: wallet.AcctTypeKMD, Position: "1"},
+				wallet.Account{Address: importedAddr, Type: wallet.AcctTypeStandalone, Position: "2"},
+				wallet.Account{Address: msigAddr, Type: wallet.AcctTypeMsig, Position: "3"},
+			))
+
+			acct, err := sqliteWallet.GetAccount(algoTypes.Address(importedAddr).String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(acct.Type).To(Equal(wallet.AcctTypeStandalone))
+		})
+
+		It("fails if the given password is incorrect", func() {
+			err := driver.MigrateWallet("sqlite", []byte(walletId), []byte("not the password"), "duckdb", []byte(migratedWalletId))
+			Expect(err).To(HaveOccurred())
+
+			By("Checking no wallet was created under the DuckDB driver")
+			metadatas, err := driver.ListWalletMetadatas()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadatas).To(HaveLen(0))
+		})
+
+		It("recreates the wallet under the DuckDB driver", func() {
+			err := driver.MigrateWallet("sqlite", []byte(walletId), []byte(walletPassword), "duckdb", []byte(migratedWalletId))
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Fetching the migrated wallet")
+			duckDbDriver, err := driver.FetchWalletDriver("duckdb")
+			Expect(err).ToNot(HaveOccurred())
+			migratedWallet, err := duckDbDriver.FetchWallet([]byte(migratedWalletId))
+			Expect(err).ToNot(HaveOccurred())
+			err = migratedWallet.Init([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred(), "The migrated wallet has the same password")
+
+			metadata, err := migratedWallet.Metadata()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata.Name).To(Equal([]byte("Foo")))
+
+			By("Checking the master derivation key")
+			migratedMdk, err := migratedWallet.ExportMasterDerivationKey([]byte(walletPassword))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(migratedMdk).To(Equal(mdk))
+
+			By("Checking the keys")
+			addrs, err := migratedWallet.ListKeys()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(addrs).To(ConsistOf(generatedAddrs[0], generatedAddrs[1], importedAddr))
+			for _, addr := range addrs {
+				sk, err := migratedWallet.ExportKey(addr, []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				origSk, err := sqliteWallet.ExportKey(addr, []byte(walletPassword))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sk).To(Equal(origSk))
+			}
+
+			By("Checking the accounts are of the right types")
+			accts, err := migratedWallet.ListAccounts()
+			Expect(err).ToNot(HaveOccurred())
+			acctTypes := map[algoTypes.Digest]wallet.AccountType{}
+			for _, acct := range accts {
+				acctTypes[acct.Address] = acct.Type
+			}
+			Expect(acctTypes).To(HaveKeyWithValue(generatedAddrs[0], wallet.AcctTypeKMD))
+			Expect(acctTypes).To(HaveKeyWithValue(generatedAddrs[1], wallet.AcctTypeKMD))
+			Expect(acctTypes).To(HaveKeyWithValue(importedAddr, wallet.AcctTypeStandalone))
+
+			By("Checking the multisig address")
+			msigAddrs, err := migratedWallet.ListMultisigAddrs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msigAddrs).To(Equal([]algoTypes.Digest{msigAddr}))
+			version, threshold, pks, err := migratedWallet.LookupMultisigPreimage(msigAddr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(BeEquivalentTo(1))
+			Expect(threshold).To(BeEquivalentTo(2))
+			Expect(pks).To(HaveLen(2))
+
+			By("Checking the next generated key is the same in both wallets")
+			nextAddr, err := migratedWallet.GenerateKey(false)
+			Expect(err).ToNot(HaveOccurred())
+			origNextAddr, err := sqliteWallet.GenerateKey(false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nextAddr).To(Equal(origNextAddr))
+			Expect(nextAddr).ToNot(BeElementOf(generatedAddrs), "A deleted key is not generated again")
+		})
+
+		It("marks the original wallet as migrated without removing it", func() {
+			Expect(filepath.Join(walletDirName, "sqlite", "Foo.000.db.migrated.json")).To(BeARegularFile())
+
+			metadatas, err := driver.ListUnmigratedWallets("sqlite")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadatas).To(HaveLen(0))
+
+			By("Attempting to migrate the wallet again")
+			err = driver.MigrateWallet("sqlite", []byte(walletId), []byte(walletPassword), "duckdb", []byte("222"))
+			Expect(err).To(MatchError("wallet has already been migrated to another driver"))
+		})
+
+		It("fails if the wallet driver does not support migrating wallets", func() {
+			_, err := driver.ListUnmigratedWallets("ledger")
+			Expect(err).To(MatchError("wallet driver does not support migrating wallets"))
+		})
+	})
+})
+
+// setupMigrationWalletDrivers initializes the wallet drivers with the wallets
+// of each driver in its own directory within the given directory
+func setupMigrationWalletDrivers(walletDirName string) {
+	logger := logging.New()
+	logger.SetLevel(logging.InfoLevel)
+
+	err := os.MkdirAll(walletDirName, 0700)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = driver.InitWalletDrivers(config.KMDConfig{
+		SessionLifetimeSecs: 3600,
+		DriverConfig: config.DriverConfig{
+			DuckDbWalletDriverConfig: config.DuckDbWalletDriverConfig{
+				WalletsDir:   filepath.Join(walletDirName, "duckdb"),
+				UnsafeScrypt: true, // For testing purposes only
+				ScryptParams: config.ScryptParams{ScryptN: 2, ScryptR: 1, ScryptP: 1},
+			},
+			SQLiteWalletDriverConfig: config.SQLiteWalletDriverConfig{
+				WalletsDir:   filepath.Join(walletDirName, "sqlite"),
+				UnsafeScrypt: true, // For testing purposes only
+				ScryptParams: config.ScryptParams{ScryptN: 2, ScryptR: 1, ScryptP: 1},
+				Disable:      true,
+			},
+			ParquetWalletDriverConfig: config.ParquetWalletDriverConfig{
+				WalletsDir:   filepath.Join(walletDirName, "parquet"),
+				UnsafeScrypt: true,
+				Disable:      true,
+			},
+			LedgerWalletDriverConfig: config.LedgerWalletDriverConfig{Disable: true},
+		},
+	}, logger)
+
+	Expect(err).NotTo(HaveOccurred())
+}
@@ -77,6 +77,11 @@ var disallowedParquetFilenameRegex = regexp.MustCompile("[^a-zA-Z0-9_-]*")
 // Requires the file encryption key in Base64.
 const addParquetKeySQL = "PRAGMA add_parquet_key('key', '%s');"
 
+// addNewParquetKeySQL is the SQL statement for setting the new Parquet file
+// encryption key within DuckDB when re-encrypting the Parquet files with a new
+// key. Requires the new file encryption key in Base64.
+const addNewParquetKeySQL = "PRAGMA add_parquet_key('new_key', '%s');"
+
 var parquetCreateMetadatasTblSchema = `
 CREATE TABLE IF NOT EXISTS metadatas (
 	driver_name TEXT NOT NULL,
@@ -187,6 +192,12 @@ func (parqwd *ParquetWalletDriver) ListWalletMetadatas() ([]wallet.Metadata, err
 		return []wallet.Metadata{}, nil
 	}
 
+	return parqwd.listAllWalletMetadatas()
+}
+
+// listAllWalletMetadatas is the guts of ListWalletMetadatas, which lists the
+// wallets even if this wallet driver is disabled
+func (parqwd *ParquetWalletDriver) listAllWalletMetadatas() ([]wallet.Metadata, error) {
 	metadatasPath := filepath.Join(parqwd.walletsDir(), ParquetMetadatasFile)
 
 	// Handle possible race over the metadatas file
@@ -443,6 +454,12 @@ func (parqwd *ParquetWalletDriver) FetchWallet(id []byte) (wallet.Wallet, error)
 		return &ParquetWallet{}, err
 	}
 
+	// Finish or roll back a password change that was interrupted
+	err = recoverPwChange(filepath.Join(parqwd.walletsDir(), string(id)), retrievedMetadata.MEPEncrypted)
+	if err != nil {
+		return &ParquetWallet{}, err
+	}
+
 	// Fill in the wallet details
 	return &ParquetWallet{
 		id:             string(id),
@@ -547,6 +564,36 @@ func (parqwd *ParquetWalletDriver) RenameWallet(newName []byte, id []byte, pw []
 	return nil
 }
 
+// DeleteWallet deletes the wallet with the given id if the given password is
+// correct. The wallet directory is wiped, which includes all of the wallet's
+// data files, such as its dApp connect session data, and then the wallet's
+// metadata is removed from the metadatas file.
+func (parqwd *ParquetWalletDriver) DeleteWallet(id []byte, pw []byte) error {
+	// Only delete directories within the wallets directory
+	walletPath := parqwd.idToPath(id)
+	if walletPath != filepath.Join(parqwd.walletsDir(), string(id)) {
+		return errWalletNotFound
+	}
+
+	fetchedWallet, err := parqwd.FetchWallet(id)
+	if err != nil {
+		return err
+	}
+
+	// Check the password
+	err = fetchedWallet.CheckPassword(pw)
+	if err != nil {
+		return err
+	}
+
+	err = wipeDir(walletPath)
+	if err != nil {
+		return err
+	}
+
+	return parqwd.removeParquetWalletMetadata(id)
+}
+
 /*******************************************************************************
  * Wallet
  ******************************************************************************/
@@ -653,6 +700,129 @@ func (pqw *ParquetWallet) CheckPassword(pw []byte) error {
 	return err
 }
 
+// ChangePassword changes the wallet's password from the old password to the new
+// password. The wallet's files and encrypted metadata are re-encrypted with a
+// new master encryption key that is protected by the new password. The change
+// is either fully applied or, if it is interrupted, finished or rolled back when
+// the wallet is fetched.
+func (pqw *ParquetWallet) ChangePassword(oldPw, newPw []byte) error {
+	if !pqw.initialized {
+		return fmt.Errorf("wallet not initialized")
+	}
+
+	// Check the password
+	err := pqw.CheckPassword(oldPw)
+	if err != nil {
+		return err
+	}
+
+	walletPath := filepath.Join(pqw.walletsPath, pqw.id)
+	metadatasPath := filepath.Join(pqw.walletsPath, ParquetMetadatasFile)
+
+	// Decrypt the master encryption key and master derivation key stored in
+	// enclaves into local copies
+	mekBuf, err := pqw.masterEncryptionKey.Open()
+	if err != nil {
+		return err
+	}
+	defer mekBuf.Destroy() // Destroy the copy when we return
+	mdkBuf, err := pqw.masterDerivationKey.Open()
+	if err != nil {
+		return err
+	}
+	defer mdkBuf.Destroy() // Destroy the copy when we return
+
+	// Generate the new master encryption key and encrypt it using the new
+	// password (which may be blank)
+	var newMasterKey [masterKeyLen]byte
+	err = fillRandomBytes(newMasterKey[:])
+	if err != nil {
+		return err
+	}
+	kdf := pqw.cfg.KDFParams()
+	newMEPEncrypted, err := encryptBlobWithKDF(newMasterKey[:], PTMasterKey, newPw, &kdf)
+	if err != nil {
+		return err
+	}
+
+	// Fetch the encrypted highest index
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var encryptedHighestIndexBlob []byte
+	row := db.QueryRow(
+		fmt.Sprintf("SELECT max_key_idx_encrypted FROM read_parquet('%s') WHERE wallet_id = ? LIMIT 1",
+			metadatasPath),
+		pqw.id,
+	)
+	err = row.Scan(&encryptedHighestIndexBlob)
+	if err != nil {
+		return err
+	}
+
+	// Re-encrypt the metadata that is encrypted with the master encryption key
+	maxKeyIdxBlob, err := decryptBlobWithPassword(encryptedHighestIndexBlob, PTMaxKeyIdx, mekBuf.Bytes())
+	if err != nil {
+		return err
+	}
+	metadata, err := parquetWalletMetadataFromPath(walletPath)
+	if err != nil {
+		return err
+	}
+	metadata.MEPEncrypted = newMEPEncrypted
+	metadata.MDKEncrypted, err = encryptBlobWithKey(mdkBuf.Bytes(), PTMasterDerivationKey, newMasterKey[:])
+	if err != nil {
+		return err
+	}
+	metadata.MaxKeyIdxEncrypted, err = encryptBlobWithKey(maxKeyIdxBlob, PTMaxKeyIdx, newMasterKey[:])
+	if err != nil {
+		return err
+	}
+	metadataJson, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	err = beginPwChange(walletPath, newMEPEncrypted)
+	if err != nil {
+		return err
+	}
+
+	// Make copies of the wallet files encrypted with the new key
+	err = pqw.rekeyParquetFiles(mekBuf.Bytes(), newMasterKey[:])
+	if err == nil {
+		err = rekeyDuckDbFiles(walletPath, mekBuf.Bytes(), newMasterKey[:])
+	}
+	if err == nil {
+		err = writeFileSync(
+			filepath.Join(walletPath, ParquetWalletMetadataFile+rekeySuffix),
+			metadataJson,
+			parquetWalletsDirPermissions,
+		)
+	}
+	if err != nil {
+		rollBackPwChange(walletPath)
+		return err
+	}
+
+	// Commit the change by replacing the metadatas file
+	err = pqw.replaceMetadata(metadatasPath, &metadata)
+	if err != nil {
+		rollBackPwChange(walletPath)
+		return err
+	}
+
+	// Use the new key and password from now on, even if the copies fail to
+	// replace the original files. The change is committed, so it will be
+	// finished when the wallet is fetched again.
+	pqw.masterEncryptionKey = memguard.NewEnclave(newMasterKey[:])
+	pqw.walletPasswordHash = fastHashWithSalt(newPw, pqw.walletPasswordSalt[:])
+
+	return finishPwChange(walletPath)
+}
+
 // CheckAddrInWallet checks if the account with the given address is stored in
 // the wallet
 func (pqw *ParquetWallet) CheckAddrInWallet(addr string) (bool, error) {
@@ -1863,12 +2033,40 @@ func (pqw *ParquetWallet) MultisigSignProgram(data []byte, src types.Digest, pk
 	return
 }
 
+// ListAccounts lists the accounts of the keys and multisig addresses in the
+// wallet. The wallet does not store accounts, so the accounts have no names and
+// are in a fixed order.
 func (pqw *ParquetWallet) ListAccounts() ([]wallet.Account, error) {
-	return nil, errNotSupported
+	keyIdxs, _, err := pqw.keyIndexes()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := pqw.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	msigAddrs, err := pqw.ListMultisigAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	return keyAndMsigAccounts(keyIdxs, keys, msigAddrs), nil
 }
 
+// GetAccount retrieves the account of the key or multisig address with the
+// given address
 func (pqw *ParquetWallet) GetAccount(addr string) (*wallet.Account, error) {
-	return nil, errNotSupported
+	decodedAddr, err := types.DecodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	accts, err := pqw.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	return findAccount(accts, types.Digest(decodedAddr))
 }
 
 func (pqw *ParquetWallet) DeleteAccount(addr string, pw []byte) error {
@@ -1964,6 +2162,12 @@ func (parqwd *ParquetWalletDriver) potentialWalletPaths() (paths []string, err e
 	return
 }
 
+// migratedFilePath gives the path of the file that marks the wallet with the
+// given id as migrated to another driver
+func (parqwd *ParquetWalletDriver) migratedFilePath(id []byte) (string, error) {
+	return filepath.Join(parqwd.walletsDir(), string(id), migratedFile), nil
+}
+
 // maybeMakeWalletsDir tries to create the wallets directory if it doesn't
 // already exist
 func (parqwd *ParquetWalletDriver) maybeMakeWalletsDir() error {
@@ -2008,6 +2212,40 @@ func (parqwd *ParquetWalletDriver) idToPath(id []byte) string {
 	return filepath.Join(parqwd.walletsDir(), string(safeID))
 }
 
+// removeParquetWalletMetadata removes the metadata of the wallet with the given
+// id from the metadatas file for the Parquet wallet driver. The old metadatas
+// file is wiped because it contains the encrypted keys of the wallet.
+func (parqwd *ParquetWalletDriver) removeParquetWalletMetadata(id []byte) error {
+	metadatasPath := filepath.Join(parqwd.walletsDir(), ParquetMetadatasFile)
+
+	// Activate mutex lock to handle a race over the metadatas file
+	parqwd.metadatasMutex.Lock()
+	defer parqwd.metadatasMutex.Unlock()
+
+	// Open database
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Write the metadatas of the other wallets into the temporary file
+	_, err = db.Exec(fmt.Sprintf(
+		"COPY (FROM read_parquet('%s') WHERE wallet_id != ? ORDER BY wallet_id) TO '%s' (FORMAT parquet)",
+		metadatasPath, metadatasPath+tempFileSuffix,
+	), id)
+	if err != nil {
+		return err
+	}
+
+	err = wipeFile(metadatasPath)
+	if err != nil {
+		return err
+	}
+
+	return removeTempFile(metadatasPath)
+}
+
 // addParquetWalletMetadata adds the given data to the metadatas file for the
 // Parquet wallet driver
 func (parqwd *ParquetWalletDriver) addParquetWalletMetadata(metadata *ParquetWalletMetadata) error {
@@ -2093,6 +2331,162 @@ func (parqwd *ParquetWalletDriver) addParquetWalletMetadata(metadata *ParquetWal
 
 /********** Wallet Helpers **********/
 
+// rekeyParquetFiles makes a copy of each of the wallet's Parquet files that is
+// encrypted with the new key instead of the old key. The secret keys within the
+// keys file are also re-encrypted with the new key.
+func (pqw *ParquetWallet) rekeyParquetFiles(oldKey, newKey []byte) error {
+	keysPath := filepath.Join(pqw.walletsPath, pqw.id, ParquetWalletKeysFile)
+	msigAddrsPath := filepath.Join(pqw.walletsPath, pqw.id, ParquetWalletMsigAddrsFile)
+
+	// Open database
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Add keys for decrypting and encrypting encrypted parquet files
+	// NOTE: These PRAGMA statements do not work as prepared statements, but
+	// there is no risk of SQL injection in this case
+	_, err = db.Exec(fmt.Sprintf(addParquetKeySQL, base64.StdEncoding.EncodeToString(oldKey)))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(addNewParquetKeySQL, base64.StdEncoding.EncodeToString(newKey)))
+	if err != nil {
+		return err
+	}
+
+	// Check if keys file exists
+	_, err = os.Stat(keysPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		// Run the schema for creating the keys table in temporary database
+		_, err = db.Exec(parquetCreateKeysTblSchema)
+		if err != nil {
+			return err
+		}
+
+		// Re-encrypt each secret key with the new key
+		_, err = db.Exec(fmt.Sprintf(
+			"INSERT INTO keys FROM read_parquet('%s', encryption_config = {footer_key: 'key'})",
+			keysPath,
+		))
+		if err != nil {
+			return err
+		}
+		rows, err := db.Query("SELECT address, secret_key_encrypted FROM keys")
+		if err != nil {
+			return err
+		}
+		reencryptedKeys := map[string][]byte{}
+		for rows.Next() {
+			var addr, skEncrypted []byte
+			err = rows.Scan(&addr, &skEncrypted)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			skBlob, err := decryptBlobWithPassword(skEncrypted, PTSecretKey, oldKey)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			reencryptedKeys[string(addr)], err = encryptBlobWithKey(skBlob, PTSecretKey, newKey)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		for addr, skEncrypted := range reencryptedKeys {
+			_, err = db.Exec("UPDATE keys SET secret_key_encrypted = ? WHERE address = ?", skEncrypted, []byte(addr))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = db.Exec(fmt.Sprintf(
+			"COPY (FROM keys ORDER BY key_idx, address) TO '%s' (FORMAT parquet, ENCRYPTION_CONFIG {footer_key: 'new_key'});",
+			keysPath+rekeySuffix,
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if multisig addresses file exists
+	_, err = os.Stat(msigAddrsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		_, err = db.Exec(fmt.Sprintf(
+			"COPY (FROM read_parquet('%s', encryption_config = {footer_key: 'key'})) TO '%s' (FORMAT parquet, ENCRYPTION_CONFIG {footer_key: 'new_key'});",
+			msigAddrsPath, msigAddrsPath+rekeySuffix,
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceMetadata replaces the wallet's metadata in the metadatas file at the
+// given path with the given metadata. The file is replaced in a single step, so
+// either all or none of the metadata is replaced.
+func (pqw *ParquetWallet) replaceMetadata(metadatasPath string, metadata *ParquetWalletMetadata) error {
+	// Handle possible race over the metadatas file
+	pqw.metadatasMutex.Lock()
+	defer pqw.metadatasMutex.Unlock()
+
+	// Open database
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Run the schema for creating the metadatas table in temporary in-memory database
+	_, err = db.Exec(parquetCreateMetadatasTblSchema)
+	if err != nil {
+		return err
+	}
+
+	// Load metadatas file into temporary in-memory table
+	_, err = db.Exec(fmt.Sprintf("INSERT INTO metadatas FROM read_parquet('%s')", metadatasPath))
+	if err != nil {
+		return err
+	}
+
+	// Update the encrypted metadata
+	_, err = db.Exec(
+		"UPDATE metadatas SET mep_encrypted=?, mdk_encrypted=?, max_key_idx_encrypted=? WHERE wallet_id=?",
+		metadata.MEPEncrypted,
+		metadata.MDKEncrypted,
+		metadata.MaxKeyIdxEncrypted,
+		pqw.id,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Replace metadatas file
+	_, err = db.Exec(fmt.Sprintf("COPY metadatas TO '%s' (FORMAT parquet)", metadatasPath+tempFileSuffix))
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(metadatasPath+tempFileSuffix, metadatasPath)
+}
+
 // DecryptAndGetMasterKey fetches the master key from the metadatas file and
 // attempts to decrypt it with the passed password
 func (pqw *ParquetWallet) DecryptAndGetMasterKey(pw []byte) ([]byte, error) {
@@ -2159,6 +2553,94 @@ func (pqw *ParquetWallet) decryptAndGetMasterDerivationKey(pw []byte) ([]byte, e
 	return mdk, nil
 }
 
+// keyIndexes gives the index of each generated key in the wallet by the key's
+// address along with the highest index a key has been generated with
+func (pqw *ParquetWallet) keyIndexes() (keyIdxs map[types.Digest]uint64, maxKeyIdx uint64, err error) {
+	if !pqw.initialized {
+		return nil, 0, fmt.Errorf("wallet not initialized")
+	}
+
+	metadatasPath := filepath.Join(pqw.walletsPath, ParquetMetadatasFile)
+	keysPath := filepath.Join(pqw.walletsPath, pqw.id, ParquetWalletKeysFile)
+
+	// Open database
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	// Decrypt the master encryption key stored in enclave into a local copy
+	mekBuf, err := pqw.masterEncryptionKey.Open()
+	if err != nil {
+		return
+	}
+	defer mekBuf.Destroy() // Destroy the copy when we return
+
+	// Fetch the encrypted highest index
+	var encryptedMaxKeyIdxBlob []byte
+	pqw.metadatasMutex.RLock()
+	row := db.QueryRow(
+		fmt.Sprintf("SELECT max_key_idx_encrypted FROM read_parquet('%s') WHERE wallet_id = ? LIMIT 1",
+			metadatasPath),
+		pqw.id,
+	)
+	err = row.Scan(&encryptedMaxKeyIdxBlob)
+	pqw.metadatasMutex.RUnlock()
+	if err != nil {
+		return
+	}
+
+	maxKeyIdx, err = decryptMaxKeyIdx(encryptedMaxKeyIdxBlob, mekBuf.Bytes())
+	if err != nil {
+		return
+	}
+
+	keyIdxs = map[types.Digest]uint64{}
+
+	// Check if keys file exists
+	_, err = os.Stat(keysPath)
+	if os.IsNotExist(err) {
+		return keyIdxs, maxKeyIdx, nil
+	}
+	if err != nil {
+		// Some unexpected error occurred
+		return
+	}
+
+	// Add key for decrypting and encrypting encrypted parquet file
+	// NOTE: This PRAGMA statement does not work as a prepared statement, but
+	// there is no risk of SQL injection in this case
+	_, err = db.Exec(fmt.Sprintf(addParquetKeySQL, base64.StdEncoding.EncodeToString(mekBuf.Bytes())))
+	if err != nil {
+		return
+	}
+
+	rows, err := db.Query(fmt.Sprintf(
+		"SELECT address, key_idx FROM read_parquet('%s', encryption_config = {footer_key: 'key'}) WHERE key_idx IS NOT NULL",
+		keysPath,
+	))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var addrBytes []byte
+		var keyIdx uint64
+		err = rows.Scan(&addrBytes, &keyIdx)
+		if err != nil {
+			return
+		}
+
+		var addr types.Digest
+		copy(addr[:], addrBytes)
+		keyIdxs[addr] = keyIdx
+	}
+
+	return keyIdxs, maxKeyIdx, rows.Err()
+}
+
 // fetchSecretKey retrieves the private key for a given public key
 func (pqw *ParquetWallet) fetchSecretKey(addr types.Digest) (sk ed25519.PrivateKey, err error) {
 	keysPath := filepath.Join(pqw.walletsPath, pqw.id, ParquetWalletKeysFile)
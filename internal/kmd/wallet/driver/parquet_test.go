@@ -440,6 +440,53 @@ var _ = Describe("Parquet Wallet Driver", func() {
 			})
 		})
 
+		Describe("DeleteWallet()", Ordered, func() {
+			const walletDirName = ".test_pq_delete_wallet"
+			var parquetDriver driver.ParquetWalletDriver
+
+			BeforeAll(func() {
+				setupParquetWalletDriver(&parquetDriver, walletDirName)
+				DeferCleanup(func() {
+					createKmdServiceCleanup(walletDirName)
+				})
+			})
+
+			It("fails if the given password is incorrect", func() {
+				By("Creating wallets")
+				for _, walletId := range []string{"000", "111"} {
+					err := parquetDriver.CreateWallet(
+						[]byte("Foo"),
+						[]byte(walletId),
+						[]byte("password"),
+						algoTypes.MasterDerivationKey{},
+					)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				By("Attempting to delete a wallet with an incorrect password")
+				err := parquetDriver.DeleteWallet([]byte("000"), []byte("not the password"))
+				Expect(err).To(HaveOccurred())
+				Expect(walletDirName+"/000").To(BeADirectory(), "The wallet is not deleted")
+			})
+
+			It("deletes the wallet with the given ID and removes its metadata", func() {
+				// NOTE: Because this `Describe` container is "Ordered", it is
+				// assumed that the wallets have been created
+				By("Deleting the wallet")
+				err := parquetDriver.DeleteWallet([]byte("000"), []byte("password"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(walletDirName+"/000").NotTo(BeAnExistingFile(), "The wallet directory is removed")
+
+				By("Checking the wallet is no longer in the metadatas file")
+				metadatas, err := parquetDriver.ListWalletMetadatas()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadatas).To(HaveLen(1))
+				Expect(metadatas[0].ID).To(Equal([]byte("111")))
+				_, err = parquetDriver.FetchWallet([]byte("000"))
+				Expect(err).To(MatchError("wallet not found"))
+			})
+		})
+
 		Describe("FetchWallet()", Ordered, func() {
 			const walletDirName = ".test_pq_fetch_wallet"
 			var parquetDriver driver.ParquetWalletDriver
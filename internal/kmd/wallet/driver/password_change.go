@@ -0,0 +1,221 @@
+package driver
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"duckysigner/internal/encdb"
+)
+
+// A wallet's password is changed by re-encrypting the wallet's data with a new
+// master encryption key (MEK) that is protected by the new password. To make
+// this crash-safe, the change is done in these steps:
+//
+//  1. The change is recorded in the password change file within the wallet's
+//     directory. The recorded change includes the new encrypted MEK.
+//  2. A copy of each wallet file is made with the ".rekey" suffix. Each copy is
+//     encrypted with the new MEK.
+//  3. The change is committed by replacing the wallet's encrypted MEK with the
+//     new one. This is done by replacing a single file, so it cannot be partly
+//     done.
+//  4. The copies replace the original files and the password change file is
+//     removed.
+//
+// If the process is interrupted, the change is recovered the next time the
+// wallet is fetched. It is finished if it was committed. Otherwise, it is
+// rolled back by removing the copies.
+
+const (
+	// pwChangeFile is the name of the file in a wallet's directory that records
+	// a password change that is in progress
+	pwChangeFile = "password_change.json"
+	// rekeySuffix is the suffix of the copy of a wallet file that is encrypted
+	// with the new master encryption key during a password change. The copy's
+	// file name is the original file name + this suffix.
+	rekeySuffix = ".rekey"
+	// encDuckDbFileExt is the file extension of the encrypted DuckDB files in
+	// a wallet's directory
+	encDuckDbFileExt = ".duckdb"
+)
+
+// rekeyEncDuckDbSQL is the SQL statement for copying all the data in the
+// encrypted DuckDB file attached as `old_db` into the new file attached as
+// `new_db`, which is encrypted with a different key
+const rekeyEncDuckDbSQL = `
+COPY FROM DATABASE old_db TO new_db;
+DETACH old_db;
+DETACH new_db;
+`
+
+// pwChange is a password change that is in progress
+type pwChange struct {
+	// The master encryption key encrypted with the new password
+	MEPEncrypted []byte `json:"mep_encrypted"`
+}
+
+// beginPwChange records the start of a password change for the wallet in the
+// given wallet directory. Whatever is left from a previous password change that
+// was not committed is removed.
+func beginPwChange(walletPath string, newMEPEncrypted []byte) error {
+	err := rollBackPwChange(walletPath)
+	if err != nil {
+		return err
+	}
+
+	changeJson, err := json.Marshal(pwChange{MEPEncrypted: newMEPEncrypted})
+	if err != nil {
+		return err
+	}
+
+	return writeFileSync(filepath.Join(walletPath, pwChangeFile), changeJson, 0600)
+}
+
+// finishPwChange replaces the wallet files in the given wallet directory with
+// their re-encrypted copies made for a committed password change
+func finishPwChange(walletPath string) error {
+	copyPaths, err := filepath.Glob(filepath.Join(walletPath, "*"+rekeySuffix))
+	if err != nil {
+		return err
+	}
+
+	for _, copyPath := range copyPaths {
+		origPath := strings.TrimSuffix(copyPath, rekeySuffix)
+
+		// Replace the write-ahead log of an encrypted DuckDB file along with it
+		// so the log of the original file is not applied to the copy
+		if strings.HasSuffix(origPath, encDuckDbFileExt) {
+			err = os.Remove(origPath + ".wal")
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		err = os.Rename(copyPath, origPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Remove(filepath.Join(walletPath, pwChangeFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// rollBackPwChange removes the re-encrypted copies of wallet files made for a
+// password change that was not committed along with the record of the change
+func rollBackPwChange(walletPath string) error {
+	copyPaths, err := filepath.Glob(filepath.Join(walletPath, "*"+rekeySuffix+"*"))
+	if err != nil {
+		return err
+	}
+
+	for _, copyPath := range copyPaths {
+		err = os.Remove(copyPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	err = os.Remove(filepath.Join(walletPath, pwChangeFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// recoverPwChange finishes or rolls back an interrupted password change for the
+// wallet in the given wallet directory. The change was committed if the given
+// encrypted master encryption key currently stored for the wallet is the one
+// recorded for the change.
+func recoverPwChange(walletPath string, currentMEPEncrypted []byte) error {
+	changeJson, err := os.ReadFile(filepath.Join(walletPath, pwChangeFile))
+	if os.IsNotExist(err) {
+		// Remove any copies left before the change was recorded
+		return rollBackPwChange(walletPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A change that was not fully recorded cannot have been committed
+	change := pwChange{}
+	if json.Unmarshal(changeJson, &change) != nil {
+		return rollBackPwChange(walletPath)
+	}
+
+	if bytes.Equal(change.MEPEncrypted, currentMEPEncrypted) {
+		return finishPwChange(walletPath)
+	}
+
+	return rollBackPwChange(walletPath)
+}
+
+// rekeyDuckDbFiles makes a copy of each encrypted DuckDB file in the given
+// wallet directory that is encrypted with the new key instead of the old key
+func rekeyDuckDbFiles(walletPath string, oldKey, newKey []byte) error {
+	filePaths, err := filepath.Glob(filepath.Join(walletPath, "*"+encDuckDbFileExt))
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range filePaths {
+		err = rekeyDuckDbFile(filePath, filePath+rekeySuffix, oldKey, newKey)
+		if err != nil {
+			return fmt.Errorf("could not re-encrypt %s: %w", filepath.Base(filePath), err)
+		}
+	}
+
+	return nil
+}
+
+// rekeyDuckDbFile copies the data in the encrypted DuckDB file with the given
+// file path into a new file that is encrypted with the new key
+func rekeyDuckDbFile(filePath, newFilePath string, oldKey, newKey []byte) error {
+	// Open DuckDB in in-memory mode
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = encdb.Attach(db, filePath, oldKey, "old_db", true)
+	if err != nil {
+		return err
+	}
+	err = encdb.Attach(db, newFilePath, newKey, "new_db", false)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(rekeyEncDuckDbSQL)
+
+	return err
+}
+
+// writeFileSync writes the data to the file with the given name like
+// os.WriteFile, but makes sure the data is on disk before returning
+func writeFileSync(name string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
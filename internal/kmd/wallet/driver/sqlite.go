@@ -258,6 +258,12 @@ func (swd *SQLiteWalletDriver) ListWalletMetadatas() (metadatas []wallet.Metadat
 		return []wallet.Metadata{}, nil
 	}
 
+	return swd.listAllWalletMetadatas()
+}
+
+// listAllWalletMetadatas is the guts of ListWalletMetadatas, which lists the
+// wallets even if this wallet driver is disabled
+func (swd *SQLiteWalletDriver) listAllWalletMetadatas() (metadatas []wallet.Metadata, err error) {
 	paths, err := swd.potentialWalletPaths()
 	if err != nil {
 		return
@@ -273,6 +279,27 @@ func (swd *SQLiteWalletDriver) ListWalletMetadatas() (metadatas []wallet.Metadat
 	return metadatas, nil
 }
 
+// migratedFilePath gives the path of the file that marks the wallet with the
+// given id as migrated to another driver. The file is next to the wallet's
+// database file.
+func (swd *SQLiteWalletDriver) migratedFilePath(id []byte) (string, error) {
+	swd.mux.Lock()
+	defer swd.mux.Unlock()
+
+	dbPaths, err := swd.findDBPathsByID(id)
+	if err != nil {
+		return "", err
+	}
+	if len(dbPaths) == 0 {
+		return "", errWalletNotFound
+	}
+	if len(dbPaths) > 1 {
+		return "", errIDConflict
+	}
+
+	return dbPaths[0] + "." + migratedFile, nil
+}
+
 // findDBPathsById returns the paths to wallets with the specified id
 func (swd *SQLiteWalletDriver) findDBPathsByID(id []byte) (paths []string, err error) {
 	return swd.findDBPathsByField("ID", id)
@@ -595,6 +622,39 @@ func (swd *SQLiteWalletDriver) RenameWallet(newName []byte, id []byte, pw []byte
 	return nil
 }
 
+// DeleteWallet deletes the wallet with the given id if the given password is
+// correct. The wallet's database file is wiped along with the journal files
+// SQLite may have left next to it.
+func (swd *SQLiteWalletDriver) DeleteWallet(id []byte, pw []byte) error {
+	swd.mux.Lock()
+	defer swd.mux.Unlock()
+
+	// Fetch the wallet
+	curWallet, err := swd.fetchWalletLocked(id)
+	if err != nil {
+		return err
+	}
+	sqWallet, ok := curWallet.(*SQLiteWallet)
+	if !ok {
+		return errSQLiteWrongType
+	}
+
+	// Check the password
+	err = sqWallet.CheckPassword(pw)
+	if err != nil {
+		return err
+	}
+
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		err = wipeFile(sqWallet.dbPath + suffix)
+		if err != nil {
+			return err
+		}
+	}
+
+	return wipeFile(sqWallet.dbPath)
+}
+
 // Metadata builds a wallet.Metadata from our metadata table
 func (sw *SQLiteWallet) Metadata() (meta wallet.Metadata, err error) {
 	// Connect to the database
@@ -921,6 +981,61 @@ func (sw *SQLiteWallet) GenerateKey(displayMnemonic bool) (addr types.Digest, er
 	return addr, nil
 }
 
+// keyIndexes gives the index of each generated key in the wallet by the key's
+// address along with the highest index a key has been generated with
+func (sw *SQLiteWallet) keyIndexes() (keyIdxs map[types.Digest]uint64, maxKeyIdx uint64, err error) {
+	// Connect to the database
+	db, err := sqlx.Connect("sqlite", dbConnectionURL(sw.dbPath))
+	if err != nil {
+		err = errDatabaseConnect
+		return
+	}
+	defer db.Close()
+
+	// Fetch the encrypted highest index
+	var encryptedHighestIndexBlob []byte
+	err = db.Get(&encryptedHighestIndexBlob, "SELECT max_key_idx_encrypted FROM metadata LIMIT 1")
+	if err != nil {
+		err = errDatabase
+		return
+	}
+
+	// Decrypt the master encryption key stored in enclave into a local copy
+	mekBuf, err := sw.masterEncryptionKey.Open()
+	if err != nil {
+		return
+	}
+	defer mekBuf.Destroy() // Destroy the copy when we return
+
+	maxKeyIdx, err = decryptMaxKeyIdx(encryptedHighestIndexBlob, mekBuf.Bytes())
+	if err != nil {
+		return
+	}
+
+	rows, err := db.Query("SELECT address, key_idx FROM keys WHERE key_idx IS NOT NULL")
+	if err != nil {
+		err = errDatabase
+		return
+	}
+	defer rows.Close()
+
+	keyIdxs = map[types.Digest]uint64{}
+	for rows.Next() {
+		var addrBytes []byte
+		var keyIdx uint64
+		err = rows.Scan(&addrBytes, &keyIdx)
+		if err != nil {
+			return
+		}
+
+		var addr types.Digest
+		copy(addr[:], addrBytes)
+		keyIdxs[addr] = keyIdx
+	}
+
+	return keyIdxs, maxKeyIdx, rows.Err()
+}
+
 // generateKeyTxLocked is a helper for GenerateKey that accepts a locked tx,
 // computes the next key that should be generated, inserts it, and returns
 // its address
@@ -1495,12 +1610,44 @@ func (sw *SQLiteWallet) MultisigSignProgram(data []byte, src types.Digest, pk ed
 	return
 }
 
+func (sw *SQLiteWallet) ChangePassword(oldPw, newPw []byte) error {
+	return errNotSupported
+}
+
+// ListAccounts lists the accounts of the keys and multisig addresses in the
+// wallet. The wallet does not store accounts, so the accounts have no names and
+// are in a fixed order.
 func (sw *SQLiteWallet) ListAccounts() ([]wallet.Account, error) {
-	return nil, errNotSupported
+	keyIdxs, _, err := sw.keyIndexes()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := sw.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	msigAddrs, err := sw.ListMultisigAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	return keyAndMsigAccounts(keyIdxs, keys, msigAddrs), nil
 }
 
+// GetAccount retrieves the account of the key or multisig address with the
+// given address
 func (sw *SQLiteWallet) GetAccount(addr string) (*wallet.Account, error) {
-	return nil, errNotSupported
+	decodedAddr, err := types.DecodeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	accts, err := sw.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	return findAccount(accts, types.Digest(decodedAddr))
 }
 
 func (sw *SQLiteWallet) DeleteAccount(addr string, pw []byte) error {
@@ -1530,3 +1677,62 @@ func (sw *SQLiteWallet) MoveAccountAbove(moveAddr string, refAddr string) error
 func (sw *SQLiteWallet) MoveAccountBelow(moveAddr string, refAddr string) error {
 	return errNotSupported
 }
+
+// keyAndMsigAccounts gives the accounts of the given keys and multisig
+// addresses for a wallet that does not store accounts. The accounts of the
+// generated keys, which are those with key indexes, come first in the order
+// they were generated, followed by the accounts of the imported keys and then
+// the multisig accounts.
+func keyAndMsigAccounts(keyIdxs map[types.Digest]uint64, keys, msigAddrs []types.Digest) []wallet.Account {
+	keys = slices.Clone(keys)
+	slices.SortFunc(keys, func(a, b types.Digest) int {
+		aIdx, aGenerated := keyIdxs[a]
+		bIdx, bGenerated := keyIdxs[b]
+		switch {
+		case aGenerated && bGenerated && aIdx != bIdx:
+			if aIdx < bIdx {
+				return -1
+			}
+			return 1
+		case aGenerated != bGenerated:
+			if aGenerated {
+				return -1
+			}
+			return 1
+		}
+		return bytes.Compare(a[:], b[:])
+	})
+	msigAddrs = slices.Clone(msigAddrs)
+	slices.SortFunc(msigAddrs, func(a, b types.Digest) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	accts := make([]wallet.Account, 0, len(keys)+len(msigAddrs))
+	for _, addr := range keys {
+		acctType := wallet.AcctTypeStandalone
+		if _, generated := keyIdxs[addr]; generated {
+			acctType = wallet.AcctTypeKMD
+		}
+		accts = append(accts, wallet.Account{Address: addr, Type: acctType})
+	}
+	for _, addr := range msigAddrs {
+		accts = append(accts, wallet.Account{Address: addr, Type: wallet.AcctTypeMsig})
+	}
+	for i := range accts {
+		accts[i].Position = fmt.Sprint(i)
+	}
+
+	return accts
+}
+
+// findAccount gives the account with the given address within the given
+// accounts
+func findAccount(accts []wallet.Account, addr types.Digest) (*wallet.Account, error) {
+	for i := range accts {
+		if accts[i].Address == addr {
+			return &accts[i], nil
+		}
+	}
+
+	return nil, errAcctNotFound
+}
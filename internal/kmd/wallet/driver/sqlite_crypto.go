@@ -73,6 +73,7 @@ type encryptedDBBlob struct {
 	Ciphertext []byte         `codec:"ciphertext"`
 	Nonce      [nonceLen]byte `codec:"nonce"`
 	Salt       [saltLen]byte  `codec:"salt"`
+	kdfHeader
 }
 
 // deriveEncryptionKeyWithSalt uses scrypt to derive a key suitable for
@@ -132,10 +133,10 @@ func encryptBlobWithKey(plaintext []byte, ptType plaintextType, key []byte) ([]b
 
 // encryptBlobWithPasswordBlankOK accepts a password, and optionally derives a
 // key from it.  If cfg is nil, the password is assumed to be already a
-// cryptographic key, and no scrypt key derivation is applied.
+// cryptographic key, and no scrypt key derivation is applied. The blob is in
+// the format used by go-algorand's kmd, so it has no KDF header (see
+// encryptBlobWithKDF).
 func encryptBlobWithPasswordBlankOK(plaintext []byte, ptType plaintextType, password []byte, cfg *config.ScryptParams) (blob []byte, err error) {
-	var nonceArr [nonceLen]byte
-
 	// Generate the key and salt
 	var key *[masterKeyLen]byte
 	var salt *[saltLen]byte
@@ -153,6 +154,24 @@ func encryptBlobWithPasswordBlankOK(plaintext []byte, ptType plaintextType, pass
 		copy(key[:], password)
 	}
 
+	// Build the encrypted database blob
+	var dbblob encryptedDBBlob
+	if cfg != nil {
+		dbblob.ScryptParams = *cfg
+		dbblob.DoScrypt = true
+		dbblob.Salt = *salt
+	} else {
+		dbblob.DoScrypt = false
+	}
+
+	return sealBlob(plaintext, ptType, key, dbblob)
+}
+
+// sealBlob encrypts the plaintext using the key and gives the given encrypted
+// database blob with the ciphertext and nonce filled in, encoded to msgpack
+func sealBlob(plaintext []byte, ptType plaintextType, key *[masterKeyLen]byte, dbblob encryptedDBBlob) (blob []byte, err error) {
+	var nonceArr [nonceLen]byte
+
 	// Generate the nonce
 	err = fillRandomBytes(nonceArr[:])
 	if err != nil {
@@ -167,21 +186,8 @@ func encryptBlobWithPasswordBlankOK(plaintext []byte, ptType plaintextType, pass
 
 	// Encode & encrypt the typed plaintext
 	encodedPT := msgpackEncode(typedPT)
-	ciphertext := secretbox.Seal(nil, encodedPT, &nonceArr, key)
-
-	// Build the encrypted database blob
-	dbblob := encryptedDBBlob{
-		Ciphertext: ciphertext,
-		Nonce:      nonceArr,
-	}
-
-	if cfg != nil {
-		dbblob.ScryptParams = *cfg
-		dbblob.DoScrypt = true
-		dbblob.Salt = *salt
-	} else {
-		dbblob.DoScrypt = false
-	}
+	dbblob.Ciphertext = secretbox.Seal(nil, encodedPT, &nonceArr, key)
+	dbblob.Nonce = nonceArr
 
 	// Encode to msgpack
 	blob = msgpackEncode(dbblob)
@@ -196,10 +202,14 @@ func decryptBlobWithPassword(blob []byte, ptType plaintextType, password []byte)
 		return
 	}
 
-	// Derive the encryption key
+	// Derive the encryption key using the KDF recorded in the blob
+	kdf, err := dbblob.kdfParams()
+	if err != nil {
+		return
+	}
 	var key *[masterKeyLen]byte
-	if dbblob.DoScrypt {
-		key, err = deriveEncryptionKeyWithSalt(password, &dbblob.Salt, dbblob.ScryptParams)
+	if kdf != nil {
+		key, err = deriveEncryptionKeyWithKDF(password, &dbblob.Salt, *kdf)
 		if err != nil {
 			return
 		}
@@ -29,6 +29,10 @@ var errMsigDataNotFound = fmt.Errorf("multisig information (pks, threshold) for
 var errTampering = fmt.Errorf("derived public key mismatch, something fishy is going on with this wallet")
 var errNoMnemonicUX = fmt.Errorf("sqlite wallet driver cannot display mnemonics")
 var errKeyExists = fmt.Errorf("key already exists in wallet")
+var errAcctNotFound = fmt.Errorf("account does not exist in this wallet")
+var errRefAcctNotFound = fmt.Errorf("reference account does not exist in this wallet")
+var errAcctExists = fmt.Errorf("account already exists in wallet")
+var errAuthKeyNotFound = fmt.Errorf("key of the account the sender is rekeyed to does not exist in this wallet")
 var errDeriveKey = fmt.Errorf("scrypt lib could not derive key from password")
 var errWrongDriver = fmt.Errorf("found database with wrong driver name in wallets dir")
 var errRandBytes = fmt.Errorf("error reading random bytes")
@@ -45,3 +49,12 @@ var errNameTooLong = fmt.Errorf("wallet name too long, must be <= %d bytes", sql
 var errIDTooLong = fmt.Errorf("wallet id too long, must be <= %d bytes", sqliteMaxWalletIDLen)
 var errMsigWrongAddr = fmt.Errorf("given multisig preimage hashes to neither Sender nor AuthAddr")
 var errMsigWrongKey = fmt.Errorf("given key is not a possible signer for this multisig")
+var errBackupVersion = fmt.Errorf("unsupported wallet backup version")
+var errBackupDriver = fmt.Errorf("wallet backup is not for this wallet driver")
+var errBackupFile = fmt.Errorf("wallet backup contains an invalid file")
+var errWalletMigrated = fmt.Errorf("wallet has already been migrated to another driver")
+var errMigrationNotSupported = fmt.Errorf("wallet driver does not support migrating wallets")
+var errMigrationMismatch = fmt.Errorf("migrated wallet does not match the original wallet")
+var errUnknownKDF = fmt.Errorf("unknown key derivation function")
+var errKDFVersion = fmt.Errorf("unsupported key derivation header version")
+var errKDFTooCostly = fmt.Errorf("key derivation function parameters exceed the maximum cost")
@@ -20,7 +20,9 @@ package driver
 
 import (
 	"crypto/ed25519"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/algorand/go-algorand-sdk/v2/types"
 )
@@ -58,3 +60,61 @@ func removeTempFile(originalFilename string) error {
 
 	return nil
 }
+
+// wipeFile overwrites the contents of the file with the given file name with
+// zeros before removing the file. The overwrite is synced to disk, but it may
+// not reach the original blocks on storage that does not write in place (e.g.
+// SSDs and copy-on-write file systems).
+func wipeFile(name string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err == nil {
+		zeros := make([]byte, 64*1024)
+		for remaining := info.Size(); remaining > 0 && err == nil; {
+			n := min(remaining, int64(len(zeros)))
+			_, err = f.Write(zeros[:n])
+			remaining -= n
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// wipeDir wipes each file within the directory with the given path, including
+// those in subdirectories, and then removes the directory. Nothing is done if
+// the directory does not exist.
+func wipeDir(path string) error {
+	err := filepath.WalkDir(path, func(filePath string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return wipeFile(filePath)
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(path)
+}
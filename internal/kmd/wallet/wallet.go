@@ -36,6 +36,7 @@ const (
 type Wallet interface {
 	Init(pw []byte) error
 	CheckPassword(pw []byte) error
+	ChangePassword(oldPw, newPw []byte) error
 	ExportMasterDerivationKey(pw []byte) (types.MasterDerivationKey, error)
 
 	Metadata() (Metadata, error)
@@ -112,8 +113,23 @@ var acctTypeName = map[AccountType]string{
 	AcctTypeWatch:      "watch",
 }
 
+// ParseAccountType returns the account type with the given name (e.g.
+// "kmd_hd", "watch")
+func ParseAccountType(name string) (AccountType, error) {
+	for acctType, typeName := range acctTypeName {
+		if typeName == name {
+			return acctType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown account type: %s", name)
+}
+
 /* END */
 
+// ErrWatchOnly is returned when attempting to sign for a watch-only account,
+// which is an account that is in a wallet without its key
+var ErrWatchOnly = fmt.Errorf("account is watch-only and has no key to sign with")
+
 // Metadata represents high-level information about a wallet, like its name, id
 // and what operations it supports
 type Metadata struct {
@@ -0,0 +1,375 @@
+// Package note_analyzer looks for signs of scams and phishing in the notes of
+// transactions. Spam transactions often carry links to phishing sites in their
+// notes, so what is found should be shown to the user before signing.
+package note_analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/algorand/go-codec/codec"
+)
+
+// Format is how a note is encoded
+type Format string
+
+const (
+	// The note is empty
+	FormatEmpty Format = "empty"
+	// The note is UTF-8 text
+	FormatText Format = "text"
+	// The note follows ARC-2 (`<dapp-name>:<data format><data>`)
+	FormatARC2 Format = "arc2"
+	// The note is MessagePack-encoded data
+	FormatMsgpack Format = "msgpack"
+	// The note is bytes that could not be decoded
+	FormatBinary Format = "binary"
+)
+
+// Code identifies a kind of finding
+type Code string
+
+const (
+	// The note contains a link
+	CodeURL Code = "url"
+	// A domain in the note uses characters that look like other characters,
+	// usually to imitate a well-known domain
+	CodeHomoglyphDomain Code = "homoglyph_domain"
+	// The note has wording that is typical of scams
+	CodeScamWording Code = "scam_wording"
+	// The note has invisible characters or characters that change the
+	// direction of the text, which can hide what the note really says
+	CodeHiddenCharacters Code = "hidden_characters"
+)
+
+// KnownDomains are well-known domains in the Algorand ecosystem that are often
+// imitated by phishing sites
+var KnownDomains = []string{
+	"algorand.co",
+	"algorand.com",
+	"algorand.foundation",
+	"algorand.org",
+	"allo.info",
+	"defly.app",
+	"folks.finance",
+	"nf.domains",
+	"pera.finance",
+	"perawallet.app",
+	"tinyman.org",
+	"vestige.fi",
+}
+
+type (
+	// Finding is something suspicious found in a note
+	Finding struct {
+		// Identifies the kind of finding
+		Code Code `json:"code"`
+		// Human-readable description of what was found
+		Message string `json:"message"`
+	}
+
+	// Analysis is the outcome of analyzing a note
+	Analysis struct {
+		// How the note is encoded
+		Format Format `json:"format"`
+		// Name of the dApp given by an ARC-2 note
+		DappName string `json:"dapp_name,omitempty"`
+		// Data format given by an ARC-2 note: "m" (MessagePack), "j" (JSON),
+		// "b" (bytes) or "u" (UTF-8 text)
+		DataFormat string `json:"data_format,omitempty"`
+		// The note as text. MessagePack data is given as JSON. It is empty if
+		// the note could not be decoded as text.
+		Text string `json:"text,omitempty"`
+		// Links found in the note
+		URLs []string `json:"urls,omitempty"`
+		// Suspicious things found in the note
+		Findings []Finding `json:"findings,omitempty"`
+	}
+)
+
+// arc2Regex matches the prefix of an ARC-2 note
+var arc2Regex = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9_/@.-]{4,31}):([mjbu])`)
+
+// urlRegex matches links with a scheme, links starting with "www." and bare
+// domains with a commonly used top-level domain
+var urlRegex = regexp.MustCompile(
+	`(?i)(?:\b(?:https?|ftp|ipfs)://|\bwww\.)[^\s<>"'` + "`" + `]+` +
+		`|(?:[\p{L}\p{N}](?:[\p{L}\p{N}-]*[\p{L}\p{N}])?\.)+` +
+		`(?:com|net|org|io|app|xyz|info|co|me|link|site|online|top|club|live|gift|finance|network|dev|fi|ai|cc|tk|ml|ga|cf|gq|click|buzz|shop|store|vip|win|icu|cyou|sbs|lol|ly|gg|to|pro|biz|us|uk|eu|ws|foundation|domains)\b` +
+		`(?:/[^\s<>"'` + "`" + `]*)?`,
+)
+
+// scamRegexes match wording that is typical of scams
+var scamRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bair\s?drops?\b`),
+	regexp.MustCompile(`(?i)\bclaim(?:ed|ing)?\b`),
+	regexp.MustCompile(`(?i)\brewards?\b`),
+	regexp.MustCompile(`(?i)\bgiveaways?\b`),
+	regexp.MustCompile(`(?i)\bfree\s+(?:algos?|tokens?|nfts?|coins?)\b`),
+	regexp.MustCompile(`(?i)\byou(?:'ve|\s+have)?\s+won\b`),
+	regexp.MustCompile(`(?i)\bwinners?\b`),
+	regexp.MustCompile(`(?i)\bcongratulations\b`),
+	regexp.MustCompile(`(?i)\b(?:verify|validate|sync)\s+your\s+(?:wallet|account)\b`),
+	regexp.MustCompile(`(?i)\bconnect\s+your\s+wallet\b`),
+	regexp.MustCompile(`(?i)\b(?:seed|recovery|secret)\s+phrase\b`),
+	regexp.MustCompile(`(?i)\bmnemonic\b`),
+	regexp.MustCompile(`(?i)\bprivate\s+key\b`),
+	regexp.MustCompile(`(?i)\bdouble\s+your\b`),
+	regexp.MustCompile(`(?i)\blimited\s+time\b`),
+	regexp.MustCompile(`(?i)\bact\s+(?:now|fast)\b`),
+	regexp.MustCompile(`(?i)\burgent(?:ly)?\b`),
+}
+
+// confusables maps characters to the ASCII characters they look like
+var confusables = map[rune]string{
+	'0': "o", '1': "l", 'i': "l", '|': "l",
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'к': "k", 'м': "m", 'н': "h",
+	'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y", 'х': "x", 'і': "l",
+	'ј': "j", 'ѕ': "s", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'һ': "h",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'ι': "l", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x",
+	// Latin look-alikes
+	'ı': "l", 'ɡ': "g", 'ɑ': "a", 'ɩ': "l", 'ℓ': "l",
+}
+
+// confusableSequences are sequences of ASCII characters that look like a
+// single character
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// msgpackHandle decodes MessagePack notes into generic values
+var msgpackHandle = &codec.MsgpackHandle{}
+
+// Analyze decodes the given note and looks for links, look-alike domains,
+// scam wording and hidden characters within it
+func Analyze(note []byte) *Analysis {
+	analysis := &Analysis{}
+	if len(note) == 0 {
+		analysis.Format = FormatEmpty
+		return analysis
+	}
+
+	if match := arc2Regex.FindSubmatch(note); match != nil {
+		analysis.Format = FormatARC2
+		analysis.DappName = string(match[1])
+		analysis.DataFormat = string(match[2])
+		data := note[len(match[0]):]
+		if analysis.DataFormat == "m" {
+			analysis.Text, _ = decodeMsgpack(data)
+		} else if utf8.Valid(data) {
+			analysis.Text = string(data)
+		}
+		// The dApp name is part of what the user sees, so it is analyzed too
+		analysis.analyzeText(analysis.DappName + ":" + analysis.Text)
+		return analysis
+	}
+
+	if utf8.Valid(note) {
+		analysis.Format = FormatText
+		analysis.Text = string(note)
+	} else if text, ok := decodeMsgpack(note); ok {
+		analysis.Format = FormatMsgpack
+		analysis.Text = text
+	} else {
+		analysis.Format = FormatBinary
+	}
+	analysis.analyzeText(analysis.Text)
+
+	return analysis
+}
+
+// HasLinks checks if any links were found in the note
+func (analysis *Analysis) HasLinks() bool {
+	return len(analysis.URLs) > 0
+}
+
+// Codes gives the codes of the findings without duplicates
+func (analysis *Analysis) Codes() (codes []Code) {
+	for _, finding := range analysis.Findings {
+		if !slices.Contains(codes, finding.Code) {
+			codes = append(codes, finding.Code)
+		}
+	}
+	return
+}
+
+// analyzeText looks for suspicious things in the given text and adds what is
+// found to the analysis
+func (analysis *Analysis) analyzeText(text string) {
+	if text == "" {
+		return
+	}
+
+	if strings.ContainsFunc(text, isHiddenChar) {
+		analysis.addFinding(CodeHiddenCharacters, "Note has hidden characters that can disguise what it says")
+		// Look for the rest without the hidden characters, which can be used
+		// to break up links and wording
+		text = strings.Map(func(r rune) rune {
+			if isHiddenChar(r) {
+				return -1
+			}
+			return r
+		}, text)
+	}
+
+	for _, rawURL := range urlRegex.FindAllString(text, -1) {
+		rawURL = strings.TrimRight(rawURL, ".,;:!?)]}")
+		if slices.Contains(analysis.URLs, rawURL) {
+			continue
+		}
+		analysis.URLs = append(analysis.URLs, rawURL)
+		analysis.addFinding(CodeURL, fmt.Sprintf("Note has a link: %s", rawURL))
+
+		host := urlHost(rawURL)
+		if imitated := imitatedDomain(host); imitated != "" {
+			analysis.addFinding(CodeHomoglyphDomain, fmt.Sprintf(
+				"Domain '%s' looks like '%s' but is not the same", host, imitated,
+			))
+		} else if !isASCII(host) || strings.Contains(host, "xn--") {
+			analysis.addFinding(CodeHomoglyphDomain, fmt.Sprintf(
+				"Domain '%s' has characters that can be mistaken for others", host,
+			))
+		}
+	}
+
+	var phrases []string
+	for _, re := range scamRegexes {
+		for _, phrase := range re.FindAllString(text, -1) {
+			phrase = strings.ToLower(phrase)
+			if !slices.Contains(phrases, phrase) {
+				phrases = append(phrases, phrase)
+			}
+		}
+	}
+	if len(phrases) > 0 {
+		analysis.addFinding(CodeScamWording, fmt.Sprintf(
+			"Note has wording that is common in scams: %s", strings.Join(phrases, ", "),
+		))
+	}
+}
+
+// addFinding adds a finding with the given code and message to the analysis
+func (analysis *Analysis) addFinding(code Code, message string) {
+	analysis.Findings = append(analysis.Findings, Finding{Code: code, Message: message})
+}
+
+// decodeMsgpack decodes the given MessagePack data and gives it as JSON.
+// Returns false if the data is not a single MessagePack value.
+func decodeMsgpack(data []byte) (string, bool) {
+	var value any
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode(&value); err != nil || dec.NumBytesRead() != len(data) {
+		return "", false
+	}
+
+	text, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return "", false
+	}
+
+	return string(text), true
+}
+
+// jsonValue converts a decoded MessagePack value to one that can be encoded
+// as JSON. Map keys become strings and bytes that are valid UTF-8 become text.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			if keyBytes, ok := key.([]byte); ok {
+				key = string(keyBytes)
+			}
+			m[fmt.Sprint(key)] = jsonValue(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, val := range v {
+			s[i] = jsonValue(val)
+		}
+		return s
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// urlHost gives the host of the given link in lowercase
+func urlHost(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// imitatedDomain gives the known domain that the given host looks like without
+// being that domain or one of its subdomains. Returns an empty string if the
+// host does not imitate any known domain.
+func imitatedDomain(host string) string {
+	hostSkeleton := skeleton(host)
+	for _, known := range KnownDomains {
+		if host == known || strings.HasSuffix(host, "."+known) {
+			return ""
+		}
+	}
+	for _, known := range KnownDomains {
+		knownSkeleton := skeleton(known)
+		if hostSkeleton == knownSkeleton || strings.HasSuffix(hostSkeleton, "."+knownSkeleton) {
+			return known
+		}
+	}
+	return ""
+}
+
+// skeleton gives what the given domain looks like by replacing characters that
+// can be mistaken for others with the characters they look like
+func skeleton(domain string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(domain) {
+		if s, ok := confusables[r]; ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return confusableSequences.Replace(b.String())
+}
+
+// isHiddenChar checks if the given character is invisible or changes the
+// direction of text
+func isHiddenChar(r rune) bool {
+	switch {
+	case r >= '\u200b' && r <= '\u200f', // Zero-width and direction marks
+		r >= '\u202a' && r <= '\u202e', // Direction embeddings and overrides
+		r >= '\u2066' && r <= '\u2069', // Direction isolates
+		r == '\u2060', r == '\ufeff':   // Word joiner and zero-width no-break space
+		return true
+	}
+	return false
+}
+
+// isASCII checks if the given string only has ASCII characters
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package note_analyzer_test
+
+import (
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/note_analyzer"
+)
+
+var _ = Describe("Analyze()", func() {
+	DescribeTable("decodes the note",
+		func(note []byte, format note_analyzer.Format, text string) {
+			analysis := note_analyzer.Analyze(note)
+			Expect(analysis.Format).To(Equal(format))
+			Expect(analysis.Text).To(Equal(text))
+		},
+		Entry("empty", []byte(nil), note_analyzer.FormatEmpty, ""),
+		Entry("UTF-8 text", []byte("héllo"), note_analyzer.FormatText, "héllo"),
+		Entry("ARC-2 JSON", []byte(`my-dapp:j{"a":1}`), note_analyzer.FormatARC2, `{"a":1}`),
+		Entry("ARC-2 MessagePack",
+			append([]byte("my-dapp:m"), msgpack.Encode(map[string]string{"a": "b"})...),
+			note_analyzer.FormatARC2, `{"a":"b"}`,
+		),
+		Entry("MessagePack",
+			msgpack.Encode(map[string]any{"msg": "hi", "n": 2}),
+			note_analyzer.FormatMsgpack, `{"msg":"hi","n":2}`,
+		),
+		Entry("binary", []byte{0xff, 0xfe, 0x00, 0x01}, note_analyzer.FormatBinary, ""),
+	)
+
+	It("gives the dApp name and data format of an ARC-2 note", func() {
+		analysis := note_analyzer.Analyze([]byte("my-dapp:uhello"))
+		Expect(analysis.DappName).To(Equal("my-dapp"))
+		Expect(analysis.DataFormat).To(Equal("u"))
+		Expect(analysis.Text).To(Equal("hello"))
+	})
+
+	DescribeTable("finds links",
+		func(note string, urls []string) {
+			analysis := note_analyzer.Analyze([]byte(note))
+			Expect(analysis.URLs).To(Equal(urls))
+			Expect(analysis.HasLinks()).To(Equal(len(urls) > 0))
+		},
+		Entry("no links", "thanks for lunch! 0.5 algo", []string(nil)),
+		Entry("link with scheme", "see https://example.com/a?b=c.", []string{"https://example.com/a?b=c"}),
+		Entry("link starting with www", "go to www.example.org now", []string{"www.example.org"}),
+		Entry("bare domain", "Visit algo-drop.xyz/claim", []string{"algo-drop.xyz/claim"}),
+		Entry("repeated link", "a.io and a.io", []string{"a.io"}),
+		Entry("ARC-2 dApp name", "free-algo.com:jnull", []string{"free-algo.com"}),
+	)
+
+	DescribeTable("finds suspicious things",
+		func(note string, codes []note_analyzer.Code) {
+			Expect(note_analyzer.Analyze([]byte(note)).Codes()).To(Equal(codes))
+		},
+		Entry("harmless note", "rent for june", []note_analyzer.Code(nil)),
+		Entry("link to a known domain", "https://perawallet.app",
+			[]note_analyzer.Code{note_analyzer.CodeURL},
+		),
+		Entry("subdomain of a known domain", "https://app.tinyman.org",
+			[]note_analyzer.Code{note_analyzer.CodeURL},
+		),
+		Entry("digit look-alike of a known domain", "https://a1gorand.foundation",
+			[]note_analyzer.Code{note_analyzer.CodeURL, note_analyzer.CodeHomoglyphDomain},
+		),
+		Entry("letter sequence look-alike of a known domain", "perawaIlet.app",
+			[]note_analyzer.Code{note_analyzer.CodeURL, note_analyzer.CodeHomoglyphDomain},
+		),
+		Entry("Cyrillic look-alike of a known domain", "https://аlgorand.com",
+			[]note_analyzer.Code{note_analyzer.CodeURL, note_analyzer.CodeHomoglyphDomain},
+		),
+		Entry("non-ASCII domain", "https://exаmple.com",
+			[]note_analyzer.Code{note_analyzer.CodeURL, note_analyzer.CodeHomoglyphDomain},
+		),
+		Entry("punycode domain", "https://xn--exmple-cua.com",
+			[]note_analyzer.Code{note_analyzer.CodeURL, note_analyzer.CodeHomoglyphDomain},
+		),
+		Entry("scam wording", "Congratulations! You have won an AIRDROP",
+			[]note_analyzer.Code{note_analyzer.CodeScamWording},
+		),
+		Entry("hidden characters breaking up a link", "example\u200b.com",
+			[]note_analyzer.Code{note_analyzer.CodeHiddenCharacters, note_analyzer.CodeURL},
+		),
+	)
+
+	It("lists the scam wording that was found", func() {
+		analysis := note_analyzer.Analyze([]byte("Claim your reward! Verify your wallet"))
+		Expect(analysis.Findings).To(HaveLen(1))
+		Expect(analysis.Findings[0].Message).To(ContainSubstring("claim, reward, verify your wallet"))
+	})
+})
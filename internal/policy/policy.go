@@ -0,0 +1,455 @@
+// Package policy is a per-wallet set of rules that decide whether a
+// transaction signing request is automatically allowed, automatically denied
+// or needs the user to be prompted. The rules are stored within an encrypted
+// database file in the wallet's directory.
+package policy
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/encdb"
+	"duckysigner/internal/note_analyzer"
+)
+
+// Action is what is done with a signing request that matches a rule
+type Action string
+
+const (
+	// ActionAllow signs the transaction without prompting the user
+	ActionAllow Action = "allow"
+	// ActionDeny rejects the transaction without prompting the user
+	ActionDeny Action = "deny"
+	// ActionPrompt prompts the user to approve the transaction
+	ActionPrompt Action = "prompt"
+)
+
+// DefaultDataFile is the default name of the encrypted database file that
+// contains the policy rules. It is stored in the wallet's directory, next to
+// the wallet's other encrypted database files.
+const DefaultDataFile = "policies.duckdb"
+
+// ErrRuleNotFound is the error returned when there is no rule with the given
+// ID
+var ErrRuleNotFound = errors.New("policy rule not found")
+
+// rulesSchema is the SQL statement for creating the policy rules table
+const rulesSchema = `
+CREATE TABLE IF NOT EXISTS db.policy_rules (
+    id UBIGINT PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    priority INTEGER NOT NULL,
+    disabled BOOLEAN NOT NULL,
+    action VARCHAR NOT NULL,
+    conditions VARCHAR NOT NULL
+);
+`
+
+// selectRulesSQL is the SQL statement for getting all the rules in the order
+// they are evaluated
+const selectRulesSQL = `SELECT id, name, priority, disabled, action, conditions
+FROM db.policy_rules ORDER BY priority, id`
+
+// writeMutex prevents rules from being added at the same time, which could
+// otherwise cause two rules to be given the same ID
+var writeMutex sync.Mutex
+
+type (
+	// Rule is a policy rule. A signing request matches a rule if it meets
+	// every condition of the rule.
+	Rule struct {
+		// ID of the rule, which is set when the rule is added
+		ID uint64 `json:"id"`
+		// Name of the rule for the user to identify it
+		Name string `json:"name"`
+		// Rules are evaluated from lowest to highest priority, and from oldest
+		// to newest for rules with the same priority. The first matching rule
+		// decides what is done with the request.
+		Priority int `json:"priority"`
+		// If the rule is not to be evaluated
+		Disabled bool `json:"disabled"`
+		// What is done with a request that matches the rule
+		Action Action `json:"action"`
+		// Conditions a request must meet to match the rule
+		Conditions Conditions `json:"conditions"`
+	}
+
+	// Conditions are the conditions of a rule. Conditions that are not set
+	// are met by any request.
+	Conditions struct {
+		// Base64-encoded IDs of the dApps the request can come from
+		DappIDs []string `json:"dapp_ids,omitempty"`
+		// Origins (e.g. "https://example.com") of the dApps the request can
+		// come from. The origin is given by the dApp when connecting, so it is
+		// not as trustworthy as the dApp ID.
+		DappOrigins []string `json:"dapp_origins,omitempty"`
+		// Addresses of the accounts that can be the sender of the transaction
+		Accounts []string `json:"accounts,omitempty"`
+		// Types of the transaction (e.g. "pay", "axfer")
+		TxnTypes []string `json:"txn_types,omitempty"`
+		// Addresses the transaction can send Algos or assets to. Transactions
+		// without a receiver do not meet this condition.
+		Receivers []string `json:"receivers,omitempty"`
+		// IDs of the assets the transaction can involve. Transactions that do
+		// not involve an asset do not meet this condition.
+		AssetIDs []uint64 `json:"asset_ids,omitempty"`
+		// IDs of the applications the transaction can call. Transactions that
+		// are not application calls do not meet this condition.
+		AppIDs []uint64 `json:"app_ids,omitempty"`
+		// The least amount of Algos (in microAlgos) or asset units the
+		// transaction can send
+		MinAmount *uint64 `json:"min_amount,omitempty"`
+		// The most amount of Algos (in microAlgos) or asset units the
+		// transaction can send
+		MaxAmount *uint64 `json:"max_amount,omitempty"`
+		// If the transaction's note must contain links. Combined with the deny
+		// action, this blocks signing transactions with notes that could lead
+		// to phishing sites.
+		NoteHasLinks bool `json:"note_has_links,omitempty"`
+	}
+
+	// Request is a transaction signing request to be evaluated against the
+	// rules
+	Request struct {
+		// Base64-encoded ID of the dApp making the request
+		DappID string
+		// URL of the dApp making the request
+		DappURL string
+		// The transaction to be signed
+		Txn types.Transaction
+		// If risks were found in the transaction. Allow rules are never
+		// applied to risky transactions, so the user is always prompted to
+		// acknowledge the risks.
+		Risky bool
+	}
+
+	// Decision is the outcome of evaluating a request against the rules
+	Decision struct {
+		// What is to be done with the request
+		Action Action `json:"action"`
+		// The rule that decided the action. It is nil if no rule matched.
+		Rule *Rule `json:"rule,omitempty"`
+	}
+)
+
+// Validate checks if the rule is valid
+func (rule *Rule) Validate() error {
+	switch rule.Action {
+	case ActionAllow, ActionDeny, ActionPrompt:
+	default:
+		return fmt.Errorf("invalid policy rule action: '%s'", rule.Action)
+	}
+
+	for _, addr := range slices.Concat(rule.Conditions.Accounts, rule.Conditions.Receivers) {
+		if _, err := types.DecodeAddress(addr); err != nil {
+			return fmt.Errorf("invalid address in policy rule: '%s'", addr)
+		}
+	}
+
+	minAmount, maxAmount := rule.Conditions.MinAmount, rule.Conditions.MaxAmount
+	if minAmount != nil && maxAmount != nil && *minAmount > *maxAmount {
+		return errors.New("policy rule minimum amount is greater than its maximum amount")
+	}
+
+	return nil
+}
+
+// Matches checks if the given request meets all the conditions
+func (cond *Conditions) Matches(req *Request) bool {
+	txn := &req.Txn
+	receiver, amount, hasAmount := txnTransfer(txn)
+
+	if len(cond.DappIDs) > 0 && !slices.Contains(cond.DappIDs, req.DappID) {
+		return false
+	}
+	if len(cond.DappOrigins) > 0 {
+		reqOrigin := Origin(req.DappURL)
+		if reqOrigin == "" || !slices.ContainsFunc(cond.DappOrigins, func(origin string) bool {
+			return Origin(origin) == reqOrigin
+		}) {
+			return false
+		}
+	}
+	if len(cond.Accounts) > 0 && !slices.Contains(cond.Accounts, txn.Sender.String()) {
+		return false
+	}
+	if len(cond.TxnTypes) > 0 && !slices.Contains(cond.TxnTypes, string(txn.Type)) {
+		return false
+	}
+	if len(cond.Receivers) > 0 && (receiver == "" || !slices.Contains(cond.Receivers, receiver)) {
+		return false
+	}
+	if len(cond.AssetIDs) > 0 {
+		assetID, ok := txnAssetID(txn)
+		if !ok || !slices.Contains(cond.AssetIDs, assetID) {
+			return false
+		}
+	}
+	if len(cond.AppIDs) > 0 && (txn.Type != types.ApplicationCallTx ||
+		!slices.Contains(cond.AppIDs, uint64(txn.ApplicationID))) {
+		return false
+	}
+	if cond.MinAmount != nil && (!hasAmount || amount < *cond.MinAmount) {
+		return false
+	}
+	if cond.MaxAmount != nil && (!hasAmount || amount > *cond.MaxAmount) {
+		return false
+	}
+	if cond.NoteHasLinks && !note_analyzer.Analyze(txn.Note).HasLinks() {
+		return false
+	}
+
+	return true
+}
+
+// Evaluate evaluates the given request against the given rules, which should
+// be in the order they are to be evaluated. The first enabled rule that
+// matches decides the action. The action is to prompt if no rule matches.
+func Evaluate(rules []Rule, req *Request) Decision {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Disabled || !rule.Conditions.Matches(req) {
+			continue
+		}
+		// Risky transactions always need the user's approval
+		if rule.Action == ActionAllow && req.Risky {
+			return Decision{Action: ActionPrompt, Rule: rule}
+		}
+		return Decision{Action: rule.Action, Rule: rule}
+	}
+
+	return Decision{Action: ActionPrompt}
+}
+
+// Origin gives the origin (scheme and host) of the given URL in lowercase.
+// Returns an empty string if the URL does not have a scheme and host.
+func Origin(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+}
+
+// Store is a set of policy rules stored within an encrypted DuckDB file
+type Store struct {
+	// Path of the encrypted database file that contains the rules
+	filePath string
+}
+
+// NewStore creates a new Store that is stored in the encrypted DuckDB file with
+// the given file path
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+// FilePath returns the path of the database file that contains the rules
+func (s *Store) FilePath() string {
+	return s.filePath
+}
+
+// ListRules retrieves all the rules, in the order they are evaluated, using
+// the given file encryption key to access the database file
+func (s *Store) ListRules(fileEncKey []byte) (rules []Rule, err error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, rulesSchema)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query(selectRulesSQL)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rule       Rule
+			action     string
+			conditions string
+		)
+
+		err = rows.Scan(&rule.ID, &rule.Name, &rule.Priority, &rule.Disabled, &action, &conditions)
+		if err != nil {
+			return
+		}
+
+		rule.Action = Action(action)
+		if err = json.Unmarshal([]byte(conditions), &rule.Conditions); err != nil {
+			return
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// AddRule validates and adds the given rule using the given file encryption key
+// to access the database file. The ID of the given rule is ignored. Returns
+// the rule as it was stored.
+func (s *Store) AddRule(rule Rule, fileEncKey []byte) (*Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, rulesSchema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var lastID sql.NullInt64
+	if err = tx.QueryRow("SELECT MAX(id) FROM db.policy_rules").Scan(&lastID); err != nil {
+		return nil, err
+	}
+	rule.ID = uint64(lastID.Int64) + 1
+
+	_, err = tx.Exec(
+		"INSERT INTO db.policy_rules VALUES (?, ?, ?, ?, ?, ?)",
+		rule.ID, rule.Name, rule.Priority, rule.Disabled, string(rule.Action), string(conditions),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// UpdateRule validates and replaces the rule that has the same ID as the given
+// rule using the given file encryption key to access the database file.
+// Returns ErrRuleNotFound if there is no rule with that ID.
+func (s *Store) UpdateRule(rule Rule, fileEncKey []byte) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, rulesSchema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec(
+		`UPDATE db.policy_rules
+		SET name = ?, priority = ?, disabled = ?, action = ?, conditions = ?
+		WHERE id = ?`,
+		rule.Name, rule.Priority, rule.Disabled, string(rule.Action), string(conditions), rule.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkRuleAffected(result)
+}
+
+// RemoveRule removes the rule with the given ID using the given file
+// encryption key to access the database file. Returns ErrRuleNotFound if there
+// is no rule with that ID.
+func (s *Store) RemoveRule(id uint64, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, rulesSchema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM db.policy_rules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return checkRuleAffected(result)
+}
+
+// Evaluate evaluates the given request against the stored rules using the
+// given file encryption key to access the database file
+func (s *Store) Evaluate(req *Request, fileEncKey []byte) (Decision, error) {
+	rules, err := s.ListRules(fileEncKey)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	return Evaluate(rules, req), nil
+}
+
+/*******************************************************************************
+ * Helpers
+ ******************************************************************************/
+
+// checkRuleAffected returns ErrRuleNotFound if the given result of a statement
+// did not affect any rule
+func checkRuleAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// txnTransfer gives the receiver and the amount of Algos or asset units sent by
+// the given transaction. The amount is only given for payments and asset
+// transfers.
+func txnTransfer(txn *types.Transaction) (receiver string, amount uint64, hasAmount bool) {
+	switch txn.Type {
+	case types.PaymentTx:
+		return txn.Receiver.String(), uint64(txn.Amount), true
+	case types.AssetTransferTx:
+		return txn.AssetReceiver.String(), txn.AssetAmount, true
+	}
+	return "", 0, false
+}
+
+// txnAssetID gives the ID of the asset the given transaction involves, if any
+func txnAssetID(txn *types.Transaction) (uint64, bool) {
+	switch txn.Type {
+	case types.AssetTransferTx:
+		return uint64(txn.XferAsset), true
+	case types.AssetConfigTx:
+		return uint64(txn.ConfigAsset), true
+	case types.AssetFreezeTx:
+		return uint64(txn.FreezeAsset), true
+	}
+	return 0, false
+}
@@ -0,0 +1,217 @@
+package policy_test
+
+import (
+	"path/filepath"
+
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/policy"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+var fileEncKey = []byte("01234567890123456789012345678901")
+
+var testParams = types.SuggestedParams{
+	Fee:             1000,
+	GenesisID:       "testnet-v1.0",
+	GenesisHash:     make([]byte, 32),
+	FirstRoundValid: 1000,
+	LastRoundValid:  2000,
+	FlatFee:         true,
+}
+
+// amount gives a pointer to the given amount
+func amount(a uint64) *uint64 {
+	return &a
+}
+
+// paymentRequest creates a request for a payment of the given amount from
+// account A to the given receiver
+func paymentRequest(receiver string, microAlgos uint64) *policy.Request {
+	txn, err := transaction.MakePaymentTxn(testAddrA, receiver, microAlgos, nil, "", testParams)
+	Expect(err).ToNot(HaveOccurred())
+	return &policy.Request{DappID: "dapp1", DappURL: "https://Example.com/app", Txn: txn}
+}
+
+var _ = Describe("Conditions.Matches()", func() {
+	DescribeTable("checks if a request meets the conditions",
+		func(cond policy.Conditions, req *policy.Request, matches bool) {
+			Expect(cond.Matches(req)).To(Equal(matches))
+		},
+		Entry("no conditions", policy.Conditions{}, paymentRequest(testAddrB, 1), true),
+		Entry("dApp ID", policy.Conditions{DappIDs: []string{"dapp1"}}, paymentRequest(testAddrB, 1), true),
+		Entry("other dApp ID", policy.Conditions{DappIDs: []string{"dapp2"}}, paymentRequest(testAddrB, 1), false),
+		Entry("dApp origin",
+			policy.Conditions{DappOrigins: []string{"https://example.com"}}, paymentRequest(testAddrB, 1), true),
+		Entry("other dApp origin",
+			policy.Conditions{DappOrigins: []string{"http://example.com"}}, paymentRequest(testAddrB, 1), false),
+		Entry("account", policy.Conditions{Accounts: []string{testAddrA}}, paymentRequest(testAddrB, 1), true),
+		Entry("other account", policy.Conditions{Accounts: []string{testAddrB}}, paymentRequest(testAddrB, 1), false),
+		Entry("transaction type", policy.Conditions{TxnTypes: []string{"pay"}}, paymentRequest(testAddrB, 1), true),
+		Entry("other transaction type",
+			policy.Conditions{TxnTypes: []string{"axfer"}}, paymentRequest(testAddrB, 1), false),
+		Entry("receiver in allowlist",
+			policy.Conditions{Receivers: []string{testAddrB}}, paymentRequest(testAddrB, 1), true),
+		Entry("receiver not in allowlist",
+			policy.Conditions{Receivers: []string{testAddrB}}, paymentRequest(testAddrA, 1), false),
+		Entry("asset ID of a payment", policy.Conditions{AssetIDs: []uint64{1}}, paymentRequest(testAddrB, 1), false),
+		Entry("app ID of a payment", policy.Conditions{AppIDs: []uint64{1}}, paymentRequest(testAddrB, 1), false),
+		Entry("amount within range",
+			policy.Conditions{MinAmount: amount(10), MaxAmount: amount(100)}, paymentRequest(testAddrB, 100), true),
+		Entry("amount below minimum", policy.Conditions{MinAmount: amount(10)}, paymentRequest(testAddrB, 9), false),
+		Entry("amount above maximum", policy.Conditions{MaxAmount: amount(100)}, paymentRequest(testAddrB, 101), false),
+		Entry("note without links", policy.Conditions{NoteHasLinks: true}, paymentRequest(testAddrB, 1), false),
+	)
+
+	It("checks if the note of the transaction contains links", func() {
+		req := paymentRequest(testAddrB, 1)
+		req.Txn.Note = []byte("Claim your airdrop at algo-drop.xyz")
+		Expect((&policy.Conditions{NoteHasLinks: true}).Matches(req)).To(BeTrue())
+	})
+
+	It("checks the asset ID and amount of asset transfers", func() {
+		txn, err := transaction.MakeAssetTransferTxn(testAddrA, testAddrB, 50, nil, testParams, "", 1234)
+		Expect(err).ToNot(HaveOccurred())
+		req := &policy.Request{Txn: txn}
+
+		Expect((&policy.Conditions{AssetIDs: []uint64{1234}, MaxAmount: amount(50)}).Matches(req)).To(BeTrue())
+		Expect((&policy.Conditions{AssetIDs: []uint64{1}}).Matches(req)).To(BeFalse())
+		Expect((&policy.Conditions{MaxAmount: amount(49)}).Matches(req)).To(BeFalse())
+	})
+
+	It("checks the app ID of application calls", func() {
+		sender, err := types.DecodeAddress(testAddrA)
+		Expect(err).ToNot(HaveOccurred())
+		txn, err := transaction.MakeApplicationNoOpTx(
+			42, nil, nil, nil, nil, testParams, sender, nil, types.Digest{}, [32]byte{}, types.Address{},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		req := &policy.Request{Txn: txn}
+
+		Expect((&policy.Conditions{AppIDs: []uint64{42}}).Matches(req)).To(BeTrue())
+		Expect((&policy.Conditions{AppIDs: []uint64{43}}).Matches(req)).To(BeFalse())
+		Expect((&policy.Conditions{MinAmount: amount(0)}).Matches(req)).To(BeFalse(), "No amount is sent")
+	})
+})
+
+var _ = Describe("Evaluate()", func() {
+	rules := []policy.Rule{
+		{ID: 1, Action: policy.ActionDeny, Conditions: policy.Conditions{Receivers: []string{testAddrA}}},
+		{ID: 2, Action: policy.ActionAllow, Disabled: true},
+		{ID: 3, Action: policy.ActionAllow, Conditions: policy.Conditions{MaxAmount: amount(1000)}},
+	}
+
+	It("uses the first enabled rule that matches", func() {
+		decision := policy.Evaluate(rules, paymentRequest(testAddrA, 1))
+		Expect(decision.Action).To(Equal(policy.ActionDeny))
+		Expect(decision.Rule.ID).To(BeEquivalentTo(1))
+
+		decision = policy.Evaluate(rules, paymentRequest(testAddrB, 1))
+		Expect(decision.Action).To(Equal(policy.ActionAllow))
+		Expect(decision.Rule.ID).To(BeEquivalentTo(3))
+	})
+
+	It("prompts when no rule matches", func() {
+		decision := policy.Evaluate(rules, paymentRequest(testAddrB, 1001))
+		Expect(decision.Action).To(Equal(policy.ActionPrompt))
+		Expect(decision.Rule).To(BeNil())
+	})
+
+	It("prompts instead of allowing risky transactions", func() {
+		req := paymentRequest(testAddrB, 1)
+		req.Risky = true
+		decision := policy.Evaluate(rules, req)
+		Expect(decision.Action).To(Equal(policy.ActionPrompt))
+		Expect(decision.Rule.ID).To(BeEquivalentTo(3))
+
+		req = paymentRequest(testAddrA, 1)
+		req.Risky = true
+		Expect(policy.Evaluate(rules, req).Action).To(Equal(policy.ActionDeny), "Still denies")
+	})
+})
+
+var _ = Describe("Rule.Validate()", func() {
+	It("fails for an invalid action", func() {
+		Expect((&policy.Rule{Action: "maybe"}).Validate()).ToNot(Succeed())
+	})
+
+	It("fails for an invalid address", func() {
+		rule := policy.Rule{Action: policy.ActionAllow, Conditions: policy.Conditions{Receivers: []string{"foo"}}}
+		Expect(rule.Validate()).ToNot(Succeed())
+	})
+
+	It("fails if the minimum amount is greater than the maximum amount", func() {
+		rule := policy.Rule{
+			Action:     policy.ActionAllow,
+			Conditions: policy.Conditions{MinAmount: amount(2), MaxAmount: amount(1)},
+		}
+		Expect(rule.Validate()).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Store", func() {
+	var store *policy.Store
+
+	BeforeEach(func() {
+		store = policy.NewStore(filepath.Join(GinkgoT().TempDir(), policy.DefaultDataFile))
+	})
+
+	It("adds, lists, updates and removes rules", func() {
+		By("Adding rules")
+		first, err := store.AddRule(policy.Rule{
+			Name:       "Small payments",
+			Priority:   10,
+			Action:     policy.ActionAllow,
+			Conditions: policy.Conditions{TxnTypes: []string{"pay"}, MaxAmount: amount(1000)},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.ID).To(BeEquivalentTo(1))
+		second, err := store.AddRule(policy.Rule{
+			Name:       "Block account",
+			Priority:   0,
+			Action:     policy.ActionDeny,
+			Conditions: policy.Conditions{Receivers: []string{testAddrA}},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second.ID).To(BeEquivalentTo(2))
+
+		By("Listing rules in order of priority")
+		rules, err := store.ListRules(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules).To(Equal([]policy.Rule{*second, *first}))
+
+		By("Evaluating a request against the rules")
+		decision, err := store.Evaluate(paymentRequest(testAddrB, 1), fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Action).To(Equal(policy.ActionAllow))
+
+		By("Updating a rule")
+		first.Disabled = true
+		Expect(store.UpdateRule(*first, fileEncKey)).To(Succeed())
+		decision, err = store.Evaluate(paymentRequest(testAddrB, 1), fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Action).To(Equal(policy.ActionPrompt))
+
+		By("Removing a rule")
+		Expect(store.RemoveRule(second.ID, fileEncKey)).To(Succeed())
+		rules, err = store.ListRules(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules).To(Equal([]policy.Rule{*first}))
+	})
+
+	It("fails to update or remove a rule that does not exist", func() {
+		Expect(store.UpdateRule(policy.Rule{ID: 99, Action: policy.ActionDeny}, fileEncKey)).
+			To(MatchError(policy.ErrRuleNotFound))
+		Expect(store.RemoveRule(99, fileEncKey)).To(MatchError(policy.ErrRuleNotFound))
+	})
+
+	It("does not add an invalid rule", func() {
+		_, err := store.AddRule(policy.Rule{Action: "maybe"}, fileEncKey)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,676 @@
+// Package spend_limit keeps track of the Algos and assets sent by signed
+// transactions and limits how much can be sent within a rolling window of
+// time, per account and per dApp connect session. The limits and the record of
+// what was sent are stored within an encrypted database file in the wallet's
+// directory.
+package spend_limit
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/encdb"
+)
+
+// Action is what is done with a signing request that would exceed a limit
+type Action string
+
+const (
+	// ActionDeny rejects the request
+	ActionDeny Action = "deny"
+	// ActionPrompt prompts the user to approve the request and acknowledge
+	// that the limit will be exceeded
+	ActionPrompt Action = "prompt"
+)
+
+// DefaultDataFile is the default name of the encrypted database file that
+// contains the spending limits and the record of what was sent. It is stored
+// in the wallet's directory, next to the wallet's other encrypted database
+// files.
+const DefaultDataFile = "spend_limits.duckdb"
+
+// MaxWindow is the longest window of time a limit can have. Records of what
+// was sent are kept for this long.
+const MaxWindow = 31 * 24 * time.Hour
+
+// ReservationTimeout is how long a reservation counts toward the limits if it
+// is neither confirmed nor released, such as when the wallet is closed while
+// the user is being asked to approve the transactions it is for
+const ReservationTimeout = time.Hour
+
+// ErrLimitNotFound is the error returned when there is no limit with the given
+// ID
+var ErrLimitNotFound = errors.New("spending limit not found")
+
+// schema is the SQL statement for creating the spending limit tables
+const schema = `
+CREATE TABLE IF NOT EXISTS db.spend_limits (
+    id UBIGINT PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    account VARCHAR NOT NULL,
+    session_id VARCHAR NOT NULL,
+    asset_id UBIGINT NOT NULL,
+    window_secs UBIGINT NOT NULL,
+    max_amount UBIGINT NOT NULL,
+    action VARCHAR NOT NULL
+);
+CREATE TABLE IF NOT EXISTS db.spend_records (
+    ts TIMESTAMP NOT NULL,
+    account VARCHAR NOT NULL,
+    session_id VARCHAR NOT NULL,
+    asset_id UBIGINT NOT NULL,
+    amount UBIGINT NOT NULL,
+    reservation VARCHAR
+);
+`
+
+// selectLimitsSQL is the SQL statement for getting all the limits
+const selectLimitsSQL = `SELECT id, name, account, session_id, asset_id,
+window_secs, max_amount, action FROM db.spend_limits ORDER BY id`
+
+// spentSQL is the SQL statement for getting the total amount of an asset sent
+// or reserved since a date-time, which is capped at the largest UBIGINT so that
+// it does not overflow. Reservations made before the second date-time given
+// are left out. Conditions are to be appended to it.
+const spentSQL = `SELECT CAST(LEAST(COALESCE(SUM(amount), 0), 18446744073709551615) AS UBIGINT)
+FROM db.spend_records WHERE asset_id = ? AND ts >= ?
+AND (reservation IS NULL OR ts >= ?)`
+
+// insertRecordSQL is the SQL statement for recording an outflow. The amount is
+// given as text because database/sql does not take uint64 values with the high
+// bit set.
+const insertRecordSQL = `INSERT INTO db.spend_records
+VALUES (?, ?, ?, ?, CAST(? AS UBIGINT), ?)`
+
+// pruneRecordsSQL is the SQL statement for removing the records older than
+// the first date-time given and the reservations made before the second
+const pruneRecordsSQL = `DELETE FROM db.spend_records
+WHERE ts < ? OR (reservation IS NOT NULL AND ts < ?)`
+
+// writeMutex prevents the database from being written to at the same time,
+// which could otherwise cause two limits to be given the same ID
+var writeMutex sync.Mutex
+
+type (
+	// Limit is a limit on the total amount of Algos or an asset that can be
+	// sent within a rolling window of time
+	Limit struct {
+		// ID of the limit, which is set when the limit is added
+		ID uint64 `json:"id"`
+		// Name of the limit for the user to identify it
+		Name string `json:"name"`
+		// Address of the account the limit is for. The limit is for all
+		// accounts together if it is empty.
+		Account string `json:"account,omitempty"`
+		// Base64-encoded ID of the dApp connect session the limit is for. The
+		// limit is for all sessions together if it is empty.
+		SessionID string `json:"session_id,omitempty"`
+		// ID of the asset the limit is for. The limit is for Algos if it is 0.
+		AssetID uint64 `json:"asset_id"`
+		// Length of the rolling window of time in seconds
+		WindowSecs uint64 `json:"window_secs"`
+		// The most amount of microAlgos or asset units that can be sent within
+		// the window
+		Max uint64 `json:"max"`
+		// What is done with a request that would exceed the limit
+		Action Action `json:"action"`
+	}
+
+	// Reservation is a set of outflows that count toward the limits while
+	// the transactions that send them wait to be signed
+	Reservation struct {
+		// Random ID of the reservation
+		ID string
+		// Base64-encoded ID of the dApp connect session the outflows are sent
+		// through
+		SessionID string
+		// Outflows that are reserved
+		Outflows []Outflow
+	}
+
+	// Status is a limit along with how much has been sent within its current
+	// window
+	Status struct {
+		Limit Limit `json:"limit"`
+		// Amount of microAlgos or asset units sent within the current window
+		Spent uint64 `json:"spent"`
+	}
+
+	// Outflow is an amount of Algos or an asset sent from an account
+	Outflow struct {
+		// Address of the account sending the Algos or asset
+		Account string
+		// ID of the asset being sent, or 0 for Algos
+		AssetID uint64
+		// Amount of microAlgos or asset units being sent
+		Amount uint64
+		// Whether the account is closed out of the Algos or asset, which sends
+		// whatever is left of the balance on top of the amount. The total
+		// amount sent cannot be known, so it exceeds any limit it counts
+		// toward.
+		CloseOut bool
+	}
+
+	// Exceeded is a limit that a signing request would exceed
+	Exceeded struct {
+		Limit Limit `json:"limit"`
+		// Amount sent within the current window before the request
+		Spent uint64 `json:"spent"`
+		// Amount the request would send that counts toward the limit
+		Amount uint64 `json:"amount"`
+		// Whether the request would close out an account, which sends an
+		// unknown amount on top of Amount
+		CloseOut bool `json:"close_out"`
+	}
+)
+
+// Validate checks if the limit is valid
+func (limit *Limit) Validate() error {
+	switch limit.Action {
+	case ActionDeny, ActionPrompt:
+	default:
+		return fmt.Errorf("invalid spending limit action: '%s'", limit.Action)
+	}
+
+	if limit.Account != "" {
+		if _, err := types.DecodeAddress(limit.Account); err != nil {
+			return fmt.Errorf("invalid spending limit account: '%s'", limit.Account)
+		}
+	}
+
+	if limit.WindowSecs == 0 || limit.Window() > MaxWindow {
+		return fmt.Errorf("spending limit window must be between 1 second and %s", MaxWindow)
+	}
+
+	return nil
+}
+
+// Window gives the length of the limit's rolling window of time
+func (limit *Limit) Window() time.Duration {
+	return time.Duration(limit.WindowSecs) * time.Second
+}
+
+// Message gives a human-readable description of how the limit would be
+// exceeded
+func (e *Exceeded) Message() string {
+	unit := "microAlgos"
+	if e.Limit.AssetID != 0 {
+		unit = fmt.Sprintf("units of asset %d", e.Limit.AssetID)
+	}
+
+	name := e.Limit.Name
+	if name == "" {
+		name = fmt.Sprintf("#%d", e.Limit.ID)
+	}
+
+	if e.CloseOut {
+		return fmt.Sprintf(
+			"Closing out the account would send all of its %s and exceed spending limit %s, which allows %d every %s (%d already sent).",
+			unit, name, e.Limit.Max, e.Limit.Window(), e.Spent,
+		)
+	}
+
+	return fmt.Sprintf(
+		"Sending %d %s would exceed spending limit %s, which allows %d every %s (%d already sent).",
+		e.Amount, unit, name, e.Limit.Max, e.Limit.Window(), e.Spent,
+	)
+}
+
+// applies checks if the given outflow through the dApp connect session with
+// the given ID counts toward the limit
+func (limit *Limit) applies(sessionID string, outflow Outflow) bool {
+	return limit.AssetID == outflow.AssetID &&
+		(limit.Account == "" || limit.Account == outflow.Account) &&
+		(limit.SessionID == "" || limit.SessionID == sessionID)
+}
+
+// Outflows gives the amounts of Algos and assets the given transaction sends
+// from the sender's account. The fee is included in the amount of Algos sent.
+// A transaction that closes out the sender's account or asset holding gives an
+// outflow that is marked as a close-out.
+func Outflows(txn types.Transaction) []Outflow {
+	sender := txn.Sender.String()
+	algos := Outflow{Account: sender, Amount: uint64(txn.Fee)}
+
+	var outflows []Outflow
+	switch txn.Type {
+	case types.PaymentTx:
+		algos.Amount = addCapped(algos.Amount, uint64(txn.Amount))
+		algos.CloseOut = !txn.CloseRemainderTo.IsZero()
+	case types.AssetTransferTx:
+		// Assets clawed back are not sent from the sender's account
+		closeOut := !txn.AssetCloseTo.IsZero()
+		if txn.AssetSender.IsZero() && (txn.AssetAmount > 0 || closeOut) {
+			outflows = append(outflows, Outflow{
+				Account:  sender,
+				AssetID:  uint64(txn.XferAsset),
+				Amount:   txn.AssetAmount,
+				CloseOut: closeOut,
+			})
+		}
+	}
+
+	return append([]Outflow{algos}, outflows...)
+}
+
+// Store is a set of spending limits and a record of what was sent stored
+// within an encrypted DuckDB file
+type Store struct {
+	// Path of the encrypted database file
+	filePath string
+}
+
+// NewStore creates a new Store that is stored in the encrypted DuckDB file with
+// the given file path
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+// FilePath returns the path of the database file
+func (s *Store) FilePath() string {
+	return s.filePath
+}
+
+// ListLimits retrieves all the limits along with how much has been sent within
+// their current windows using the given file encryption key to access the
+// database file
+func (s *Store) ListLimits(fileEncKey []byte) (statuses []Status, err error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	limits, err := queryLimits(db)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, limit := range limits {
+		spent, err := querySpent(db, &limit, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{Limit: limit, Spent: spent})
+	}
+
+	return
+}
+
+// AddLimit validates and adds the given limit using the given file encryption
+// key to access the database file. The ID of the given limit is ignored.
+// Returns the limit as it was stored.
+func (s *Store) AddLimit(limit Limit, fileEncKey []byte) (*Limit, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var lastID sql.NullInt64
+	if err = tx.QueryRow("SELECT MAX(id) FROM db.spend_limits").Scan(&lastID); err != nil {
+		return nil, err
+	}
+	limit.ID = uint64(lastID.Int64) + 1
+
+	_, err = tx.Exec(
+		"INSERT INTO db.spend_limits VALUES (?, ?, ?, ?, ?, ?, CAST(? AS UBIGINT), ?)",
+		limit.ID, limit.Name, limit.Account, limit.SessionID, limit.AssetID,
+		limit.WindowSecs, strconv.FormatUint(limit.Max, 10), string(limit.Action),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &limit, nil
+}
+
+// RemoveLimit removes the limit with the given ID using the given file
+// encryption key to access the database file. Returns ErrLimitNotFound if there
+// is no limit with that ID.
+func (s *Store) RemoveLimit(id uint64, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM db.spend_limits WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLimitNotFound
+	}
+
+	return nil
+}
+
+// Check checks if sending the given outflows through the dApp connect session
+// with the given ID would exceed any of the limits using the given file
+// encryption key to access the database file. Returns the limits that would be
+// exceeded, which include every limit a close-out counts toward.
+func (s *Store) Check(sessionID string, outflows []Outflow, fileEncKey []byte) ([]Exceeded, error) {
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return check(db, sessionID, outflows, time.Now())
+}
+
+// Reserve checks if sending the given outflows through the dApp connect
+// session with the given ID would exceed any of the limits and reserves them
+// unless a limit that denies them would be exceeded, using the given file
+// encryption key to access the database file. Reserved outflows count toward
+// the limits when other outflows are checked, until the reservation is
+// confirmed with Confirm once the outflows are signed or released with Release
+// if they are not. Returns the reservation, which is nil if the outflows were
+// not reserved, and the limits that would be exceeded.
+func (s *Store) Reserve(
+	sessionID string,
+	outflows []Outflow,
+	fileEncKey []byte,
+) (reservation *Reservation, exceeded []Exceeded, err error) {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	now := time.Now()
+	if exceeded, err = check(db, sessionID, outflows, now); err != nil {
+		return nil, nil, err
+	}
+	for _, e := range exceeded {
+		if e.Limit.Action == ActionDeny {
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	reservation = &Reservation{ID: rand.Text(), SessionID: sessionID, Outflows: outflows}
+	if err = insertRecords(tx, now, sessionID, outflows, reservation.ID); err != nil {
+		return nil, nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return
+}
+
+// Confirm records that the outflows of the given reservation were sent using
+// the given file encryption key to access the database file. The outflows are
+// recorded even if the reservation has run out. Records older than MaxWindow
+// are removed.
+func (s *Store) Confirm(reservation *Reservation, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE db.spend_records SET ts = ?, reservation = NULL WHERE reservation = ?",
+		now.UTC(), reservation.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// The reservation ran out and was removed
+		if err = insertRecords(tx, now, reservation.SessionID, reservation.Outflows, ""); err != nil {
+			return err
+		}
+	}
+
+	if err = pruneRecords(tx, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Release removes the given reservation without recording its outflows as
+// sent using the given file encryption key to access the database file
+func (s *Store) Release(reservation *Reservation, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM db.spend_records WHERE reservation = ?", reservation.ID)
+	return err
+}
+
+// Record records that the given outflows were sent through the dApp connect
+// session with the given ID using the given file encryption key to access the
+// database file. Records older than MaxWindow are removed.
+func (s *Store) Record(sessionID string, outflows []Outflow, fileEncKey []byte) error {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	db, err := encdb.Open(s.filePath, fileEncKey, schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err = insertRecords(tx, now, sessionID, outflows, ""); err != nil {
+		return err
+	}
+	if err = pruneRecords(tx, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+/*******************************************************************************
+ * Helpers
+ ******************************************************************************/
+
+// queryLimits gets all the limits from the given database
+func queryLimits(db *sql.DB) (limits []Limit, err error) {
+	rows, err := db.Query(selectLimitsSQL)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			limit  Limit
+			action string
+		)
+
+		err = rows.Scan(
+			&limit.ID,
+			&limit.Name,
+			&limit.Account,
+			&limit.SessionID,
+			&limit.AssetID,
+			&limit.WindowSecs,
+			&limit.Max,
+			&action,
+		)
+		if err != nil {
+			return
+		}
+
+		limit.Action = Action(action)
+		limits = append(limits, limit)
+	}
+
+	return limits, rows.Err()
+}
+
+// check finds the limits that sending the given outflows through the dApp
+// connect session with the given ID at the given date-time would exceed, using
+// the given database
+func check(db *sql.DB, sessionID string, outflows []Outflow, now time.Time) (exceeded []Exceeded, err error) {
+	limits, err := queryLimits(db)
+	if err != nil {
+		return
+	}
+
+	for _, limit := range limits {
+		var amount uint64
+		var closeOut bool
+		for _, outflow := range outflows {
+			if limit.applies(sessionID, outflow) {
+				amount = addCapped(amount, outflow.Amount)
+				closeOut = closeOut || outflow.CloseOut
+			}
+		}
+		if amount == 0 && !closeOut {
+			continue
+		}
+
+		spent, err := querySpent(db, &limit, now)
+		if err != nil {
+			return nil, err
+		}
+		if closeOut || amount > limit.Max || spent > limit.Max-amount {
+			exceeded = append(exceeded, Exceeded{Limit: limit, Spent: spent, Amount: amount, CloseOut: closeOut})
+		}
+	}
+
+	return
+}
+
+// execer is what can execute SQL statements, which is either a database or a
+// database transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertRecords records the given outflows sent through the dApp connect
+// session with the given ID at the given date-time as part of the reservation
+// with the given ID, or as sent if the reservation ID is empty
+func insertRecords(db execer, ts time.Time, sessionID string, outflows []Outflow, reservationID string) error {
+	var reservation sql.NullString
+	if reservationID != "" {
+		reservation = sql.NullString{String: reservationID, Valid: true}
+	}
+
+	for _, outflow := range outflows {
+		if outflow.Amount == 0 {
+			continue
+		}
+		_, err := db.Exec(
+			insertRecordSQL,
+			ts.UTC(), outflow.Account, sessionID, outflow.AssetID,
+			strconv.FormatUint(outflow.Amount, 10), reservation,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneRecords removes the records that are older than MaxWindow and the
+// reservations that have run out at the given date-time
+func pruneRecords(db execer, now time.Time) error {
+	_, err := db.Exec(pruneRecordsSQL, now.UTC().Add(-MaxWindow), now.UTC().Add(-ReservationTimeout))
+	return err
+}
+
+// addCapped adds the given amounts, giving the largest uint64 instead of
+// overflowing
+func addCapped(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// querySpent gets the total amount sent or reserved that counts toward the
+// given limit within the window that ends at the given date-time
+func querySpent(db *sql.DB, limit *Limit, end time.Time) (spent uint64, err error) {
+	query := spentSQL
+	args := []any{limit.AssetID, end.UTC().Add(-limit.Window()), end.UTC().Add(-ReservationTimeout)}
+
+	if limit.Account != "" {
+		query += " AND account = ?"
+		args = append(args, limit.Account)
+	}
+	if limit.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, limit.SessionID)
+	}
+
+	err = db.QueryRow(query, args...).Scan(&spent)
+	return
+}
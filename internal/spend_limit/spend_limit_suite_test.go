@@ -0,0 +1,13 @@
+package spend_limit_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSpendLimit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Spending Limit Suite")
+}
@@ -0,0 +1,314 @@
+package spend_limit_test
+
+import (
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/spend_limit"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+var fileEncKey = []byte("01234567890123456789012345678901")
+
+var testParams = types.SuggestedParams{
+	Fee:             1000,
+	GenesisID:       "testnet-v1.0",
+	GenesisHash:     make([]byte, 32),
+	FirstRoundValid: 1000,
+	LastRoundValid:  2000,
+	FlatFee:         true,
+}
+
+var _ = Describe("Outflows()", func() {
+	It("includes the amount and fee of a payment", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 5000, nil, "", testParams)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spend_limit.Outflows(txn)).To(Equal([]spend_limit.Outflow{
+			{Account: testAddrA, Amount: 6000},
+		}))
+	})
+
+	It("includes the asset amount and fee of an asset transfer", func() {
+		txn, err := transaction.MakeAssetTransferTxn(testAddrA, testAddrB, 25, nil, testParams, "", 1234)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spend_limit.Outflows(txn)).To(Equal([]spend_limit.Outflow{
+			{Account: testAddrA, Amount: 1000},
+			{Account: testAddrA, AssetID: 1234, Amount: 25},
+		}))
+	})
+
+	It("marks a payment that closes out the account", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 0, nil, testAddrB, testParams)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spend_limit.Outflows(txn)).To(Equal([]spend_limit.Outflow{
+			{Account: testAddrA, Amount: 1000, CloseOut: true},
+		}))
+	})
+
+	It("marks an asset transfer that closes out the asset holding", func() {
+		txn, err := transaction.MakeAssetTransferTxn(testAddrA, testAddrB, 0, nil, testParams, testAddrB, 1234)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spend_limit.Outflows(txn)).To(Equal([]spend_limit.Outflow{
+			{Account: testAddrA, Amount: 1000},
+			{Account: testAddrA, AssetID: 1234, CloseOut: true},
+		}))
+	})
+
+	It("only includes the fee of a clawback", func() {
+		txn, err := transaction.MakeAssetRevocationTxn(testAddrA, testAddrB, 25, testAddrA, nil, testParams, 1234)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spend_limit.Outflows(txn)).To(Equal([]spend_limit.Outflow{
+			{Account: testAddrA, Amount: 1000},
+		}))
+	})
+})
+
+var _ = Describe("Limit.Validate()", func() {
+	DescribeTable("checks the limit",
+		func(limit spend_limit.Limit, valid bool) {
+			if valid {
+				Expect(limit.Validate()).To(Succeed())
+			} else {
+				Expect(limit.Validate()).ToNot(Succeed())
+			}
+		},
+		Entry("valid", spend_limit.Limit{Action: spend_limit.ActionDeny, WindowSecs: 3600}, true),
+		Entry("invalid action", spend_limit.Limit{Action: "allow", WindowSecs: 3600}, false),
+		Entry("invalid account",
+			spend_limit.Limit{Action: spend_limit.ActionDeny, WindowSecs: 3600, Account: "foo"}, false),
+		Entry("no window", spend_limit.Limit{Action: spend_limit.ActionDeny}, false),
+		Entry("window that is too long", spend_limit.Limit{
+			Action:     spend_limit.ActionDeny,
+			WindowSecs: uint64((spend_limit.MaxWindow + time.Second).Seconds()),
+		}, false),
+	)
+})
+
+var _ = Describe("Store", func() {
+	var store *spend_limit.Store
+
+	BeforeEach(func() {
+		store = spend_limit.NewStore(filepath.Join(GinkgoT().TempDir(), spend_limit.DefaultDataFile))
+	})
+
+	It("adds, lists and removes limits", func() {
+		limit, err := store.AddLimit(spend_limit.Limit{
+			Name:       "Daily",
+			Account:    testAddrA,
+			WindowSecs: 86400,
+			Max:        1_000_000,
+			Action:     spend_limit.ActionPrompt,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(limit.ID).To(BeEquivalentTo(1))
+
+		Expect(store.Record("", []spend_limit.Outflow{{Account: testAddrA, Amount: 300}}, fileEncKey)).To(Succeed())
+
+		statuses, err := store.ListLimits(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(Equal([]spend_limit.Status{{Limit: *limit, Spent: 300}}))
+
+		Expect(store.RemoveLimit(limit.ID, fileEncKey)).To(Succeed())
+		Expect(store.RemoveLimit(limit.ID, fileEncKey)).To(MatchError(spend_limit.ErrLimitNotFound))
+		statuses, err = store.ListLimits(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(BeEmpty())
+	})
+
+	It("finds limits that would be exceeded", func() {
+		accountLimit, err := store.AddLimit(spend_limit.Limit{
+			Account: testAddrA, WindowSecs: 3600, Max: 1000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		sessionLimit, err := store.AddLimit(spend_limit.Limit{
+			SessionID: "session1", AssetID: 1234, WindowSecs: 3600, Max: 10, Action: spend_limit.ActionPrompt,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Recording what was sent")
+		Expect(store.Record("session1", []spend_limit.Outflow{
+			{Account: testAddrA, Amount: 600},
+			{Account: testAddrB, AssetID: 1234, Amount: 8},
+		}, fileEncKey)).To(Succeed())
+
+		By("Checking outflows within the limits")
+		exceeded, err := store.Check("session1", []spend_limit.Outflow{
+			{Account: testAddrA, Amount: 400},
+			{Account: testAddrA, AssetID: 1234, Amount: 2},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(BeEmpty())
+
+		By("Checking outflows that exceed the limits")
+		exceeded, err = store.Check("session1", []spend_limit.Outflow{
+			{Account: testAddrA, Amount: 401},
+			{Account: testAddrA, AssetID: 1234, Amount: 3},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{
+			{Limit: *accountLimit, Spent: 600, Amount: 401},
+			{Limit: *sessionLimit, Spent: 8, Amount: 3},
+		}))
+
+		By("Checking outflows for another account and session")
+		exceeded, err = store.Check("session2", []spend_limit.Outflow{
+			{Account: testAddrB, Amount: 5000},
+			{Account: testAddrB, AssetID: 1234, Amount: 50},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(BeEmpty())
+	})
+
+	It("finds every limit a close-out would exceed", func() {
+		algoLimit, err := store.AddLimit(spend_limit.Limit{
+			Account: testAddrA, WindowSecs: 3600, Max: 1_000_000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.AddLimit(spend_limit.Limit{
+			AssetID: 1234, WindowSecs: 3600, Max: 10, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Checking a payment of nothing that closes out the account")
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 0, nil, testAddrB, testParams)
+		Expect(err).ToNot(HaveOccurred())
+		exceeded, err := store.Check("", spend_limit.Outflows(txn), fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{
+			{Limit: *algoLimit, Amount: 1000, CloseOut: true},
+		}), "Only the Algo limit applies to the close-out")
+		Expect(exceeded[0].Message()).To(HavePrefix("Closing out the account"))
+	})
+
+	It("finds limits that amounts too large to add up would exceed", func() {
+		assetLimit, err := store.AddLimit(spend_limit.Limit{
+			AssetID: 1234, WindowSecs: 3600, Max: 1000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Checking outflows that add up to more than the largest amount")
+		exceeded, err := store.Check("", []spend_limit.Outflow{
+			{Account: testAddrA, AssetID: 1234, Amount: math.MaxUint64},
+			{Account: testAddrB, AssetID: 1234, Amount: 2},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{
+			{Limit: *assetLimit, Amount: math.MaxUint64},
+		}))
+
+		By("Checking an outflow that adds up to more than the largest amount with what was sent")
+		Expect(store.Record("", []spend_limit.Outflow{
+			{Account: testAddrA, AssetID: 1234, Amount: math.MaxUint64 - 10},
+		}, fileEncKey)).To(Succeed())
+		Expect(store.Record("", []spend_limit.Outflow{
+			{Account: testAddrA, AssetID: 1234, Amount: math.MaxUint64 - 10},
+		}, fileEncKey)).To(Succeed())
+		exceeded, err = store.Check("", []spend_limit.Outflow{
+			{Account: testAddrA, AssetID: 1234, Amount: 20},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{
+			{Limit: *assetLimit, Spent: math.MaxUint64, Amount: 20},
+		}))
+	})
+
+	It("counts reserved outflows until the reservation is released", func() {
+		limit, err := store.AddLimit(spend_limit.Limit{
+			Account: testAddrA, WindowSecs: 3600, Max: 1000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		outflows := []spend_limit.Outflow{{Account: testAddrA, Amount: 600}}
+
+		By("Reserving outflows that do not exceed the limit")
+		reservation, exceeded, err := store.Reserve("session1", outflows, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(BeEmpty())
+		Expect(reservation).To(Equal(&spend_limit.Reservation{
+			ID: reservation.ID, SessionID: "session1", Outflows: outflows,
+		}))
+		Expect(reservation.ID).ToNot(BeEmpty())
+
+		By("Reserving outflows that together with the reserved ones exceed the limit")
+		otherReservation, exceeded, err := store.Reserve("session2", outflows, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherReservation).To(BeNil())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{{Limit: *limit, Spent: 600, Amount: 600}}))
+
+		By("Releasing the reservation")
+		Expect(store.Release(reservation, fileEncKey)).To(Succeed())
+		statuses, err := store.ListLimits(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(Equal([]spend_limit.Status{{Limit: *limit}}))
+		otherReservation, exceeded, err = store.Reserve("session2", outflows, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherReservation).ToNot(BeNil())
+		Expect(exceeded).To(BeEmpty())
+	})
+
+	It("reserves outflows that exceed limits which do not deny them", func() {
+		limit, err := store.AddLimit(spend_limit.Limit{
+			WindowSecs: 3600, Max: 1000, Action: spend_limit.ActionPrompt,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		reservation, exceeded, err := store.Reserve("", []spend_limit.Outflow{
+			{Account: testAddrA, Amount: 1500},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reservation).ToNot(BeNil())
+		Expect(exceeded).To(Equal([]spend_limit.Exceeded{{Limit: *limit, Amount: 1500}}))
+	})
+
+	It("records reserved outflows when the reservation is confirmed", func() {
+		limit, err := store.AddLimit(spend_limit.Limit{
+			WindowSecs: 3600, Max: 1000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		reservation, _, err := store.Reserve("", []spend_limit.Outflow{
+			{Account: testAddrA, Amount: 600},
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Confirm(reservation, fileEncKey)).To(Succeed())
+
+		By("Checking the confirmed outflows cannot be released")
+		Expect(store.Release(reservation, fileEncKey)).To(Succeed())
+		statuses, err := store.ListLimits(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(Equal([]spend_limit.Status{{Limit: *limit, Spent: 600}}))
+
+		By("Confirming a reservation that was already removed")
+		Expect(store.Confirm(&spend_limit.Reservation{
+			ID: "removed", Outflows: []spend_limit.Outflow{{Account: testAddrA, Amount: 300}},
+		}, fileEncKey)).To(Succeed())
+		statuses, err = store.ListLimits(fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(Equal([]spend_limit.Status{{Limit: *limit, Spent: 900}}))
+	})
+
+	It("only counts what was sent within the window", func() {
+		_, err := store.AddLimit(spend_limit.Limit{
+			WindowSecs: 1, Max: 1000, Action: spend_limit.ActionDeny,
+		}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Record("", []spend_limit.Outflow{{Account: testAddrA, Amount: 1000}}, fileEncKey)).To(Succeed())
+
+		exceeded, err := store.Check("", []spend_limit.Outflow{{Account: testAddrA, Amount: 1}}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(HaveLen(1))
+
+		time.Sleep(1100 * time.Millisecond)
+
+		exceeded, err = store.Check("", []spend_limit.Outflow{{Account: testAddrA, Amount: 1}}, fileEncKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exceeded).To(BeEmpty())
+	})
+})
@@ -0,0 +1,721 @@
+// Package txn_decoder decodes Algorand transactions into human-readable
+// summaries that can be shown to the user when the user is asked to approve
+// signing a transaction
+package txn_decoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/algorand/go-algorand-sdk/v2/abi"
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+)
+
+type (
+	// Summary is a human-readable summary of a transaction
+	Summary struct {
+		// Transaction ID
+		ID string `json:"id"`
+		// Transaction type (e.g. "pay", "axfer")
+		Type string `json:"type"`
+		// Human-readable name of the transaction type
+		TypeName string `json:"type_name"`
+		// Address of the sender
+		Sender string `json:"sender"`
+		// Address of the receiver of Algos or assets, if any
+		Receiver string `json:"receiver,omitempty"`
+		// Address that the remaining Algos or assets of the sender are sent to
+		// when the sender's account or asset holding is closed, if any
+		CloseTo string `json:"close_to,omitempty"`
+		// Amount of Algos being sent, if any
+		Amount *AlgoAmount `json:"amount,omitempty"`
+		// ID of the asset involved, if any
+		AssetID uint64 `json:"asset_id,omitempty"`
+		// Amount of the asset being sent in the asset's base units, if any
+		AssetAmount uint64 `json:"asset_amount,omitempty"`
+		// Address of the account assets are being clawed back from, if any
+		AssetSender string `json:"asset_sender,omitempty"`
+		// Transaction fee
+		Fee AlgoAmount `json:"fee"`
+		// First round the transaction is valid
+		FirstValid uint64 `json:"first_valid"`
+		// Last round the transaction is valid
+		LastValid uint64 `json:"last_valid"`
+		// ID of the network the transaction is for
+		GenesisID string `json:"genesis_id,omitempty"`
+		// Base64-encoded genesis hash of the network the transaction is for
+		GenesisHash string `json:"genesis_hash"`
+		// Base64-encoded lease, if any
+		Lease string `json:"lease,omitempty"`
+		// Note, if any
+		Note *Bytes `json:"note,omitempty"`
+		// Base64-encoded ID of the group the transaction is in, if any
+		Group string `json:"group,omitempty"`
+		// Address the sender's account is being rekeyed to, if any
+		RekeyTo string `json:"rekey_to,omitempty"`
+
+		// Details only for key registration transactions
+		KeyReg *KeyRegDetails `json:"keyreg,omitempty"`
+		// Details only for asset configuration transactions
+		AssetConfig *AssetConfigDetails `json:"asset_config,omitempty"`
+		// Details only for asset freeze transactions
+		AssetFreeze *AssetFreezeDetails `json:"asset_freeze,omitempty"`
+		// Details only for application call transactions
+		AppCall *AppCallDetails `json:"app_call,omitempty"`
+		// Details only for heartbeat transactions
+		Heartbeat *HeartbeatDetails `json:"heartbeat,omitempty"`
+
+		// What is known about each address involved, keyed by address. It is
+		// only set after the addresses are resolved.
+		Addresses map[string]AddressInfo `json:"addresses,omitempty"`
+	}
+
+	// AddressInfo is what is known about an address
+	AddressInfo struct {
+		// Label of the address in the address book, if it is in there
+		Label string `json:"label,omitempty"`
+		// If the address is in the address book and is trusted
+		Trusted bool `json:"trusted"`
+		// If the address is of an account in the wallet
+		InWallet bool `json:"in_wallet"`
+		// If the address is in the address book or the wallet. Unknown
+		// addresses should be clearly pointed out to the user.
+		Known bool `json:"known"`
+	}
+
+	// AlgoAmount is an amount of Algos
+	AlgoAmount struct {
+		// The amount in microAlgos
+		MicroAlgos uint64 `json:"microalgos"`
+		// The amount in Algos as a decimal string, which avoids the rounding
+		// errors of floating-point numbers
+		Algos string `json:"algos"`
+	}
+
+	// Bytes is a byte string in forms that are readable by a person
+	Bytes struct {
+		// The bytes as text if they are valid and printable UTF-8
+		Text string `json:"text,omitempty"`
+		// The bytes in hexadecimal
+		Hex string `json:"hex"`
+	}
+
+	// KeyRegDetails are the details of a key registration transaction
+	KeyRegDetails struct {
+		// If the account is being registered online, otherwise offline
+		Online bool `json:"online"`
+		// If the account is being marked as never participating again
+		Nonparticipation bool `json:"nonparticipation"`
+		// Base64-encoded participation keys
+		VotePK       string `json:"vote_pk,omitempty"`
+		SelectionPK  string `json:"selection_pk,omitempty"`
+		StateProofPK string `json:"state_proof_pk,omitempty"`
+		// Rounds the participation keys are valid
+		VoteFirst uint64 `json:"vote_first,omitempty"`
+		VoteLast  uint64 `json:"vote_last,omitempty"`
+		// Dilution of the participation keys
+		VoteKeyDilution uint64 `json:"vote_key_dilution,omitempty"`
+	}
+
+	// AssetConfigDetails are the details of an asset configuration transaction
+	AssetConfigDetails struct {
+		// What is being done to the asset: "create", "reconfigure" or
+		// "destroy"
+		Action string `json:"action"`
+		// Parameters of the asset being created or reconfigured
+		Total         uint64 `json:"total,omitempty"`
+		Decimals      uint32 `json:"decimals,omitempty"`
+		DefaultFrozen bool   `json:"default_frozen,omitempty"`
+		UnitName      string `json:"unit_name,omitempty"`
+		AssetName     string `json:"asset_name,omitempty"`
+		URL           string `json:"url,omitempty"`
+		MetadataHash  string `json:"metadata_hash,omitempty"`
+		Manager       string `json:"manager,omitempty"`
+		Reserve       string `json:"reserve,omitempty"`
+		Freeze        string `json:"freeze,omitempty"`
+		Clawback      string `json:"clawback,omitempty"`
+	}
+
+	// AssetFreezeDetails are the details of an asset freeze transaction
+	AssetFreezeDetails struct {
+		// Address of the account whose asset holding is being frozen or
+		// unfrozen
+		Account string `json:"account"`
+		// If the asset holding is being frozen, otherwise unfrozen
+		Frozen bool `json:"frozen"`
+	}
+
+	// AppCallDetails are the details of an application call transaction
+	AppCallDetails struct {
+		// ID of the application being called. It is 0 when the application is
+		// being created.
+		AppID uint64 `json:"app_id"`
+		// Name of the on-completion action (e.g. "NoOp", "OptIn")
+		OnCompletion string `json:"on_completion"`
+		// Application arguments
+		Args []Bytes `json:"args,omitempty"`
+		// Foreign accounts
+		Accounts []string `json:"accounts,omitempty"`
+		// Foreign assets
+		ForeignAssets []uint64 `json:"foreign_assets,omitempty"`
+		// Foreign applications
+		ForeignApps []uint64 `json:"foreign_apps,omitempty"`
+		// Box references
+		Boxes []BoxRef `json:"boxes,omitempty"`
+		// Resources in the access list
+		Access []AccessRef `json:"access,omitempty"`
+		// Size of the approval program in bytes, if it is being set
+		ApprovalProgramLen int `json:"approval_program_len,omitempty"`
+		// Size of the clear state program in bytes, if it is being set
+		ClearProgramLen int `json:"clear_program_len,omitempty"`
+		// State schema of the application being created
+		GlobalNumUint      uint64 `json:"global_num_uint,omitempty"`
+		GlobalNumByteSlice uint64 `json:"global_num_byte_slice,omitempty"`
+		LocalNumUint       uint64 `json:"local_num_uint,omitempty"`
+		LocalNumByteSlice  uint64 `json:"local_num_byte_slice,omitempty"`
+		ExtraProgramPages  uint32 `json:"extra_program_pages,omitempty"`
+		// The ARC-4 ABI method being called. It is only set after the method
+		// is decoded.
+		Method *MethodCall `json:"method,omitempty"`
+	}
+
+	// MethodCall is an ARC-4 ABI method call decoded from the arguments of an
+	// application call using the spec of the application
+	MethodCall struct {
+		// Name of the method
+		Name string `json:"name"`
+		// Signature of the method (e.g. "swap(uint64,uint64)void")
+		Signature string `json:"signature"`
+		// The arguments of the call, in order
+		Args []MethodArg `json:"args"`
+		// The call as text (e.g. "swap(asset_in=31566704, amount=1000000)")
+		Text string `json:"text"`
+	}
+
+	// MethodArg is an argument of an ARC-4 ABI method call
+	MethodArg struct {
+		// Name of the argument, if any
+		Name string `json:"name,omitempty"`
+		// ABI type of the argument (e.g. "uint64", "account", "pay")
+		Type string `json:"type"`
+		// The value of the argument as JSON. Reference arguments are given as
+		// the address, asset ID or app ID they refer to. It is null for
+		// transaction arguments, which are the transactions before the call
+		// in the group.
+		Value json.RawMessage `json:"value"`
+	}
+
+	// BoxRef is a reference to a box of an application
+	BoxRef struct {
+		// ID of the application the box belongs to. It is 0 for the
+		// application being called.
+		AppID uint64 `json:"app_id"`
+		// Name of the box
+		Name Bytes `json:"name"`
+	}
+
+	// AccessRef is a resource in the access list of an application call
+	AccessRef struct {
+		// What kind of resource it is: "address", "asset", "app", "holding",
+		// "locals" or "box"
+		Kind    string `json:"kind"`
+		Address string `json:"address,omitempty"`
+		AssetID uint64 `json:"asset_id,omitempty"`
+		AppID   uint64 `json:"app_id,omitempty"`
+		// For holding and locals references, the 1-based positions in the
+		// access list of the referenced address, asset or app. 0 means the
+		// sender or the application being called.
+		AddressIndex uint64 `json:"address_index,omitempty"`
+		AssetIndex   uint64 `json:"asset_index,omitempty"`
+		AppIndex     uint64 `json:"app_index,omitempty"`
+		// For box references, the name of the box
+		BoxName *Bytes `json:"box_name,omitempty"`
+	}
+
+	// HeartbeatDetails are the details of a heartbeat transaction
+	HeartbeatDetails struct {
+		// Address of the account the heartbeat is for
+		Address string `json:"address"`
+	}
+)
+
+// AddressResolver finds what is known about addresses
+type AddressResolver interface {
+	// ResolveAddress gives what is known about the given address
+	ResolveAddress(addr string) (AddressInfo, error)
+}
+
+// MethodResolver finds the ARC-4 ABI methods of applications
+type MethodResolver interface {
+	// AppMethods gives the ABI methods of the application with the given ID,
+	// or nil if they are not known
+	AppMethods(appID uint64) ([]abi.Method, error)
+}
+
+// maxMethodAppArgs is the most application arguments an ARC-4 ABI method
+// call can have apart from the method selector. If a method has more
+// arguments, the rest are encoded together as a tuple in the last application
+// argument.
+const maxMethodAppArgs = 15
+
+// typeNames are the human-readable names of each transaction type
+var typeNames = map[types.TxType]string{
+	types.PaymentTx:         "Payment",
+	types.KeyRegistrationTx: "Key Registration",
+	types.AssetConfigTx:     "Asset Configuration",
+	types.AssetTransferTx:   "Asset Transfer",
+	types.AssetFreezeTx:     "Asset Freeze",
+	types.ApplicationCallTx: "Application Call",
+	types.StateProofTx:      "State Proof",
+	types.HeartbeatTx:       "Heartbeat",
+}
+
+// onCompletionNames are the names of each application call on-completion
+// action
+var onCompletionNames = map[types.OnCompletion]string{
+	types.NoOpOC:              "NoOp",
+	types.OptInOC:             "OptIn",
+	types.CloseOutOC:          "CloseOut",
+	types.ClearStateOC:        "ClearState",
+	types.UpdateApplicationOC: "UpdateApplication",
+	types.DeleteApplicationOC: "DeleteApplication",
+}
+
+// Decode creates a human-readable summary of the given transaction
+func Decode(txn types.Transaction) *Summary {
+	summary := Summary{
+		ID:          crypto.GetTxID(txn),
+		Type:        string(txn.Type),
+		TypeName:    typeNames[txn.Type],
+		Sender:      txn.Sender.String(),
+		Fee:         NewAlgoAmount(uint64(txn.Fee)),
+		FirstValid:  uint64(txn.FirstValid),
+		LastValid:   uint64(txn.LastValid),
+		GenesisID:   txn.GenesisID,
+		GenesisHash: base64.StdEncoding.EncodeToString(txn.GenesisHash[:]),
+		RekeyTo:     optionalAddr(txn.RekeyTo),
+	}
+
+	if summary.TypeName == "" {
+		summary.TypeName = "Unknown"
+	}
+	if txn.Lease != ([32]byte{}) {
+		summary.Lease = base64.StdEncoding.EncodeToString(txn.Lease[:])
+	}
+	if len(txn.Note) > 0 {
+		note := NewBytes(txn.Note)
+		summary.Note = &note
+	}
+	if txn.Group != (types.Digest{}) {
+		summary.Group = base64.StdEncoding.EncodeToString(txn.Group[:])
+	}
+
+	switch txn.Type {
+	case types.PaymentTx:
+		amount := NewAlgoAmount(uint64(txn.Amount))
+		summary.Amount = &amount
+		summary.Receiver = txn.Receiver.String()
+		summary.CloseTo = optionalAddr(txn.CloseRemainderTo)
+	case types.KeyRegistrationTx:
+		summary.KeyReg = decodeKeyReg(txn.KeyregTxnFields)
+	case types.AssetConfigTx:
+		summary.AssetID = uint64(txn.ConfigAsset)
+		summary.AssetConfig = decodeAssetConfig(txn.AssetConfigTxnFields)
+	case types.AssetTransferTx:
+		summary.AssetID = uint64(txn.XferAsset)
+		summary.AssetAmount = txn.AssetAmount
+		summary.Receiver = txn.AssetReceiver.String()
+		summary.CloseTo = optionalAddr(txn.AssetCloseTo)
+		summary.AssetSender = optionalAddr(txn.AssetSender)
+	case types.AssetFreezeTx:
+		summary.AssetID = uint64(txn.FreezeAsset)
+		summary.AssetFreeze = &AssetFreezeDetails{
+			Account: txn.FreezeAccount.String(),
+			Frozen:  txn.AssetFrozen,
+		}
+	case types.ApplicationCallTx:
+		summary.AppCall = decodeAppCall(txn.ApplicationCallTxnFields)
+	case types.HeartbeatTx:
+		if txn.HeartbeatTxnFields != nil {
+			summary.Heartbeat = &HeartbeatDetails{Address: txn.HbAddress.String()}
+		}
+	}
+
+	return &summary
+}
+
+// ResolveAddresses uses the given resolver to find what is known about the
+// sender and each address the transaction sends to, closes to, rekeys to,
+// claws back from or freezes
+func (summary *Summary) ResolveAddresses(resolver AddressResolver) error {
+	addrs := []string{
+		summary.Sender,
+		summary.Receiver,
+		summary.CloseTo,
+		summary.RekeyTo,
+		summary.AssetSender,
+	}
+	if summary.AssetFreeze != nil {
+		addrs = append(addrs, summary.AssetFreeze.Account)
+	}
+
+	summary.Addresses = map[string]AddressInfo{}
+	for _, addr := range addrs {
+		if _, resolved := summary.Addresses[addr]; addr == "" || resolved {
+			continue
+		}
+
+		info, err := resolver.ResolveAddress(addr)
+		if err != nil {
+			return err
+		}
+		info.Known = info.Label != "" || info.InWallet
+		summary.Addresses[addr] = info
+	}
+
+	return nil
+}
+
+// DecodeMethod uses the given resolver to find the ABI methods of the
+// application being called and decodes the ARC-4 ABI method call from the
+// application arguments. The method is not decoded if the application's
+// methods are not known or none of them have the method selector in the first
+// application argument.
+func (summary *Summary) DecodeMethod(resolver MethodResolver) error {
+	appCall := summary.AppCall
+	if appCall == nil || appCall.AppID == 0 || len(appCall.Args) == 0 {
+		return nil
+	}
+
+	methods, err := resolver.AppMethods(appCall.AppID)
+	if err != nil {
+		return err
+	}
+
+	appArgs := make([][]byte, len(appCall.Args))
+	for i, arg := range appCall.Args {
+		if appArgs[i], err = hex.DecodeString(arg.Hex); err != nil {
+			return err
+		}
+	}
+
+	for i := range methods {
+		method := &methods[i]
+		if !bytes.Equal(method.GetSelector(), appArgs[0]) {
+			continue
+		}
+
+		call, err := decodeMethodCall(method, appArgs[1:], summary.Sender, appCall)
+		if err != nil {
+			return fmt.Errorf("could not decode call to method '%s': %w", method.GetSignature(), err)
+		}
+		appCall.Method = call
+		return nil
+	}
+
+	return nil
+}
+
+// NewAlgoAmount creates an AlgoAmount from the given amount of microAlgos
+func NewAlgoAmount(microAlgos uint64) AlgoAmount {
+	return AlgoAmount{
+		MicroAlgos: microAlgos,
+		Algos:      fmt.Sprintf("%d.%06d", microAlgos/1_000_000, microAlgos%1_000_000),
+	}
+}
+
+// NewBytes creates a Bytes from the given byte slice. The text form is only
+// set if the bytes are valid UTF-8 without any control characters other than
+// whitespace.
+func NewBytes(b []byte) Bytes {
+	bytes := Bytes{Hex: hex.EncodeToString(b)}
+
+	if isPrintableUTF8(b) {
+		bytes.Text = string(b)
+	}
+
+	return bytes
+}
+
+/*******************************************************************************
+ * Helpers
+ ******************************************************************************/
+
+// isPrintableUTF8 checks if the given bytes are valid UTF-8 that does not
+// contain any control characters other than whitespace
+func isPrintableUTF8(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// optionalAddr returns the given address as a string, or an empty string if
+// the address is the zero address (not set)
+func optionalAddr(addr types.Address) string {
+	if addr.IsZero() {
+		return ""
+	}
+	return addr.String()
+}
+
+// decodeKeyReg creates the details of a key registration transaction
+func decodeKeyReg(fields types.KeyregTxnFields) *KeyRegDetails {
+	details := KeyRegDetails{
+		Online:           fields.VotePK != (types.VotePK{}),
+		Nonparticipation: fields.Nonparticipation,
+	}
+
+	if details.Online {
+		details.VotePK = base64.StdEncoding.EncodeToString(fields.VotePK[:])
+		details.SelectionPK = base64.StdEncoding.EncodeToString(fields.SelectionPK[:])
+		if fields.StateProofPK != (types.MerkleVerifier{}) {
+			details.StateProofPK = base64.StdEncoding.EncodeToString(fields.StateProofPK[:])
+		}
+		details.VoteFirst = uint64(fields.VoteFirst)
+		details.VoteLast = uint64(fields.VoteLast)
+		details.VoteKeyDilution = fields.VoteKeyDilution
+	}
+
+	return &details
+}
+
+// decodeAssetConfig creates the details of an asset configuration transaction
+func decodeAssetConfig(fields types.AssetConfigTxnFields) *AssetConfigDetails {
+	details := AssetConfigDetails{Action: "reconfigure"}
+
+	if fields.ConfigAsset == 0 {
+		details.Action = "create"
+	} else if fields.AssetParams.IsZero() {
+		details.Action = "destroy"
+		return &details
+	}
+
+	params := fields.AssetParams
+	details.Total = params.Total
+	details.Decimals = params.Decimals
+	details.DefaultFrozen = params.DefaultFrozen
+	details.UnitName = params.UnitName
+	details.AssetName = params.AssetName
+	details.URL = params.URL
+	if params.MetadataHash != ([32]byte{}) {
+		details.MetadataHash = base64.StdEncoding.EncodeToString(params.MetadataHash[:])
+	}
+	details.Manager = optionalAddr(params.Manager)
+	details.Reserve = optionalAddr(params.Reserve)
+	details.Freeze = optionalAddr(params.Freeze)
+	details.Clawback = optionalAddr(params.Clawback)
+
+	return &details
+}
+
+// decodeAppCall creates the details of an application call transaction
+func decodeAppCall(fields types.ApplicationCallTxnFields) *AppCallDetails {
+	details := AppCallDetails{
+		AppID:              uint64(fields.ApplicationID),
+		OnCompletion:       onCompletionNames[fields.OnCompletion],
+		ApprovalProgramLen: len(fields.ApprovalProgram),
+		ClearProgramLen:    len(fields.ClearStateProgram),
+		GlobalNumUint:      fields.GlobalStateSchema.NumUint,
+		GlobalNumByteSlice: fields.GlobalStateSchema.NumByteSlice,
+		LocalNumUint:       fields.LocalStateSchema.NumUint,
+		LocalNumByteSlice:  fields.LocalStateSchema.NumByteSlice,
+		ExtraProgramPages:  fields.ExtraProgramPages,
+	}
+
+	if details.OnCompletion == "" {
+		details.OnCompletion = fmt.Sprintf("Unknown (%d)", fields.OnCompletion)
+	}
+
+	for _, arg := range fields.ApplicationArgs {
+		details.Args = append(details.Args, NewBytes(arg))
+	}
+	for _, acct := range fields.Accounts {
+		details.Accounts = append(details.Accounts, acct.String())
+	}
+	for _, asset := range fields.ForeignAssets {
+		details.ForeignAssets = append(details.ForeignAssets, uint64(asset))
+	}
+	for _, app := range fields.ForeignApps {
+		details.ForeignApps = append(details.ForeignApps, uint64(app))
+	}
+	for _, box := range fields.BoxReferences {
+		details.Boxes = append(details.Boxes, BoxRef{
+			AppID: box.ForeignAppIdx,
+			Name:  NewBytes(box.Name),
+		})
+	}
+	for _, ref := range fields.Access {
+		details.Access = append(details.Access, decodeAccessRef(ref))
+	}
+
+	return &details
+}
+
+// decodeAccessRef creates a summary of a resource in the access list of an
+// application call
+func decodeAccessRef(ref types.ResourceRef) AccessRef {
+	switch {
+	case !ref.Address.IsZero():
+		return AccessRef{Kind: "address", Address: ref.Address.String()}
+	case ref.Asset != 0:
+		return AccessRef{Kind: "asset", AssetID: uint64(ref.Asset)}
+	case ref.App != 0:
+		return AccessRef{Kind: "app", AppID: uint64(ref.App)}
+	case ref.Holding != (types.HoldingRef{}):
+		return AccessRef{
+			Kind:         "holding",
+			AddressIndex: ref.Holding.Address,
+			AssetIndex:   ref.Holding.Asset,
+		}
+	case ref.Locals != (types.LocalsRef{}):
+		return AccessRef{
+			Kind:         "locals",
+			AddressIndex: ref.Locals.Address,
+			AppIndex:     ref.Locals.App,
+		}
+	default:
+		name := NewBytes(ref.Box.Name)
+		return AccessRef{Kind: "box", AppIndex: ref.Box.ForeignAppIdx, BoxName: &name}
+	}
+}
+
+// decodeMethodCall decodes the call to the given ABI method from the given
+// application arguments (without the method selector) of the given
+// application call sent by the given sender
+func decodeMethodCall(method *abi.Method, appArgs [][]byte, sender string, appCall *AppCallDetails) (*MethodCall, error) {
+	call := MethodCall{
+		Name:      method.Name,
+		Signature: method.GetSignature(),
+		Args:      make([]MethodArg, len(method.Args)),
+	}
+
+	// Find the types of the arguments that are in the application arguments,
+	// which are all except the transaction arguments. Reference arguments are
+	// encoded as indexes into the foreign arrays.
+	var argIndexes []int
+	var argTypes []abi.Type
+	for i := range method.Args {
+		arg := &method.Args[i]
+		call.Args[i] = MethodArg{Name: arg.Name, Type: arg.Type, Value: json.RawMessage("null")}
+		if arg.IsTransactionArg() {
+			continue
+		}
+
+		typeStr := arg.Type
+		if arg.IsReferenceArg() {
+			typeStr = "uint8"
+		}
+		argType, err := abi.TypeOf(typeStr)
+		if err != nil {
+			return nil, err
+		}
+		argIndexes = append(argIndexes, i)
+		argTypes = append(argTypes, argType)
+	}
+
+	// Match the application arguments with the types, where the arguments
+	// that do not fit are encoded together as a tuple in the last application
+	// argument
+	values := make([]any, len(argTypes))
+	numAppArgs := min(len(argTypes), maxMethodAppArgs)
+	if len(appArgs) != numAppArgs {
+		return nil, fmt.Errorf("expected %d application arguments, got %d", numAppArgs, len(appArgs))
+	}
+	for i := range numAppArgs {
+		if i == maxMethodAppArgs-1 && len(argTypes) > maxMethodAppArgs {
+			tupleType, err := abi.MakeTupleType(argTypes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tupleValue, err := tupleType.Decode(appArgs[i])
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %w", argIndexes[i], err)
+			}
+			copy(values[i:], tupleValue.([]any))
+			break
+		}
+
+		value, err := argTypes[i].Decode(appArgs[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", argIndexes[i], err)
+		}
+		values[i] = value
+	}
+
+	for i, value := range values {
+		arg := &call.Args[argIndexes[i]]
+		var err error
+		if method.Args[argIndexes[i]].IsReferenceArg() {
+			arg.Value, err = resolveMethodRef(arg.Type, value.(uint8), sender, appCall)
+		} else {
+			arg.Value, err = argTypes[i].MarshalToJSON(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", argIndexes[i], err)
+		}
+	}
+
+	argTexts := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		valueText := string(arg.Value)
+		if method.Args[i].IsTransactionArg() {
+			valueText = "<" + arg.Type + " transaction>"
+		}
+		if arg.Name != "" {
+			valueText = arg.Name + "=" + valueText
+		}
+		argTexts[i] = valueText
+	}
+	call.Text = call.Name + "(" + strings.Join(argTexts, ", ") + ")"
+
+	return &call, nil
+}
+
+// resolveMethodRef gives the JSON of the address, asset ID or app ID that the
+// given index of a reference argument of the given type refers to in the
+// foreign arrays of the given application call
+func resolveMethodRef(refType string, index uint8, sender string, appCall *AppCallDetails) (json.RawMessage, error) {
+	var ref any
+	switch refType {
+	case abi.AccountReferenceType:
+		// Index 0 is the sender
+		if index == 0 {
+			ref = sender
+		} else if int(index) <= len(appCall.Accounts) {
+			ref = appCall.Accounts[index-1]
+		}
+	case abi.AssetReferenceType:
+		if int(index) < len(appCall.ForeignAssets) {
+			ref = appCall.ForeignAssets[index]
+		}
+	case abi.ApplicationReferenceType:
+		// Index 0 is the application being called
+		if index == 0 {
+			ref = appCall.AppID
+		} else if int(index) <= len(appCall.ForeignApps) {
+			ref = appCall.ForeignApps[index-1]
+		}
+	}
+
+	if ref == nil {
+		return nil, errors.New("reference index out of range")
+	}
+
+	return json.Marshal(ref)
+}
@@ -0,0 +1,13 @@
+package txn_decoder_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTxnDecoder(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Decoder Suite")
+}
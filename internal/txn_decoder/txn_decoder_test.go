@@ -0,0 +1,337 @@
+package txn_decoder_test
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/abi"
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/txn_decoder"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+var testParams = types.SuggestedParams{
+	Fee:             2000,
+	GenesisID:       "testnet-v1.0",
+	GenesisHash:     make([]byte, 32),
+	FirstRoundValid: 1000,
+	LastRoundValid:  2000,
+	FlatFee:         true,
+}
+
+var _ = Describe("Decode()", func() {
+	It("decodes the common fields of a transaction", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 1, []byte("hello"), "", testParams)
+		Expect(err).ToNot(HaveOccurred())
+		txn.Lease = [32]byte{1}
+		txn.Group = types.Digest{2}
+		txn.RekeyTo, _ = types.DecodeAddress(testAddrB)
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.ID).To(Equal(crypto.GetTxID(txn)))
+		Expect(summary.Type).To(Equal("pay"))
+		Expect(summary.TypeName).To(Equal("Payment"))
+		Expect(summary.Sender).To(Equal(testAddrA))
+		Expect(summary.Fee).To(Equal(txn_decoder.AlgoAmount{MicroAlgos: 2000, Algos: "0.002000"}))
+		Expect(summary.FirstValid).To(BeEquivalentTo(1000))
+		Expect(summary.LastValid).To(BeEquivalentTo(2000))
+		Expect(summary.GenesisID).To(Equal("testnet-v1.0"))
+		Expect(summary.GenesisHash).To(Equal(base64.StdEncoding.EncodeToString(make([]byte, 32))))
+		Expect(summary.Lease).To(Equal(base64.StdEncoding.EncodeToString(txn.Lease[:])))
+		Expect(summary.Note).To(Equal(&txn_decoder.Bytes{Text: "hello", Hex: "68656c6c6f"}))
+		Expect(summary.Group).To(Equal(base64.StdEncoding.EncodeToString(txn.Group[:])))
+		Expect(summary.RekeyTo).To(Equal(testAddrB))
+	})
+
+	It("leaves out optional fields that are not set", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 1, nil, "", testParams)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.Lease).To(BeEmpty())
+		Expect(summary.Note).To(BeNil())
+		Expect(summary.Group).To(BeEmpty())
+		Expect(summary.RekeyTo).To(BeEmpty())
+		Expect(summary.CloseTo).To(BeEmpty())
+	})
+
+	It("decodes a payment transaction", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 5_123_456, nil, testAddrA, testParams)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.Receiver).To(Equal(testAddrB))
+		Expect(summary.Amount).To(Equal(&txn_decoder.AlgoAmount{MicroAlgos: 5_123_456, Algos: "5.123456"}))
+		Expect(summary.CloseTo).To(Equal(testAddrA))
+	})
+
+	It("decodes an asset transfer transaction", func() {
+		txn, err := transaction.MakeAssetTransferTxn(testAddrA, testAddrB, 25, nil, testParams, "", 1234)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.TypeName).To(Equal("Asset Transfer"))
+		Expect(summary.AssetID).To(BeEquivalentTo(1234))
+		Expect(summary.AssetAmount).To(BeEquivalentTo(25))
+		Expect(summary.Receiver).To(Equal(testAddrB))
+		Expect(summary.Amount).To(BeNil(), "No Algos are sent")
+	})
+
+	DescribeTable("decodes the action of an asset configuration transaction",
+		func(makeTxn func() (types.Transaction, error), action string) {
+			txn, err := makeTxn()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(txn_decoder.Decode(txn).AssetConfig.Action).To(Equal(action))
+		},
+		Entry("create", func() (types.Transaction, error) {
+			return transaction.MakeAssetCreateTxn(
+				testAddrA, nil, testParams, 100, 2, false,
+				testAddrA, "", "", "", "FOO", "Foo", "https://example.com", "",
+			)
+		}, "create"),
+		Entry("reconfigure", func() (types.Transaction, error) {
+			return transaction.MakeAssetConfigTxn(
+				testAddrA, nil, testParams, 1234, testAddrB, "", "", "", false,
+			)
+		}, "reconfigure"),
+		Entry("destroy", func() (types.Transaction, error) {
+			return transaction.MakeAssetDestroyTxn(testAddrA, nil, testParams, 1234)
+		}, "destroy"),
+	)
+
+	It("decodes the parameters of a new asset", func() {
+		txn, err := transaction.MakeAssetCreateTxn(
+			testAddrA, nil, testParams, 100, 2, true,
+			testAddrA, testAddrB, "", "", "FOO", "Foo", "https://example.com", "",
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		details := txn_decoder.Decode(txn).AssetConfig
+		Expect(details.Total).To(BeEquivalentTo(100))
+		Expect(details.Decimals).To(BeEquivalentTo(2))
+		Expect(details.DefaultFrozen).To(BeTrue())
+		Expect(details.UnitName).To(Equal("FOO"))
+		Expect(details.AssetName).To(Equal("Foo"))
+		Expect(details.URL).To(Equal("https://example.com"))
+		Expect(details.Manager).To(Equal(testAddrA))
+		Expect(details.Reserve).To(Equal(testAddrB))
+		Expect(details.Freeze).To(BeEmpty())
+	})
+
+	It("decodes an asset freeze transaction", func() {
+		txn, err := transaction.MakeAssetFreezeTxn(testAddrA, nil, testParams, 1234, testAddrB, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.AssetID).To(BeEquivalentTo(1234))
+		Expect(summary.AssetFreeze).To(Equal(&txn_decoder.AssetFreezeDetails{Account: testAddrB, Frozen: true}))
+	})
+
+	It("decodes an offline key registration transaction", func() {
+		txn, err := transaction.MakeKeyRegTxnWithStateProofKey(
+			testAddrA, nil, testParams, "", "", "", 0, 0, 0, true,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.TypeName).To(Equal("Key Registration"))
+		Expect(summary.KeyReg).To(Equal(&txn_decoder.KeyRegDetails{Nonparticipation: true}))
+	})
+
+	It("decodes an application call transaction", func() {
+		sender, _ := types.DecodeAddress(testAddrA)
+		txn, err := transaction.MakeApplicationOptInTxWithBoxes(
+			42,
+			[][]byte{[]byte("hi"), {0x00, 0xff}},
+			[]string{testAddrB},
+			[]uint64{7},
+			[]uint64{8},
+			[]types.AppBoxReference{{AppID: 0, Name: []byte("box")}},
+			testParams,
+			sender,
+			nil,
+			types.Digest{},
+			[32]byte{},
+			types.Address{},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		details := txn_decoder.Decode(txn).AppCall
+		Expect(details.AppID).To(BeEquivalentTo(42))
+		Expect(details.OnCompletion).To(Equal("OptIn"))
+		Expect(details.Args).To(Equal([]txn_decoder.Bytes{
+			{Text: "hi", Hex: "6869"},
+			{Hex: "00ff"},
+		}))
+		Expect(details.Accounts).To(Equal([]string{testAddrB}))
+		Expect(details.ForeignApps).To(Equal([]uint64{7}))
+		Expect(details.ForeignAssets).To(Equal([]uint64{8}))
+		Expect(details.Boxes).To(HaveLen(1))
+		Expect(details.Boxes[0].Name.Text).To(Equal("box"))
+	})
+})
+
+// fakeResolver resolves addresses using a map of known addresses
+type fakeResolver map[string]txn_decoder.AddressInfo
+
+func (r fakeResolver) ResolveAddress(addr string) (txn_decoder.AddressInfo, error) {
+	if addr == "fail" {
+		return txn_decoder.AddressInfo{}, errors.New("resolve failed")
+	}
+	return r[addr], nil
+}
+
+var _ = Describe("Summary.ResolveAddresses()", func() {
+	It("resolves the addresses involved and marks which are known", func() {
+		txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 1, nil, testAddrB, testParams)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.ResolveAddresses(fakeResolver{
+			testAddrA: {InWallet: true},
+		})).To(Succeed())
+		Expect(summary.Addresses).To(Equal(map[string]txn_decoder.AddressInfo{
+			testAddrA: {InWallet: true, Known: true},
+			testAddrB: {},
+		}))
+
+		Expect(summary.ResolveAddresses(fakeResolver{
+			testAddrB: {Label: "Bob", Trusted: true},
+		})).To(Succeed())
+		Expect(summary.Addresses[testAddrA].Known).To(BeFalse())
+		Expect(summary.Addresses[testAddrB]).To(Equal(txn_decoder.AddressInfo{
+			Label: "Bob", Trusted: true, Known: true,
+		}))
+	})
+
+	It("fails if an address cannot be resolved", func() {
+		summary := &txn_decoder.Summary{Sender: "fail"}
+		Expect(summary.ResolveAddresses(fakeResolver{})).ToNot(Succeed())
+	})
+})
+
+// fakeMethodResolver gives the ABI methods of applications from a map
+type fakeMethodResolver map[uint64][]abi.Method
+
+func (r fakeMethodResolver) AppMethods(appID uint64) ([]abi.Method, error) {
+	return r[appID], nil
+}
+
+// makeMethodCallTxn creates a call to the given method of application 42 with
+// the given application arguments after the method selector
+func makeMethodCallTxn(method abi.Method, args ...[]byte) types.Transaction {
+	sender, _ := types.DecodeAddress(testAddrA)
+	txn, err := transaction.MakeApplicationNoOpTx(
+		42,
+		append([][]byte{method.GetSelector()}, args...),
+		[]string{testAddrB},
+		[]uint64{7},
+		[]uint64{31566704},
+		testParams,
+		sender,
+		nil,
+		types.Digest{},
+		[32]byte{},
+		types.Address{},
+	)
+	Expect(err).ToNot(HaveOccurred())
+	return txn
+}
+
+// mustEncode ABI-encodes the given value as the given type
+func mustEncode(typeStr string, value any) []byte {
+	abiType, err := abi.TypeOf(typeStr)
+	Expect(err).ToNot(HaveOccurred())
+	encoded, err := abiType.Encode(value)
+	Expect(err).ToNot(HaveOccurred())
+	return encoded
+}
+
+var _ = Describe("Summary.DecodeMethod()", func() {
+	swap := abi.Method{
+		Name: "swap",
+		Args: []abi.Arg{
+			{Name: "payment", Type: "pay"},
+			{Name: "asset_in", Type: "asset"},
+			{Name: "amount", Type: "uint64"},
+			{Name: "receiver", Type: "account"},
+			{Name: "memo", Type: "string"},
+		},
+		Returns: abi.Return{Type: "void"},
+	}
+
+	It("decodes the method call into named, typed values", func() {
+		txn := makeMethodCallTxn(swap, []byte{0}, mustEncode("uint64", uint64(1_000_000)), []byte{1}, mustEncode("string", "hi"))
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.DecodeMethod(fakeMethodResolver{42: {swap}})).To(Succeed())
+		Expect(summary.AppCall.Method).To(Equal(&txn_decoder.MethodCall{
+			Name:      "swap",
+			Signature: "swap(pay,asset,uint64,account,string)void",
+			Args: []txn_decoder.MethodArg{
+				{Name: "payment", Type: "pay", Value: []byte("null")},
+				{Name: "asset_in", Type: "asset", Value: []byte("31566704")},
+				{Name: "amount", Type: "uint64", Value: []byte("1000000")},
+				{Name: "receiver", Type: "account", Value: []byte(`"` + testAddrB + `"`)},
+				{Name: "memo", Type: "string", Value: []byte(`"hi"`)},
+			},
+			Text: `swap(payment=<pay transaction>, asset_in=31566704, amount=1000000, receiver="` + testAddrB + `", memo="hi")`,
+		}))
+	})
+
+	It("decodes the arguments that are encoded together as a tuple", func() {
+		manyArgs := abi.Method{Name: "many", Returns: abi.Return{Type: "void"}}
+		var appArgs [][]byte
+		for i := range 16 {
+			manyArgs.Args = append(manyArgs.Args, abi.Arg{Type: "uint8"})
+			if i < 14 {
+				appArgs = append(appArgs, []byte{uint8(i)})
+			}
+		}
+		appArgs = append(appArgs, []byte{14, 15})
+		txn := makeMethodCallTxn(manyArgs, appArgs...)
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.DecodeMethod(fakeMethodResolver{42: {manyArgs}})).To(Succeed())
+		Expect(summary.AppCall.Method.Args).To(HaveLen(16))
+		Expect(summary.AppCall.Method.Args[15].Value).To(BeEquivalentTo("15"))
+	})
+
+	It("does not decode the call if the method is not known", func() {
+		txn := makeMethodCallTxn(swap, []byte{0}, mustEncode("uint64", uint64(1)), []byte{1}, mustEncode("string", ""))
+
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.DecodeMethod(fakeMethodResolver{})).To(Succeed())
+		Expect(summary.AppCall.Method).To(BeNil())
+
+		other, err := abi.MethodFromSignature("other(uint64)void")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.DecodeMethod(fakeMethodResolver{42: {other}})).To(Succeed())
+		Expect(summary.AppCall.Method).To(BeNil())
+	})
+
+	It("fails if the arguments do not match the method", func() {
+		txn := makeMethodCallTxn(swap, []byte{0})
+		summary := txn_decoder.Decode(txn)
+		Expect(summary.DecodeMethod(fakeMethodResolver{42: {swap}})).ToNot(Succeed())
+
+		txn = makeMethodCallTxn(swap, []byte{5}, mustEncode("uint64", uint64(1)), []byte{1}, mustEncode("string", ""))
+		summary = txn_decoder.Decode(txn)
+		Expect(summary.DecodeMethod(fakeMethodResolver{42: {swap}})).To(MatchError(ContainSubstring("out of range")))
+	})
+})
+
+var _ = Describe("NewBytes()", func() {
+	It("only gives the text of printable UTF-8", func() {
+		Expect(txn_decoder.NewBytes([]byte("héllo\n")).Text).To(Equal("héllo\n"))
+		Expect(txn_decoder.NewBytes([]byte{0xc3, 0x28}).Text).To(BeEmpty(), "Invalid UTF-8")
+		Expect(txn_decoder.NewBytes([]byte("a\x00b")).Text).To(BeEmpty(), "Control character")
+	})
+})
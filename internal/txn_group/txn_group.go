@@ -0,0 +1,207 @@
+// Package txn_group checks transaction groups that are to be signed and works
+// out what the whole group does to the accounts in the wallet, so the user can
+// approve the group as a whole instead of each transaction separately
+package txn_group
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+)
+
+// ErrEmptyGroup is the error returned when a transaction group has no
+// transactions
+var ErrEmptyGroup = errors.New("the transaction group is empty")
+
+// ErrGroupTooLarge is the error returned when a transaction group has more
+// transactions than allowed by the network
+var ErrGroupTooLarge = fmt.Errorf("the transaction group has more than %d transactions", types.MaxTxGroupSize)
+
+// ErrGroupIDMismatch is the error returned when the group ID of a transaction
+// in a group does not match the group ID computed from the group's
+// transactions
+var ErrGroupIDMismatch = errors.New("the group ID does not match the transactions in the group")
+
+// ErrNotInGroup is the error returned when a transaction to be signed is not in
+// the group or is not in the same order as it is in the group
+var ErrNotInGroup = errors.New("the transaction is not in the group or is out of order")
+
+// Flow is the net flow of Algos or an asset for an account across a whole
+// transaction group. The net change of the account's balance is what was
+// received minus what was sent.
+type Flow struct {
+	// Address of the account
+	Account string `json:"account"`
+	// ID of the asset, which is 0 for Algos
+	AssetID uint64 `json:"asset_id"`
+	// Amount (in microAlgos or base units of the asset) sent from the account,
+	// including the fees the account pays
+	Sent uint64 `json:"sent"`
+	// Amount (in microAlgos or base units of the asset) received by the
+	// account
+	Received uint64 `json:"received"`
+}
+
+// Verify checks if the given transactions form a valid transaction group,
+// which is when the group ID of every transaction is the group ID computed
+// from all of the transactions. A single transaction without a group ID is
+// also valid.
+func Verify(group []types.Transaction) error {
+	if len(group) == 0 {
+		return ErrEmptyGroup
+	}
+	if len(group) > types.MaxTxGroupSize {
+		return ErrGroupTooLarge
+	}
+	if len(group) == 1 && group[0].Group == (types.Digest{}) {
+		return nil
+	}
+
+	ungrouped := make([]types.Transaction, len(group))
+	for i, txn := range group {
+		ungrouped[i] = txn
+		ungrouped[i].Group = types.Digest{}
+	}
+	gid, err := crypto.ComputeGroupID(ungrouped)
+	if err != nil {
+		return err
+	}
+
+	for i, txn := range group {
+		if txn.Group != gid {
+			return fmt.Errorf("%w: transaction %d", ErrGroupIDMismatch, i)
+		}
+	}
+
+	return nil
+}
+
+// Locate finds the given transactions in the given group. The transactions
+// must appear in the group in the same order they are given. Returns the index
+// of each transaction in the group.
+func Locate(group []types.Transaction, txns []types.Transaction) ([]int, error) {
+	groupIDs := make([]string, len(group))
+	for i, txn := range group {
+		groupIDs[i] = crypto.GetTxID(txn)
+	}
+
+	indexes := make([]int, 0, len(txns))
+	next := 0
+	for i, txn := range txns {
+		txID := crypto.GetTxID(txn)
+		found := -1
+		for j := next; j < len(groupIDs); j++ {
+			if groupIDs[j] == txID {
+				found = j
+				break
+			}
+		}
+		if found == -1 {
+			return nil, fmt.Errorf("%w: transaction %d (%s)", ErrNotInGroup, i, txID)
+		}
+		indexes = append(indexes, found)
+		next = found + 1
+	}
+
+	return indexes, nil
+}
+
+// NetFlows adds up the Algos and assets sent and received by the accounts for
+// which the given function returns true across the whole given group. The fee
+// of each transaction is counted as sent by its sender, so fees paid for other
+// transactions in the group (i.e. fee pooling) are counted for the account
+// that pays them. The flows are in the order the accounts and assets first
+// appear in the group.
+//
+// Amounts that are only known once the group is confirmed (e.g. the remaining
+// balance sent when closing an account) are not included.
+func NetFlows(group []types.Transaction, isAccount func(addr string) (bool, error)) ([]Flow, error) {
+	tracker := flowTracker{isAccount: isAccount, index: map[flowKey]int{}, flows: []Flow{}}
+
+	for _, txn := range group {
+		sender := txn.Sender.String()
+		if err := tracker.send(sender, "", 0, uint64(txn.Fee)); err != nil {
+			return nil, err
+		}
+
+		var err error
+		switch txn.Type {
+		case types.PaymentTx:
+			err = tracker.send(sender, txn.Receiver.String(), 0, uint64(txn.Amount))
+		case types.AssetTransferTx:
+			assetSender := sender
+			if !txn.AssetSender.IsZero() { // Clawback
+				assetSender = txn.AssetSender.String()
+			}
+			err = tracker.send(assetSender, txn.AssetReceiver.String(), uint64(txn.XferAsset), txn.AssetAmount)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tracker.flows, nil
+}
+
+// flowKey identifies an Algo or asset holding of an account
+type flowKey struct {
+	account string
+	assetID uint64
+}
+
+// flowTracker adds up the flows of the accounts that are tracked
+type flowTracker struct {
+	// Checks if an account is tracked
+	isAccount func(addr string) (bool, error)
+	// Index of each holding's flow in the flows
+	index map[flowKey]int
+	flows []Flow
+}
+
+// send records the given amount of Algos or assets as sent from one account to
+// another. Either account can be empty (e.g. for fees). Zero amounts are not
+// recorded.
+func (t *flowTracker) send(from, to string, assetID, amount uint64) error {
+	if amount == 0 {
+		return nil
+	}
+	if from != "" {
+		flow, err := t.flow(from, assetID)
+		if err != nil {
+			return err
+		}
+		if flow != nil {
+			flow.Sent += amount
+		}
+	}
+	if to != "" {
+		flow, err := t.flow(to, assetID)
+		if err != nil {
+			return err
+		}
+		if flow != nil {
+			flow.Received += amount
+		}
+	}
+	return nil
+}
+
+// flow gives the flow for the given holding, adding it to the flows if it is
+// not there yet. Gives nil if the account is not tracked.
+func (t *flowTracker) flow(account string, assetID uint64) (*Flow, error) {
+	key := flowKey{account, assetID}
+	if i, ok := t.index[key]; ok {
+		return &t.flows[i], nil
+	}
+
+	tracked, err := t.isAccount(account)
+	if err != nil || !tracked {
+		return nil, err
+	}
+
+	t.index[key] = len(t.flows)
+	t.flows = append(t.flows, Flow{Account: account, AssetID: assetID})
+	return &t.flows[len(t.flows)-1], nil
+}
@@ -0,0 +1,13 @@
+package txn_group_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTxnGroup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Group Suite")
+}
@@ -0,0 +1,145 @@
+package txn_group_test
+
+import (
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/crypto"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/txn_group"
+)
+
+const addrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const addrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+// makePayment creates a payment transaction
+func makePayment(sender, receiver string, amount, fee uint64) types.Transaction {
+	senderAddr, err := types.DecodeAddress(sender)
+	Expect(err).NotTo(HaveOccurred())
+	receiverAddr, err := types.DecodeAddress(receiver)
+	Expect(err).NotTo(HaveOccurred())
+	return types.Transaction{
+		Type: types.PaymentTx,
+		Header: types.Header{
+			Sender:     senderAddr,
+			Fee:        types.MicroAlgos(fee),
+			FirstValid: 1,
+			LastValid:  1000,
+			GenesisID:  "testnet-v1.0",
+		},
+		PaymentTxnFields: types.PaymentTxnFields{Receiver: receiverAddr, Amount: types.MicroAlgos(amount)},
+	}
+}
+
+// makeAssetTransfer creates an asset transfer transaction
+func makeAssetTransfer(sender, receiver string, assetID, amount, fee uint64) types.Transaction {
+	txn := makePayment(sender, receiver, 0, fee)
+	txn.Type = types.AssetTransferTx
+	txn.AssetReceiver = txn.Receiver
+	txn.Receiver = types.Address{}
+	txn.XferAsset = types.AssetIndex(assetID)
+	txn.AssetAmount = amount
+	return txn
+}
+
+// assignGroupID sets the group ID of the given transactions
+func assignGroupID(txns ...types.Transaction) []types.Transaction {
+	gid, err := crypto.ComputeGroupID(txns)
+	Expect(err).NotTo(HaveOccurred())
+	for i := range txns {
+		txns[i].Group = gid
+	}
+	return txns
+}
+
+// isAddrA checks if the given address is address A
+func isAddrA(addr string) (bool, error) {
+	return addr == addrA, nil
+}
+
+var _ = Describe("Verify()", func() {
+	It("accepts a group with a valid group ID", func() {
+		group := assignGroupID(makePayment(addrA, addrB, 1, 1000), makePayment(addrB, addrA, 2, 1000))
+		Expect(txn_group.Verify(group)).To(Succeed())
+	})
+
+	It("accepts a single transaction without a group ID", func() {
+		Expect(txn_group.Verify([]types.Transaction{makePayment(addrA, addrB, 1, 1000)})).To(Succeed())
+	})
+
+	It("rejects a group whose group ID does not match its transactions", func() {
+		group := assignGroupID(makePayment(addrA, addrB, 1, 1000), makePayment(addrB, addrA, 2, 1000))
+		By("changing a transaction after the group ID was assigned")
+		group[1].Amount = 2_000_000
+		Expect(errors.Is(txn_group.Verify(group), txn_group.ErrGroupIDMismatch)).To(BeTrue())
+
+		By("leaving out a transaction of the group")
+		group = assignGroupID(makePayment(addrA, addrB, 1, 1000), makePayment(addrB, addrA, 2, 1000))
+		Expect(errors.Is(txn_group.Verify(group[:1]), txn_group.ErrGroupIDMismatch)).To(BeTrue())
+
+		By("leaving out the group ID of a transaction")
+		group = []types.Transaction{makePayment(addrA, addrB, 1, 1000), makePayment(addrB, addrA, 2, 1000)}
+		Expect(errors.Is(txn_group.Verify(group), txn_group.ErrGroupIDMismatch)).To(BeTrue())
+	})
+
+	It("rejects empty and oversized groups", func() {
+		Expect(txn_group.Verify(nil)).To(MatchError(txn_group.ErrEmptyGroup))
+		group := make([]types.Transaction, types.MaxTxGroupSize+1)
+		for i := range group {
+			group[i] = makePayment(addrA, addrB, uint64(i), 1000)
+		}
+		Expect(txn_group.Verify(group)).To(MatchError(txn_group.ErrGroupTooLarge))
+	})
+})
+
+var _ = Describe("Locate()", func() {
+	group := assignGroupID(
+		makePayment(addrA, addrB, 1, 1000),
+		makePayment(addrB, addrA, 2, 1000),
+		makePayment(addrA, addrB, 3, 1000),
+	)
+
+	It("gives the indexes of the transactions in the group", func() {
+		indexes, err := txn_group.Locate(group, []types.Transaction{group[0], group[2]})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(indexes).To(Equal([]int{0, 2}))
+	})
+
+	It("fails if a transaction is not in the group", func() {
+		_, err := txn_group.Locate(group, []types.Transaction{makePayment(addrA, addrB, 4, 1000)})
+		Expect(errors.Is(err, txn_group.ErrNotInGroup)).To(BeTrue())
+	})
+
+	It("fails if the transactions are not in the same order as in the group", func() {
+		_, err := txn_group.Locate(group, []types.Transaction{group[2], group[0]})
+		Expect(errors.Is(err, txn_group.ErrNotInGroup)).To(BeTrue())
+		_, err = txn_group.Locate(group, []types.Transaction{group[0], group[0]})
+		Expect(errors.Is(err, txn_group.ErrNotInGroup)).To(BeTrue())
+	})
+})
+
+var _ = Describe("NetFlows()", func() {
+	It("adds up what the tracked accounts send and receive across the group", func() {
+		group := assignGroupID(
+			// A pays the fees of the whole group
+			makePayment(addrA, addrB, 5_000_000, 3000),
+			makeAssetTransfer(addrB, addrA, 10, 100, 0),
+			makePayment(addrB, addrA, 1_000_000, 0),
+		)
+
+		flows, err := txn_group.NetFlows(group, isAddrA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flows).To(Equal([]txn_group.Flow{
+			{Account: addrA, AssetID: 0, Sent: 5_003_000, Received: 1_000_000},
+			{Account: addrA, AssetID: 10, Received: 100},
+		}))
+	})
+
+	It("gives no flows when no tracked account is involved", func() {
+		flows, err := txn_group.NetFlows([]types.Transaction{makePayment(addrB, addrB, 1, 1000)}, isAddrA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flows).To(BeEmpty())
+	})
+})
@@ -0,0 +1,189 @@
+// Package txn_risk detects fields in transactions that are dangerous to sign
+// (e.g. rekeying the sender's account), so the user can be warned about them
+// and made to explicitly acknowledge them before signing
+package txn_risk
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/txn_decoder"
+)
+
+// Code is a code that identifies a kind of risk
+type Code string
+
+const (
+	// CodeRekey is for transactions that rekey the sender's account, which
+	// gives control of the account to another key
+	CodeRekey Code = "rekey"
+	// CodeCloseRemainderTo is for payments that close the sender's account
+	// and send all of its remaining Algos to another account
+	CodeCloseRemainderTo Code = "close_remainder_to"
+	// CodeAssetCloseTo is for asset transfers that close the sender's asset
+	// holding and send all of its remaining assets to another account
+	CodeAssetCloseTo Code = "asset_close_to"
+	// CodeClawback is for asset transfers that claw back assets from an
+	// account in the wallet
+	CodeClawback Code = "clawback"
+	// CodeKeyRegOffline is for key registrations that take the sender's
+	// account offline
+	CodeKeyRegOffline Code = "keyreg_offline"
+	// CodeKeyRegNonparticipation is for key registrations that permanently
+	// stop the sender's account from participating in consensus
+	CodeKeyRegNonparticipation Code = "keyreg_nonparticipation"
+	// CodeHighFee is for transactions with a very high fee
+	CodeHighFee Code = "high_fee"
+	// CodeAppClearState is for application calls that clear the sender's
+	// local state of the application, which may lose assets held by the
+	// application on the sender's behalf
+	CodeAppClearState Code = "app_clear_state"
+	// CodeAppUpdate is for application calls that update the application's
+	// programs
+	CodeAppUpdate Code = "app_update"
+	// CodeAppDelete is for application calls that delete the application
+	CodeAppDelete Code = "app_delete"
+	// CodeSpendLimit is for transactions that would exceed a spending limit.
+	// It is not flagged by the Checker, but by the signing handlers.
+	CodeSpendLimit Code = "spend_limit"
+	// CodeSimulationFailed is for transactions that failed when they were
+	// simulated, so they would most likely be rejected by the network. It is
+	// not flagged by the Checker, but by the signing handlers.
+	CodeSimulationFailed Code = "simulation_failed"
+)
+
+// DefaultHighFeeThreshold is the default fee (in microAlgos) above which a
+// transaction fee is considered to be very high
+const DefaultHighFeeThreshold uint64 = 100_000 // 0.1 Algos
+
+// Flag is a risk found in a transaction
+type Flag struct {
+	// Code identifying the kind of risk
+	Code Code `json:"code"`
+	// Human-readable description of the risk
+	Message string `json:"message"`
+}
+
+// Checker checks transactions for risks
+type Checker struct {
+	// Fee (in microAlgos) above which a transaction fee is considered to be
+	// very high.
+	// Default: DefaultHighFeeThreshold
+	HighFeeThreshold uint64
+	// Checks if the given address is of an account in the wallet. Used to find
+	// clawbacks from accounts in the wallet. If it is not set, all clawbacks
+	// are flagged.
+	IsWalletAddr func(addr string) (bool, error)
+}
+
+// Check checks the given transaction for risks. Returns the flags for the
+// risks found, which is empty if none were found.
+func (c *Checker) Check(txn types.Transaction) (flags []Flag, err error) {
+	flags = []Flag{}
+
+	if !txn.RekeyTo.IsZero() {
+		flags = append(flags, Flag{
+			Code:    CodeRekey,
+			Message: fmt.Sprintf("The sender's account will be rekeyed to %s, which gives that account full control of the sender's account.", txn.RekeyTo),
+		})
+	}
+
+	highFeeThreshold := c.HighFeeThreshold
+	if highFeeThreshold == 0 {
+		highFeeThreshold = DefaultHighFeeThreshold
+	}
+	if uint64(txn.Fee) > highFeeThreshold {
+		flags = append(flags, Flag{
+			Code: CodeHighFee,
+			Message: fmt.Sprintf(
+				"The fee of %s Algos is higher than %s Algos.",
+				txn_decoder.NewAlgoAmount(uint64(txn.Fee)).Algos,
+				txn_decoder.NewAlgoAmount(highFeeThreshold).Algos,
+			),
+		})
+	}
+
+	switch txn.Type {
+	case types.PaymentTx:
+		if !txn.CloseRemainderTo.IsZero() {
+			flags = append(flags, Flag{
+				Code:    CodeCloseRemainderTo,
+				Message: fmt.Sprintf("The sender's account will be closed and all of its remaining Algos will be sent to %s.", txn.CloseRemainderTo),
+			})
+		}
+	case types.AssetTransferTx:
+		if !txn.AssetCloseTo.IsZero() {
+			flags = append(flags, Flag{
+				Code:    CodeAssetCloseTo,
+				Message: fmt.Sprintf("The sender's holding of asset %d will be closed and all of its remaining units will be sent to %s.", txn.XferAsset, txn.AssetCloseTo),
+			})
+		}
+		if !txn.AssetSender.IsZero() {
+			isWalletAddr := true
+			if c.IsWalletAddr != nil {
+				if isWalletAddr, err = c.IsWalletAddr(txn.AssetSender.String()); err != nil {
+					return nil, err
+				}
+			}
+			if isWalletAddr {
+				flags = append(flags, Flag{
+					Code:    CodeClawback,
+					Message: fmt.Sprintf("Units of asset %d will be clawed back from %s.", txn.XferAsset, txn.AssetSender),
+				})
+			}
+		}
+	case types.KeyRegistrationTx:
+		if txn.Nonparticipation {
+			flags = append(flags, Flag{
+				Code:    CodeKeyRegNonparticipation,
+				Message: "The sender's account will be permanently marked as not participating in consensus. This cannot be undone.",
+			})
+		} else if txn.VotePK == (types.VotePK{}) {
+			flags = append(flags, Flag{
+				Code:    CodeKeyRegOffline,
+				Message: "The sender's account will be taken offline and will stop participating in consensus.",
+			})
+		}
+	case types.ApplicationCallTx:
+		switch txn.OnCompletion {
+		case types.ClearStateOC:
+			flags = append(flags, Flag{
+				Code:    CodeAppClearState,
+				Message: fmt.Sprintf("The sender's local state of application %d will be cleared, which may lose anything the application holds for the sender.", txn.ApplicationID),
+			})
+		case types.UpdateApplicationOC:
+			flags = append(flags, Flag{
+				Code:    CodeAppUpdate,
+				Message: fmt.Sprintf("The programs of application %d will be replaced.", txn.ApplicationID),
+			})
+		case types.DeleteApplicationOC:
+			flags = append(flags, Flag{
+				Code:    CodeAppDelete,
+				Message: fmt.Sprintf("Application %d will be deleted.", txn.ApplicationID),
+			})
+		}
+	}
+
+	return
+}
+
+// Unacknowledged gives the codes of the given flags that are not in the given
+// list of acknowledged codes
+func Unacknowledged(flags []Flag, acknowledged []Code) (codes []Code) {
+	for _, flag := range flags {
+		if !slices.Contains(acknowledged, flag.Code) {
+			codes = append(codes, flag.Code)
+		}
+	}
+	return
+}
+
+// Codes gives the codes of the given flags
+func Codes(flags []Flag) (codes []Code) {
+	for _, flag := range flags {
+		codes = append(codes, flag.Code)
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package txn_risk_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTxnRisk(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Risk Suite")
+}
@@ -0,0 +1,174 @@
+package txn_risk_test
+
+import (
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/txn_risk"
+)
+
+const testAddrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const testAddrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+var testParams = types.SuggestedParams{
+	Fee:             1000,
+	GenesisID:       "testnet-v1.0",
+	GenesisHash:     make([]byte, 32),
+	FirstRoundValid: 1000,
+	LastRoundValid:  2000,
+	FlatFee:         true,
+}
+
+// mustAddr decodes the given address string
+func mustAddr(addr string) types.Address {
+	decoded, err := types.DecodeAddress(addr)
+	Expect(err).ToNot(HaveOccurred())
+	return decoded
+}
+
+// makePayment creates a payment transaction from account A to account B
+func makePayment() types.Transaction {
+	txn, err := transaction.MakePaymentTxn(testAddrA, testAddrB, 1000, nil, "", testParams)
+	Expect(err).ToNot(HaveOccurred())
+	return txn
+}
+
+// makeAppCall creates an application call transaction with the given
+// on-completion action
+func makeAppCall(onComplete types.OnCompletion) types.Transaction {
+	txn := makePayment()
+	txn.Type = types.ApplicationCallTx
+	txn.PaymentTxnFields = types.PaymentTxnFields{}
+	txn.ApplicationID = 42
+	txn.OnCompletion = onComplete
+	return txn
+}
+
+var _ = Describe("Checker", func() {
+	Describe("Check()", func() {
+		DescribeTable("flags risky transactions",
+			func(makeTxn func() types.Transaction, codes []txn_risk.Code) {
+				checker := txn_risk.Checker{}
+				flags, err := checker.Check(makeTxn())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(txn_risk.Codes(flags)).To(Equal(codes))
+				for _, flag := range flags {
+					Expect(flag.Message).ToNot(BeEmpty())
+				}
+			},
+			Entry("safe payment", makePayment, []txn_risk.Code(nil)),
+			Entry("rekey", func() types.Transaction {
+				txn := makePayment()
+				txn.RekeyTo = mustAddr(testAddrB)
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeRekey}),
+			Entry("close remainder to", func() types.Transaction {
+				txn := makePayment()
+				txn.CloseRemainderTo = mustAddr(testAddrB)
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeCloseRemainderTo}),
+			Entry("asset close to", func() types.Transaction {
+				txn, err := transaction.MakeAssetTransferTxn(testAddrA, testAddrB, 0, nil, testParams, testAddrB, 1234)
+				Expect(err).ToNot(HaveOccurred())
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeAssetCloseTo}),
+			Entry("clawback", func() types.Transaction {
+				txn, err := transaction.MakeAssetRevocationTxn(testAddrA, testAddrB, 10, testAddrA, nil, testParams, 1234)
+				Expect(err).ToNot(HaveOccurred())
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeClawback}),
+			Entry("keyreg going offline", func() types.Transaction {
+				txn, err := transaction.MakeKeyRegTxnWithStateProofKey(testAddrA, nil, testParams, "", "", "", 0, 0, 0, false)
+				Expect(err).ToNot(HaveOccurred())
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeKeyRegOffline}),
+			Entry("keyreg nonparticipation", func() types.Transaction {
+				txn, err := transaction.MakeKeyRegTxnWithStateProofKey(testAddrA, nil, testParams, "", "", "", 0, 0, 0, true)
+				Expect(err).ToNot(HaveOccurred())
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeKeyRegNonparticipation}),
+			Entry("high fee", func() types.Transaction {
+				txn := makePayment()
+				txn.Fee = types.MicroAlgos(txn_risk.DefaultHighFeeThreshold + 1)
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeHighFee}),
+			Entry("app no-op", func() types.Transaction {
+				return makeAppCall(types.NoOpOC)
+			}, []txn_risk.Code(nil)),
+			Entry("app clear state", func() types.Transaction {
+				return makeAppCall(types.ClearStateOC)
+			}, []txn_risk.Code{txn_risk.CodeAppClearState}),
+			Entry("app update", func() types.Transaction {
+				return makeAppCall(types.UpdateApplicationOC)
+			}, []txn_risk.Code{txn_risk.CodeAppUpdate}),
+			Entry("app delete", func() types.Transaction {
+				return makeAppCall(types.DeleteApplicationOC)
+			}, []txn_risk.Code{txn_risk.CodeAppDelete}),
+			Entry("multiple risks", func() types.Transaction {
+				txn := makePayment()
+				txn.RekeyTo = mustAddr(testAddrB)
+				txn.CloseRemainderTo = mustAddr(testAddrB)
+				return txn
+			}, []txn_risk.Code{txn_risk.CodeRekey, txn_risk.CodeCloseRemainderTo}),
+		)
+
+		It("uses the given high fee threshold", func() {
+			txn := makePayment()
+			txn.Fee = 5000
+
+			flags, err := (&txn_risk.Checker{HighFeeThreshold: 5000}).Check(txn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(flags).To(BeEmpty())
+
+			flags, err = (&txn_risk.Checker{HighFeeThreshold: 4999}).Check(txn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(txn_risk.Codes(flags)).To(Equal([]txn_risk.Code{txn_risk.CodeHighFee}))
+		})
+
+		It("only flags clawbacks from accounts in the wallet", func() {
+			checker := txn_risk.Checker{
+				IsWalletAddr: func(addr string) (bool, error) { return addr == testAddrA, nil },
+			}
+
+			txn, err := transaction.MakeAssetRevocationTxn(testAddrA, testAddrB, 10, testAddrA, nil, testParams, 1234)
+			Expect(err).ToNot(HaveOccurred())
+			flags, err := checker.Check(txn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(flags).To(BeEmpty(), "Clawback from account not in wallet")
+
+			txn, err = transaction.MakeAssetRevocationTxn(testAddrB, testAddrA, 10, testAddrB, nil, testParams, 1234)
+			Expect(err).ToNot(HaveOccurred())
+			flags, err = checker.Check(txn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(txn_risk.Codes(flags)).To(Equal([]txn_risk.Code{txn_risk.CodeClawback}))
+		})
+
+		It("fails if the wallet address check fails", func() {
+			checkErr := errors.New("wallet is locked")
+			checker := txn_risk.Checker{
+				IsWalletAddr: func(string) (bool, error) { return false, checkErr },
+			}
+
+			txn, err := transaction.MakeAssetRevocationTxn(testAddrB, testAddrA, 10, testAddrB, nil, testParams, 1234)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = checker.Check(txn)
+			Expect(err).To(MatchError(checkErr))
+		})
+	})
+})
+
+var _ = Describe("Unacknowledged()", func() {
+	It("gives the codes that were not acknowledged", func() {
+		flags := []txn_risk.Flag{{Code: txn_risk.CodeRekey}, {Code: txn_risk.CodeHighFee}}
+		Expect(txn_risk.Unacknowledged(flags, nil)).
+			To(Equal([]txn_risk.Code{txn_risk.CodeRekey, txn_risk.CodeHighFee}))
+		Expect(txn_risk.Unacknowledged(flags, []txn_risk.Code{txn_risk.CodeHighFee})).
+			To(Equal([]txn_risk.Code{txn_risk.CodeRekey}))
+		Expect(txn_risk.Unacknowledged(flags, []txn_risk.Code{txn_risk.CodeHighFee, txn_risk.CodeRekey})).
+			To(BeEmpty())
+	})
+})
@@ -0,0 +1,312 @@
+// Package txn_simulator simulates transactions with the algod API of their
+// network before they are signed, so the user can see what signing them would
+// do (e.g. balance changes and application state changes) and whether they
+// would fail
+package txn_simulator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/algod"
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/txn_decoder"
+)
+
+// App state change actions
+const (
+	// ActionSetBytes is for a key that is set to a byte string value
+	ActionSetBytes = "set_bytes"
+	// ActionSetUint is for a key that is set to an integer value
+	ActionSetUint = "set_uint"
+	// ActionDelete is for a key that is deleted
+	ActionDelete = "delete"
+)
+
+// ErrUnknownNetwork is the error returned when the network of the transactions
+// to simulate is not known
+var ErrUnknownNetwork = errors.New("the network of the transactions is not known")
+
+type (
+	// Result is the result of simulating a transaction or transaction group
+	Result struct {
+		// If the transactions would succeed if they were signed and submitted
+		WouldSucceed bool `json:"would_succeed"`
+		// Why the transactions would fail
+		FailureMessage string `json:"failure_message,omitempty"`
+		// Path to the transaction that would fail, which is the index of the
+		// transaction in the group followed by the indexes of the inner
+		// transactions
+		FailedAt []uint64 `json:"failed_at,omitempty"`
+		// Changes to the Algo and asset balances of the accounts involved
+		BalanceChanges []BalanceChange `json:"balance_changes"`
+		// Changes to the global and local states of applications
+		AppStateChanges []AppStateChange `json:"app_state_changes"`
+		// Inner transactions that would be issued by applications
+		InnerTxns []*txn_decoder.Summary `json:"inner_txns"`
+		// Why the transactions could not be simulated. It is only set if
+		// signing is allowed without the simulation (i.e. failing open).
+		Error string `json:"error,omitempty"`
+	}
+
+	// BalanceChange is the change to the balance of an Algo or asset holding
+	// of an account. The net change is what was received minus what was sent.
+	BalanceChange struct {
+		// Address of the account
+		Address string `json:"address"`
+		// ID of the asset, which is 0 for Algos
+		AssetID uint64 `json:"asset_id"`
+		// Amount (in microAlgos or base units of the asset) sent from the
+		// account, including fees
+		Sent uint64 `json:"sent"`
+		// Amount (in microAlgos or base units of the asset) received by the
+		// account
+		Received uint64 `json:"received"`
+	}
+
+	// AppStateChange is a change to a key in the global state or the local
+	// state of an account for an application
+	AppStateChange struct {
+		// ID of the application
+		AppID uint64 `json:"app_id"`
+		// Address of the account whose local state changed. It is empty if
+		// the global state changed.
+		Address string `json:"address,omitempty"`
+		// The key that changed
+		Key txn_decoder.Bytes `json:"key"`
+		// What was done to the key (e.g. set to a byte string value or
+		// deleted)
+		Action string `json:"action"`
+		// The byte string value the key was set to
+		Bytes *txn_decoder.Bytes `json:"bytes,omitempty"`
+		// The integer value the key was set to
+		Uint uint64 `json:"uint,omitempty"`
+	}
+)
+
+// Failed returns true if the simulation showed the transactions would fail. It
+// is false if the transactions could not be simulated.
+func (r *Result) Failed() bool {
+	return r != nil && r.Error == "" && !r.WouldSucceed
+}
+
+// Simulator simulates transactions before they are signed
+type Simulator struct {
+	// Whether to simulate and what to do if simulation is not possible
+	Config config.SimulationConfig
+	// Gives the algod client for the network of the transactions
+	AlgodProvider algod.Provider
+	// Gives the profile of the network the given transaction is for, or nil if
+	// the network is not known
+	Network func(txn types.Transaction) *config.NetworkProfile
+}
+
+// Simulate simulates the given transaction group (or single transaction)
+// unsigned. Returns nil without simulating if simulation is disabled. If the
+// transactions could not be simulated, an error is returned when failing
+// closed, or else a Result with only the error message is returned.
+func (s *Simulator) Simulate(ctx context.Context, txns []types.Transaction) (*Result, error) {
+	if s == nil || !s.Config.Enabled {
+		return nil, nil
+	}
+
+	result, err := s.simulate(ctx, txns)
+	if err != nil {
+		if s.Config.FailClosed {
+			return nil, err
+		}
+		return &Result{Error: err.Error()}, nil
+	}
+
+	return result, nil
+}
+
+// simulate simulates the given transaction group using the algod API of its
+// network
+func (s *Simulator) simulate(ctx context.Context, txns []types.Transaction) (*Result, error) {
+	if len(txns) == 0 {
+		return nil, errors.New("no transactions to simulate")
+	}
+
+	var network *config.NetworkProfile
+	if s.Network != nil {
+		network = s.Network(txns[0])
+	}
+	if network == nil {
+		return nil, ErrUnknownNetwork
+	}
+
+	client, err := s.AlgodProvider(*network)
+	if err != nil {
+		return nil, err
+	}
+
+	request := models.SimulateRequest{
+		AllowEmptySignatures: true,
+		TxnGroups:            []models.SimulateRequestTransactionGroup{{}},
+	}
+	for _, txn := range txns {
+		request.TxnGroups[0].Txns = append(request.TxnGroups[0].Txns, types.SignedTxn{Txn: txn})
+	}
+
+	resp, err := client.Simulate(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("could not simulate the transactions: %w", err)
+	}
+	if len(resp.TxnGroups) != 1 {
+		return nil, fmt.Errorf("expected the result of 1 transaction group, got %d", len(resp.TxnGroups))
+	}
+
+	return newResult(txns, resp.TxnGroups[0]), nil
+}
+
+// newResult creates the Result for the given transaction group from the given
+// simulation result of the group
+func newResult(txns []types.Transaction, groupResult models.SimulateTransactionGroupResult) *Result {
+	result := Result{
+		WouldSucceed:    groupResult.FailureMessage == "",
+		FailureMessage:  groupResult.FailureMessage,
+		FailedAt:        groupResult.FailedAt,
+		BalanceChanges:  []BalanceChange{},
+		AppStateChanges: []AppStateChange{},
+		InnerTxns:       []*txn_decoder.Summary{},
+	}
+	balances := balanceTracker{result: &result, index: map[balanceKey]int{}}
+
+	for i, txn := range txns {
+		var txnResult models.PendingTransactionResponse
+		if i < len(groupResult.TxnResults) {
+			txnResult = groupResult.TxnResults[i].TxnResult
+		}
+		result.addTxn(txn, txnResult, &balances)
+	}
+
+	return &result
+}
+
+// addTxn adds the changes made by the given transaction, as given in its
+// simulation result, and the changes made by its inner transactions to the
+// result
+func (r *Result) addTxn(txn types.Transaction, txnResult models.PendingTransactionResponse, balances *balanceTracker) {
+	sender := txn.Sender.String()
+	balances.send(sender, "", 0, uint64(txn.Fee))
+
+	switch txn.Type {
+	case types.PaymentTx:
+		balances.send(sender, txn.Receiver.String(), 0, uint64(txn.Amount))
+		if !txn.CloseRemainderTo.IsZero() {
+			balances.send(sender, txn.CloseRemainderTo.String(), 0, txnResult.ClosingAmount)
+		}
+	case types.AssetTransferTx:
+		assetSender := sender
+		if !txn.AssetSender.IsZero() { // Clawback
+			assetSender = txn.AssetSender.String()
+		}
+		balances.send(assetSender, txn.AssetReceiver.String(), uint64(txn.XferAsset), txn.AssetAmount)
+		if !txn.AssetCloseTo.IsZero() {
+			balances.send(assetSender, txn.AssetCloseTo.String(), uint64(txn.XferAsset), txnResult.AssetClosingAmount)
+		}
+	case types.AssetConfigTx:
+		if txn.ConfigAsset == 0 && txnResult.AssetIndex != 0 { // Asset creation
+			balances.send("", sender, txnResult.AssetIndex, txn.AssetParams.Total)
+		}
+	case types.ApplicationCallTx:
+		appID := uint64(txn.ApplicationID)
+		if appID == 0 { // Application creation
+			appID = txnResult.ApplicationIndex
+		}
+		for _, kv := range txnResult.GlobalStateDelta {
+			r.AppStateChanges = append(r.AppStateChanges, newAppStateChange(appID, "", kv))
+		}
+		for _, acctDelta := range txnResult.LocalStateDelta {
+			for _, kv := range acctDelta.Delta {
+				r.AppStateChanges = append(r.AppStateChanges, newAppStateChange(appID, acctDelta.Address, kv))
+			}
+		}
+	}
+
+	for _, inner := range txnResult.InnerTxns {
+		r.InnerTxns = append(r.InnerTxns, txn_decoder.Decode(inner.Transaction.Txn))
+		r.addTxn(inner.Transaction.Txn, inner, balances)
+	}
+}
+
+// newAppStateChange creates an AppStateChange for the given key-value change
+// to the state of the given application
+func newAppStateChange(appID uint64, address string, kv models.EvalDeltaKeyValue) AppStateChange {
+	change := AppStateChange{AppID: appID, Address: address, Key: decodeB64Bytes(kv.Key)}
+
+	switch kv.Value.Action {
+	case 1:
+		change.Action = ActionSetBytes
+		value := decodeB64Bytes(kv.Value.Bytes)
+		change.Bytes = &value
+	case 2:
+		change.Action = ActionSetUint
+		change.Uint = kv.Value.Uint
+	default:
+		change.Action = ActionDelete
+	}
+
+	return change
+}
+
+// decodeB64Bytes decodes the given base64 byte string into a Bytes. The base64
+// string is used as is if it cannot be decoded.
+func decodeB64Bytes(b64 string) txn_decoder.Bytes {
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		b = []byte(b64)
+	}
+	return txn_decoder.NewBytes(b)
+}
+
+// balanceKey identifies an Algo or asset holding of an account
+type balanceKey struct {
+	address string
+	assetID uint64
+}
+
+// balanceTracker adds up the balance changes of a result, keeping them in the
+// order the holdings were first changed
+type balanceTracker struct {
+	result *Result
+	// Index of each holding's balance change in the result
+	index map[balanceKey]int
+}
+
+// send records the given amount of Algos or assets as sent from one account to
+// another. Either account can be empty (e.g. for fees or newly created
+// assets). Zero amounts are not recorded.
+func (t *balanceTracker) send(from, to string, assetID, amount uint64) {
+	if amount == 0 {
+		return
+	}
+	if from != "" {
+		t.change(from, assetID).Sent += amount
+	}
+	if to != "" {
+		t.change(to, assetID).Received += amount
+	}
+}
+
+// change gives the balance change for the given holding, adding it to the
+// result if it is not there yet
+func (t *balanceTracker) change(address string, assetID uint64) *BalanceChange {
+	key := balanceKey{address, assetID}
+	i, ok := t.index[key]
+	if !ok {
+		i = len(t.result.BalanceChanges)
+		t.index[key] = i
+		t.result.BalanceChanges = append(t.result.BalanceChanges, BalanceChange{
+			Address: address,
+			AssetID: assetID,
+		})
+	}
+	return &t.result.BalanceChanges[i]
+}
@@ -0,0 +1,13 @@
+package txn_simulator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTxnSimulator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Simulator Suite")
+}
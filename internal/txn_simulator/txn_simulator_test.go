@@ -0,0 +1,256 @@
+package txn_simulator_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/v2/encoding/json"
+	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/algod"
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_simulator"
+)
+
+const addrA = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+const addrB = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+
+// makeTxn creates a transaction of the given type from address A
+func makeTxn(txnType types.TxType) types.Transaction {
+	sender, err := types.DecodeAddress(addrA)
+	Expect(err).NotTo(HaveOccurred())
+	return types.Transaction{
+		Type: txnType,
+		Header: types.Header{
+			Sender:     sender,
+			Fee:        1000,
+			FirstValid: 1,
+			LastValid:  1000,
+			GenesisID:  "testnet-v1.0",
+		},
+	}
+}
+
+// mustDecodeAddr decodes the given address
+func mustDecodeAddr(addr string) types.Address {
+	decoded, err := types.DecodeAddress(addr)
+	Expect(err).NotTo(HaveOccurred())
+	return decoded
+}
+
+// b64 encodes the given string in base64
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+var _ = Describe("Simulator", func() {
+	var server *httptest.Server
+	var simulateRequests []models.SimulateRequest
+	var simulateResp models.SimulateResponse
+	var simulator *txn_simulator.Simulator
+
+	BeforeEach(func() {
+		// Stand-in for the algod API
+		simulateRequests = nil
+		simulateResp = models.SimulateResponse{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2/transactions/simulate" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			var request models.SimulateRequest
+			Expect(msgpack.Decode(body, &request)).To(Succeed())
+			simulateRequests = append(simulateRequests, request)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(json.Encode(simulateResp))
+		}))
+		DeferCleanup(server.Close)
+
+		testNet := config.NetworkProfile{Name: "TestNet", GenesisID: "testnet-v1.0", AlgodURL: server.URL}
+		simulator = &txn_simulator.Simulator{
+			Config:        config.SimulationConfig{Enabled: true},
+			AlgodProvider: algod.NewHTTPClient,
+			Network: func(txn types.Transaction) *config.NetworkProfile {
+				if txn.GenesisID == testNet.GenesisID {
+					return &testNet
+				}
+				return nil
+			},
+		}
+	})
+
+	It("does not simulate if it is disabled", func() {
+		simulator.Config.Enabled = false
+		result, err := simulator.Simulate(context.Background(), []types.Transaction{makeTxn(types.PaymentTx)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+		Expect(simulateRequests).To(BeEmpty())
+
+		var nilSimulator *txn_simulator.Simulator
+		result, err = nilSimulator.Simulate(context.Background(), []types.Transaction{makeTxn(types.PaymentTx)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+	})
+
+	It("simulates unsigned transactions and gives the balance changes", func() {
+		txn := makeTxn(types.PaymentTx)
+		txn.Receiver = mustDecodeAddr(addrB)
+		txn.Amount = 5_000_000
+		simulateResp = models.SimulateResponse{
+			TxnGroups: []models.SimulateTransactionGroupResult{{
+				TxnResults: []models.SimulateTransactionResult{{}},
+			}},
+		}
+
+		result, err := simulator.Simulate(context.Background(), []types.Transaction{txn})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("checking the request")
+		Expect(simulateRequests).To(HaveLen(1))
+		Expect(simulateRequests[0].AllowEmptySignatures).To(BeTrue())
+		Expect(simulateRequests[0].TxnGroups).To(HaveLen(1))
+		Expect(simulateRequests[0].TxnGroups[0].Txns).To(Equal([]types.SignedTxn{{Txn: txn}}))
+
+		By("checking the result")
+		Expect(result.WouldSucceed).To(BeTrue())
+		Expect(result.Error).To(BeEmpty())
+		Expect(result.BalanceChanges).To(Equal([]txn_simulator.BalanceChange{
+			{Address: addrA, AssetID: 0, Sent: 5_001_000},
+			{Address: addrB, AssetID: 0, Received: 5_000_000},
+		}))
+		Expect(result.AppStateChanges).To(BeEmpty())
+		Expect(result.InnerTxns).To(BeEmpty())
+	})
+
+	It("gives the app state changes and inner transactions", func() {
+		txn := makeTxn(types.ApplicationCallTx)
+		txn.ApplicationID = 123
+		innerTxn := types.Transaction{
+			Type: types.AssetTransferTx,
+			Header: types.Header{
+				Sender: mustDecodeAddr(addrB),
+				Fee:    0,
+			},
+			AssetTransferTxnFields: types.AssetTransferTxnFields{
+				XferAsset:     10,
+				AssetAmount:   25,
+				AssetReceiver: mustDecodeAddr(addrA),
+			},
+		}
+		simulateResp = models.SimulateResponse{
+			TxnGroups: []models.SimulateTransactionGroupResult{{
+				TxnResults: []models.SimulateTransactionResult{{
+					TxnResult: models.PendingTransactionResponse{
+						GlobalStateDelta: []models.EvalDeltaKeyValue{
+							{Key: b64("counter"), Value: models.EvalDelta{Action: 2, Uint: 7}},
+						},
+						LocalStateDelta: []models.AccountStateDelta{{
+							Address: addrA,
+							Delta: []models.EvalDeltaKeyValue{
+								{Key: b64("name"), Value: models.EvalDelta{Action: 1, Bytes: b64("ducky")}},
+								{Key: b64("old"), Value: models.EvalDelta{Action: 3}},
+							},
+						}},
+						InnerTxns: []models.PendingTransactionResponse{
+							{Transaction: types.SignedTxn{Txn: innerTxn}},
+						},
+					},
+				}},
+			}},
+		}
+
+		result, err := simulator.Simulate(context.Background(), []types.Transaction{txn})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.BalanceChanges).To(Equal([]txn_simulator.BalanceChange{
+			{Address: addrA, AssetID: 0, Sent: 1000},
+			{Address: addrB, AssetID: 10, Sent: 25},
+			{Address: addrA, AssetID: 10, Received: 25},
+		}))
+		nameValue := txn_decoder.NewBytes([]byte("ducky"))
+		Expect(result.AppStateChanges).To(Equal([]txn_simulator.AppStateChange{
+			{AppID: 123, Key: txn_decoder.NewBytes([]byte("counter")), Action: txn_simulator.ActionSetUint, Uint: 7},
+			{AppID: 123, Address: addrA, Key: txn_decoder.NewBytes([]byte("name")), Action: txn_simulator.ActionSetBytes, Bytes: &nameValue},
+			{AppID: 123, Address: addrA, Key: txn_decoder.NewBytes([]byte("old")), Action: txn_simulator.ActionDelete},
+		}))
+		Expect(result.InnerTxns).To(HaveLen(1))
+		Expect(result.InnerTxns[0].Type).To(Equal(string(types.AssetTransferTx)))
+		Expect(result.InnerTxns[0].Sender).To(Equal(addrB))
+	})
+
+	It("gives the closing amounts", func() {
+		txn := makeTxn(types.PaymentTx)
+		txn.Receiver = mustDecodeAddr(addrB)
+		txn.CloseRemainderTo = mustDecodeAddr(addrB)
+		simulateResp = models.SimulateResponse{
+			TxnGroups: []models.SimulateTransactionGroupResult{{
+				TxnResults: []models.SimulateTransactionResult{{
+					TxnResult: models.PendingTransactionResponse{ClosingAmount: 3_000_000},
+				}},
+			}},
+		}
+
+		result, err := simulator.Simulate(context.Background(), []types.Transaction{txn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.BalanceChanges).To(Equal([]txn_simulator.BalanceChange{
+			{Address: addrA, AssetID: 0, Sent: 3_001_000},
+			{Address: addrB, AssetID: 0, Received: 3_000_000},
+		}))
+	})
+
+	It("gives the reason the transactions would fail", func() {
+		simulateResp = models.SimulateResponse{
+			TxnGroups: []models.SimulateTransactionGroupResult{{
+				FailureMessage: "transaction rejected: overspend",
+				FailedAt:       []uint64{0},
+				TxnResults:     []models.SimulateTransactionResult{{}},
+			}},
+		}
+
+		result, err := simulator.Simulate(context.Background(), []types.Transaction{makeTxn(types.PaymentTx)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.WouldSucceed).To(BeFalse())
+		Expect(result.FailureMessage).To(Equal("transaction rejected: overspend"))
+		Expect(result.FailedAt).To(Equal([]uint64{0}))
+	})
+
+	Context("when the transactions cannot be simulated", func() {
+		BeforeEach(func() {
+			// Make algod unreachable
+			server.Close()
+		})
+
+		It("fails open by default", func() {
+			result, err := simulator.Simulate(context.Background(), []types.Transaction{makeTxn(types.PaymentTx)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.WouldSucceed).To(BeFalse())
+			Expect(result.Error).NotTo(BeEmpty())
+		})
+
+		It("fails closed if configured to", func() {
+			simulator.Config.FailClosed = true
+			result, err := simulator.Simulate(context.Background(), []types.Transaction{makeTxn(types.PaymentTx)})
+			Expect(err).To(HaveOccurred())
+			Expect(result).To(BeNil())
+		})
+
+		It("fails for transactions for an unknown network", func() {
+			simulator.Config.FailClosed = true
+			txn := makeTxn(types.PaymentTx)
+			txn.GenesisID = "foonet-v1"
+			_, err := simulator.Simulate(context.Background(), []types.Transaction{txn})
+			Expect(err).To(MatchError(txn_simulator.ErrUnknownNetwork))
+		})
+	})
+})
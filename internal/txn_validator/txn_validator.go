@@ -0,0 +1,163 @@
+// Package txn_validator checks that a transaction is sane before it is signed,
+// such as checking that its fee is not absurdly high and that it is for a
+// network the wallet is configured for
+package txn_validator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/algorand/go-algorand-sdk/v2/types"
+
+	"duckysigner/internal/kmd/config"
+)
+
+// Code identifies why a transaction is invalid
+type Code string
+
+const (
+	// The fee is higher than the maximum
+	CodeFeeTooHigh Code = "fee_too_high"
+	// The first valid round is after the last valid round
+	CodeInvalidValidityWindow Code = "invalid_validity_window"
+	// The transaction is valid for more rounds than the maximum
+	CodeValidityWindowTooLong Code = "validity_window_too_long"
+	// The transaction has a lease and is valid for more rounds than the
+	// maximum for leases
+	CodeLeaseWindowTooLong Code = "lease_window_too_long"
+	// The transaction does not have a genesis hash
+	CodeMissingGenesisHash Code = "missing_genesis_hash"
+	// The transaction is for a network that is not configured
+	CodeUnknownNetwork Code = "unknown_network"
+	// The transaction is for a network other than the ones allowed (e.g. the
+	// networks a dApp connect session is bound to)
+	CodeWrongNetwork Code = "wrong_network"
+	// The wallet does not support signing the type of transaction
+	CodeUnsupportedTxnType Code = "unsupported_txn_type"
+)
+
+// DefaultMaxFee is the default highest fee (in microAlgos) a transaction can
+// have
+const DefaultMaxFee uint64 = 1_000_000 // 1 Algo
+
+// DefaultMaxValidRounds is the default most rounds a transaction can be valid
+// for, which is the most the protocol allows
+const DefaultMaxValidRounds uint64 = 1000
+
+// DefaultMaxLeaseRounds is the default most rounds a transaction with a lease
+// can be valid for
+const DefaultMaxLeaseRounds uint64 = DefaultMaxValidRounds
+
+// ValidationError is the error given when a transaction is invalid
+type ValidationError struct {
+	// Identifies why the transaction is invalid
+	Code Code `json:"code"`
+	// Human-readable description of why the transaction is invalid
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Validator checks transactions before they are signed
+type Validator struct {
+	config config.TxnValidationConfig
+	// Profiles of the networks transactions can be for
+	networks []config.NetworkProfile
+}
+
+// New creates a Validator with the given configuration that only allows
+// transactions for the given networks. Transactions for any network are
+// allowed if no networks are given.
+func New(cfg config.TxnValidationConfig, networks []config.NetworkProfile) *Validator {
+	return &Validator{config: cfg, networks: networks}
+}
+
+// Networks returns the profiles of the networks transactions can be for
+func (v *Validator) Networks() []config.NetworkProfile {
+	return v.networks
+}
+
+// Validate checks if the given transaction is sane and can be signed by a
+// wallet that supports the given transaction types. Returns a
+// *ValidationError if the transaction is invalid.
+func (v *Validator) Validate(txn types.Transaction, supportedTxns []types.TxType) error {
+	if !slices.Contains(supportedTxns, txn.Type) {
+		return newError(CodeUnsupportedTxnType,
+			"wallet does not support signing '%s' transactions", txn.Type)
+	}
+
+	maxFee := orDefault(v.config.MaxFee, DefaultMaxFee)
+	if uint64(txn.Fee) > maxFee {
+		return newError(CodeFeeTooHigh,
+			"transaction fee of %d microAlgos is higher than the maximum of %d microAlgos", txn.Fee, maxFee)
+	}
+
+	if txn.FirstValid > txn.LastValid {
+		return newError(CodeInvalidValidityWindow,
+			"transaction first valid round (%d) is after its last valid round (%d)", txn.FirstValid, txn.LastValid)
+	}
+	validRounds := uint64(txn.LastValid - txn.FirstValid)
+	if maxValidRounds := orDefault(v.config.MaxValidRounds, DefaultMaxValidRounds); validRounds > maxValidRounds {
+		return newError(CodeValidityWindowTooLong,
+			"transaction is valid for %d rounds, which is more than the maximum of %d rounds", validRounds, maxValidRounds)
+	}
+	maxLeaseRounds := orDefault(v.config.MaxLeaseRounds, DefaultMaxLeaseRounds)
+	if txn.Lease != ([32]byte{}) && validRounds > maxLeaseRounds {
+		return newError(CodeLeaseWindowTooLong,
+			"transaction with a lease is valid for %d rounds, which is more than the maximum of %d rounds",
+			validRounds, maxLeaseRounds)
+	}
+
+	if txn.GenesisHash == (types.Digest{}) {
+		return newError(CodeMissingGenesisHash, "transaction does not have a genesis hash")
+	}
+	if len(v.networks) > 0 && v.Network(txn) == nil {
+		return newError(CodeUnknownNetwork, "transaction is for a network that is not configured: '%s' (%s)",
+			txn.GenesisID, base64.StdEncoding.EncodeToString(txn.GenesisHash[:]))
+	}
+
+	return nil
+}
+
+// ValidateNetwork checks if the given transaction is for one of the networks
+// with the given profile names. Returns a *ValidationError if it is not.
+func (v *Validator) ValidateNetwork(txn types.Transaction, networkNames []string) error {
+	genesisHash := base64.StdEncoding.EncodeToString(txn.GenesisHash[:])
+	for _, network := range v.networks {
+		if slices.Contains(networkNames, network.Name) && network.Matches(txn.GenesisID, genesisHash) {
+			return nil
+		}
+	}
+
+	return newError(CodeWrongNetwork, "transaction is for a network other than the allowed networks: %s",
+		strings.Join(networkNames, ", "))
+}
+
+// Network gives the profile of the network the given transaction is for.
+// Returns nil if the transaction is not for any of the validator's networks.
+func (v *Validator) Network(txn types.Transaction) *config.NetworkProfile {
+	genesisHash := base64.StdEncoding.EncodeToString(txn.GenesisHash[:])
+	for i := range v.networks {
+		if v.networks[i].Matches(txn.GenesisID, genesisHash) {
+			return &v.networks[i]
+		}
+	}
+	return nil
+}
+
+// newError creates a ValidationError with the given code and formatted message
+func newError(code Code, format string, args ...any) *ValidationError {
+	return &ValidationError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// orDefault gives the given value, or the default value if it is zero
+func orDefault(value, defaultValue uint64) uint64 {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
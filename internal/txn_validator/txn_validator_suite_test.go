@@ -0,0 +1,13 @@
+package txn_validator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTxnValidator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transaction Validator Suite")
+}
@@ -0,0 +1,156 @@
+package txn_validator_test
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/algorand/go-algorand-sdk/v2/transaction"
+	"github.com/algorand/go-algorand-sdk/v2/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/txn_validator"
+)
+
+const testAddr = "RMAZSNHVLAMY5AUWWTSDON4S2HIUV7AYY6MWWEMKYH63YLHAKLZNHQIL3A"
+
+var testGenesisHash = []byte("01234567890123456789012345678901")
+
+var mainNet = config.NetworkProfile{
+	Name:        "MainNet",
+	GenesisID:   "mainnet-v1.0",
+	GenesisHash: "wGHE2Pwdvd7S12BL5FaOP20EGYesN73ktiC1qzkkit8=",
+}
+
+var testNet = config.NetworkProfile{
+	Name:        "TestNet",
+	GenesisID:   "testnet-v1.0",
+	GenesisHash: base64.StdEncoding.EncodeToString(testGenesisHash),
+}
+
+var localNet = config.NetworkProfile{Name: "LocalNet", GenesisID: "dockernet-v1"}
+
+var supportedTxns = []types.TxType{types.PaymentTx, types.AssetTransferTx}
+
+// makePayment creates a valid payment transaction
+func makePayment() types.Transaction {
+	txn, err := transaction.MakePaymentTxn(testAddr, testAddr, 1, nil, "", types.SuggestedParams{
+		Fee:             1000,
+		GenesisID:       "testnet-v1.0",
+		GenesisHash:     testGenesisHash,
+		FirstRoundValid: 1000,
+		LastRoundValid:  2000,
+		FlatFee:         true,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return txn
+}
+
+var _ = Describe("Validator", func() {
+	DescribeTable("Validate()",
+		func(cfg config.TxnValidationConfig, modify func(*types.Transaction), code txn_validator.Code) {
+			txn := makePayment()
+			if modify != nil {
+				modify(&txn)
+			}
+
+			err := txn_validator.New(cfg, nil).Validate(txn, supportedTxns)
+			expectValidationError(err, code)
+		},
+		Entry("valid transaction", config.TxnValidationConfig{}, nil, txn_validator.Code("")),
+		Entry("unsupported transaction type", config.TxnValidationConfig{}, func(txn *types.Transaction) {
+			txn.Type = types.KeyRegistrationTx
+		}, txn_validator.CodeUnsupportedTxnType),
+		Entry("fee above default maximum", config.TxnValidationConfig{}, func(txn *types.Transaction) {
+			txn.Fee = types.MicroAlgos(txn_validator.DefaultMaxFee + 1)
+		}, txn_validator.CodeFeeTooHigh),
+		Entry("fee at configured maximum", config.TxnValidationConfig{MaxFee: 1000}, nil, txn_validator.Code("")),
+		Entry("fee above configured maximum", config.TxnValidationConfig{MaxFee: 999}, nil,
+			txn_validator.CodeFeeTooHigh),
+		Entry("first valid after last valid", config.TxnValidationConfig{}, func(txn *types.Transaction) {
+			txn.FirstValid = txn.LastValid + 1
+		}, txn_validator.CodeInvalidValidityWindow),
+		Entry("validity window above default maximum", config.TxnValidationConfig{}, func(txn *types.Transaction) {
+			txn.LastValid = txn.FirstValid + types.Round(txn_validator.DefaultMaxValidRounds) + 1
+		}, txn_validator.CodeValidityWindowTooLong),
+		Entry("validity window above configured maximum", config.TxnValidationConfig{MaxValidRounds: 999}, nil,
+			txn_validator.CodeValidityWindowTooLong),
+		Entry("lease within window", config.TxnValidationConfig{MaxLeaseRounds: 1000}, func(txn *types.Transaction) {
+			txn.Lease = [32]byte{1}
+		}, txn_validator.Code("")),
+		Entry("lease with window above maximum", config.TxnValidationConfig{MaxLeaseRounds: 10},
+			func(txn *types.Transaction) {
+				txn.Lease = [32]byte{1}
+			}, txn_validator.CodeLeaseWindowTooLong),
+		Entry("window above lease maximum without a lease", config.TxnValidationConfig{MaxLeaseRounds: 10}, nil,
+			txn_validator.Code("")),
+		Entry("missing genesis hash", config.TxnValidationConfig{}, func(txn *types.Transaction) {
+			txn.GenesisHash = types.Digest{}
+		}, txn_validator.CodeMissingGenesisHash),
+	)
+
+	DescribeTable("Validate() with networks",
+		func(networks []config.NetworkProfile, modify func(*types.Transaction), code txn_validator.Code) {
+			txn := makePayment()
+			if modify != nil {
+				modify(&txn)
+			}
+
+			err := txn_validator.New(config.TxnValidationConfig{}, networks).Validate(txn, supportedTxns)
+			expectValidationError(err, code)
+		},
+		Entry("configured network", []config.NetworkProfile{mainNet, testNet}, nil, txn_validator.Code("")),
+		Entry("configured network without genesis ID in transaction", []config.NetworkProfile{testNet},
+			func(txn *types.Transaction) {
+				txn.GenesisID = ""
+			}, txn_validator.Code("")),
+		Entry("network with other genesis ID", []config.NetworkProfile{testNet}, func(txn *types.Transaction) {
+			txn.GenesisID = "betanet-v1.0"
+		}, txn_validator.CodeUnknownNetwork),
+		Entry("network that is not configured", []config.NetworkProfile{mainNet}, nil,
+			txn_validator.CodeUnknownNetwork),
+		Entry("network identified by genesis ID only", []config.NetworkProfile{localNet},
+			func(txn *types.Transaction) {
+				txn.GenesisID = localNet.GenesisID
+			}, txn_validator.Code("")),
+	)
+
+	Describe("ValidateNetwork()", func() {
+		validator := txn_validator.New(config.TxnValidationConfig{}, []config.NetworkProfile{mainNet, testNet, localNet})
+
+		It("allows transactions for the given networks", func() {
+			Expect(validator.ValidateNetwork(makePayment(), []string{"LocalNet", "TestNet"})).To(Succeed())
+		})
+
+		It("rejects transactions for other networks", func() {
+			expectValidationError(
+				validator.ValidateNetwork(makePayment(), []string{"MainNet"}),
+				txn_validator.CodeWrongNetwork,
+			)
+			expectValidationError(validator.ValidateNetwork(makePayment(), nil), txn_validator.CodeWrongNetwork)
+		})
+	})
+
+	It("gives the network of a transaction", func() {
+		validator := txn_validator.New(config.TxnValidationConfig{}, []config.NetworkProfile{mainNet, testNet})
+		Expect(validator.Network(makePayment())).To(Equal(&testNet))
+
+		txn := makePayment()
+		txn.GenesisID = "mainnet-v1.0"
+		Expect(validator.Network(txn)).To(BeNil())
+	})
+})
+
+// expectValidationError checks that the given error is a validation error
+// with the given code, or that there is no error if the code is empty
+func expectValidationError(err error, code txn_validator.Code) {
+	GinkgoHelper()
+	if code == "" {
+		Expect(err).ToNot(HaveOccurred())
+		return
+	}
+	var validationErr *txn_validator.ValidationError
+	Expect(errors.As(err, &validationErr)).To(BeTrue())
+	Expect(validationErr.Code).To(Equal(code))
+}
@@ -2,11 +2,22 @@ package wallet_session
 
 import (
 	"crypto/ed25519"
+	"duckysigner/internal/address_book"
+	"duckysigner/internal/app_spec"
+	"duckysigner/internal/audit"
+	"duckysigner/internal/kmd/config"
 	"duckysigner/internal/kmd/wallet"
+	"duckysigner/internal/policy"
+	"duckysigner/internal/spend_limit"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_validator"
 	"encoding/base64"
 	"errors"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/algorand/go-algorand-sdk/v2/abi"
 	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
 	"github.com/algorand/go-algorand-sdk/v2/mnemonic"
 	"github.com/algorand/go-algorand-sdk/v2/types"
@@ -21,10 +32,52 @@ type WalletSession struct {
 	Password *memguard.Enclave
 	// The file path where the session's wallet data is stored, if applicable
 	FilePath string
+	// Checks transactions before they are signed. A validator with the default
+	// configuration and network profiles is used if it is nil.
+	TxnValidator *txn_validator.Validator
 	// The date-time when this wallet session expires
 	expiration time.Time
+	// Locked for reading while the data files in the session's file path are
+	// in use, and locked for writing while they are re-encrypted when the
+	// wallet's password is changed
+	dataFilesMutex sync.RWMutex
 }
 
+// Account is the information of an account in the session wallet
+type Account struct {
+	// Address of the account
+	Address string `json:"address"`
+	// Type of account (e.g. "kmd_hd", "standalone")
+	Type string `json:"type"`
+	// Name of the account
+	Name string `json:"name,omitempty"`
+	// Address the account is rekeyed to, if the account is rekeyed
+	RekeyedTo string `json:"rekeyed_to,omitempty"`
+}
+
+// MultisigAccount is the information of a multisig account in the session
+// wallet along with the preimage of its address
+type MultisigAccount struct {
+	Account
+	// Version of the multisig
+	Version uint8 `json:"version"`
+	// Number of signers that must sign for the account
+	Threshold uint8 `json:"threshold"`
+	// Signers of the multisig, in the order they are in the preimage
+	Signers []MultisigSigner `json:"signers"`
+}
+
+// MultisigSigner is one of the signers of a multisig account
+type MultisigSigner struct {
+	// Address of the signer
+	Address string `json:"address"`
+	// Whether the signer's key is in the session wallet
+	Local bool `json:"local"`
+}
+
+// errNotMsigAcct is given when an account is expected to be a multisig account
+var errNotMsigAcct = errors.New("account is not a multisig account")
+
 // Check returns an error if the session is no longer valid
 func (session *WalletSession) Check() error {
 	if time.Now().After(session.expiration) {
@@ -68,6 +121,43 @@ func (session *WalletSession) ExportWallet(password string) (string, error) {
 	return mnemonic.FromMasterDerivationKey(mdk)
 }
 
+// ChangePassword changes the password of the session wallet from the old
+// password to the new password. All of the wallet's encrypted files, including
+// the data files in the session's file path, are re-encrypted. The session
+// continues with the new password. The change waits until the data files are
+// no longer in use, and they cannot be used until the change is done.
+func (session *WalletSession) ChangePassword(oldPassword, newPassword string) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	session.dataFilesMutex.Lock()
+	defer session.dataFilesMutex.Unlock()
+
+	err := (*session.Wallet).ChangePassword([]byte(oldPassword), []byte(newPassword))
+	if err != nil {
+		return err
+	}
+
+	session.Password = memguard.NewEnclave([]byte(newPassword))
+
+	return nil
+}
+
+// UseDataFiles marks the data files in the session's file path as in use (e.g.
+// while a dApp connect request is being handled) until UnuseDataFiles is
+// called, which keeps the wallet's password from being changed in the meantime.
+// It waits for a password change that is in progress to be done.
+func (session *WalletSession) UseDataFiles() {
+	session.dataFilesMutex.RLock()
+}
+
+// UnuseDataFiles marks the data files in the session's file path as no longer
+// in use after they were marked as in use by UseDataFiles
+func (session *WalletSession) UnuseDataFiles() {
+	session.dataFilesMutex.RUnlock()
+}
+
 func (session *WalletSession) CheckAddrInWallet(addr string) (bool, error) {
 	if err := session.Check(); err != nil {
 		return false, err
@@ -76,18 +166,19 @@ func (session *WalletSession) CheckAddrInWallet(addr string) (bool, error) {
 	return (*session.Wallet).CheckAddrInWallet(addr)
 }
 
-// ListAccounts lists the addresses of all accounts within the session wallet
+// ListAccounts lists the addresses of all accounts within the session wallet,
+// including the accounts that are watch-only
 func (session *WalletSession) ListAccounts() (acctAddrs []string, err error) {
 	if err = session.Check(); err != nil {
 		return
 	}
 
-	// Get the public keys of accounts stored in wallet
-	pks, err := (*session.Wallet).ListKeys()
+	// Get the accounts stored in wallet
+	accts, err := (*session.Wallet).ListAccounts()
 
-	// Convert the list of public keys to a list of addresses
-	for _, pk := range pks {
-		acctAddrs = append(acctAddrs, types.Address(pk).String())
+	// Convert the list of accounts to a list of addresses
+	for _, acct := range accts {
+		acctAddrs = append(acctAddrs, types.Address(acct.Address).String())
 	}
 
 	return
@@ -171,6 +262,361 @@ func (session *WalletSession) RemoveAccount(acctAddr string) (err error) {
 	return (*session.Wallet).DeleteKey(types.Digest(decodedAcctAddr), pwBuf.Bytes())
 }
 
+// GetAccounts retrieves the information of all the accounts within the session
+// wallet, ordered according to the position of each account in the list of
+// accounts
+func (session *WalletSession) GetAccounts() ([]Account, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	accts, err := (*session.Wallet).ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Account, len(accts))
+	for i := range accts {
+		infos[i] = toAccount(&accts[i])
+	}
+
+	return infos, nil
+}
+
+// GetAccount retrieves the information of the account with the given address
+// within the session wallet
+func (session *WalletSession) GetAccount(acctAddr string) (*Account, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	info := toAccount(acct)
+	return &info, nil
+}
+
+// AddWatchAccount adds the account with the given address to the session wallet
+// as a watch-only account, which is an account without its key. The account is
+// given the name if one is given and is placed last in the list of accounts.
+func (session *WalletSession) AddWatchAccount(acctAddr, name string) (*Account, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	decodedAcctAddr, err := types.DecodeAddress(acctAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	acct := wallet.Account{
+		Address: types.Digest(decodedAcctAddr),
+		Type:    wallet.AcctTypeWatch,
+		Name:    name,
+	}
+	if err := (*session.Wallet).AddAccountBelow(&acct, ""); err != nil {
+		return nil, err
+	}
+
+	info := toAccount(&acct)
+	return &info, nil
+}
+
+// IsWatchAccount checks if the account with the given address within the
+// session wallet is watch-only, meaning nothing can be signed for it
+func (session *WalletSession) IsWatchAccount(acctAddr string) (bool, error) {
+	if err := session.Check(); err != nil {
+		return false, err
+	}
+
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return false, err
+	}
+
+	return acct.Type == wallet.AcctTypeWatch, nil
+}
+
+// HasSigningKey checks if the key of the account with the given address is in
+// the session wallet, meaning the account can sign. Watch-only and multisig
+// accounts do not have their keys in the wallet.
+func (session *WalletSession) HasSigningKey(acctAddr string) (bool, error) {
+	if err := session.Check(); err != nil {
+		return false, err
+	}
+
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return false, err
+	}
+
+	return acct.Type == wallet.AcctTypeKMD || acct.Type == wallet.AcctTypeStandalone, nil
+}
+
+// AuthAddr returns the address of the account whose key signs for the account
+// with the given address within the session wallet. This is the address of the
+// account it is rekeyed to if it is rekeyed. Otherwise, it is the given address.
+func (session *WalletSession) AuthAddr(acctAddr string) (string, error) {
+	if err := session.Check(); err != nil {
+		return "", err
+	}
+
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return "", err
+	}
+
+	if acct.RekeyedTo == (types.Digest{}) {
+		return acctAddr, nil
+	}
+	return types.Address(acct.RekeyedTo).String(), nil
+}
+
+// RenameAccount changes the name of the account with the given address within
+// the session wallet. An empty name removes the account's name.
+func (session *WalletSession) RenameAccount(acctAddr, name string) (*Account, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve password from memory enclave
+	pwBuf, err := session.Password.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer pwBuf.Destroy()
+
+	acct, err := (*session.Wallet).UpdateAccountName(acctAddr, name, pwBuf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	info := toAccount(acct)
+	return &info, nil
+}
+
+// SetAccountRekeyedTo records the address the account with the given address
+// within the session wallet is rekeyed to. An empty address records that the
+// account is not rekeyed.
+func (session *WalletSession) SetAccountRekeyedTo(acctAddr, rekeyedTo string) (*Account, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	// Retrieve password from memory enclave
+	pwBuf, err := session.Password.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer pwBuf.Destroy()
+
+	acct, err := (*session.Wallet).UpdateAccountRekeyedTo(acctAddr, rekeyedTo, pwBuf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	info := toAccount(acct)
+	return &info, nil
+}
+
+// MoveAccountAbove moves the account with the given address above the
+// "reference" account with the given address in the session wallet's list of
+// accounts. If no reference address is given, the account is placed first.
+func (session *WalletSession) MoveAccountAbove(acctAddr, refAddr string) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	return (*session.Wallet).MoveAccountAbove(acctAddr, refAddr)
+}
+
+// MoveAccountBelow moves the account with the given address below the
+// "reference" account with the given address in the session wallet's list of
+// accounts. If no reference address is given, the account is placed last.
+func (session *WalletSession) MoveAccountBelow(acctAddr, refAddr string) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	return (*session.Wallet).MoveAccountBelow(acctAddr, refAddr)
+}
+
+// AddMultisigAccount adds the multisig account with the given version,
+// threshold and signer addresses to the session wallet. The account is given
+// the name if one is given and is placed last in the list of accounts.
+func (session *WalletSession) AddMultisigAccount(version, threshold uint8, signerAddrs []string, name string) (*MultisigAccount, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	// The public key of a signer is its decoded address
+	pks := make([]ed25519.PublicKey, len(signerAddrs))
+	for i, signerAddr := range signerAddrs {
+		decodedAddr, err := types.DecodeAddress(signerAddr)
+		if err != nil {
+			return nil, err
+		}
+		pks[i] = ed25519.PublicKey(decodedAddr[:])
+	}
+
+	addr, err := (*session.Wallet).ImportMultisigAddr(version, threshold, pks)
+	if err != nil {
+		return nil, err
+	}
+	acctAddr := types.Address(addr).String()
+
+	if name != "" {
+		if _, err := session.RenameAccount(acctAddr, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return session.GetMultisigAccount(acctAddr)
+}
+
+// ListMultisigAccounts retrieves the information of all the multisig accounts
+// within the session wallet, ordered according to the position of each account
+// in the list of accounts
+func (session *WalletSession) ListMultisigAccounts() ([]MultisigAccount, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	accts, err := (*session.Wallet).ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	localKeys, err := session.localKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []MultisigAccount{}
+	for i := range accts {
+		if accts[i].Type != wallet.AcctTypeMsig {
+			continue
+		}
+		info, err := session.toMultisigAccount(&accts[i], localKeys)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// GetMultisigAccount retrieves the information of the multisig account with the
+// given address within the session wallet
+func (session *WalletSession) GetMultisigAccount(acctAddr string) (*MultisigAccount, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return nil, err
+	}
+	if acct.Type != wallet.AcctTypeMsig {
+		return nil, errNotMsigAcct
+	}
+	localKeys, err := session.localKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := session.toMultisigAccount(acct, localKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RemoveMultisigAccount removes the multisig account with the given address
+// along with the preimage of its address from the session wallet. The keys of
+// its signers are not removed.
+func (session *WalletSession) RemoveMultisigAccount(acctAddr string) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	// Make sure only a multisig account is removed, not an account with a key
+	acct, err := (*session.Wallet).GetAccount(acctAddr)
+	if err != nil {
+		return err
+	}
+	if acct.Type != wallet.AcctTypeMsig {
+		return errNotMsigAcct
+	}
+
+	// Retrieve password from memory enclave
+	pwBuf, err := session.Password.Open()
+	if err != nil {
+		return err
+	}
+	defer pwBuf.Destroy()
+
+	return (*session.Wallet).DeleteMultisigAddr(acct.Address, pwBuf.Bytes())
+}
+
+// localKeys gives the set of the addresses of the keys in the session wallet
+func (session *WalletSession) localKeys() (map[types.Digest]bool, error) {
+	addrs, err := (*session.Wallet).ListKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[types.Digest]bool, len(addrs))
+	for _, addr := range addrs {
+		keys[addr] = true
+	}
+	return keys, nil
+}
+
+// toMultisigAccount converts the given wallet multisig account into the
+// information of the multisig account that is given out by the session, marking
+// the signers whose keys are in the given set of local keys
+func (session *WalletSession) toMultisigAccount(acct *wallet.Account, localKeys map[types.Digest]bool) (MultisigAccount, error) {
+	version, threshold, pks, err := (*session.Wallet).LookupMultisigPreimage(acct.Address)
+	if err != nil {
+		return MultisigAccount{}, err
+	}
+
+	signers := make([]MultisigSigner, len(pks))
+	for i, pk := range pks {
+		var signerAddr types.Digest
+		copy(signerAddr[:], pk)
+		signers[i] = MultisigSigner{
+			Address: types.Address(signerAddr).String(),
+			Local:   localKeys[signerAddr],
+		}
+	}
+
+	return MultisigAccount{
+		Account:   toAccount(acct),
+		Version:   version,
+		Threshold: threshold,
+		Signers:   signers,
+	}, nil
+}
+
+// toAccount converts the given wallet account into the information of the
+// account that is given out by the session
+func toAccount(acct *wallet.Account) Account {
+	info := Account{
+		Address: types.Address(acct.Address).String(),
+		Type:    acct.Type.String(),
+		Name:    acct.Name,
+	}
+	if acct.RekeyedTo != (types.Digest{}) {
+		info.RekeyedTo = types.Address(acct.RekeyedTo).String()
+	}
+	return info
+}
+
 // SignTransaction signs the given Base64-encoded transaction. If specified, the
 // account with the given address will be used to sign the transaction if it is
 // in the session wallet. Otherwise, the account that is the "sender" of the
@@ -193,6 +639,10 @@ func (session *WalletSession) SignTransaction(txB64, acctAddr string) (stxB64 st
 		return
 	}
 
+	if err = session.ValidateTransaction(tx); err != nil {
+		return
+	}
+
 	// Convert account address (if given) to public key
 	var acctPk []byte
 	if acctAddr == "" {
@@ -221,6 +671,47 @@ func (session *WalletSession) SignTransaction(txB64, acctAddr string) (stxB64 st
 	return base64.StdEncoding.EncodeToString(stxBytes), nil
 }
 
+// ValidateTransaction checks if the given transaction is sane and of a type the
+// session wallet can sign. Returns a *txn_validator.ValidationError if the
+// transaction is invalid.
+func (session *WalletSession) ValidateTransaction(txn types.Transaction) error {
+	metadata, err := (*session.Wallet).Metadata()
+	if err != nil {
+		return err
+	}
+
+	return session.txnValidator().Validate(txn, metadata.SupportedTransactions)
+}
+
+// ValidateTransactionNetwork checks if the given transaction is for one of the
+// networks with the given profile names (e.g. the networks a dApp connect
+// session is bound to). Returns a *txn_validator.ValidationError if it is not.
+func (session *WalletSession) ValidateTransactionNetwork(txn types.Transaction, networkNames []string) error {
+	return session.txnValidator().ValidateNetwork(txn, networkNames)
+}
+
+// TransactionNetwork returns the profile of the network the given transaction
+// is for, or nil if the network is not one the session wallet can sign
+// transactions for
+func (session *WalletSession) TransactionNetwork(txn types.Transaction) *config.NetworkProfile {
+	return session.txnValidator().Network(txn)
+}
+
+// NetworkProfiles returns the profiles of the networks the session wallet can
+// sign transactions for
+func (session *WalletSession) NetworkProfiles() []config.NetworkProfile {
+	return session.txnValidator().Networks()
+}
+
+// txnValidator returns the session's transaction validator, or a validator
+// with the default configuration and network profiles if none was set
+func (session *WalletSession) txnValidator() *txn_validator.Validator {
+	if session.TxnValidator == nil {
+		return txn_validator.New(config.TxnValidationConfig{}, config.DefaultNetworkProfiles())
+	}
+	return session.TxnValidator
+}
+
 // GetMasterKey returns the wallet's master key
 func (session *WalletSession) GetMasterKey() (key []byte, err error) {
 	// Retrieve password from memory enclave
@@ -233,4 +724,419 @@ func (session *WalletSession) GetMasterKey() (key []byte, err error) {
 	return (*session.Wallet).DecryptAndGetMasterKey(pwBuf.Bytes())
 }
 
-// TODO: ChangePassword(password string) error
+// AuditLog returns the audit log of the session wallet, which is stored in the
+// session's file path
+func (session *WalletSession) AuditLog() *audit.Log {
+	return audit.NewLog(filepath.Join(session.FilePath, audit.DefaultDataFile))
+}
+
+// RecordAuditEntry adds the given entry to the session wallet's audit log.
+// Returns the entry as it was recorded.
+func (session *WalletSession) RecordAuditEntry(entry audit.Entry) (*audit.Entry, error) {
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AuditLog().Append(entry, mek)
+}
+
+// QueryAuditLog retrieves the entries in the session wallet's audit log that
+// match the given filter
+func (session *WalletSession) QueryAuditLog(filter audit.Filter) ([]audit.Entry, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AuditLog().Query(filter, mek)
+}
+
+// ExportAuditLog exports all the entries in the session wallet's audit log as
+// JSON. Fails if the audit log has been tampered with.
+func (session *WalletSession) ExportAuditLog() ([]byte, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AuditLog().Export(mek)
+}
+
+// VerifyAuditLog checks if the session wallet's audit log has been tampered
+// with. Returns an error if it has.
+func (session *WalletSession) VerifyAuditLog() error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.AuditLog().Verify(mek)
+}
+
+// PolicyStore returns the store of the session wallet's signing policy rules
+func (session *WalletSession) PolicyStore() *policy.Store {
+	return policy.NewStore(filepath.Join(session.FilePath, policy.DefaultDataFile))
+}
+
+// ListPolicyRules retrieves all the session wallet's signing policy rules in
+// the order they are evaluated
+func (session *WalletSession) ListPolicyRules() ([]policy.Rule, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.PolicyStore().ListRules(mek)
+}
+
+// AddPolicyRule adds the given rule to the session wallet's signing policy.
+// Returns the rule as it was stored.
+func (session *WalletSession) AddPolicyRule(rule policy.Rule) (*policy.Rule, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.PolicyStore().AddRule(rule, mek)
+}
+
+// UpdatePolicyRule replaces the rule in the session wallet's signing policy
+// that has the same ID as the given rule
+func (session *WalletSession) UpdatePolicyRule(rule policy.Rule) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.PolicyStore().UpdateRule(rule, mek)
+}
+
+// RemovePolicyRule removes the rule with the given ID from the session
+// wallet's signing policy
+func (session *WalletSession) RemovePolicyRule(id uint64) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.PolicyStore().RemoveRule(id, mek)
+}
+
+// EvaluatePolicy evaluates the given signing request against the session
+// wallet's signing policy
+func (session *WalletSession) EvaluatePolicy(req *policy.Request) (policy.Decision, error) {
+	if err := session.Check(); err != nil {
+		return policy.Decision{}, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return policy.Decision{}, err
+	}
+
+	return session.PolicyStore().Evaluate(req, mek)
+}
+
+// SpendLimitStore returns the store of the session wallet's spending limits
+func (session *WalletSession) SpendLimitStore() *spend_limit.Store {
+	return spend_limit.NewStore(filepath.Join(session.FilePath, spend_limit.DefaultDataFile))
+}
+
+// ListSpendLimits retrieves all the session wallet's spending limits along
+// with how much has been sent within their current windows
+func (session *WalletSession) ListSpendLimits() ([]spend_limit.Status, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.SpendLimitStore().ListLimits(mek)
+}
+
+// AddSpendLimit adds the given spending limit to the session wallet. Returns
+// the limit as it was stored.
+func (session *WalletSession) AddSpendLimit(limit spend_limit.Limit) (*spend_limit.Limit, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.SpendLimitStore().AddLimit(limit, mek)
+}
+
+// RemoveSpendLimit removes the spending limit with the given ID from the
+// session wallet
+func (session *WalletSession) RemoveSpendLimit(id uint64) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.SpendLimitStore().RemoveLimit(id, mek)
+}
+
+// ReserveSpend checks if sending the given outflows through the dApp connect
+// session with the given ID would exceed any of the session wallet's spending
+// limits and reserves them unless a limit that denies them would be exceeded.
+// The reservation counts toward the limits until it is confirmed with
+// ConfirmSpend or released with ReleaseSpend, so that concurrent requests
+// cannot together exceed a limit that each of them would not exceed on its
+// own. Returns the reservation, which is nil if the outflows were not reserved,
+// and the limits that would be exceeded.
+func (session *WalletSession) ReserveSpend(
+	sessionID string,
+	outflows []spend_limit.Outflow,
+) (*spend_limit.Reservation, []spend_limit.Exceeded, error) {
+	if err := session.Check(); err != nil {
+		return nil, nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session.SpendLimitStore().Reserve(sessionID, outflows, mek)
+}
+
+// ConfirmSpend records that the outflows of the given reservation were
+// signed, which counts them toward the session wallet's spending limits
+func (session *WalletSession) ConfirmSpend(reservation *spend_limit.Reservation) error {
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.SpendLimitStore().Confirm(reservation, mek)
+}
+
+// ReleaseSpend removes the given reservation of outflows that were not signed
+// from the session wallet's spending limits
+func (session *WalletSession) ReleaseSpend(reservation *spend_limit.Reservation) error {
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.SpendLimitStore().Release(reservation, mek)
+}
+
+// RecordSpend records that the given outflows were signed through the dApp
+// connect session with the given ID, which counts them toward the session
+// wallet's spending limits
+func (session *WalletSession) RecordSpend(sessionID string, outflows []spend_limit.Outflow) error {
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.SpendLimitStore().Record(sessionID, outflows, mek)
+}
+
+// AddressBook returns the session wallet's address book
+func (session *WalletSession) AddressBook() *address_book.Store {
+	return address_book.NewStore(filepath.Join(session.FilePath, address_book.DefaultDataFile))
+}
+
+// ListContacts retrieves all the contacts in the session wallet's address book
+func (session *WalletSession) ListContacts() ([]address_book.Contact, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AddressBook().ListContacts(mek)
+}
+
+// GetContact retrieves the contact with the given address from the session
+// wallet's address book
+func (session *WalletSession) GetContact(address string) (*address_book.Contact, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AddressBook().GetContact(address, mek)
+}
+
+// SaveContact adds the given contact to the session wallet's address book or
+// updates it if there is already a contact with the same address. Returns the
+// contact as it was stored.
+func (session *WalletSession) SaveContact(contact address_book.Contact) (*address_book.Contact, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AddressBook().SaveContact(contact, mek)
+}
+
+// RemoveContact removes the contact with the given address from the session
+// wallet's address book
+func (session *WalletSession) RemoveContact(address string) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.AddressBook().RemoveContact(address, mek)
+}
+
+// ResolveAddress gives what is known about the given address from the session
+// wallet's accounts and address book
+func (session *WalletSession) ResolveAddress(addr string) (info txn_decoder.AddressInfo, err error) {
+	info.InWallet, err = session.CheckAddrInWallet(addr)
+	if err != nil {
+		return
+	}
+
+	contact, err := session.GetContact(addr)
+	if errors.Is(err, address_book.ErrContactNotFound) {
+		return info, nil
+	} else if err != nil {
+		return
+	}
+
+	info.Label = contact.Label
+	info.Trusted = contact.Trusted
+
+	return
+}
+
+// AppSpecStore returns the store of the session wallet's app specs
+func (session *WalletSession) AppSpecStore() *app_spec.Store {
+	return app_spec.NewStore(filepath.Join(session.FilePath, app_spec.DefaultDataFile))
+}
+
+// ListAppSpecs retrieves all the app specs in the session wallet
+func (session *WalletSession) ListAppSpecs() ([]app_spec.Spec, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AppSpecStore().ListSpecs(mek)
+}
+
+// GetAppSpec retrieves the app spec for the application with the given ID from
+// the session wallet
+func (session *WalletSession) GetAppSpec(appID uint64) (*app_spec.Spec, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AppSpecStore().GetSpec(appID, mek)
+}
+
+// SaveAppSpec adds the given ARC-32 or ARC-56 app spec JSON for the application
+// with the given ID to the session wallet, or replaces the existing app spec
+// for that application. The dApp ID is the ID of the dApp registering the
+// spec, which must be empty if the user is adding the spec. Returns the app
+// spec as it was stored.
+func (session *WalletSession) SaveAppSpec(appID uint64, specJSON string, dappID string) (*app_spec.Spec, error) {
+	if err := session.Check(); err != nil {
+		return nil, err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return session.AppSpecStore().SaveSpec(appID, specJSON, dappID, mek)
+}
+
+// RemoveAppSpec removes the app spec for the application with the given ID
+// from the session wallet
+func (session *WalletSession) RemoveAppSpec(appID uint64) error {
+	if err := session.Check(); err != nil {
+		return err
+	}
+
+	mek, err := session.GetMasterKey()
+	if err != nil {
+		return err
+	}
+
+	return session.AppSpecStore().RemoveSpec(appID, mek)
+}
+
+// AppMethods gives the ABI methods of the application with the given ID from
+// its app spec in the session wallet. Gives nil if there is no app spec for the
+// application.
+func (session *WalletSession) AppMethods(appID uint64) ([]abi.Method, error) {
+	spec, err := session.GetAppSpec(appID)
+	if errors.Is(err, app_spec.ErrSpecNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return spec.Methods, nil
+}
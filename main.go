@@ -49,8 +49,10 @@ func main() {
 						ScryptP: 32,
 					},
 				},
-				ParquetWalletDriverConfig: config.ParquetWalletDriverConfig{Disable: true, WalletsDir: "duckdb_wallets"},
-				SQLiteWalletDriverConfig:  config.SQLiteWalletDriverConfig{Disable: true, UnsafeScrypt: true, WalletsDir: "duckdb_wallets"},
+				// The Parquet and SQLite wallet drivers are disabled, but their
+				// wallets can still be migrated to the DuckDB wallet driver
+				ParquetWalletDriverConfig: config.ParquetWalletDriverConfig{Disable: true, UnsafeScrypt: true},
+				SQLiteWalletDriverConfig:  config.SQLiteWalletDriverConfig{Disable: true, UnsafeScrypt: true},
 				LedgerWalletDriverConfig:  config.LedgerWalletDriverConfig{Disable: true},
 			},
 		},
@@ -172,6 +174,23 @@ func main() {
 		app.Event.Emit("txn_sign_prompt_load", e.Data)
 	})
 
+	app.Event.On("txn_group_sign_prompt", func(e *application.CustomEvent) {
+		app.Window.NewWithOptions(application.WebviewWindowOptions{
+			Title: "Sign Transaction Group",
+			Mac: application.MacWindow{
+				InvisibleTitleBarHeight: 50,
+				Backdrop:                application.MacBackdropTranslucent,
+				TitleBar:                application.MacTitleBarHiddenInset,
+			},
+			// BackgroundColour: application.NewRGB(27, 38, 54),
+			URL:    "/txn-group-sign-approval",
+			Width:  512,
+			Height: 700,
+		})
+		// Send event to load contents in window
+		app.Event.Emit("txn_group_sign_prompt_load", e.Data)
+	})
+
 	// Run the application. This blocks until the application has been exited.
 	// If an error occurred while running the application, log it and exit.
 	if err := app.Run(); err != nil {
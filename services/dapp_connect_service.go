@@ -14,10 +14,16 @@ import (
 	"github.com/labstack/gommon/log"
 	"github.com/wailsapp/wails/v3/pkg/application"
 
+	"duckysigner/internal/algod"
 	dc "duckysigner/internal/dapp_connect"
+	"duckysigner/internal/dapp_connect/approval"
 	"duckysigner/internal/dapp_connect/handlers"
+	mw "duckysigner/internal/dapp_connect/middleware"
+	"duckysigner/internal/dapp_connect/prompt"
 	"duckysigner/internal/dapp_connect/session"
 	"duckysigner/internal/tools"
+	"duckysigner/internal/txn_risk"
+	"duckysigner/internal/txn_simulator"
 )
 
 // DappConnectService is a Wails binding allows for a Wails frontend to interact
@@ -53,6 +59,19 @@ type DappConnectService struct {
 	KMDService *KMDService
 	// Amount of time (in seconds) to wait for user approval of a session
 	ApprovalTimeout uint64
+	// What asks the user to approve requests from dApps (e.g. a terminal or a
+	// scripted approver for testing).
+	// Default: Prompts the user through the UI of the Wails app
+	Approver approval.Approver
+	// Fee (in microAlgos) above which a transaction fee is flagged as very
+	// high when asking the user to approve signing a transaction.
+	// Default: `txn_risk.DefaultHighFeeThreshold`
+	HighFeeThreshold uint64
+	// Gives the client for the algod API of a network. Typically used to set a
+	// fake algod client when testing.
+	// Default: The algod provider of the KMD service, or else
+	// `algod.NewHTTPClient`
+	AlgodProvider algod.Provider
 
 	// Current Echo instance used to control the server
 	echo *echo.Echo
@@ -91,6 +110,14 @@ func (dcs *DappConnectService) Start() bool {
 	if dcs.ECDHCurve == nil {
 		dcs.ECDHCurve = ecdh.X25519()
 	}
+	// Set algod provider if it is not set
+	if dcs.AlgodProvider == nil {
+		if dcs.KMDService != nil && dcs.KMDService.AlgodProvider != nil {
+			dcs.AlgodProvider = dcs.KMDService.AlgodProvider
+		} else {
+			dcs.AlgodProvider = algod.NewHTTPClient
+		}
+	}
 	// Set up other stuff
 	dc.SetupCustomValidator(dcs.echo)
 	dcs.setupServerRoutes(dcs.echo)
@@ -179,11 +206,36 @@ func (dcs *DappConnectService) setupServerRoutes(e *echo.Echo) {
 		return
 	}
 
+	// Keep the wallet's password from being changed while a request is being
+	// handled
+	e.Use(mw.UseWalletDataFiles(walletSession))
+
 	sessionManager := session.NewManager(dcs.ECDHCurve, &session.SessionConfig{
 		DataDir:             walletSession.FilePath,
 		ApprovalTimeoutSecs: dcs.ApprovalTimeout,
 	})
 
+	// Prompt the user through the UI if no other approver is set
+	approver := dcs.Approver
+	if approver == nil {
+		var promptEvents prompt.EventManager
+		if dcs.WailsApp != nil {
+			promptEvents = dcs.WailsApp.Event
+		}
+		approver = prompt.NewWailsApprover(prompt.NewBroker(promptEvents, dcs.echo.Logger))
+	}
+
+	riskChecker := &txn_risk.Checker{
+		HighFeeThreshold: dcs.HighFeeThreshold,
+		IsWalletAddr:     walletSession.CheckAddrInWallet,
+	}
+
+	simulator := &txn_simulator.Simulator{
+		Config:        dcs.KMDService.Config.Simulation,
+		AlgodProvider: dcs.AlgodProvider,
+		Network:       walletSession.TransactionNetwork,
+	}
+
 	e.GET("/", handlers.RootGet(
 		dcs.echo, walletSession, sessionManager,
 	))
@@ -191,12 +243,18 @@ func (dcs *DappConnectService) setupServerRoutes(e *echo.Echo) {
 		dcs.echo, walletSession, sessionManager, dcs.ECDHCurve,
 	))
 	e.POST("/session/confirm", handlers.SessionConfirmPost(
-		dcs.echo, dcs.WailsApp, walletSession, sessionManager, dcs.ECDHCurve,
+		dcs.echo, approver, walletSession, sessionManager, dcs.ECDHCurve,
 	))
 	e.GET("/session/end", handlers.SessionEndGet(
 		dcs.echo, walletSession, sessionManager,
 	))
 	e.POST("/transaction/sign", handlers.TransactionSignPost(
-		dcs.echo, dcs.WailsApp, walletSession, sessionManager, dcs.ECDHCurve,
+		dcs.echo, approver, riskChecker, simulator, walletSession, sessionManager, dcs.ECDHCurve,
+	))
+	e.POST("/transaction/group/sign", handlers.TransactionGroupSignPost(
+		dcs.echo, approver, riskChecker, simulator, walletSession, sessionManager, dcs.ECDHCurve,
+	))
+	e.POST("/app/spec", handlers.AppSpecPost(
+		dcs.echo, walletSession, sessionManager,
 	))
 }
@@ -1,21 +1,35 @@
 package services
 
 import (
+	"context"
 	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
 	"github.com/algorand/go-algorand-sdk/v2/mnemonic"
 	"github.com/algorand/go-algorand-sdk/v2/types"
 	"github.com/awnumar/memguard"
 	logging "github.com/sirupsen/logrus"
 
+	"duckysigner/internal/address_book"
+	"duckysigner/internal/algod"
+	"duckysigner/internal/app_spec"
+	"duckysigner/internal/audit"
 	"duckysigner/internal/dapp_connect/session"
 	"duckysigner/internal/kmd/config"
 	"duckysigner/internal/kmd/wallet"
 	"duckysigner/internal/kmd/wallet/driver"
+	"duckysigner/internal/policy"
+	"duckysigner/internal/spend_limit"
 	"duckysigner/internal/tools"
+	"duckysigner/internal/txn_validator"
 	ws "duckysigner/internal/wallet_session"
 )
 
@@ -35,6 +49,10 @@ type KMDService struct {
 	// testing.
 	// Default: `ecdh.X25519()` from the `crypto/ecdh` package
 	ECDHCurve tools.ECDHCurve
+	// Gives the client for the algod API of a network. Typically used to set a
+	// fake algod client when testing.
+	// Default: `algod.NewHTTPClient`
+	AlgodProvider algod.Provider
 
 	// If KMD configuration and drivers have been initialized
 	kmdInitialized bool
@@ -60,13 +78,7 @@ func (service *KMDService) StartSession(walletID, password string) (err error) {
 		return
 	}
 
-	var walletDir string
-	// Calculate the path of the wallet directory
-	if service.Config.DriverConfig.DuckDbWalletDriverConfig.WalletsDir != "" {
-		walletDir = filepath.Join(service.Config.DriverConfig.DuckDbWalletDriverConfig.WalletsDir, walletID)
-	} else {
-		walletDir = filepath.Join(service.Config.DataDir, KMDServiceWalletDir, walletID)
-	}
+	walletDir := service.walletDir(walletID)
 
 	// Initialize wallet in order to securely load master derivation key (MDK)
 	// and master encryption key (MEK) into memory
@@ -74,9 +86,10 @@ func (service *KMDService) StartSession(walletID, password string) (err error) {
 
 	service.sessionMutex.Lock()
 	service.session = &ws.WalletSession{
-		Wallet:   &fetchedWallet,
-		Password: memguard.NewEnclave([]byte(password)),
-		FilePath: walletDir,
+		Wallet:       &fetchedWallet,
+		Password:     memguard.NewEnclave([]byte(password)),
+		FilePath:     walletDir,
+		TxnValidator: txn_validator.New(service.Config.TxnValidation, service.Config.Networks),
 	}
 	service.session.SetExpiration(
 		time.Now().Add(time.Duration(service.Config.SessionLifetimeSecs) * time.Second),
@@ -273,13 +286,337 @@ func (service *KMDService) CheckWalletPassword(walletID, password string) error
 	return nil
 }
 
-// func (service *KMDService) RemoveWallet(walletID string, password string) error {
-// 	err := service.init()
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
+// BackupWallet checks the given password of the wallet with the given walletID
+// and writes a backup of the wallet to the file at the given backupPath. The
+// backup is encrypted with the given backupPassphrase. Unlike exporting the
+// wallet mnemonic, the backup includes imported keys, multisig addresses and
+// account names. The wallet's data files, such as its signing policy, spending
+// limits, address book, app specs and audit log, are always included. The
+// wallet's dApp connect sessions are also included if includeSessions is true.
+func (service *KMDService) BackupWallet(walletID, password, backupPassphrase, backupPath string, includeSessions bool) error {
+	err := service.init()
+	if err != nil {
+		return err
+	}
+
+	backupDriver, err := service.getBackupDriver()
+	if err != nil {
+		return err
+	}
+
+	// NOTE: The head of the audit log's hash chain is backed up before the
+	// audit log so the backed up head cannot be ahead of the backed up log
+	extraFiles := []string{
+		policy.DefaultDataFile,
+		spend_limit.DefaultDataFile,
+		address_book.DefaultDataFile,
+		app_spec.DefaultDataFile,
+		audit.DefaultHeadFile,
+		audit.DefaultDataFile,
+	}
+	if includeSessions {
+		extraFiles = append(extraFiles, session.DefaultConfigFile, session.DefaultDataFile)
+	}
+
+	backupData, err := backupDriver.BackupWallet(
+		[]byte(walletID),
+		[]byte(password),
+		[]byte(backupPassphrase),
+		extraFiles,
+	)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(backupPath, backupData, 0600)
+}
+
+// RestoreWallet restores the wallet in the backup file at the given backupPath
+// as a new wallet using the given backupPassphrase. The restored wallet has the
+// same name and password as the wallet that was backed up.
+func (service *KMDService) RestoreWallet(backupPath, backupPassphrase string) (restoredWalletData wallet.Metadata, err error) {
+	err = service.init()
+	if err != nil {
+		return
+	}
+
+	backupDriver, err := service.getBackupDriver()
+	if err != nil {
+		return
+	}
+
+	backupData, err := os.ReadFile(backupPath)
+	if err != nil {
+		return
+	}
+
+	// Generate new ID that will assigned to restored wallet
+	walletID, err := wallet.GenerateWalletID()
+	if err != nil {
+		return
+	}
+
+	err = backupDriver.RestoreWallet(walletID, backupData, []byte(backupPassphrase))
+	if err != nil {
+		return
+	}
+
+	// Get the restored wallet
+	restoredWallet, err := service.getWallet(string(walletID))
+	if err != nil {
+		return
+	}
+
+	return restoredWallet.Metadata()
+}
+
+// RemoveWallet deletes the wallet with the given walletID if the given password
+// is correct. All of the wallet's data, including its dApp connect session
+// data, is wiped. The current session is ended if it is for the deleted wallet.
+func (service *KMDService) RemoveWallet(walletID string, password string) error {
+	err := service.init()
+	if err != nil {
+		return err
+	}
+
+	pqDriver, err := driver.FetchWalletDriver(KMDServiceWalletDriver)
+	if err != nil {
+		return err
+	}
+
+	err = pqDriver.DeleteWallet([]byte(walletID), []byte(password))
+	if err != nil {
+		return err
+	}
+
+	// The session is checked using the wallet directory because the wallet's
+	// metadata is no longer available
+	service.sessionMutex.Lock()
+	if service.session != nil && service.session.FilePath == service.walletDir(walletID) {
+		// Expire the session in case it is still held elsewhere (e.g. by the
+		// dApp connect server)
+		service.session.SetExpiration(time.Now())
+		service.session = nil
+	}
+	service.sessionMutex.Unlock()
+
+	return nil
+}
+
+// ListUnmigratedWallets gives the metadata of the wallets under the wallet
+// driver with the given driverName (e.g. "sqlite" or "parquet") that have not
+// been migrated to the wallet driver used by the service
+func (service *KMDService) ListUnmigratedWallets(driverName string) ([]wallet.Metadata, error) {
+	err := service.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.ListUnmigratedWallets(driverName)
+}
+
+// MigrateWallet migrates the wallet with the given walletID under the wallet
+// driver with the given driverName to the wallet driver used by the service.
+// The migrated wallet is given a new ID, but has the same name, password, keys
+// and multisig addresses. The original wallet is kept, but it is marked as
+// migrated once the migrated wallet is checked to have the same keys.
+func (service *KMDService) MigrateWallet(driverName, walletID, password string) (migratedWalletData wallet.Metadata, err error) {
+	err = service.init()
+	if err != nil {
+		return
+	}
+
+	// Generate new ID that will assigned to migrated wallet
+	newWalletID, err := wallet.GenerateWalletID()
+	if err != nil {
+		return
+	}
+
+	err = driver.MigrateWallet(
+		driverName,
+		[]byte(walletID),
+		[]byte(password),
+		KMDServiceWalletDriver,
+		newWalletID,
+	)
+	if err != nil {
+		return
+	}
+
+	// Get the migrated wallet
+	migratedWallet, err := service.getWallet(string(newWalletID))
+	if err != nil {
+		return
+	}
+
+	return migratedWallet.Metadata()
+}
+
+// ImportKmdWallet creates a new wallet from the go-algorand kmd wallet file
+// (e.g. a ".db" file in a `goal` data directory's "kmd-v0.5" directory) at the
+// given filePath using the wallet's password. The new wallet has the same name,
+// password, keys and multisig addresses as the kmd wallet. The kmd wallet file
+// is not modified. The records in the file that could not be imported are
+// given along with the new wallet's metadata.
+func (service *KMDService) ImportKmdWallet(filePath, password string) (driver.KmdWalletImport, error) {
+	err := service.init()
+	if err != nil {
+		return driver.KmdWalletImport{}, err
+	}
+
+	// Generate new ID that will assigned to imported wallet
+	walletID, err := wallet.GenerateWalletID()
+	if err != nil {
+		return driver.KmdWalletImport{}, err
+	}
+
+	return driver.ImportKmdWallet(filePath, []byte(password), KMDServiceWalletDriver, walletID)
+}
+
+// ListNetworkProfiles gives the profiles of the networks transactions can be
+// signed for
+func (service *KMDService) ListNetworkProfiles() ([]config.NetworkProfile, error) {
+	err := service.init()
+	if err != nil {
+		return nil, err
+	}
+	return service.Config.Networks, nil
+}
+
+// SaveNetworkProfile adds the given network profile, or replaces the profile
+// with the same name if there is one, and saves the profiles. The current
+// wallet session only allows transactions for the saved profiles afterwards.
+func (service *KMDService) SaveNetworkProfile(profile config.NetworkProfile) error {
+	err := service.init()
+	if err != nil {
+		return err
+	}
+
+	profiles := slices.Clone(service.Config.Networks)
+	i := slices.IndexFunc(profiles, func(p config.NetworkProfile) bool { return p.Name == profile.Name })
+	if i == -1 {
+		profiles = append(profiles, profile)
+	} else {
+		profiles[i] = profile
+	}
+
+	return service.setNetworkProfiles(profiles)
+}
+
+// RemoveNetworkProfile removes the network profile with the given name and
+// saves the remaining profiles
+func (service *KMDService) RemoveNetworkProfile(name string) error {
+	err := service.init()
+	if err != nil {
+		return err
+	}
+
+	profiles := slices.DeleteFunc(slices.Clone(service.Config.Networks), func(p config.NetworkProfile) bool {
+		return p.Name == name
+	})
+	if len(profiles) == len(service.Config.Networks) {
+		return fmt.Errorf("there is no network profile named '%s'", name)
+	}
+
+	return service.setNetworkProfiles(profiles)
+}
+
+// Algod gives the client for the algod API of the network with the profile
+// that has the given name
+// FOR THE BACKEND ONLY
+func (service *KMDService) Algod(networkName string) (algod.Client, error) {
+	err := service.init()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(service.Config.Networks, func(p config.NetworkProfile) bool { return p.Name == networkName })
+	if i == -1 {
+		return nil, fmt.Errorf("there is no network profile named '%s'", networkName)
+	}
+
+	return service.AlgodProvider(service.Config.Networks[i])
+}
+
+// AccountInfo gives the information (e.g. balance and assets) of the account
+// with the given address on the network with the given profile name
+func (service *KMDService) AccountInfo(networkName, address string) (models.Account, error) {
+	client, err := service.Algod(networkName)
+	if err != nil {
+		return models.Account{}, err
+	}
+	return client.AccountInfo(context.Background(), address)
+}
+
+// AssetInfo gives the information (e.g. name and decimals) of the asset with
+// the given ID on the network with the given profile name
+func (service *KMDService) AssetInfo(networkName string, assetID uint64) (models.Asset, error) {
+	client, err := service.Algod(networkName)
+	if err != nil {
+		return models.Asset{}, err
+	}
+	return client.AssetInfo(context.Background(), assetID)
+}
+
+// TransactionStatus gives the information of the transaction with the given ID
+// on the network with the given profile name. The transaction has been
+// confirmed if its confirmed round is set, and was rejected if its pool error
+// is set. Only works for transactions that are pending or were recently
+// confirmed.
+func (service *KMDService) TransactionStatus(networkName, txID string) (models.PendingTransactionInfoResponse, error) {
+	client, err := service.Algod(networkName)
+	if err != nil {
+		return models.PendingTransactionInfoResponse{}, err
+	}
+	return client.PendingTxnInfo(context.Background(), txID)
+}
+
+// RevokeDappSession revokes the dApp connect session with the given ID for the
+// wallet in the current wallet session. The revocation is recorded in the
+// wallet's audit log, and an error is returned if it could not be recorded.
+func (service *KMDService) RevokeDappSession(sessionId string) (err error) {
+	if service.session == nil {
+		return errors.New("no wallet session")
+	}
+	if err = service.session.Check(); err != nil {
+		return
+	}
+
+	sessionManager := session.NewManager(
+		service.ECDHCurve,
+		&session.SessionConfig{DataDir: service.session.FilePath},
+	)
+	mek, err := service.session.GetMasterKey()
+	if err != nil {
+		return
+	}
+
+	auditEntry := audit.Entry{
+		Event:     audit.EventSessionRevoke,
+		SessionID: sessionId,
+		Decision:  audit.DecisionApproved,
+	}
+	defer func() {
+		if err != nil {
+			auditEntry.Decision = audit.DecisionFailed
+			auditEntry.Details = err.Error()
+		}
+		_, auditErr := service.session.AuditLog().Append(auditEntry, mek)
+		if err == nil {
+			err = auditErr
+		}
+	}()
+
+	dcSession, err := sessionManager.GetSession(sessionId, mek)
+	if err != nil {
+		return
+	}
+	if dcSession != nil {
+		auditEntry.DappID = base64.StdEncoding.EncodeToString(dcSession.DappId().Bytes())
+	}
+
+	return sessionManager.RemoveSession(sessionId, mek)
+}
 
 // CleanUp performs various operations to clean up and release resources used by
 // the KMD service.
@@ -334,16 +671,87 @@ func (service *KMDService) init() (err error) {
 		return
 	}
 
+	// Load the network profiles if none are set
+	if service.Config.Networks == nil {
+		if kmdConfig.Networks == nil {
+			kmdConfig.Networks, err = config.LoadNetworkProfiles(service.networksDir())
+			if err != nil {
+				return
+			}
+		}
+		service.Config.Networks = kmdConfig.Networks
+	}
+
 	// Set ECDH curve if it is not set
 	if service.ECDHCurve == nil {
 		service.ECDHCurve = ecdh.X25519()
 	}
 
+	// Set algod provider if it is not set
+	if service.AlgodProvider == nil {
+		service.AlgodProvider = algod.NewHTTPClient
+	}
+
 	service.kmdInitialized = true
 
 	return
 }
 
+// networksDir gives the directory where the network profiles are saved, which
+// is the data directory or else the directory of the configuration file
+func (service *KMDService) networksDir() string {
+	if service.Config.DataDir != "" {
+		return service.Config.DataDir
+	}
+	return service.ConfigPath
+}
+
+// setNetworkProfiles saves the given network profiles and makes the current
+// wallet session use them
+func (service *KMDService) setNetworkProfiles(profiles []config.NetworkProfile) error {
+	if err := config.ValidateNetworkProfiles(profiles); err != nil {
+		return err
+	}
+
+	if err := config.SaveNetworkProfiles(service.networksDir(), profiles); err != nil {
+		return err
+	}
+	service.Config.Networks = profiles
+
+	service.sessionMutex.Lock()
+	if service.session != nil {
+		service.session.TxnValidator = txn_validator.New(service.Config.TxnValidation, profiles)
+	}
+	service.sessionMutex.Unlock()
+
+	return nil
+}
+
+// walletDir gives the path of the directory of the wallet with the given
+// walletID, which is where the wallet's session data is stored
+func (service *KMDService) walletDir(walletID string) string {
+	if service.Config.DriverConfig.DuckDbWalletDriverConfig.WalletsDir != "" {
+		return filepath.Join(service.Config.DriverConfig.DuckDbWalletDriverConfig.WalletsDir, walletID)
+	}
+	return filepath.Join(service.Config.DataDir, KMDServiceWalletDir, walletID)
+}
+
+// getBackupDriver gets the wallet driver as a driver that can back up and
+// restore wallets
+func (service *KMDService) getBackupDriver() (driver.BackupDriver, error) {
+	walletDriver, err := driver.FetchWalletDriver(KMDServiceWalletDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	backupDriver, ok := walletDriver.(driver.BackupDriver)
+	if !ok {
+		return nil, errors.New("wallet driver does not support backups")
+	}
+
+	return backupDriver, nil
+}
+
 // getWallet gets the wallet with the given walletID
 func (service *KMDService) getWallet(walletID string) (wallet.Wallet, error) {
 	pqDriver, err := driver.FetchWalletDriver(KMDServiceWalletDriver)
@@ -365,6 +773,14 @@ func (service *KMDService) SessionCheck() error {
 	return service.session.Check()
 }
 
+// SessionChangePassword changes the password of the session wallet from the
+// given oldPassword to the given newPassword
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionChangePassword(oldPassword, newPassword string) error {
+	return service.session.ChangePassword(oldPassword, newPassword)
+}
+
 // SessionListAccounts lists the addresses of all accounts within the session
 // wallet
 //
@@ -413,9 +829,227 @@ func (service *KMDService) SessionRemoveAccount(acctAddr string) (err error) {
 	return service.session.RemoveAccount(acctAddr)
 }
 
+// SessionGetAccounts retrieves the information of all the accounts within the
+// session wallet in the order they are listed
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionGetAccounts() ([]ws.Account, error) {
+	return service.session.GetAccounts()
+}
+
+// SessionGetAccount retrieves the information of the account with the given
+// acctAddr within the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionGetAccount(acctAddr string) (*ws.Account, error) {
+	return service.session.GetAccount(acctAddr)
+}
+
+// SessionAddWatchAccount adds the account with the given acctAddr to the
+// session wallet as a watch-only account with the given name
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionAddWatchAccount(acctAddr, name string) (*ws.Account, error) {
+	return service.session.AddWatchAccount(acctAddr, name)
+}
+
+// SessionAddMultisigAccount adds the multisig account with the given version,
+// threshold and signerAddrs to the session wallet with the given name
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionAddMultisigAccount(version, threshold uint8, signerAddrs []string, name string) (*ws.MultisigAccount, error) {
+	return service.session.AddMultisigAccount(version, threshold, signerAddrs, name)
+}
+
+// SessionListMultisigAccounts retrieves the information of all the multisig
+// accounts within the session wallet in the order they are listed
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionListMultisigAccounts() ([]ws.MultisigAccount, error) {
+	return service.session.ListMultisigAccounts()
+}
+
+// SessionGetMultisigAccount retrieves the information of the multisig account
+// with the given acctAddr within the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionGetMultisigAccount(acctAddr string) (*ws.MultisigAccount, error) {
+	return service.session.GetMultisigAccount(acctAddr)
+}
+
+// SessionRemoveMultisigAccount removes the multisig account with the given
+// acctAddr from the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRemoveMultisigAccount(acctAddr string) error {
+	return service.session.RemoveMultisigAccount(acctAddr)
+}
+
+// SessionRenameAccount changes the name of the account with the given acctAddr
+// within the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRenameAccount(acctAddr, name string) (*ws.Account, error) {
+	return service.session.RenameAccount(acctAddr, name)
+}
+
+// SessionSetAccountRekeyedTo records the address the account with the given
+// acctAddr within the session wallet is rekeyed to
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionSetAccountRekeyedTo(acctAddr, rekeyedTo string) (*ws.Account, error) {
+	return service.session.SetAccountRekeyedTo(acctAddr, rekeyedTo)
+}
+
+// SessionMoveAccountAbove moves the account with the given acctAddr above the
+// account with the given refAddr in the session wallet's list of accounts
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionMoveAccountAbove(acctAddr, refAddr string) error {
+	return service.session.MoveAccountAbove(acctAddr, refAddr)
+}
+
+// SessionMoveAccountBelow moves the account with the given acctAddr below the
+// account with the given refAddr in the session wallet's list of accounts
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionMoveAccountBelow(acctAddr, refAddr string) error {
+	return service.session.MoveAccountBelow(acctAddr, refAddr)
+}
+
 // SessionSignTransaction signs the given Base64-encoded transaction
 //
 // FOR THE FRONTEND ONLY
 func (service *KMDService) SessionSignTransaction(txB64, acctAddr string) (string, error) {
 	return service.session.SignTransaction(txB64, acctAddr)
 }
+
+// SessionQueryAuditLog retrieves the entries in the session wallet's audit log
+// that match the given filter
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionQueryAuditLog(filter audit.Filter) ([]audit.Entry, error) {
+	return service.session.QueryAuditLog(filter)
+}
+
+// SessionExportAuditLog exports all the entries in the session wallet's audit
+// log as a JSON string
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionExportAuditLog() (string, error) {
+	exported, err := service.session.ExportAuditLog()
+	return string(exported), err
+}
+
+// SessionVerifyAuditLog checks if the session wallet's audit log has been
+// tampered with
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionVerifyAuditLog() error {
+	return service.session.VerifyAuditLog()
+}
+
+// SessionListPolicyRules retrieves all the session wallet's signing policy
+// rules in the order they are evaluated
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionListPolicyRules() ([]policy.Rule, error) {
+	return service.session.ListPolicyRules()
+}
+
+// SessionAddPolicyRule adds the given rule to the session wallet's signing
+// policy. Returns the rule as it was stored.
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionAddPolicyRule(rule policy.Rule) (*policy.Rule, error) {
+	return service.session.AddPolicyRule(rule)
+}
+
+// SessionUpdatePolicyRule replaces the rule in the session wallet's signing
+// policy that has the same ID as the given rule
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionUpdatePolicyRule(rule policy.Rule) error {
+	return service.session.UpdatePolicyRule(rule)
+}
+
+// SessionRemovePolicyRule removes the rule with the given ID from the session
+// wallet's signing policy
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRemovePolicyRule(id uint64) error {
+	return service.session.RemovePolicyRule(id)
+}
+
+// SessionListSpendLimits retrieves all the session wallet's spending limits
+// along with how much has been sent within their current windows
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionListSpendLimits() ([]spend_limit.Status, error) {
+	return service.session.ListSpendLimits()
+}
+
+// SessionAddSpendLimit adds the given spending limit to the session wallet.
+// Returns the limit as it was stored.
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionAddSpendLimit(limit spend_limit.Limit) (*spend_limit.Limit, error) {
+	return service.session.AddSpendLimit(limit)
+}
+
+// SessionRemoveSpendLimit removes the spending limit with the given ID from
+// the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRemoveSpendLimit(id uint64) error {
+	return service.session.RemoveSpendLimit(id)
+}
+
+// SessionListContacts retrieves all the contacts in the session wallet's
+// address book
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionListContacts() ([]address_book.Contact, error) {
+	return service.session.ListContacts()
+}
+
+// SessionSaveContact adds the given contact to the session wallet's address
+// book or updates the contact with the same address. Returns the contact as it
+// was stored.
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionSaveContact(contact address_book.Contact) (*address_book.Contact, error) {
+	return service.session.SaveContact(contact)
+}
+
+// SessionRemoveContact removes the contact with the given address from the
+// session wallet's address book
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRemoveContact(address string) error {
+	return service.session.RemoveContact(address)
+}
+
+// SessionListAppSpecs retrieves all the app specs in the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionListAppSpecs() ([]app_spec.Spec, error) {
+	return service.session.ListAppSpecs()
+}
+
+// SessionSaveAppSpec adds the given ARC-32 or ARC-56 app spec JSON for the
+// application with the given ID to the session wallet, or replaces the app
+// spec for that application. Returns the app spec as it was stored.
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionSaveAppSpec(appID uint64, specJSON string) (*app_spec.Spec, error) {
+	return service.session.SaveAppSpec(appID, specJSON, "")
+}
+
+// SessionRemoveAppSpec removes the app spec for the application with the
+// given ID from the session wallet
+//
+// FOR THE FRONTEND ONLY
+func (service *KMDService) SessionRemoveAppSpec(appID uint64) error {
+	return service.session.RemoveAppSpec(appID)
+}
@@ -1,13 +1,25 @@
 package services_test
 
 import (
+	"duckysigner/internal/address_book"
+	"duckysigner/internal/algod"
+	"duckysigner/internal/audit"
 	"duckysigner/internal/kmd/config"
+	"duckysigner/internal/kmd/wallet"
+	"duckysigner/internal/policy"
+	"duckysigner/internal/spend_limit"
+	"duckysigner/internal/txn_decoder"
+	"duckysigner/internal/txn_validator"
+	ws "duckysigner/internal/wallet_session"
 	"duckysigner/services"
 	. "duckysigner/services"
 	"encoding/base64"
+	"errors"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/algorand/go-algorand-sdk/v2/client/v2/common/models"
 	"github.com/algorand/go-algorand-sdk/v2/encoding/msgpack"
 	"github.com/algorand/go-algorand-sdk/v2/types"
 	"github.com/awnumar/memguard"
@@ -82,16 +94,25 @@ var _ = Describe("KmdService", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(retrievedWalletInfoB.Name).To(BeEquivalentTo("B_wallet"), "Renamed wallet should have new name")
 
-			// By("Removing a wallet")
-			// err = kmdService.RemoveWallet(string(retrievedWalletInfoA.ID), "passwordA")
-			// Expect(err).NotTo(HaveOccurred())
-			// // Should not in list after removal
-			// walletsInfo, err = kmdService.ListWallets()
-			// Expect(err).NotTo(HaveOccurred())
-			// Expect(walletsInfo).To(HaveLen(1), "Removed wallet should not be in list")
-			// // Should not be retrievable after removal
-			// _, err = kmdService.GetWalletInfo(string(retrievedWalletInfoA.ID))
-			// Expect(err).To(HaveOccurred(), "Removed wallet should not be retrievable")
+			By("Failing to remove a wallet if the given password is wrong")
+			err = kmdService.RemoveWallet(string(retrievedWalletInfoA.ID), "notpasswordA")
+			Expect(err).To(HaveOccurred())
+
+			By("Removing a wallet that is open in the current session")
+			err = kmdService.StartSession(string(retrievedWalletInfoA.ID), "passwordA")
+			Expect(err).NotTo(HaveOccurred())
+			openSession := kmdService.Session()
+			err = kmdService.RemoveWallet(string(retrievedWalletInfoA.ID), "passwordA")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kmdService.Session()).To(BeNil(), "The session for the removed wallet should be ended")
+			Expect(openSession.Check()).NotTo(Succeed(), "The session for the removed wallet should be expired")
+			// Should not in list after removal
+			walletsInfo, err = kmdService.ListWallets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(walletsInfo).To(HaveLen(1), "Removed wallet should not be in list")
+			// Should not be retrievable after removal
+			_, err = kmdService.GetWalletInfo(string(retrievedWalletInfoA.ID))
+			Expect(err).To(HaveOccurred(), "Removed wallet should not be retrievable")
 		})
 
 		It("can manage wallet sessions", func() {
@@ -189,6 +210,144 @@ var _ = Describe("KmdService", func() {
 			Expect(exportedMnemonic).To(Equal(testWalletMnemonic), "Exported mnemonic should be the same as imported mnemonic")
 		})
 
+		It("can change the wallet password", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_change_pw"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Importing a wallet and starting a session with it")
+			importedWalletInfo, err := kmdService.ImportWalletMnemonic(testWalletMnemonic, "Change Password Test Wallet", "old password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(importedWalletInfo.ID), "old password")
+			Expect(err).NotTo(HaveOccurred())
+			acctAddr, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Failing to change the password if given the wrong password")
+			Expect(kmdService.SessionChangePassword("wrong password", "new password")).NotTo(Succeed())
+
+			By("Changing the password once the data files are no longer in use")
+			kmdService.Session().UseDataFiles()
+			pwChanged := make(chan error)
+			go func() {
+				defer GinkgoRecover()
+				pwChanged <- kmdService.SessionChangePassword("old password", "new password")
+			}()
+			Consistently(pwChanged, "200ms").ShouldNot(Receive(), "The password is not changed while the data files are in use")
+			kmdService.Session().UnuseDataFiles()
+			Eventually(pwChanged, "10s").Should(Receive(Not(HaveOccurred())))
+			Expect(kmdService.Session().Check()).To(Succeed(), "The session should still be valid")
+			exportedMnemonic, err := kmdService.Session().ExportWallet("new password")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exportedMnemonic).To(Equal(testWalletMnemonic))
+
+			By("Starting a new session with the new password")
+			kmdService.EndSession()
+			Expect(kmdService.StartSession(string(importedWalletInfo.ID), "old password")).NotTo(Succeed())
+			Expect(kmdService.StartSession(string(importedWalletInfo.ID), "new password")).To(Succeed())
+			acctAddrs, err := kmdService.SessionListAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acctAddrs).To(ConsistOf(acctAddr))
+		})
+
+		It("can back up and restore a wallet", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_backup"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+			backupPath := GinkgoT().TempDir() + "/wallet.backup"
+
+			By("Creating a wallet with accounts that are not derived from its mnemonic")
+			walletInfo, err := kmdService.CreateWallet("Backup Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionImportAccount(testStandaloneAcctMnemonic)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionRenameAccount(testStandaloneAcctAddr, "Imported")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Storing data in the wallet's data files")
+			_, err = kmdService.SessionAddPolicyRule(policy.Rule{
+				Name:       "Test dApp",
+				Action:     policy.ActionAllow,
+				Conditions: policy.Conditions{DappIDs: []string{"dapp"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionAddSpendLimit(spend_limit.Limit{
+				Name:       "Daily",
+				WindowSecs: 86400,
+				Max:        5_000_000,
+				Action:     spend_limit.ActionPrompt,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionSaveContact(address_book.Contact{
+				Address: "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4",
+				Label:   "Bob",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionSaveAppSpec(123, `{
+				"name": "Swapper",
+				"methods": [{"name": "swap", "args": [], "returns": {"type": "void"}}]
+			}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kmdService.RevokeDappSession("not-a-session")).To(Succeed())
+
+			By("Failing to back up the wallet with the wrong password")
+			err = kmdService.BackupWallet(string(walletInfo.ID), "not the password", "backup passphrase", backupPath, true)
+			Expect(err).To(HaveOccurred())
+
+			By("Backing up the wallet")
+			err = kmdService.BackupWallet(string(walletInfo.ID), "password", "backup passphrase", backupPath, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupPath).To(BeARegularFile())
+
+			By("Failing to restore the wallet with the wrong passphrase")
+			_, err = kmdService.RestoreWallet(backupPath, "not the backup passphrase")
+			Expect(err).To(HaveOccurred())
+
+			By("Restoring the wallet")
+			restoredWalletInfo, err := kmdService.RestoreWallet(backupPath, "backup passphrase")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restoredWalletInfo.ID).NotTo(Equal(walletInfo.ID), "The restored wallet is a new wallet")
+			Expect(restoredWalletInfo.Name).To(BeEquivalentTo("Backup Test Wallet"))
+			walletsInfo, err := kmdService.ListWallets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(walletsInfo).To(HaveLen(2))
+
+			By("Checking the restored wallet has the imported account")
+			err = kmdService.StartSession(string(restoredWalletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+			acct, err := kmdService.Session().GetAccount(testStandaloneAcctAddr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.Name).To(Equal("Imported"))
+
+			By("Checking the restored wallet has the data in the wallet's data files")
+			rules, err := kmdService.SessionListPolicyRules()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(HaveLen(1))
+			statuses, err := kmdService.SessionListSpendLimits()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			contacts, err := kmdService.SessionListContacts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contacts).To(HaveLen(1))
+			specs, err := kmdService.SessionListAppSpecs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(specs).To(HaveLen(1))
+			entries, err := kmdService.SessionQueryAuditLog(audit.Filter{Event: audit.EventSessionRevoke})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(kmdService.SessionVerifyAuditLog()).To(Succeed())
+		})
+
 		It("can manage accounts in wallet", func() {
 			By("Initializing KMD")
 			const walletDirName = ".test_kmd_wallet_accts"
@@ -266,6 +425,200 @@ var _ = Describe("KmdService", func() {
 			Expect(accts).NotTo(ContainElement(testStandaloneAcctAddr), "The removed imported account should not be in the account list")
 		})
 
+		It("can name and order accounts", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_acct_order"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Acct Order Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding accounts to the wallet")
+			acctA, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+			acctB, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+			acctC, err := kmdService.SessionImportAccount(testStandaloneAcctMnemonic)
+			Expect(err).NotTo(HaveOccurred())
+			accts, err := kmdService.SessionGetAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accts).To(HaveExactElements(
+				HaveField("Address", acctA), HaveField("Address", acctB), HaveField("Address", acctC),
+			), "Accounts are listed in the order they were added")
+			Expect(accts[2].Type).To(Equal("standalone"))
+
+			By("Naming an account")
+			acct, err := kmdService.SessionRenameAccount(acctB, "Savings")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.Name).To(Equal("Savings"))
+			acct, err = kmdService.SessionGetAccount(acctB)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.Name).To(Equal("Savings"))
+
+			By("Recording the address an account is rekeyed to")
+			acct, err = kmdService.SessionSetAccountRekeyedTo(acctC, acctA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.RekeyedTo).To(Equal(acctA))
+
+			By("Reordering the accounts")
+			Expect(kmdService.SessionMoveAccountAbove(acctC, acctA)).To(Succeed())
+			Expect(kmdService.SessionMoveAccountBelow(acctA, "")).To(Succeed())
+			accts, err = kmdService.SessionGetAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accts).To(HaveExactElements(
+				HaveField("Address", acctC), HaveField("Address", acctB), HaveField("Address", acctA),
+			))
+
+			By("Removing an account")
+			Expect(kmdService.SessionRemoveAccount(acctB)).To(Succeed())
+			accts, err = kmdService.SessionGetAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accts).To(HaveExactElements(HaveField("Address", acctC), HaveField("Address", acctA)))
+		})
+
+		It("can add a watch-only account", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_watch_acct"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Watch Acct Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding a watch-only account")
+			acct, err := kmdService.SessionAddWatchAccount(testStandaloneAcctAddr, "Cold Storage")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.Type).To(Equal("watch"))
+			Expect(acct.Name).To(Equal("Cold Storage"))
+
+			By("Listing the watch-only account with the other accounts")
+			acctAddrs, err := kmdService.SessionListAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acctAddrs).To(ContainElement(testStandaloneAcctAddr))
+			inWallet, err := kmdService.Session().CheckAddrInWallet(testStandaloneAcctAddr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inWallet).To(BeTrue())
+
+			By("Failing to sign a transaction for the watch-only account")
+			// A transaction sent by the watch-only account
+			const knownSignedTxnB64 = "gqNzaWfEQHOy8+zozpBTp3wOA1ZzANbN2LXeHTUTFre5xg0WpsPiKTm9Eto4Kq+XuutVHvaTMa9v7KxpWB+tZ79iOeCqDgyjdHhuiKNmZWXNA+iiZnbOAnvsNaNnZW6sdGVzdG5ldC12MS4womdoxCBIY7UYpLPITsgQ8i1PEIHLD3HwWaesIN7GL39w5Qk6IqJsds4Ce/Ado3JjdsQgiwGZNPVYGY6ClrTkNzeS0dFK/BjHmWsRisH9vCzgUvKjc25kxCCLAZk09VgZjoKWtOQ3N5LR0Ur8GMeZaxGKwf28LOBS8qR0eXBlo3BheQ=="
+			knownSignedTxn := types.SignedTxn{}
+			err = knownSignedTxn.FromBase64String(knownSignedTxnB64)
+			Expect(err).NotTo(HaveOccurred())
+			unsignedTxnB64 := base64.StdEncoding.EncodeToString(msgpack.Encode(knownSignedTxn.Txn))
+			_, err = kmdService.SessionSignTransaction(unsignedTxnB64, "")
+			Expect(err).To(MatchError(wallet.ErrWatchOnly))
+		})
+
+		It("can sign for a rekeyed account", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_rekeyed_acct"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Rekeyed Acct Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding a watch-only account rekeyed to an account with its key in the wallet")
+			authAcctAddr, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionAddWatchAccount(testStandaloneAcctAddr, "")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kmdService.SessionSetAccountRekeyedTo(testStandaloneAcctAddr, authAcctAddr)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Signing a transaction sent by the rekeyed account")
+			// A transaction sent by the rekeyed account
+			const knownSignedTxnB64 = "gqNzaWfEQHOy8+zozpBTp3wOA1ZzANbN2LXeHTUTFre5xg0WpsPiKTm9Eto4Kq+XuutVHvaTMa9v7KxpWB+tZ79iOeCqDgyjdHhuiKNmZWXNA+iiZnbOAnvsNaNnZW6sdGVzdG5ldC12MS4womdoxCBIY7UYpLPITsgQ8i1PEIHLD3HwWaesIN7GL39w5Qk6IqJsds4Ce/Ado3JjdsQgiwGZNPVYGY6ClrTkNzeS0dFK/BjHmWsRisH9vCzgUvKjc25kxCCLAZk09VgZjoKWtOQ3N5LR0Ur8GMeZaxGKwf28LOBS8qR0eXBlo3BheQ=="
+			knownSignedTxn := types.SignedTxn{}
+			err = knownSignedTxn.FromBase64String(knownSignedTxnB64)
+			Expect(err).NotTo(HaveOccurred())
+			unsignedTxnB64 := base64.StdEncoding.EncodeToString(msgpack.Encode(knownSignedTxn.Txn))
+			stxB64, err := kmdService.SessionSignTransaction(unsignedTxnB64, "")
+			Expect(err).NotTo(HaveOccurred())
+			stx := types.SignedTxn{}
+			err = stx.FromBase64String(stxB64)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stx.AuthAddr.String()).To(Equal(authAcctAddr), "Signed by the account the sender is rekeyed to")
+		})
+
+		It("can manage multisig accounts", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_msig_acct"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Msig Acct Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding a multisig account with a signer whose key is in the wallet")
+			localAcctAddr, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+			msigAcct, err := kmdService.SessionAddMultisigAccount(
+				1, 2, []string{localAcctAddr, testStandaloneAcctAddr}, "Treasury",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msigAcct.Type).To(Equal("msig"))
+			Expect(msigAcct.Name).To(Equal("Treasury"))
+			Expect(msigAcct.Version).To(BeEquivalentTo(1))
+			Expect(msigAcct.Threshold).To(BeEquivalentTo(2))
+			Expect(msigAcct.Signers).To(HaveExactElements(
+				ws.MultisigSigner{Address: localAcctAddr, Local: true},
+				ws.MultisigSigner{Address: testStandaloneAcctAddr, Local: false},
+			))
+
+			By("Listing the multisig account")
+			msigAccts, err := kmdService.SessionListMultisigAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msigAccts).To(HaveExactElements(*msigAcct))
+			accts, err := kmdService.SessionGetAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accts).To(HaveExactElements(
+				HaveField("Address", localAcctAddr), HaveField("Address", msigAcct.Address),
+			), "Multisig account is listed with the other accounts")
+
+			By("Failing to get or remove an account that is not a multisig account")
+			_, err = kmdService.SessionGetMultisigAccount(localAcctAddr)
+			Expect(err).To(MatchError("account is not a multisig account"))
+			err = kmdService.SessionRemoveMultisigAccount(localAcctAddr)
+			Expect(err).To(MatchError("account is not a multisig account"))
+
+			By("Removing the multisig account")
+			err = kmdService.SessionRemoveMultisigAccount(msigAcct.Address)
+			Expect(err).NotTo(HaveOccurred())
+			msigAccts, err = kmdService.SessionListMultisigAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msigAccts).To(BeEmpty())
+			acctAddrs, err := kmdService.SessionListAccounts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acctAddrs).To(Equal([]string{localAcctAddr}), "Signer's account was not removed")
+		})
+
 		It("can sign a transaction", func() {
 			const knownSignedTxnB64 = "gqNzaWfEQHOy8+zozpBTp3wOA1ZzANbN2LXeHTUTFre5xg0WpsPiKTm9Eto4Kq+XuutVHvaTMa9v7KxpWB+tZ79iOeCqDgyjdHhuiKNmZWXNA+iiZnbOAnvsNaNnZW6sdGVzdG5ldC12MS4womdoxCBIY7UYpLPITsgQ8i1PEIHLD3HwWaesIN7GL39w5Qk6IqJsds4Ce/Ado3JjdsQgiwGZNPVYGY6ClrTkNzeS0dFK/BjHmWsRisH9vCzgUvKjc25kxCCLAZk09VgZjoKWtOQ3N5LR0Ur8GMeZaxGKwf28LOBS8qR0eXBlo3BheQ=="
 
@@ -310,6 +663,347 @@ var _ = Describe("KmdService", func() {
 			outputSignedTxn2, err := kmdService.Session().SignTransaction(unsignedTxnB64, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(outputSignedTxn2).To(Equal(knownSignedTxnB64))
+
+			By("Refusing to sign a transaction with an absurdly high fee")
+			absurdFeeTxn := knownSignedTxn.Txn
+			absurdFeeTxn.Fee = 50_000_000
+			_, err = kmdService.Session().SignTransaction(
+				base64.StdEncoding.EncodeToString(msgpack.Encode(absurdFeeTxn)), "",
+			)
+			var validationErr *txn_validator.ValidationError
+			Expect(errors.As(err, &validationErr)).To(BeTrue())
+			Expect(validationErr.Code).To(Equal(txn_validator.CodeFeeTooHigh))
+		})
+
+		It("can manage the audit log", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_audit_log"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Audit Log Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Recording entries")
+			_, err = kmdService.Session().RecordAuditEntry(audit.Entry{
+				Event:    audit.EventTxnSign,
+				DappID:   "dapp",
+				TxnID:    "TXNID",
+				TxnType:  "pay",
+				Decision: audit.DecisionApproved,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kmdService.RevokeDappSession("not-a-session")).To(Succeed())
+
+			By("Querying the audit log")
+			entries, err := kmdService.SessionQueryAuditLog(audit.Filter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].TxnID).To(Equal("TXNID"))
+			Expect(entries[1].Event).To(Equal(audit.EventSessionRevoke))
+			Expect(entries[1].SessionID).To(Equal("not-a-session"))
+
+			entries, err = kmdService.SessionQueryAuditLog(audit.Filter{Event: audit.EventTxnSign})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+
+			By("Verifying the audit log")
+			Expect(kmdService.SessionVerifyAuditLog()).To(Succeed())
+
+			By("Exporting the audit log")
+			exported, err := kmdService.SessionExportAuditLog()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exported).To(ContainSubstring(`"event": "session_revoke"`))
+
+			By("Failing to revoke a session if the revocation cannot be recorded")
+			auditLogPath := filepath.Join(kmdService.Session().FilePath, audit.DefaultDataFile)
+			Expect(os.Remove(auditLogPath)).To(Succeed())
+			Expect(os.Mkdir(auditLogPath, 0700)).To(Succeed())
+			Expect(kmdService.RevokeDappSession("not-a-session")).NotTo(Succeed())
+		})
+
+		It("can manage signing policy rules", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_policy_rules"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Policy Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding a rule")
+			rule, err := kmdService.SessionAddPolicyRule(policy.Rule{
+				Name:       "Test dApp",
+				Action:     policy.ActionAllow,
+				Conditions: policy.Conditions{DappIDs: []string{"dapp"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			rules, err := kmdService.SessionListPolicyRules()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(Equal([]policy.Rule{*rule}))
+
+			By("Updating the rule")
+			rule.Action = policy.ActionDeny
+			Expect(kmdService.SessionUpdatePolicyRule(*rule)).To(Succeed())
+			rules, err = kmdService.SessionListPolicyRules()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules[0].Action).To(Equal(policy.ActionDeny))
+
+			By("Removing the rule")
+			Expect(kmdService.SessionRemovePolicyRule(rule.ID)).To(Succeed())
+			rules, err = kmdService.SessionListPolicyRules()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rules).To(BeEmpty())
+		})
+
+		It("can manage spending limits", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_spend_limits"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Spending Limit Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding a limit")
+			limit, err := kmdService.SessionAddSpendLimit(spend_limit.Limit{
+				Name:       "Daily",
+				WindowSecs: 86400,
+				Max:        5_000_000,
+				Action:     spend_limit.ActionPrompt,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Recording what was sent")
+			err = kmdService.Session().RecordSpend("session", []spend_limit.Outflow{{Amount: 1_000_000}})
+			Expect(err).NotTo(HaveOccurred())
+			statuses, err := kmdService.SessionListSpendLimits()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(Equal([]spend_limit.Status{{Limit: *limit, Spent: 1_000_000}}))
+
+			By("Removing the limit")
+			Expect(kmdService.SessionRemoveSpendLimit(limit.ID)).To(Succeed())
+			statuses, err = kmdService.SessionListSpendLimits()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(BeEmpty())
+		})
+
+		It("can manage the address book", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_address_book"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("Address Book Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+			acct, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Saving a contact")
+			const contactAddr = "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+			contact, err := kmdService.SessionSaveContact(address_book.Contact{
+				Address: contactAddr,
+				Label:   "Bob",
+				Tags:    []string{"friend"},
+				Trusted: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			contacts, err := kmdService.SessionListContacts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contacts).To(Equal([]address_book.Contact{*contact}))
+
+			By("Resolving addresses")
+			info, err := kmdService.Session().ResolveAddress(contactAddr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(txn_decoder.AddressInfo{Label: "Bob", Trusted: true}))
+			info, err = kmdService.Session().ResolveAddress(acct)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(txn_decoder.AddressInfo{InWallet: true}))
+
+			By("Removing the contact")
+			Expect(kmdService.SessionRemoveContact(contactAddr)).To(Succeed())
+			contacts, err = kmdService.SessionListContacts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contacts).To(BeEmpty())
+		})
+
+		It("can manage app specs", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_app_specs"
+			kmdService := createKmdService(walletDirName)
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Creating a new wallet and starting a session with it")
+			walletInfo, err := kmdService.CreateWallet("App Spec Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Saving an app spec")
+			spec, err := kmdService.SessionSaveAppSpec(123, `{
+				"name": "Swapper",
+				"methods": [{
+					"name": "swap",
+					"args": [{"type": "asset", "name": "asset_in"}, {"type": "uint64", "name": "amount"}],
+					"returns": {"type": "void"}
+				}]
+			}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.Name).To(Equal("Swapper"))
+			specs, err := kmdService.SessionListAppSpecs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(specs).To(HaveLen(1))
+
+			By("Getting the methods of the app")
+			methods, err := kmdService.Session().AppMethods(123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(methods).To(HaveLen(1))
+			Expect(methods[0].Name).To(Equal("swap"))
+			methods, err = kmdService.Session().AppMethods(456)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(methods).To(BeNil())
+
+			By("Removing the app spec")
+			Expect(kmdService.SessionRemoveAppSpec(123)).To(Succeed())
+			specs, err = kmdService.SessionListAppSpecs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(specs).To(BeEmpty())
+		})
+
+		It("can manage network profiles", func() {
+			By("Initializing KMD")
+			const walletDirName = ".test_kmd_networks"
+			kmdService := createKmdService(walletDirName)
+			kmdService.Config.DataDir = walletDirName
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Listing the default profiles")
+			profiles, err := kmdService.ListNetworkProfiles()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).To(Equal(config.DefaultNetworkProfiles()))
+
+			By("Starting a session")
+			walletInfo, err := kmdService.CreateWallet("Networks Test Wallet", "password")
+			Expect(err).NotTo(HaveOccurred())
+			err = kmdService.StartSession(string(walletInfo.ID), "password")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Saving a custom profile")
+			customNet := config.NetworkProfile{
+				Name:      "Custom",
+				GenesisID: "custom-v1",
+				AlgodURL:  "http://localhost:5001",
+			}
+			Expect(kmdService.SaveNetworkProfile(customNet)).To(Succeed())
+			profiles, err = config.LoadNetworkProfiles(walletDirName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).To(ContainElement(customNet))
+			Expect(kmdService.Session().NetworkProfiles()).To(ContainElement(customNet))
+
+			By("Not saving an invalid profile")
+			err = kmdService.SaveNetworkProfile(config.NetworkProfile{Name: "Fake MainNet", GenesisID: "mainnet-v1.0"})
+			Expect(err).To(HaveOccurred())
+
+			By("Removing the MainNet profile")
+			Expect(kmdService.RemoveNetworkProfile("MainNet")).To(Succeed())
+			Expect(kmdService.RemoveNetworkProfile("MainNet")).NotTo(Succeed())
+			profiles, err = kmdService.ListNetworkProfiles()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).NotTo(ContainElement(HaveField("Name", "MainNet")))
+
+			By("Rejecting transactions for networks without a profile")
+			acct, err := kmdService.SessionGenerateAccount()
+			Expect(err).NotTo(HaveOccurred())
+			sender, err := types.DecodeAddress(acct)
+			Expect(err).NotTo(HaveOccurred())
+			mainNetHash, err := base64.StdEncoding.DecodeString("wGHE2Pwdvd7S12BL5FaOP20EGYesN73ktiC1qzkkit8=")
+			Expect(err).NotTo(HaveOccurred())
+			txn := types.Transaction{
+				Type: types.PaymentTx,
+				Header: types.Header{
+					Sender:      sender,
+					Fee:         1000,
+					FirstValid:  1,
+					LastValid:   1000,
+					GenesisID:   "mainnet-v1.0",
+					GenesisHash: types.Digest(mainNetHash),
+				},
+				PaymentTxnFields: types.PaymentTxnFields{Receiver: sender},
+			}
+			err = kmdService.Session().ValidateTransaction(txn)
+			var validationErr *txn_validator.ValidationError
+			Expect(errors.As(err, &validationErr)).To(BeTrue())
+			Expect(validationErr.Code).To(Equal(txn_validator.CodeUnknownNetwork))
+		})
+
+		It("can get chain information from algod", func() {
+			By("Initializing KMD with a fake algod client")
+			const walletDirName = ".test_kmd_algod"
+			fakeAlgod := algod.NewFake()
+			kmdService := createKmdService(walletDirName)
+			kmdService.Config.Networks = config.DefaultNetworkProfiles()
+			kmdService.AlgodProvider = fakeAlgod.Provider()
+			DeferCleanup(func() {
+				kmdService.CleanUp()
+				createKmdServiceCleanup(walletDirName)
+			})
+
+			By("Getting the information of an account")
+			fakeAlgod.Accounts[testStandaloneAcctAddr] = models.Account{
+				Address: testStandaloneAcctAddr,
+				Amount:  5_000_000,
+			}
+			acct, err := kmdService.AccountInfo("TestNet", testStandaloneAcctAddr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acct.Amount).To(BeEquivalentTo(5_000_000))
+
+			By("Getting the information of an asset")
+			fakeAlgod.Assets[10] = models.Asset{Index: 10, Params: models.AssetParams{Name: "Duck Coin"}}
+			asset, err := kmdService.AssetInfo("TestNet", 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(asset.Params.Name).To(Equal("Duck Coin"))
+
+			By("Getting the status of a transaction")
+			fakeAlgod.PendingTxns["ABC"] = models.PendingTransactionInfoResponse{ConfirmedRound: 42}
+			status, err := kmdService.TransactionStatus("TestNet", "ABC")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.ConfirmedRound).To(BeEquivalentTo(42))
+			_, err = kmdService.TransactionStatus("TestNet", "XYZ")
+			Expect(errors.Is(err, algod.ErrNotFound)).To(BeTrue())
+
+			By("Not getting information for a network without a profile")
+			_, err = kmdService.AccountInfo("FooNet", testStandaloneAcctAddr)
+			Expect(err).To(HaveOccurred())
 		})
 	})
 })